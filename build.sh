@@ -12,12 +12,12 @@ cd mcp_orchestrator
 
 # Build the main orchestrator
 echo "Building main orchestrator..."
-go build -o bin/mcp-orchestrator main.go
+go build -o bin/mcp-orchestrator .
 
 # Build the stdio proxy
 echo "Building stdio proxy..."
 cd cmd/stdio
-go build -o mcp-orchestrator-stdio main.go enhanced_discovery.go
+go build -o mcp-orchestrator-stdio .
 mv mcp-orchestrator-stdio ../../bin/
 cd ../..
 
@@ -28,4 +28,4 @@ echo "  - mcp_orchestrator/bin/mcp-orchestrator-stdio"
 echo ""
 echo "To use:"
 echo "  1. Run ./mcp_orchestrator/bin/mcp-orchestrator to start the web UI"
-echo "  2. Add mcp_orchestrator/bin/mcp-orchestrator-stdio to your Claude Desktop config" 
\ No newline at end of file
+echo "  2. Run ./mcp_orchestrator/bin/mcp-orchestrator configure to add the stdio proxy to your Claude Desktop config"
\ No newline at end of file
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// activityReportTimeout bounds the request telling the orchestrator a server was used
+const activityReportTimeout = 5 * time.Second
+
+// reportActivity tells the orchestrator a tool call was routed to a server,
+// so servers with an idle timeout aren't stopped while the client uses them.
+// Calls run in their own processes, so the orchestrator can't see them itself.
+func (p *StdioProxy) reportActivity(serverID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), activityReportTimeout)
+	defer cancel()
+
+	req, err := p.api.newRequest(ctx, http.MethodPost, "/api/servers/"+url.PathEscape(serverID)+"/activity")
+	if err != nil {
+		return
+	}
+
+	resp, err := p.api.Do(req)
+	if err != nil {
+		log.Printf("Warning: Failed to report activity for server %s: %v", serverID, err)
+		return
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mcp_orchestrator/internal/performance"
+)
+
+// newAdmissionProxy builds a proxy with a budget of one call to search,
+// reporting its state to a stub orchestrator
+func newAdmissionProxy(t *testing.T) *StdioProxy {
+	orchestrator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(orchestrator.Close)
+
+	config := ProxyConfig{
+		Quarantine:  performance.DefaultQuarantineConfig(),
+		ToolBudgets: map[string]performance.Budget{"search": {MaxCalls: 1, Window: time.Minute}},
+	}
+	api := newOrchestratorClient(orchestrator.URL, config)
+	quarantine := performance.NewQuarantineManager(config.Quarantine)
+	return &StdioProxy{
+		api:               api,
+		quarantine:        quarantine,
+		budgets:           performance.NewBudgetTracker(config.ToolBudgets, nil),
+		calls:             performance.NewCallLimiter(1, nil, 10*time.Millisecond),
+		enhancedDiscovery: NewEnhancedDiscovery(api, quarantine, config),
+		config:            config,
+	}
+}
+
+func TestAdmitToolCallQuarantineDoesNotSpendBudget(t *testing.T) {
+	p := newAdmissionProxy(t)
+	for i := 0; i < p.config.Quarantine.MaxConsecutiveFailures; i++ {
+		p.quarantine.RecordResult("brave", false)
+	}
+
+	if _, rejection := p.admitToolCall(context.Background(), "brave", "search", "web"); rejection == nil {
+		t.Fatal("a call to a quarantined server was admitted")
+	}
+	if used := p.budgets.GetStatus()[0].Used; used != 0 {
+		t.Errorf("a rejected call used %d of the budget", used)
+	}
+}
+
+func TestAdmitToolCallBudgetReleasesSlot(t *testing.T) {
+	p := newAdmissionProxy(t)
+
+	release, rejection := p.admitToolCall(context.Background(), "brave", "search", "web")
+	if rejection != nil {
+		t.Fatalf("first call rejected: %v", rejection)
+	}
+	release()
+
+	if _, rejection := p.admitToolCall(context.Background(), "brave", "search", "web"); rejection == nil {
+		t.Fatal("a call over budget was admitted")
+	}
+
+	// The rejected call must give its slot back for calls to other tools
+	release, rejection = p.admitToolCall(context.Background(), "brave", "fetch", "web")
+	if rejection != nil {
+		t.Fatalf("call after a budget rejection rejected: %v", rejection)
+	}
+	release()
+}
+
+// Later pages come from the cached full result but are still calls: they
+// count against the budget like the first page did
+func TestCachedResultPageRespectsBudget(t *testing.T) {
+	p := newAggregatingProxy(t, &atomic.Bool{})
+	p.budgets = performance.NewBudgetTracker(map[string]performance.Budget{"alpha_search": {MaxCalls: 1, Window: time.Minute}}, nil)
+	if err := p.budgets.Allow("alpha_search", ""); err != nil {
+		t.Fatalf("first call rejected: %v", err)
+	}
+
+	arguments := map[string]interface{}{"query": "mcp"}
+	full := map[string]interface{}{"content": []interface{}{
+		map[string]interface{}{"type": "text", "text": "one"},
+		map[string]interface{}{"type": "text", "text": "two"},
+	}}
+	p.resultPages.Set(resultPageKey("alpha", "alpha_search", arguments), full, 0)
+
+	result := p.forwardToolCall(context.Background(), MCPMessage{Params: map[string]interface{}{
+		"name":      "alpha_search",
+		"arguments": map[string]interface{}{"query": "mcp", "_page": 2.0, "_page_size": 1.0},
+	}})
+
+	rpcErr, _ := result.(map[string]interface{})["error"].(map[string]interface{})
+	if rpcErr == nil || rpcErr["code"] != errCodeBudgetExceeded {
+		t.Errorf("got %v, want a budget error", result)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newAggregatingProxy builds a proxy whose orchestrator reports three running
+// servers. alpha and gamma have their tools in the discovery cache; the
+// install directory of agg-test-missing doesn't exist, so its discovery
+// fails. While apiDown is set the orchestrator answers /api/servers with 500.
+func newAggregatingProxy(t *testing.T, apiDown *atomic.Bool) *StdioProxy {
+	orchestrator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/servers":
+			if apiDown.Load() {
+				http.Error(w, "database locked", http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprint(w, `{"servers": [
+				{"id": "alpha", "status": "running"},
+				{"id": "agg-test-missing", "status": "running"},
+				{"id": "gamma", "status": "running"}
+			]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(orchestrator.Close)
+
+	config := ProxyConfig{
+		DiscoveryConcurrency: 1,
+		DiscoveryRetry:       RetryPolicy{MaxAttempts: 1},
+		ToolList:             defaultToolList(),
+	}
+	p := NewStdioProxy(orchestrator.URL, config)
+
+	for _, serverID := range []string{"alpha", "gamma"} {
+		p.enhancedDiscovery.setCachedTools(serverID, CachedToolData{
+			Tools: []interface{}{map[string]interface{}{
+				"name":        serverID + "_search",
+				"description": "Tool under test",
+				"inputSchema": map[string]interface{}{"type": "object"},
+			}},
+			ServerID:  serverID,
+			Status:    "success",
+			Timestamp: time.Now(),
+		})
+	}
+	return p
+}
+
+// toolServers returns the server of each discovered tool, by tool name
+func toolServers(tools []interface{}) map[string]interface{} {
+	servers := make(map[string]interface{}, len(tools))
+	for _, toolData := range tools {
+		tool := toolData.(map[string]interface{})
+		servers[tool["name"].(string)] = tool["_server_id"]
+	}
+	return servers
+}
+
+// hasDiagnostic reports whether a diagnostic of the given type was raised for a server
+func hasDiagnostic(diagnostics []DiagnosticIssue, serverID, issueType string) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.ServerID == serverID && diagnostic.Type == issueType {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDiscoveryKeepsToolsWhenOneOfThreeServersFails(t *testing.T) {
+	p := newAggregatingProxy(t, &atomic.Bool{})
+
+	tools, diagnostics := p.enhancedDiscovery.DiscoverToolsWithDiagnostics()
+
+	servers := toolServers(tools)
+	if len(servers) != 2 || servers["alpha_search"] != "alpha" || servers["gamma_search"] != "gamma" {
+		t.Errorf("got tools %v, want alpha_search and gamma_search", servers)
+	}
+	if !hasDiagnostic(diagnostics, "agg-test-missing", "tool_discovery_failed") {
+		t.Errorf("no tool_discovery_failed diagnostic for the failing server: %v", diagnostics)
+	}
+	if hasDiagnostic(diagnostics, "alpha", "tool_discovery_failed") || hasDiagnostic(diagnostics, "gamma", "tool_discovery_failed") {
+		t.Errorf("healthy servers reported as failing: %v", diagnostics)
+	}
+}
+
+func TestDiscoveryUsesLastServerListWhenAPIFails(t *testing.T) {
+	var apiDown atomic.Bool
+	p := newAggregatingProxy(t, &apiDown)
+
+	if tools, _ := p.enhancedDiscovery.DiscoverToolsWithDiagnostics(); len(tools) != 2 {
+		t.Fatalf("got %d tools before the API failed, want 2", len(tools))
+	}
+
+	apiDown.Store(true)
+	p.enhancedDiscovery.Invalidate()
+	tools, diagnostics := p.enhancedDiscovery.DiscoverToolsWithDiagnostics()
+
+	if servers := toolServers(tools); len(servers) != 2 {
+		t.Errorf("got tools %v after the API failed, want those of alpha and gamma", servers)
+	}
+	if !hasDiagnostic(diagnostics, "orchestrator", "api_error_response") {
+		t.Errorf("API failure not reported: %v", diagnostics)
+	}
+	if !hasDiagnostic(diagnostics, "orchestrator", "stale_server_list") {
+		t.Errorf("no stale_server_list warning: %v", diagnostics)
+	}
+}
+
+func TestDiscoveryWithoutAnyServerList(t *testing.T) {
+	var apiDown atomic.Bool
+	apiDown.Store(true)
+	p := newAggregatingProxy(t, &apiDown)
+
+	tools, diagnostics := p.enhancedDiscovery.DiscoverToolsWithDiagnostics()
+
+	if len(tools) != 0 {
+		t.Errorf("got %d tools without a server list, want none", len(tools))
+	}
+	if hasDiagnostic(diagnostics, "orchestrator", "stale_server_list") {
+		t.Errorf("stale_server_list reported without a previous list: %v", diagnostics)
+	}
+}
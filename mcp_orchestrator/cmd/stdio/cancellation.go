@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// requestKey identifies a request id in the in-flight call map. The type is
+// included so the string id "1" and the numeric id 1 stay distinct.
+func requestKey(id interface{}) string {
+	return fmt.Sprintf("%T:%v", id, id)
+}
+
+// trackCall returns the context for handling a tool call, registered under
+// the request id so notifications/cancelled can abort it. The returned func
+// must be called once the call has finished.
+func (p *StdioProxy) trackCall(id interface{}) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if id == nil {
+		return ctx, cancel
+	}
+
+	key := requestKey(id)
+	p.cancelMu.Lock()
+	p.cancels[key] = cancel
+	p.cancelMu.Unlock()
+
+	return ctx, func() {
+		p.cancelMu.Lock()
+		delete(p.cancels, key)
+		p.cancelMu.Unlock()
+		cancel()
+	}
+}
+
+// cancelCall handles notifications/cancelled by cancelling the context of the
+// matching in-flight tool call. Unknown or finished requests are ignored, as
+// the cancellation may arrive after the response was sent.
+func (p *StdioProxy) cancelCall(params interface{}) {
+	paramMap, ok := params.(map[string]interface{})
+	if !ok {
+		return
+	}
+	requestID, ok := paramMap["requestId"]
+	if !ok || requestID == nil {
+		return
+	}
+
+	p.cancelMu.Lock()
+	cancel, exists := p.cancels[requestKey(requestID)]
+	p.cancelMu.Unlock()
+
+	if !exists {
+		return
+	}
+
+	reason, _ := paramMap["reason"].(string)
+	log.Printf("Cancelling tool call %v: %s", requestID, reason)
+	cancel()
+}
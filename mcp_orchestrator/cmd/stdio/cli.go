@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"mcp_orchestrator/internal/version"
+)
+
+// cliOptions are the proxy's command-line flags
+type cliOptions struct {
+	orchestratorURL string
+}
+
+// parseCommandLine reads the proxy's flags. It handles --version and --help
+// itself, reporting that the process should exit with the returned code;
+// otherwise the options to run the proxy with are returned.
+func parseCommandLine(args []string) (cliOptions, int, bool) {
+	var options cliOptions
+
+	flags := flag.NewFlagSet(stdioBinaryName, flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	showVersion := flags.Bool("version", false, "print version and build information, then exit")
+	showHelp := flags.Bool("help", false, "print this help, then exit")
+	flags.StringVar(&options.orchestratorURL, "orchestrator-url", "",
+		"orchestrator base URL (default: $MCP_ORCHESTRATOR_URL or "+defaultOrchestratorURL+")")
+	flags.Usage = func() {
+		printUsage(flags.Output(), flags)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return options, 0, true
+		}
+		return options, 2, true
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected argument %q\n", flags.Arg(0))
+		printUsage(os.Stderr, flags)
+		return options, 2, true
+	}
+
+	if *showHelp {
+		printUsage(os.Stdout, flags)
+		return options, 0, true
+	}
+	if *showVersion {
+		printVersion(os.Stdout)
+		return options, 0, true
+	}
+
+	return options, 0, false
+}
+
+// stdioBinaryName is the name the proxy is built and installed as
+const stdioBinaryName = "mcp-orchestrator-stdio"
+
+// printUsage describes the proxy and its flags
+func printUsage(w io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: %s [flags]\n\n", stdioBinaryName)
+	fmt.Fprintln(w, "Serves the MCP Orchestrator's tools to an MCP client such as Claude Desktop over")
+	fmt.Fprintln(w, "stdin and stdout. Run it from the client's configuration rather than by hand;")
+	fmt.Fprintf(w, "the orchestrator's \"configure\" command adds it to Claude Desktop.\n\nFlags:\n")
+
+	flags.SetOutput(w)
+	flags.PrintDefaults()
+	flags.SetOutput(os.Stderr)
+}
+
+// printVersion prints the proxy version on the first line, which the
+// orchestrator reads to check the installed proxy, followed by build details
+func printVersion(w io.Writer) {
+	fmt.Fprintf(w, "%s %s\n", stdioBinaryName, version.Version)
+	fmt.Fprintf(w, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			fmt.Fprintf(w, "commit: %s\n", setting.Value)
+		case "vcs.time":
+			fmt.Fprintf(w, "commit time: %s\n", setting.Value)
+		case "vcs.modified":
+			if setting.Value == "true" {
+				fmt.Fprintln(w, "modified: true")
+			}
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp_orchestrator/internal/performance"
+	"mcp_orchestrator/internal/profiles"
+	"mcp_orchestrator/internal/servers"
+)
+
+// ProxyConfig holds runtime settings for the stdio proxy
+type ProxyConfig struct {
+	Quarantine           performance.QuarantineConfig
+	DiscoveryConcurrency int           // Maximum server subprocesses spawned at once for tool discovery
+	DiscoveryWindow      time.Duration // How long one discovery pass is shared between requests
+	ToolBudgets          map[string]performance.Budget
+	CategoryBudgets      map[string]performance.Budget
+	MaxResultBytes       int             // Tool results above this size are truncated; 0 disables truncation
+	ServerOverrides      serverOverrides // Per-server command, args and working directory from the active profile
+	DiscoveryRetry       RetryPolicy     // Retries for servers without a retry override in the active profile
+	ToolList             ToolListDefaults
+	MaxConcurrentCalls   int               // Tool calls in flight per server, unless the profile overrides it
+	CallQueueTimeout     time.Duration     // How long a call waits for a busy server before failing
+	ToolsChangedInterval time.Duration     // How often discovery re-runs to notify the client of tool changes
+	StateReportInterval  time.Duration     // How often quarantine and budget state is reported to the orchestrator
+	HealthCheckTimeout   time.Duration     // Timeout of a single orchestrator readiness request
+	HealthCheckAttempts  int               // Readiness requests made before the orchestrator is reported down
+	ServerAccess         ServerAccess      // Servers the proxy may list tools from and route calls to
+	LazyStart            bool              // Start a stopped server when a call targets one of its tools
+	LazyStartTimeout     time.Duration     // How long a call waits for a lazily started server
+	MaxOutputBytes       int               // Output read from one server process before it is killed
+	APIRetry             RetryPolicy       // Retries for orchestrator API GET requests
+	ServersCacheTTL      time.Duration     // How long the orchestrator's server list is reused
+	ResultFormat         ResultFormat      // Shape of tool call results returned to the client
+	SharedEnv            map[string]string // Environment given to every server, beneath its own variables
+	MaxTools             int               // Hard cap on the tools tools/list pages through, above any profile limits
+	DiscoveryCache       performance.ToolListTTL
+	Profile              profiles.Profile // Active profile, whose server and tool selection tools/list applies
+}
+
+// ToolListDefaults are the tools/list settings used when a client doesn't pass them
+type ToolListDefaults struct {
+	Limit       int
+	SchemaLevel SchemaLevel
+	AdjustLimit bool                  // Cap the page size for large tool sets
+	MaxLimit    int                   // Page size cap for small tool sets
+	ContextCaps []profiles.ContextCap // Page size caps for large tool sets, largest tool count first
+}
+
+// defaultToolList returns 25 tools per page with standard schemas, capped
+// harder as the number of matching tools grows
+func defaultToolList() ToolListDefaults {
+	return ToolListDefaults{
+		Limit:       25,
+		SchemaLevel: SchemaLevelStandard,
+		AdjustLimit: true,
+		MaxLimit:    50,
+		ContextCaps: []profiles.ContextCap{
+			{AboveTools: 200, MaxLimit: 20}, // e.g. GoHighLevel's 253 tools
+			{AboveTools: 100, MaxLimit: 30},
+			{AboveTools: 50, MaxLimit: 40},
+		},
+	}
+}
+
+// RetryPolicy controls how tool discovery retries a failing server
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts in total, including the first
+	BaseBackoff time.Duration // Delay before the first retry; doubled for each retry after it
+	MaxBackoff  time.Duration // Upper bound on a single delay
+}
+
+// Backoff returns the jittered delay after a failed attempt (1-based). The
+// delay grows exponentially, and half of it is randomized so servers that
+// failed together don't retry in lockstep.
+func (r RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := r.BaseBackoff
+	for i := 1; i < attempt && delay < r.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.MaxBackoff {
+		delay = r.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// defaultOrchestratorURL is where the orchestrator's HTTP API listens by default
+const defaultOrchestratorURL = "http://localhost:8080"
+
+// loadOrchestratorURL returns the orchestrator's base URL: the --orchestrator-url
+// flag if given, then MCP_ORCHESTRATOR_URL, then the local default
+func loadOrchestratorURL(flagValue string) (string, error) {
+	if raw := strings.TrimSpace(flagValue); raw != "" {
+		return parseOrchestratorURL(raw, "--orchestrator-url")
+	}
+	if raw := strings.TrimSpace(os.Getenv("MCP_ORCHESTRATOR_URL")); raw != "" {
+		return parseOrchestratorURL(raw, "MCP_ORCHESTRATOR_URL")
+	}
+
+	return defaultOrchestratorURL, nil
+}
+
+// parseOrchestratorURL validates an orchestrator base URL; source names where
+// it came from for the error message
+func parseOrchestratorURL(raw, source string) (string, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q: %v", source, raw, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("invalid %s %q: scheme must be http or https", source, raw)
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("invalid %s %q: missing host", source, raw)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", fmt.Errorf("invalid %s %q: query and fragment are not allowed", source, raw)
+	}
+
+	// Endpoint paths are appended to the URL, so drop any trailing slash
+	return strings.TrimRight(raw, "/"), nil
+}
+
+// defaultDiscoveryConcurrency limits discovery so startup doesn't spawn every server at once
+const defaultDiscoveryConcurrency = 4
+
+// defaultDiscoveryWindow covers a client's typical burst of list, categories and call requests
+const defaultDiscoveryWindow = 5 * time.Second
+
+// defaultCallQueueTimeout is how long a call waits for a busy server
+const defaultCallQueueTimeout = 30 * time.Second
+
+// defaultHealthCheckTimeout leaves room for an orchestrator on a busy machine
+const defaultHealthCheckTimeout = 3 * time.Second
+
+// defaultHealthCheckAttempts retries a failed readiness check once
+const defaultHealthCheckAttempts = 2
+
+// defaultToolsChangedInterval is how often idle clients are checked for stale tool lists
+const defaultToolsChangedInterval = 30 * time.Second
+
+// defaultStateReportInterval reports well within the orchestrator's report TTL
+const defaultStateReportInterval = 15 * time.Second
+
+// defaultDiscoveryRetry makes three attempts, waiting roughly 2s and then 4s between them
+var defaultDiscoveryRetry = RetryPolicy{
+	MaxAttempts: 3,
+	BaseBackoff: 2 * time.Second,
+	MaxBackoff:  30 * time.Second,
+}
+
+// defaultAPIRetry rides out an orchestrator restart or a brief overload
+var defaultAPIRetry = RetryPolicy{
+	MaxAttempts: 3,
+	BaseBackoff: 250 * time.Millisecond,
+	MaxBackoff:  2 * time.Second,
+}
+
+// defaultServersCacheTTL shares one server list between the lookups of a request
+const defaultServersCacheTTL = 2 * time.Second
+
+// defaultMaxTools caps the tools tools/list pages through, far above any real
+// tool set, so a runaway discovery can't flood clients
+const defaultMaxTools = 5000
+
+// loadDiscoveryCache reads how long discovered tool lists are cached. The
+// jitter is a fraction of the TTL, e.g. 0.1 for ±10%.
+func loadDiscoveryCache() performance.ToolListTTL {
+	config := performance.DefaultToolListTTL()
+	config.TTL = envDuration("MCP_DISCOVERY_CACHE_TTL", config.TTL)
+	config.Jitter = envFloat("MCP_DISCOVERY_CACHE_JITTER", config.Jitter)
+	config.Grace = envDuration("MCP_DISCOVERY_CACHE_GRACE", config.Grace)
+	return config
+}
+
+// loadProxyConfig reads proxy settings from the environment, falling back to defaults
+func loadProxyConfig() ProxyConfig {
+	quarantine := performance.DefaultQuarantineConfig()
+	quarantine.MaxConsecutiveFailures = envInt("MCP_QUARANTINE_MAX_FAILURES", quarantine.MaxConsecutiveFailures)
+	quarantine.MinSuccessRate = envFloat("MCP_QUARANTINE_MIN_SUCCESS_RATE", quarantine.MinSuccessRate)
+	quarantine.MinCalls = envInt("MCP_QUARANTINE_MIN_CALLS", quarantine.MinCalls)
+	quarantine.WindowSize = envInt("MCP_QUARANTINE_WINDOW_SIZE", quarantine.WindowSize)
+	quarantine.ProbeInterval = envDuration("MCP_QUARANTINE_PROBE_INTERVAL", quarantine.ProbeInterval)
+
+	profile := loadActiveProfile()
+	limits := profile.ToolLimits
+
+	return ProxyConfig{
+		Quarantine:           quarantine,
+		DiscoveryConcurrency: envInt("MCP_DISCOVERY_CONCURRENCY", defaultDiscoveryConcurrency),
+		DiscoveryWindow:      envDuration("MCP_DISCOVERY_WINDOW", defaultDiscoveryWindow),
+		ToolBudgets:          performance.ProfileBudgets(limits.ToolBudgets),
+		CategoryBudgets:      performance.ProfileBudgets(limits.CategoryBudgets),
+		MaxResultBytes:       envInt("MCP_MAX_RESULT_BYTES", limits.MaxResultBytes),
+		ServerOverrides:      serverOverrides(profile.ServerConfigs),
+		DiscoveryRetry: RetryPolicy{
+			MaxAttempts: envInt("MCP_DISCOVERY_MAX_ATTEMPTS", defaultDiscoveryRetry.MaxAttempts),
+			BaseBackoff: envDuration("MCP_DISCOVERY_BACKOFF", defaultDiscoveryRetry.BaseBackoff),
+			MaxBackoff:  envDuration("MCP_DISCOVERY_MAX_BACKOFF", defaultDiscoveryRetry.MaxBackoff),
+		},
+		ToolList: loadToolListDefaults(limits.ToolList),
+		// Match the orchestrator's connection pool so both bound a server alike
+		MaxConcurrentCalls:   envInt("MCP_MAX_CONCURRENT_CALLS", performance.DefaultPoolConfig("").MaxConnections),
+		CallQueueTimeout:     envDuration("MCP_CALL_QUEUE_TIMEOUT", defaultCallQueueTimeout),
+		ToolsChangedInterval: envDuration("MCP_TOOLS_CHANGED_INTERVAL", defaultToolsChangedInterval),
+		StateReportInterval:  envDuration("MCP_STATE_REPORT_INTERVAL", defaultStateReportInterval),
+		HealthCheckTimeout:   envDuration("MCP_HEALTH_CHECK_TIMEOUT", defaultHealthCheckTimeout),
+		HealthCheckAttempts:  envInt("MCP_HEALTH_CHECK_ATTEMPTS", defaultHealthCheckAttempts),
+		ServerAccess:         loadServerAccess(),
+		LazyStart:            envBool("MCP_LAZY_START", false),
+		LazyStartTimeout:     envDuration("MCP_LAZY_START_TIMEOUT", defaultLazyStartTimeout),
+		MaxOutputBytes:       envInt("MCP_MAX_OUTPUT_BYTES", defaultMaxOutputBytes),
+		APIRetry: RetryPolicy{
+			MaxAttempts: envInt("MCP_API_MAX_ATTEMPTS", defaultAPIRetry.MaxAttempts),
+			BaseBackoff: envDuration("MCP_API_BACKOFF", defaultAPIRetry.BaseBackoff),
+			MaxBackoff:  defaultAPIRetry.MaxBackoff,
+		},
+		ServersCacheTTL: envDuration("MCP_SERVERS_CACHE_TTL", defaultServersCacheTTL),
+		ResultFormat:    loadResultFormat(),
+		SharedEnv:       loadSharedEnv(),
+		MaxTools:        envInt("MCP_MAX_TOOLS", defaultMaxTools),
+		DiscoveryCache:  loadDiscoveryCache(),
+		Profile:         profile,
+	}
+}
+
+// loadSharedEnv reads the environment the orchestrator shares with every server
+func loadSharedEnv() map[string]string {
+	env, err := servers.LoadSharedEnv(servers.DefaultBasePath())
+	if err != nil {
+		log.Printf("Warning: Ignoring shared environment: %v", err)
+		return map[string]string{}
+	}
+	return env
+}
+
+// serverEnviron returns the environment a server subprocess starts from: the
+// proxy's own, then the shared variables over it
+func (c ProxyConfig) serverEnviron() []string {
+	return servers.MergeEnv(os.Environ(), c.SharedEnv)
+}
+
+// loadToolListDefaults applies the active profile's tools/list settings, and
+// then the environment, over the built-in defaults
+func loadToolListDefaults(config profiles.ToolListConfig) ToolListDefaults {
+	defaults := defaultToolList()
+	if config.DefaultLimit > 0 {
+		defaults.Limit = config.DefaultLimit
+	}
+	if config.AdjustLimit != nil {
+		defaults.AdjustLimit = *config.AdjustLimit
+	}
+	if config.MaxLimit > 0 {
+		defaults.MaxLimit = config.MaxLimit
+	}
+	if len(config.ContextCaps) > 0 {
+		caps := make([]profiles.ContextCap, 0, len(config.ContextCaps))
+		for _, contextCap := range config.ContextCaps {
+			if contextCap.AboveTools >= 0 && contextCap.MaxLimit > 0 {
+				caps = append(caps, contextCap)
+			}
+		}
+		sort.Slice(caps, func(i, j int) bool {
+			return caps[i].AboveTools > caps[j].AboveTools
+		})
+		defaults.ContextCaps = caps
+	}
+
+	defaults.Limit = envInt("MCP_TOOLS_LIST_LIMIT", defaults.Limit)
+
+	levelName := config.SchemaLevel
+	if env := os.Getenv("MCP_TOOLS_LIST_SCHEMA_LEVEL"); env != "" {
+		levelName = env
+	}
+	if levelName != "" {
+		level, err := parseConfiguredSchemaLevel(levelName)
+		if err != nil {
+			log.Printf("Warning: Ignoring default tools/list schema level: %v", err)
+		} else {
+			defaults.SchemaLevel = level
+		}
+	}
+
+	return defaults
+}
+
+// loadActiveProfile reads the active profile, or an empty one when none is available
+func loadActiveProfile() profiles.Profile {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return profiles.Profile{}
+	}
+
+	profile := profiles.NewProfileManager(filepath.Join(homeDir, ".mcp_orchestrator")).GetActiveProfile()
+	if profile == nil {
+		return profiles.Profile{}
+	}
+
+	return *profile
+}
+
+// envInt reads a positive integer from the environment
+func envInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// envFloat reads a non-negative float from the environment
+func envFloat(key string, fallback float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil && value >= 0 {
+		return value
+	}
+	return fallback
+}
+
+// envBool reads a boolean (e.g. "true" or "1") from the environment
+func envBool(key string, fallback bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// envDuration reads a positive duration (e.g. "30s") from the environment
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
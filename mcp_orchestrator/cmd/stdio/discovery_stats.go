@@ -0,0 +1,91 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"mcp_orchestrator/internal/performance"
+)
+
+// discoveryTimings records the duration of every discovery run per server
+type discoveryTimings struct {
+	mu      sync.Mutex
+	servers map[string]*discoveryTotals
+}
+
+// discoveryTotals accumulates the runs of one server
+type discoveryTotals struct {
+	runs     int
+	failures int
+	total    time.Duration
+	min      time.Duration
+	max      time.Duration
+	last     time.Duration
+	lastRun  time.Time
+}
+
+// newDiscoveryTimings creates an empty timing record
+func newDiscoveryTimings() *discoveryTimings {
+	return &discoveryTimings{servers: make(map[string]*discoveryTotals)}
+}
+
+// record adds one discovery run of a server
+func (t *discoveryTimings) record(serverID string, duration time.Duration, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	totals, exists := t.servers[serverID]
+	if !exists {
+		totals = &discoveryTotals{min: duration}
+		t.servers[serverID] = totals
+	}
+
+	totals.runs++
+	if failed {
+		totals.failures++
+	}
+	totals.total += duration
+	if duration < totals.min {
+		totals.min = duration
+	}
+	if duration > totals.max {
+		totals.max = duration
+	}
+	totals.last = duration
+	totals.lastRun = time.Now()
+}
+
+// summary returns the timings of every server, slowest on average first
+func (t *discoveryTimings) summary() []performance.DiscoveryTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timings := make([]performance.DiscoveryTiming, 0, len(t.servers))
+	for serverID, totals := range t.servers {
+		timings = append(timings, performance.DiscoveryTiming{
+			ServerID: serverID,
+			Runs:     totals.runs,
+			Failures: totals.failures,
+			MinMs:    milliseconds(totals.min),
+			AvgMs:    milliseconds(totals.total / time.Duration(totals.runs)),
+			MaxMs:    milliseconds(totals.max),
+			LastMs:   milliseconds(totals.last),
+			LastRun:  totals.lastRun,
+		})
+	}
+
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].AvgMs != timings[j].AvgMs {
+			return timings[i].AvgMs > timings[j].AvgMs
+		}
+		return timings[i].ServerID < timings[j].ServerID
+	})
+
+	return timings
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -2,23 +2,56 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"mcp_orchestrator/internal/mcpclient"
+	"mcp_orchestrator/internal/performance"
+	"mcp_orchestrator/internal/profiles"
+	"mcp_orchestrator/internal/servers"
 )
 
 // EnhancedDiscovery provides robust tool discovery with diagnostics
 type EnhancedDiscovery struct {
-	orchestratorURL string
-	cache           map[string]CachedToolData
-	cacheMutex      sync.RWMutex
-	diagnostics     *DiagnosticsCollector
+	api            *orchestratorClient
+	cache          *performance.ToolCache
+	diagnostics    *DiagnosticsCollector
+	quarantine     *performance.QuarantineManager
+	discoverySlots chan struct{} // Bounds concurrent discovery subprocesses
+	passWindow     time.Duration // How long a completed discovery pass is reused
+	passMutex      sync.Mutex    // Held while a pass runs so callers share it
+	lastPass       *discoveryPass
+	overrides      serverOverrides   // Launch overrides from the active profile
+	retry          RetryPolicy       // Default discovery retry policy; profiles may override it per server
+	passListener   func(hash string) // Called with the tool set hash after every fresh pass
+	access         ServerAccess      // Servers whose tools may be discovered
+	timings        *discoveryTimings // Duration of every discovery run per server
+	usage          *toolUsage        // Calls per tool, for servers exposing their most popular tools
+	ownersMu       sync.Mutex        // Guards owners
+	owners         map[string]string // Server that last provided each tool, kept after the server stops
+	maxOutput      int               // Output read from one discovery subprocess before it is killed
+	sharedEnv      map[string]string // Environment given to every server, beneath its own variables
+	revalidateMu   sync.Mutex        // Guards revalidating
+	revalidating   map[string]bool   // Servers whose stale tools are being rediscovered in the background
+}
+
+// discoveryPass is the combined result of discovering every running server
+type discoveryPass struct {
+	tools       []interface{}
+	diagnostics []DiagnosticIssue
+	disabled    []string  // Servers skipped because they are disabled
+	capped      []ToolCap // Servers whose tools were capped by max_exposed_tools
+	stopped     []string  // Installed servers that aren't running
+	hash        string    // Order-independent hash of the discovered tools
+	completedAt time.Time
 }
 
 // CachedToolData stores tools with metadata
@@ -47,18 +80,237 @@ type DiagnosticIssue struct {
 }
 
 // NewEnhancedDiscovery creates an enhanced discovery system
-func NewEnhancedDiscovery(orchestratorURL string) *EnhancedDiscovery {
+func NewEnhancedDiscovery(api *orchestratorClient, quarantine *performance.QuarantineManager, config ProxyConfig) *EnhancedDiscovery {
+	maxConcurrent := config.DiscoveryConcurrency
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+
+	cache := performance.NewToolCache()
+	cache.SetToolListTTL(config.DiscoveryCache)
+
 	return &EnhancedDiscovery{
-		orchestratorURL: orchestratorURL,
-		cache:           make(map[string]CachedToolData),
-		diagnostics:     &DiagnosticsCollector{},
+		api:            api,
+		cache:          cache,
+		diagnostics:    &DiagnosticsCollector{},
+		quarantine:     quarantine,
+		discoverySlots: make(chan struct{}, maxConcurrent),
+		passWindow:     config.DiscoveryWindow,
+		overrides:      config.ServerOverrides,
+		retry:          config.DiscoveryRetry,
+		access:         config.ServerAccess,
+		timings:        newDiscoveryTimings(),
+		usage:          newToolUsage(),
+		owners:         make(map[string]string),
+		maxOutput:      config.MaxOutputBytes,
+		sharedEnv:      config.SharedEnv,
+		revalidating:   make(map[string]bool),
 	}
 }
 
-// DiscoverToolsWithDiagnostics performs robust tool discovery
+// Warmup discovers and caches tools for every running server so the first
+// tools/list is served from cache. Returns the number of servers warmed.
+func (ed *EnhancedDiscovery) Warmup() int {
+	// Requests arriving mid-warmup wait for it rather than spawning servers again
+	ed.passMutex.Lock()
+	defer ed.passMutex.Unlock()
+
+	var serverIDs []string
+	for _, server := range ed.getRunningServers() {
+		serverID, _ := server["id"].(string)
+		status, _ := server["status"].(string)
+		if serverID == "" || status != "running" || ed.quarantine.IsQuarantined(serverID) || !ed.access.Allows(serverID) {
+			continue
+		}
+		serverIDs = append(serverIDs, serverID)
+	}
+
+	load := func(serverID string) (interface{}, error) {
+		// Warmup makes a single attempt; a regular pass retries whatever failed
+		policy := ed.overrides.retryPolicy(serverID, ed.retry)
+		policy.MaxAttempts = 1
+		tools, err := ed.discoverServerToolsWithRetry(serverID, policy)
+		if err != nil {
+			return nil, err
+		}
+
+		return CachedToolData{
+			Tools:     tools,
+			ServerID:  serverID,
+			Status:    "success",
+			Timestamp: time.Now(),
+		}, nil
+	}
+
+	return ed.cache.WarmupCache(serverIDs, load, cap(ed.discoverySlots))
+}
+
+// SetPassListener registers a function called with the tool set hash after
+// every fresh discovery pass
+func (ed *EnhancedDiscovery) SetPassListener(listener func(hash string)) {
+	ed.passMutex.Lock()
+	defer ed.passMutex.Unlock()
+
+	ed.passListener = listener
+}
+
+// DiscoverToolsWithDiagnostics performs robust tool discovery. A pass completed
+// within the freshness window is reused, and callers arriving while a pass is
+// running wait for it, so tools/list, tools/categories and tool call routing in
+// quick succession share one set of discovery spawns.
 func (ed *EnhancedDiscovery) DiscoverToolsWithDiagnostics() ([]interface{}, []DiagnosticIssue) {
-	servers := ed.getRunningServers()
+	ed.passMutex.Lock()
+
+	if ed.lastPass != nil && time.Since(ed.lastPass.completedAt) < ed.passWindow {
+		defer ed.passMutex.Unlock()
+		return copyTools(ed.lastPass.tools), ed.lastPass.diagnostics
+	}
+
+	pass := ed.discoverAll()
+	pass.hash = discoveryHash(pass.tools)
+	pass.completedAt = time.Now()
+	ed.lastPass = pass
+	listener := ed.passListener
+	ed.passMutex.Unlock()
+
+	// The listener may write to the client, so it runs outside the lock
+	if listener != nil {
+		listener(pass.hash)
+	}
+
+	// Callers get their own maps so none can race with another on a shared pass
+	return copyTools(pass.tools), pass.diagnostics
+}
+
+// DisabledServerForTool returns the disabled server that last provided a tool,
+// or "" when the tool doesn't belong to a disabled server, along with every
+// server the latest pass skipped as disabled
+func (ed *EnhancedDiscovery) DisabledServerForTool(toolName string) (string, []string) {
+	ed.passMutex.Lock()
+	var disabled []string
+	if ed.lastPass != nil {
+		disabled = ed.lastPass.disabled
+	}
+	ed.passMutex.Unlock()
+
+	for _, serverID := range disabled {
+		cached := ed.getCachedTools(serverID)
+		if cached == nil {
+			continue
+		}
+		for _, toolData := range cached.Tools {
+			if tool, ok := toolData.(map[string]interface{}); ok && tool["name"] == toolName {
+				return serverID, disabled
+			}
+		}
+	}
+
+	return "", disabled
+}
+
+// StoppedServerForTool returns the stopped server that last provided a tool,
+// or "" when the tool's server isn't known to be stopped
+func (ed *EnhancedDiscovery) StoppedServerForTool(toolName string) string {
+	ed.passMutex.Lock()
+	var stopped []string
+	if ed.lastPass != nil {
+		stopped = ed.lastPass.stopped
+	}
+	ed.passMutex.Unlock()
+
+	ed.ownersMu.Lock()
+	owner := ed.owners[toolName]
+	ed.ownersMu.Unlock()
+
+	for _, serverID := range stopped {
+		if serverID == owner {
+			return serverID
+		}
+	}
+	return ""
+}
+
+// Invalidate makes the next discovery call run a fresh pass, e.g. after a
+// server was started
+func (ed *EnhancedDiscovery) Invalidate() {
+	ed.passMutex.Lock()
+	defer ed.passMutex.Unlock()
+
+	ed.lastPass = nil
+	ed.api.InvalidateServers()
+}
+
+// recordOwners remembers which server provides each of its tools
+func (ed *EnhancedDiscovery) recordOwners(serverID string, tools []interface{}) {
+	ed.ownersMu.Lock()
+	defer ed.ownersMu.Unlock()
+
+	for _, toolData := range tools {
+		if tool, ok := toolData.(map[string]interface{}); ok {
+			if name, ok := tool["name"].(string); ok {
+				ed.owners[name] = serverID
+			}
+		}
+	}
+}
+
+// CappedServers returns the servers whose tools the latest pass capped
+func (ed *EnhancedDiscovery) CappedServers() []ToolCap {
+	ed.passMutex.Lock()
+	defer ed.passMutex.Unlock()
+
+	if ed.lastPass == nil {
+		return []ToolCap{}
+	}
+	return ed.lastPass.capped
+}
+
+// RecordToolCall counts a call to a server's tool towards its popularity
+func (ed *EnhancedDiscovery) RecordToolCall(serverID, toolName string) {
+	ed.usage.record(serverID, toolName)
+}
+
+// copyTools returns a copy of a tool list with each tool's top-level map copied
+func copyTools(tools []interface{}) []interface{} {
+	copied := make([]interface{}, 0, len(tools))
+	for _, toolData := range tools {
+		if tool, ok := toolData.(map[string]interface{}); ok {
+			copied = append(copied, copyTool(tool))
+			continue
+		}
+		copied = append(copied, toolData)
+	}
+	return copied
+}
+
+// copyTool returns a shallow copy of a tool map. Nested schemas are shared but
+// are never modified after discovery.
+func copyTool(tool map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(tool)+3)
+	for key, value := range tool {
+		copied[key] = value
+	}
+	return copied
+}
+
+// discoverAll discovers tools from every running server the proxy may route to
+func (ed *EnhancedDiscovery) discoverAll() *discoveryPass {
+	servers := ed.allowedServers(ed.getRunningServers())
 	var allTools []interface{}
+
+	disabled := []string{}
+	stopped := []string{}
+	categories := make(map[string]string, len(servers))
+	for _, server := range servers {
+		serverID, _ := server["id"].(string)
+		categories[serverID], _ = server["category"].(string)
+		switch status, _ := server["status"].(string); status {
+		case "disabled":
+			disabled = append(disabled, serverID)
+		case "stopped", "installed":
+			stopped = append(stopped, serverID)
+		}
+	}
 	var wg sync.WaitGroup
 	toolsChan := make(chan CachedToolData, len(servers))
 
@@ -70,6 +322,14 @@ func (ed *EnhancedDiscovery) DiscoverToolsWithDiagnostics() ([]interface{}, []Di
 			serverID, _ := serverData["id"].(string)
 			status, _ := serverData["status"].(string)
 
+			// Disabled servers are deliberately hidden, so this is informational
+			if status == "disabled" {
+				ed.addDiagnostic(serverID, "server_disabled",
+					fmt.Sprintf("Server %s is disabled", serverID), "info",
+					"Enable the server in the MCP Orchestrator UI to list its tools")
+				return
+			}
+
 			if status != "running" {
 				ed.addDiagnostic(serverID, "server_not_running",
 					fmt.Sprintf("Server %s has status: %s", serverID, status), "warning",
@@ -77,18 +337,58 @@ func (ed *EnhancedDiscovery) DiscoverToolsWithDiagnostics() ([]interface{}, []Di
 				return
 			}
 
-			// Check cache first
-			if cached := ed.getCachedTools(serverID); cached != nil {
-				toolsChan <- *cached
-				return
+			// Quarantined servers are hidden until a half-open probe succeeds
+			probing := false
+			if ed.quarantine.IsQuarantined(serverID) {
+				if !ed.quarantine.Allow(serverID) {
+					ed.addDiagnostic(serverID, "server_quarantined",
+						fmt.Sprintf("Server %s is quarantined after repeated failures", serverID), "warning",
+						"The server will be probed again automatically; check its logs and credentials")
+					return
+				}
+				probing = true
+			}
+
+			// A refresh requested through the orchestrator makes older cache entries stale
+			if refreshedAt, ok := serverData["tools_refreshed_at"].(string); ok {
+				ed.invalidateIfRefreshed(serverID, refreshedAt)
+			}
+
+			// Check cache first (a probe always performs a fresh discovery)
+			if !probing {
+				if cached := ed.getCachedTools(serverID); cached != nil {
+					toolsChan <- *cached
+					return
+				}
+				// Within the grace window the stale tools are served while
+				// they are rediscovered in the background
+				if stale := ed.getStaleTools(serverID); stale != nil {
+					ed.revalidate(serverID)
+					toolsChan <- *stale
+					return
+				}
 			}
 
 			// Perform discovery with diagnostics
-			tools, err := ed.discoverServerToolsWithRetry(serverID, 3)
+			policy := ed.overrides.retryPolicy(serverID, ed.retry)
+			if probing {
+				policy.MaxAttempts = 1
+			}
+			tools, err := ed.discoverServerToolsWithRetry(serverID, policy)
+			if probing {
+				ed.quarantine.RecordResult(serverID, err == nil)
+			}
 			if err != nil {
-				ed.addDiagnostic(serverID, "tool_discovery_failed",
-					fmt.Sprintf("Failed to discover tools: %v", err), "error",
-					"Check server logs, verify credentials, and ensure dependencies are installed")
+				var tooLarge *outputTooLargeError
+				if errors.As(err, &tooLarge) {
+					ed.addDiagnostic(serverID, "output_too_large",
+						fmt.Sprintf("Tool discovery stopped: %v", err), "error",
+						"Check the server isn't writing logs to stdout, or raise MCP_MAX_OUTPUT_BYTES")
+				} else {
+					ed.addDiagnostic(serverID, "tool_discovery_failed",
+						fmt.Sprintf("Failed to discover tools: %v", err), "error",
+						"Check server logs, verify credentials, and ensure dependencies are installed")
+				}
 
 				toolsChan <- CachedToolData{
 					ServerID:  serverID,
@@ -115,46 +415,31 @@ func (ed *EnhancedDiscovery) DiscoverToolsWithDiagnostics() ([]interface{}, []Di
 	close(toolsChan)
 
 	// Collect results
+	capped := []ToolCap{}
 	for cached := range toolsChan {
 		if cached.Status == "success" {
+			// Keep one huge server from crowding out the others
+			serverTools, toolCap := ed.overrides.capTools(cached.ServerID, cached.Tools, ed.usage)
+			if toolCap != nil {
+				capped = append(capped, *toolCap)
+				ed.addDiagnostic(cached.ServerID, "tools_capped",
+					fmt.Sprintf("Server %s exposes %d of its %d tools", cached.ServerID, toolCap.Exposed, toolCap.Total), "info",
+					"Raise max_exposed_tools or adjust tool_priority in the active profile to expose other tools")
+			}
+
+			ed.recordOwners(cached.ServerID, cached.Tools)
+
 			// Add server metadata to each tool
-			for _, toolData := range cached.Tools {
-				if tool, ok := toolData.(map[string]interface{}); ok {
+			for _, toolData := range serverTools {
+				if cachedTool, ok := toolData.(map[string]interface{}); ok {
+					// Never write to the cached maps; other passes may be reading them
+					tool := copyTool(cachedTool)
 					tool["_server_id"] = cached.ServerID
 					tool["_discovered_at"] = cached.Timestamp.Unix()
 
 					// Set category if not already set
 					if tool["category"] == nil || tool["category"] == "" {
-						switch cached.ServerID {
-						case "gohighlevel":
-							tool["category"] = "gohighlevel"
-						case "meta-ads":
-							tool["category"] = "meta-ads"
-						case "google-ads":
-							tool["category"] = "google-ads"
-						case "github":
-							tool["category"] = "development"
-						case "puppeteer":
-							tool["category"] = "web_browser"
-						case "slack":
-							tool["category"] = "communication"
-						case "gmail":
-							tool["category"] = "email"
-						case "brave-search":
-							tool["category"] = "search"
-						case "notion":
-							tool["category"] = "productivity"
-						case "figma":
-							tool["category"] = "design"
-						case "google-maps":
-							tool["category"] = "maps"
-						case "stripe":
-							tool["category"] = "payments"
-						case "docker":
-							tool["category"] = "development"
-						default:
-							tool["category"] = cached.ServerID
-						}
+						tool["category"] = profiles.ToolCategory(cached.ServerID, categories[cached.ServerID])
 					}
 
 					allTools = append(allTools, tool)
@@ -163,12 +448,39 @@ func (ed *EnhancedDiscovery) DiscoverToolsWithDiagnostics() ([]interface{}, []Di
 		}
 	}
 
-	return allTools, ed.getDiagnostics()
+	sort.Slice(capped, func(i, j int) bool {
+		return capped[i].ServerID < capped[j].ServerID
+	})
+
+	return &discoveryPass{
+		tools:       allTools,
+		diagnostics: ed.getDiagnostics(),
+		disabled:    disabled,
+		capped:      capped,
+		stopped:     stopped,
+	}
+}
+
+// allowedServers drops servers excluded by the allowlist or denylist
+func (ed *EnhancedDiscovery) allowedServers(servers []map[string]interface{}) []map[string]interface{} {
+	allowed := make([]map[string]interface{}, 0, len(servers))
+	for _, server := range servers {
+		serverID, _ := server["id"].(string)
+		if !ed.access.Allows(serverID) {
+			ed.addDiagnostic(serverID, "server_not_allowed",
+				fmt.Sprintf("Server %s is excluded by the proxy's server allowlist or denylist", serverID), "info",
+				"Add the server to MCP_ALLOWED_SERVERS or remove it from MCP_DENIED_SERVERS")
+			continue
+		}
+		allowed = append(allowed, server)
+	}
+	return allowed
 }
 
 // discoverServerToolsWithRetry performs tool discovery with retry logic
-func (ed *EnhancedDiscovery) discoverServerToolsWithRetry(serverID string, maxRetries int) ([]interface{}, error) {
+func (ed *EnhancedDiscovery) discoverServerToolsWithRetry(serverID string, policy RetryPolicy) ([]interface{}, error) {
 	var lastErr error
+	maxRetries := policy.MaxAttempts
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		tools, err := ed.discoverServerTools(serverID)
@@ -180,9 +492,15 @@ func (ed *EnhancedDiscovery) discoverServerToolsWithRetry(serverID string, maxRe
 			return tools, nil
 		}
 
+		// A server flooding its output will do so again
+		var tooLarge *outputTooLargeError
+		if errors.As(err, &tooLarge) {
+			return nil, err
+		}
+
 		lastErr = err
 		if attempt < maxRetries {
-			backoffDelay := time.Duration(attempt) * 2 * time.Second
+			backoffDelay := policy.Backoff(attempt)
 			ed.addDiagnostic(serverID, "retry_attempt",
 				fmt.Sprintf("Retry %d/%d after %v: %v", attempt, maxRetries, backoffDelay, err),
 				"warning", "")
@@ -193,8 +511,26 @@ func (ed *EnhancedDiscovery) discoverServerToolsWithRetry(serverID string, maxRe
 	return nil, fmt.Errorf("failed after %d attempts: %v", maxRetries, lastErr)
 }
 
-// discoverServerTools discovers tools for a specific server
+// discoverServerTools discovers tools for a specific server, recording how
+// long the server took. Time spent waiting for a discovery slot isn't counted.
 func (ed *EnhancedDiscovery) discoverServerTools(serverID string) ([]interface{}, error) {
+	ed.discoverySlots <- struct{}{}
+	defer func() { <-ed.discoverySlots }()
+
+	start := time.Now()
+	tools, err := ed.runServerDiscovery(serverID)
+	ed.timings.record(serverID, time.Since(start), err != nil)
+
+	return tools, err
+}
+
+// DiscoveryTimings returns discovery durations per server, slowest first
+func (ed *EnhancedDiscovery) DiscoveryTimings() []performance.DiscoveryTiming {
+	return ed.timings.summary()
+}
+
+// runServerDiscovery spawns a server and lists its tools
+func (ed *EnhancedDiscovery) runServerDiscovery(serverID string) ([]interface{}, error) {
 	serverPath := "/Users/user/.mcp_orchestrator/" + serverID
 
 	// Pre-flight checks
@@ -202,9 +538,11 @@ func (ed *EnhancedDiscovery) discoverServerTools(serverID string) ([]interface{}
 		return nil, fmt.Errorf("preflight check failed: %v", err)
 	}
 
-	// Create MCP messages
-	messages := ed.createMCPMessages()
-	input := strings.Join(messages, "\n") + "\n"
+	// Handshake, then list the tools
+	input, err := mcpclient.BuildHandshake(mcpclient.ToolsListRequest())
+	if err != nil {
+		return nil, err
+	}
 
 	// Determine execution strategy based on server type
 	cmd, err := ed.createServerCommand(serverID, serverPath)
@@ -224,14 +562,18 @@ func (ed *EnhancedDiscovery) discoverServerTools(serverID string) ([]interface{}
 	cmdCtx.Env = cmd.Env
 	cmdCtx.Stdin = strings.NewReader(input)
 
-	// Capture both stdout and stderr
-	output, err := cmdCtx.CombinedOutput()
+	// Capture both stdout and stderr, up to the output cap
+	output, err := runWithOutputCap(cmdCtx, ed.maxOutput, true)
+	var tooLarge *outputTooLargeError
+	if errors.As(err, &tooLarge) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("execution failed: %v, output: %s", err, string(output))
 	}
 
 	// Parse tools from output
-	tools, err := ed.parseToolsFromOutput(string(output))
+	tools, err := mcpclient.ParseToolsResponse(string(output))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse tools: %v", err)
 	}
@@ -323,41 +665,35 @@ func (ed *EnhancedDiscovery) checkNodeJSServerRequirements(serverPath string) er
 
 // createServerCommand creates the appropriate command for server execution
 func (ed *EnhancedDiscovery) createServerCommand(serverID, serverPath string) (*exec.Cmd, error) {
-	var cmd *exec.Cmd
+	var command string
+	var args []string
 
 	switch serverID {
 	case "gohighlevel":
-		cmd = exec.Command("node", "dist/server.js")
-		cmd.Dir = serverPath
+		command, args = "node", []string{"dist/server.js"}
 
 	case "meta-ads":
-		pythonPath := filepath.Join(serverPath, "venv", "bin", "python")
-		if _, err := os.Stat(pythonPath); os.IsNotExist(err) {
-			pythonPath = filepath.Join(serverPath, "venv", "Scripts", "python.exe")
-		}
-		cmd = exec.Command(pythonPath, "-m", "meta_ads_mcp")
-		cmd.Dir = serverPath
+		command, args = venvPython(serverPath), []string{"-m", "meta_ads_mcp"}
 
 	case "google-ads":
-		pythonPath := filepath.Join(serverPath, "venv", "bin", "python")
-		if _, err := os.Stat(pythonPath); os.IsNotExist(err) {
-			pythonPath = filepath.Join(serverPath, "venv", "Scripts", "python.exe")
-		}
-		cmd = exec.Command(pythonPath, "-m", "mcp_google_ads")
-		cmd.Dir = serverPath
+		command, args = venvPython(serverPath), []string{"-m", "mcp_google_ads"}
 
 	default:
 		// Generic npm-based servers
-		cmd = exec.Command("npx", "-y", "@modelcontextprotocol/server-"+serverID)
-		cmd.Dir = serverPath
+		command, args = "npx", []string{"-y", "@modelcontextprotocol/server-" + serverID}
 	}
 
-	// Set environment variables
-	env := os.Environ()
+	// The active profile may point the server at a different build
+	command, args, dir := ed.overrides.launch(serverID, serverPath, command, args, ed.sharedEnv)
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+
+	// Set environment variables: shared ones first, so the server's own win
+	env := servers.MergeEnv(os.Environ(), ed.sharedEnv)
 
 	// Load .env file if it exists
 	envFile := filepath.Join(serverPath, ".env")
-	if envVars, err := ed.loadEnvFile(envFile); err == nil {
+	if envVars, err := mcpclient.LoadEnvFile(envFile); err == nil {
 		for key, value := range envVars {
 			env = append(env, fmt.Sprintf("%s=%s", key, value))
 		}
@@ -370,29 +706,13 @@ func (ed *EnhancedDiscovery) createServerCommand(serverID, serverPath string) (*
 	return cmd, nil
 }
 
-// loadEnvFile loads environment variables from .env file
-func (ed *EnhancedDiscovery) loadEnvFile(filename string) (map[string]string, error) {
-	envVars := make(map[string]string)
-
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, err
-	}
-
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			envVars[parts[0]] = parts[1]
-		}
+// venvPython returns the Python interpreter of a server's virtual environment
+func venvPython(serverPath string) string {
+	pythonPath := filepath.Join(serverPath, "venv", "bin", "python")
+	if _, err := os.Stat(pythonPath); os.IsNotExist(err) {
+		pythonPath = filepath.Join(serverPath, "venv", "Scripts", "python.exe")
 	}
-
-	return envVars, nil
+	return pythonPath
 }
 
 // addServerSpecificEnv adds server-specific environment variables
@@ -413,111 +733,127 @@ func (ed *EnhancedDiscovery) addServerSpecificEnv(env []string, serverID string)
 	return env
 }
 
-// createMCPMessages creates the standard MCP message sequence
-func (ed *EnhancedDiscovery) createMCPMessages() []string {
-	initMsg := map[string]interface{}{
-		"id":      1,
-		"method":  "initialize",
-		"jsonrpc": "2.0",
-		"params": map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities":    map[string]interface{}{},
-			"clientInfo": map[string]interface{}{
-				"name":    "mcp-orchestrator",
-				"version": "1.0.0",
-			},
-		},
-	}
-
-	notifyMsg := map[string]interface{}{
-		"method":  "notifications/initialized",
-		"jsonrpc": "2.0",
-	}
-
-	toolsMsg := map[string]interface{}{
-		"id":      2,
-		"method":  "tools/list",
-		"jsonrpc": "2.0",
-		"params":  map[string]interface{}{},
-	}
-
-	messages := make([]string, 3)
-
-	if data, err := json.Marshal(initMsg); err == nil {
-		messages[0] = string(data)
+// Cache management methods
+func (ed *EnhancedDiscovery) getCachedTools(serverID string) *CachedToolData {
+	// Entries go stale after the tool cache's jittered TTL
+	if value, exists := ed.cache.GetCachedToolList(serverID); exists {
+		if cached, ok := value.(CachedToolData); ok {
+			return &cached
+		}
 	}
 
-	if data, err := json.Marshal(notifyMsg); err == nil {
-		messages[1] = string(data)
-	}
+	return nil
+}
 
-	if data, err := json.Marshal(toolsMsg); err == nil {
-		messages[2] = string(data)
+// getStaleTools returns a server's cached tools once they have gone stale but
+// are still within the grace window
+func (ed *EnhancedDiscovery) getStaleTools(serverID string) *CachedToolData {
+	if value, exists := ed.cache.GetStaleToolList(serverID); exists {
+		if cached, ok := value.(CachedToolData); ok {
+			return &cached
+		}
 	}
 
-	return messages
+	return nil
 }
 
-// parseToolsFromOutput extracts tools from server output
-func (ed *EnhancedDiscovery) parseToolsFromOutput(output string) ([]interface{}, error) {
-	lines := strings.Split(output, "\n")
-
-	// Try line-by-line parsing first
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || !strings.HasPrefix(line, "{") {
-			continue
+// revalidate rediscovers a server's stale tools in the background, unless
+// that is already under way. The next pass picks up the refreshed tools; on
+// failure the stale tools are served until the grace window ends.
+func (ed *EnhancedDiscovery) revalidate(serverID string) {
+	ed.revalidateMu.Lock()
+	if ed.revalidating[serverID] {
+		ed.revalidateMu.Unlock()
+		return
+	}
+	ed.revalidating[serverID] = true
+	ed.revalidateMu.Unlock()
+
+	go func() {
+		defer func() {
+			ed.revalidateMu.Lock()
+			delete(ed.revalidating, serverID)
+			ed.revalidateMu.Unlock()
+		}()
+
+		tools, err := ed.discoverServerToolsWithRetry(serverID, ed.overrides.retryPolicy(serverID, ed.retry))
+		if err != nil {
+			log.Printf("Warning: Failed to refresh stale tools of %s: %v", serverID, err)
+			return
 		}
 
-		var msg map[string]interface{}
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			continue
-		}
+		ed.setCachedTools(serverID, CachedToolData{
+			Tools:     tools,
+			ServerID:  serverID,
+			Status:    "success",
+			Timestamp: time.Now(),
+		})
+		ed.Invalidate()
+	}()
+}
 
-		// Check if this is our tools/list response (ID 2)
-		if id, ok := msg["id"]; ok {
-			var idNum float64
-			switch v := id.(type) {
-			case float64:
-				idNum = v
-			case int:
-				idNum = float64(v)
-			default:
-				continue
-			}
+func (ed *EnhancedDiscovery) setCachedTools(serverID string, data CachedToolData) {
+	ed.cache.CacheToolList(serverID, data)
+}
 
-			if idNum == 2 {
-				if result, ok := msg["result"].(map[string]interface{}); ok {
-					if tools, ok := result["tools"].([]interface{}); ok {
-						return tools, nil
-					}
-				}
-			}
+// DiscoveryCacheEntry describes a server's cached discovery result
+type DiscoveryCacheEntry struct {
+	ServerID  string    `json:"server_id"`
+	Timestamp time.Time `json:"timestamp"`
+	ToolCount int       `json:"tool_count"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// CacheEntries returns the cached discovery result of every server, sorted by server ID
+func (ed *EnhancedDiscovery) CacheEntries() []DiscoveryCacheEntry {
+	entries := []DiscoveryCacheEntry{}
+	for serverID, value := range ed.cache.CachedToolLists() {
+		if cached, ok := value.(CachedToolData); ok {
+			entries = append(entries, DiscoveryCacheEntry{
+				ServerID:  serverID,
+				Timestamp: cached.Timestamp,
+				ToolCount: len(cached.Tools),
+				Status:    cached.Status,
+				Error:     cached.Error,
+			})
 		}
 	}
-
-	return nil, fmt.Errorf("no valid tools response found in output")
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ServerID < entries[j].ServerID
+	})
+	return entries
 }
 
-// Cache management methods
-func (ed *EnhancedDiscovery) getCachedTools(serverID string) *CachedToolData {
-	ed.cacheMutex.RLock()
-	defer ed.cacheMutex.RUnlock()
-
-	if cached, exists := ed.cache[serverID]; exists {
-		// Cache expires after 5 minutes
-		if time.Since(cached.Timestamp) < 5*time.Minute {
-			return &cached
+// ClearCache drops the cached tools of one server, or of every server when
+// serverID is empty, and returns the servers cleared
+func (ed *EnhancedDiscovery) ClearCache(serverID string) []string {
+	cleared := []string{}
+	for _, entry := range ed.CacheEntries() {
+		if serverID == "" || entry.ServerID == serverID {
+			ed.cache.InvalidateServer(entry.ServerID)
+			cleared = append(cleared, entry.ServerID)
 		}
 	}
-
-	return nil
+	ed.Invalidate()
+	return cleared
 }
 
-func (ed *EnhancedDiscovery) setCachedTools(serverID string, data CachedToolData) {
-	ed.cacheMutex.Lock()
-	defer ed.cacheMutex.Unlock()
-	ed.cache[serverID] = data
+// invalidateIfRefreshed drops a server's cached tools when the orchestrator
+// refreshed its tools after they were cached
+func (ed *EnhancedDiscovery) invalidateIfRefreshed(serverID, refreshedAt string) {
+	refreshed, err := time.Parse(time.RFC3339Nano, refreshedAt)
+	if err != nil {
+		return
+	}
+
+	cached := ed.getCachedTools(serverID)
+	if cached == nil {
+		cached = ed.getStaleTools(serverID)
+	}
+	if cached != nil && cached.Timestamp.Before(refreshed) {
+		ed.cache.InvalidateServer(serverID)
+	}
 }
 
 // Diagnostics methods
@@ -549,54 +885,21 @@ func (ed *EnhancedDiscovery) getRunningServers() []map[string]interface{} {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	client := &http.Client{Timeout: 15 * time.Second}
-	req, err := http.NewRequestWithContext(ctx, "GET", ed.orchestratorURL+"/api/servers", nil)
-	if err != nil {
-		ed.addDiagnostic("orchestrator", "api_request_failed",
-			fmt.Sprintf("Failed to create API request: %v", err), "error",
-			"Check if orchestrator is running and accessible")
-		return []map[string]interface{}{}
-	}
-
-	resp, err := client.Do(req)
+	servers, err := ed.api.KnownServers(ctx)
 	if err != nil {
-		ed.addDiagnostic("orchestrator", "api_connection_failed",
-			fmt.Sprintf("Failed to connect to orchestrator API: %v", err), "error",
-			"Start the MCP Orchestrator service")
-		return []map[string]interface{}{}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		ed.addDiagnostic("orchestrator", "api_error_response",
-			fmt.Sprintf("Orchestrator API returned status %d", resp.StatusCode), "error",
-			"Check orchestrator logs for errors")
-		return []map[string]interface{}{}
-	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		ed.addDiagnostic("orchestrator", "api_parse_failed",
-			fmt.Sprintf("Failed to parse API response: %v", err), "error",
-			"Check orchestrator API response format")
-		return []map[string]interface{}{}
-	}
-
-	servers, ok := result["servers"].([]interface{})
-	if !ok {
-		ed.addDiagnostic("orchestrator", "unexpected_response",
-			"API response does not contain expected servers array", "warning",
-			"Check orchestrator API implementation")
-		return []map[string]interface{}{}
-	}
-
-	// Convert to proper format
-	var serverList []map[string]interface{}
-	for _, serverData := range servers {
-		if server, ok := serverData.(map[string]interface{}); ok {
-			serverList = append(serverList, server)
+		var failure *apiError
+		if errors.As(err, &failure) {
+			ed.addDiagnostic("orchestrator", failure.IssueType, failure.Error(), failure.Severity, failure.Resolution)
 		}
+		if servers == nil {
+			return []map[string]interface{}{}
+		}
+
+		// Keep serving the servers we know about rather than no tools at all
+		ed.addDiagnostic("orchestrator", "stale_server_list",
+			fmt.Sprintf("Using the last known list of %d servers", len(servers)), "warning",
+			"Server statuses may be out of date until the orchestrator API responds again")
 	}
 
-	return serverList
+	return servers
 }
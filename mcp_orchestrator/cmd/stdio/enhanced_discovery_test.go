@@ -0,0 +1,52 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestConcurrentDiscoveryDoesNotShareTools runs discovery from many
+// goroutines that each modify the tools they get back, as tools/list and call
+// routing do. Run with -race to catch maps shared between callers.
+func TestConcurrentDiscoveryDoesNotShareTools(t *testing.T) {
+	p := newListingProxy(t, 50)
+	p.enhancedDiscovery.passWindow = time.Minute
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// Some callers force fresh passes built from the shared cache
+			if i%4 == 0 {
+				p.enhancedDiscovery.passMutex.Lock()
+				p.enhancedDiscovery.lastPass = nil
+				p.enhancedDiscovery.passMutex.Unlock()
+			}
+
+			tools, _ := p.enhancedDiscovery.DiscoverToolsWithDiagnostics()
+			for _, toolData := range tools {
+				tool := toolData.(map[string]interface{})
+				tool["description"] = "changed by a caller"
+				delete(tool, "_server_id")
+			}
+
+			// tools/list copies and annotates tools concurrently as well
+			p.handleToolsList(MCPMessage{ID: i, JSONRPC: "2.0", Method: "tools/list"})
+		}(i)
+	}
+	wg.Wait()
+
+	tools, _ := p.enhancedDiscovery.DiscoverToolsWithDiagnostics()
+	if len(tools) != 50 {
+		t.Fatalf("discovered %d tools, want 50", len(tools))
+	}
+	for _, toolData := range tools {
+		tool := toolData.(map[string]interface{})
+		if tool["description"] != "Tool under test" || tool["_server_id"] != "big" {
+			t.Fatalf("a caller's change leaked into discovery: %v", tool)
+		}
+	}
+}
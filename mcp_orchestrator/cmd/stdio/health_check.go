@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// Reasons an orchestrator health check failed
+const (
+	orchestratorTimeout     = "timeout"
+	orchestratorRefused     = "connection_refused"
+	orchestratorNotReady    = "not_ready"
+	orchestratorUnreachable = "unreachable"
+)
+
+// orchestratorCheckRetryDelay is the pause between health check attempts
+const orchestratorCheckRetryDelay = 250 * time.Millisecond
+
+// orchestratorCheckError explains why the orchestrator was considered down
+type orchestratorCheckError struct {
+	Reason     string
+	Attempts   int
+	Timeout    time.Duration
+	StatusCode int
+	Err        error
+}
+
+// Error implements the error interface
+func (e *orchestratorCheckError) Error() string {
+	switch e.Reason {
+	case orchestratorTimeout:
+		return fmt.Sprintf("MCP Orchestrator did not respond within %v (%d attempts); it may be busy or hung", e.Timeout, e.Attempts)
+	case orchestratorRefused:
+		return "MCP Orchestrator is not running: connection refused"
+	case orchestratorNotReady:
+		return fmt.Sprintf("MCP Orchestrator is running but not ready (HTTP %d)", e.StatusCode)
+	default:
+		return fmt.Sprintf("MCP Orchestrator is unreachable: %v", e.Err)
+	}
+}
+
+// checkOrchestrator checks that the orchestrator is ready, retrying briefly so
+// a momentarily busy orchestrator isn't reported as down
+func (p *StdioProxy) checkOrchestrator() error {
+	attempts := p.config.HealthCheckAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var checkErr *orchestratorCheckError
+	for attempt := 1; attempt <= attempts; attempt++ {
+		checkErr = p.probeOrchestrator()
+		if checkErr == nil {
+			return nil
+		}
+		checkErr.Attempts = attempt
+
+		if attempt < attempts {
+			time.Sleep(orchestratorCheckRetryDelay)
+		}
+	}
+
+	return checkErr
+}
+
+// probeOrchestrator makes a single readiness request to the orchestrator
+func (p *StdioProxy) probeOrchestrator() *orchestratorCheckError {
+	timeout := p.config.HealthCheckTimeout
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Readiness rather than liveness, so calls aren't routed before state is loaded
+	req, err := p.api.newRequest(ctx, http.MethodGet, "/health/ready")
+	if err != nil {
+		return &orchestratorCheckError{Reason: orchestratorUnreachable, Err: err}
+	}
+
+	// Sent once; failed checks are retried by checkOrchestrator's attempts
+	resp, err := p.api.client.Do(req)
+	if err != nil {
+		var netErr net.Error
+		switch {
+		case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+			return &orchestratorCheckError{Reason: orchestratorTimeout, Timeout: timeout, Err: err}
+		case errors.Is(err, syscall.ECONNREFUSED):
+			return &orchestratorCheckError{Reason: orchestratorRefused, Err: err}
+		default:
+			return &orchestratorCheckError{Reason: orchestratorUnreachable, Err: err}
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &orchestratorCheckError{Reason: orchestratorNotReady, StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
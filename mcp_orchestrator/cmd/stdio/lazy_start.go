@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// lazyStartPollInterval is how often a starting server's health is checked
+const lazyStartPollInterval = 500 * time.Millisecond
+
+// defaultLazyStartTimeout is how long a call waits for a stopped server to start and become healthy
+const defaultLazyStartTimeout = 30 * time.Second
+
+// defaultLazyStartBackoff spaces out start attempts for a server that keeps failing to start
+var defaultLazyStartBackoff = RetryPolicy{
+	BaseBackoff: 5 * time.Second,
+	MaxBackoff:  5 * time.Minute,
+}
+
+// lazyStarter starts stopped servers on demand, backing off after failed
+// starts so calls to a broken server don't set off a storm of restarts
+type lazyStarter struct {
+	mu      sync.Mutex
+	servers map[string]*lazyStartState
+	backoff RetryPolicy
+}
+
+// lazyStartState tracks start attempts for one server
+type lazyStartState struct {
+	mu          sync.Mutex // Held while the server starts so concurrent calls share one start
+	failures    int
+	nextAttempt time.Time
+	lastError   string
+}
+
+// lazyStartError reports a server that could not be started for a call
+type lazyStartError struct {
+	ServerID   string
+	Reason     string
+	RetryAfter time.Duration // Set when the start was skipped because of earlier failures
+}
+
+// Error implements error
+func (e *lazyStartError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("server %s failed to start recently (%s); not retrying for %v",
+			e.ServerID, e.Reason, e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("server %s is stopped and could not be started: %s", e.ServerID, e.Reason)
+}
+
+// newLazyStarter creates a lazy starter with the given backoff between failed starts
+func newLazyStarter(backoff RetryPolicy) *lazyStarter {
+	return &lazyStarter{
+		servers: make(map[string]*lazyStartState),
+		backoff: backoff,
+	}
+}
+
+// state returns a server's start state, creating it on first use
+func (ls *lazyStarter) state(serverID string) *lazyStartState {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	state, exists := ls.servers[serverID]
+	if !exists {
+		state = &lazyStartState{}
+		ls.servers[serverID] = state
+	}
+	return state
+}
+
+// startServerForCall asks the orchestrator to start a stopped server and waits
+// until it answers health probes, within the lazy start timeout
+func (p *StdioProxy) startServerForCall(ctx context.Context, serverID string) error {
+	state := p.starter.state(serverID)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.LazyStartTimeout)
+	defer cancel()
+
+	// A call waiting on the lock may find the server already started
+	if healthy, _ := p.serverHealthy(ctx, serverID); healthy {
+		return nil
+	}
+
+	if wait := time.Until(state.nextAttempt); wait > 0 {
+		return &lazyStartError{ServerID: serverID, Reason: state.lastError, RetryAfter: wait}
+	}
+
+	err := p.requestServerStart(ctx, serverID)
+	if err == nil {
+		err = p.waitForServerHealthy(ctx, serverID)
+	}
+	if err != nil {
+		state.failures++
+		state.nextAttempt = time.Now().Add(p.starter.backoff.Backoff(state.failures))
+		state.lastError = err.Error()
+		return &lazyStartError{ServerID: serverID, Reason: err.Error()}
+	}
+
+	state.failures = 0
+	state.nextAttempt = time.Time{}
+	state.lastError = ""
+	return nil
+}
+
+// requestServerStart asks the orchestrator to start a server
+func (p *StdioProxy) requestServerStart(ctx context.Context, serverID string) error {
+	req, err := p.api.newRequest(ctx, http.MethodPost, "/api/servers/"+url.PathEscape(serverID)+"/start")
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.api.Do(req)
+	if err != nil {
+		return fmt.Errorf("start request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&body) == nil && body.Error != "" {
+			return fmt.Errorf("%s", body.Error)
+		}
+		return fmt.Errorf("orchestrator returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// waitForServerHealthy polls a server's health until it responds or ctx ends
+func (p *StdioProxy) waitForServerHealthy(ctx context.Context, serverID string) error {
+	ticker := time.NewTicker(lazyStartPollInterval)
+	defer ticker.Stop()
+
+	for {
+		healthy, status := p.serverHealthy(ctx, serverID)
+		if healthy {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if status == "" {
+				status = "unknown"
+			}
+			return fmt.Errorf("server did not become healthy in time (last status: %s)", status)
+		case <-ticker.C:
+		}
+	}
+}
+
+// serverHealthy probes a server through the orchestrator, returning whether
+// it is healthy along with the reported health status
+func (p *StdioProxy) serverHealthy(ctx context.Context, serverID string) (bool, string) {
+	req, err := p.api.newRequest(ctx, http.MethodGet, "/api/servers/"+url.PathEscape(serverID)+"/health")
+	if err != nil {
+		return false, ""
+	}
+
+	resp, err := p.api.Do(req)
+	if err != nil {
+		return false, ""
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Health struct {
+			Status string `json:"status"`
+		} `json:"health"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&body) != nil {
+		return false, ""
+	}
+
+	return body.Health.Status == "healthy", body.Health.Status
+}
@@ -3,15 +3,24 @@ package main
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"mcp_orchestrator/internal/mcpclient"
+	"mcp_orchestrator/internal/performance"
+	"mcp_orchestrator/internal/profiles"
+	"mcp_orchestrator/internal/version"
 )
 
 // MCPMessage represents a generic MCP message
@@ -27,21 +36,51 @@ type MCPMessage struct {
 // StdioProxy handles stdio communication with Claude Desktop
 type StdioProxy struct {
 	orchestratorURL   string
-	client            *http.Client
+	api               *orchestratorClient // Pooled, retrying client for the orchestrator API
 	reader            *bufio.Reader
 	writer            *bufio.Writer
 	enhancedDiscovery *EnhancedDiscovery
+	quarantine        *performance.QuarantineManager
+	budgets           *performance.BudgetTracker
+	calls             *performance.CallLimiter
+	config            ProxyConfig
+	writeMu           sync.Mutex                    // Serializes responses from concurrent tool calls
+	inFlight          sync.WaitGroup                // Tool calls still being handled
+	toolsMu           sync.Mutex                    // Guards clientReady and lastToolsHash
+	clientReady       bool                          // Set once the client sends notifications/initialized
+	lastToolsHash     string                        // Tool set hash the client was last told about
+	cancelMu          sync.Mutex                    // Guards cancels
+	cancels           map[string]context.CancelFunc // Cancel funcs of in-flight tool calls by request id
+	starter           *lazyStarter                  // Starts stopped servers for calls when lazy start is on
+	readiness         *credentialReadiness          // Missing credentials per server, for tools/list
+	resultPages       *performance.Cache            // Full tool results kept for their later pages
+	proxyID           string                        // Identifies this proxy's state reports
 }
 
 // NewStdioProxy creates a new stdio proxy
-func NewStdioProxy(orchestratorURL string) *StdioProxy {
-	return &StdioProxy{
+func NewStdioProxy(orchestratorURL string, config ProxyConfig) *StdioProxy {
+	quarantine := performance.NewQuarantineManager(config.Quarantine)
+	api := newOrchestratorClient(orchestratorURL, config)
+
+	proxy := &StdioProxy{
 		orchestratorURL:   orchestratorURL,
-		client:            &http.Client{Timeout: 60 * time.Second}, // Increased timeout
+		api:               api,
 		reader:            bufio.NewReader(os.Stdin),
 		writer:            bufio.NewWriter(os.Stdout),
-		enhancedDiscovery: NewEnhancedDiscovery(orchestratorURL),
-	}
+		enhancedDiscovery: NewEnhancedDiscovery(api, quarantine, config),
+		quarantine:        quarantine,
+		budgets:           performance.NewBudgetTracker(config.ToolBudgets, config.CategoryBudgets),
+		calls:             performance.NewCallLimiter(config.MaxConcurrentCalls, config.ServerOverrides.callLimits(), config.CallQueueTimeout),
+		config:            config,
+		cancels:           make(map[string]context.CancelFunc),
+		starter:           newLazyStarter(defaultLazyStartBackoff),
+		readiness:         newCredentialReadiness(),
+		resultPages:       newResultPageCache(),
+		proxyID:           fmt.Sprintf("stdio-%d", os.Getpid()),
+	}
+	proxy.enhancedDiscovery.SetPassListener(proxy.trackToolSet)
+
+	return proxy
 }
 
 // Start starts the stdio proxy
@@ -49,13 +88,20 @@ func (p *StdioProxy) Start() error {
 	// Disable logging to stderr to avoid interfering with MCP communication
 	log.SetOutput(io.Discard)
 
+	// Prefill the tool cache while the client is still connecting
+	go p.enhancedDiscovery.Warmup()
+	go p.watchToolChanges(p.config.ToolsChangedInterval)
+	go p.watchStateReports(p.config.StateReportInterval)
+
 	for {
 		if err := p.handleMessage(); err != nil {
 			if err == io.EOF {
+				// Let in-flight tool calls deliver their responses
+				p.inFlight.Wait()
 				return nil
 			}
 			// Send error response and continue
-			errorMsg := p.sendErrorResponse(nil, fmt.Sprintf("Error: %v", err))
+			errorMsg := p.sendErrorResponse(nil, errCodeInternal, fmt.Sprintf("Error: %v", err), nil)
 			p.sendResponse(errorMsg)
 			continue
 		}
@@ -79,10 +125,32 @@ func (p *StdioProxy) handleMessage() error {
 	// Parse JSON message
 	var msg MCPMessage
 	if err := json.Unmarshal([]byte(line), &msg); err != nil {
-		errorMsg := p.sendErrorResponse(nil, fmt.Sprintf("Invalid JSON: %v", err))
+		// Echo the request id when it can still be recovered so the client
+		// can match the error to its request
+		errorMsg := p.sendErrorResponse(extractRequestID(line), errCodeParse, fmt.Sprintf("Invalid JSON: %v", err), nil)
 		return p.sendResponse(errorMsg)
 	}
 
+	// Tool calls run concurrently so one slow server doesn't hold up the
+	// client; per-server call limits bound how many reach each server
+	if msg.Method == "tools/call" {
+		ctx, finish := p.trackCall(msg.ID)
+		p.inFlight.Add(1)
+		go func() {
+			defer p.inFlight.Done()
+			defer finish()
+
+			response := p.handleToolCall(ctx, msg)
+
+			// A cancelled request gets no response
+			if ctx.Err() == context.Canceled {
+				return
+			}
+			p.sendResponse(response)
+		}()
+		return nil
+	}
+
 	// Route the message
 	response := p.routeMessage(msg)
 
@@ -94,11 +162,58 @@ func (p *StdioProxy) handleMessage() error {
 	return nil
 }
 
+// extractRequestID leniently scans a message that failed to decode for its
+// top-level id. Tokens are read until the id is found or the JSON breaks, so
+// an id that precedes a syntax error is still recovered. Returns nil when no
+// valid string or number id can be found.
+func extractRequestID(line string) interface{} {
+	decoder := json.NewDecoder(strings.NewReader(line))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+
+	for decoder.More() {
+		keyToken, err := decoder.Token()
+		if err != nil {
+			return nil
+		}
+		key, ok := keyToken.(string)
+		if !ok {
+			return nil
+		}
+
+		if key == "id" {
+			valueToken, err := decoder.Token()
+			if err != nil {
+				return nil
+			}
+			switch id := valueToken.(type) {
+			case string, float64:
+				return id
+			}
+			return nil
+		}
+
+		// Skip the value of any other member, nested objects included
+		var skipped json.RawMessage
+		if err := decoder.Decode(&skipped); err != nil {
+			return nil
+		}
+	}
+
+	return nil
+}
+
 // routeMessage routes messages to the orchestrator
 func (p *StdioProxy) routeMessage(msg MCPMessage) *MCPMessage {
 	// Handle notifications (no response needed)
-	if msg.Method == "notifications/initialized" || msg.Method == "notifications/cancelled" {
-		return nil // No response for notifications
+	if msg.Method == "notifications/initialized" {
+		p.markClientReady()
+		return nil
+	}
+	if msg.Method == "notifications/cancelled" {
+		p.cancelCall(msg.Params)
+		return nil
 	}
 
 	// Handle basic MCP methods directly
@@ -112,8 +227,26 @@ func (p *StdioProxy) routeMessage(msg MCPMessage) *MCPMessage {
 	case "tools/categories":
 		response := p.handleToolsCategories(msg)
 		return &response
+	case "tools/get":
+		response := p.handleToolGet(msg)
+		return &response
 	case "tools/call":
-		response := p.handleToolCall(msg)
+		response := p.handleToolCall(context.Background(), msg)
+		return &response
+	case "servers/quarantine":
+		response := p.handleQuarantineStatus(msg)
+		return &response
+	case "tools/budgets":
+		response := p.handleBudgetStatus(msg)
+		return &response
+	case "servers/stats":
+		response := p.handleServerStats(msg)
+		return &response
+	case "servers/discovery":
+		response := p.handleDiscoveryStats(msg)
+		return &response
+	case "servers/discovery/cache":
+		response := p.handleDiscoveryCache(msg)
 		return &response
 	case "resources/list":
 		response := p.handleResourcesList(msg)
@@ -122,7 +255,8 @@ func (p *StdioProxy) routeMessage(msg MCPMessage) *MCPMessage {
 		response := p.handlePromptsList(msg)
 		return &response
 	default:
-		response := p.sendErrorResponse(msg.ID, fmt.Sprintf("Unknown method: %s", msg.Method))
+		response := p.sendErrorResponse(msg.ID, errCodeMethodNotFound, fmt.Sprintf("Unknown method: %s", msg.Method),
+			map[string]interface{}{"method": msg.Method})
 		return &response
 	}
 }
@@ -133,32 +267,43 @@ func (p *StdioProxy) handleInitialize(msg MCPMessage) MCPMessage {
 		ID:      msg.ID,
 		JSONRPC: "2.0",
 		Result: map[string]interface{}{
-			"protocolVersion": "2024-11-05",
+			"protocolVersion": mcpclient.ProtocolVersion,
 			"capabilities": map[string]interface{}{
-				"tools": map[string]interface{}{},
+				"tools": map[string]interface{}{
+					"listChanged": true,
+				},
 			},
 			"serverInfo": map[string]interface{}{
 				"name":    "MCP Orchestrator",
-				"version": "1.0.0",
+				"version": version.Version,
 			},
 		},
 	}
 }
 
-// handleToolsList handles the tools/list request with pagination and filtering
+// handleToolsList handles the tools/list request with pagination and filtering.
+// Every response carries _meta.etag, a hash of the returned page; a client that
+// sends it back as the if_none_match param gets a result with no tools and
+// _meta.not_modified set when the page is unchanged.
 func (p *StdioProxy) handleToolsList(msg MCPMessage) MCPMessage {
 	// Check if orchestrator is running
-	if !p.isOrchestratorRunning() {
-		return p.sendErrorResponse(msg.ID, "MCP Orchestrator is not running")
+	if err := p.checkOrchestrator(); err != nil {
+		return p.orchestratorUnavailable(msg.ID, err)
 	}
 
-	// Parse parameters for pagination and filtering
-	var limit int = 25 // Balanced default limit for context management
+	// Parse parameters for pagination and filtering; defaults come from the
+	// proxy config and client params override them
+	defaults := p.config.ToolList
+	var limit int = defaults.Limit
 	var offset int = 0
 	var category string
 	var namePattern string
-	var simplified bool = true    // Default to simplified mode
-	var ultraMinimal bool = false // Ultra-minimal mode for very large tool sets
+	var simplified bool = true    // Legacy flag; implied when only ultra_minimal is passed
+	var ultraMinimal bool = false // Legacy flag for very large tool sets
+	var legacyFlags bool          // Whether the client passed either legacy flag
+	var schemaLevelName string    // Named schema level; overrides simplified/ultra_minimal
+	var ifNoneMatch string        // Hash from a previous response's _meta.etag
+	var adjustLimit bool = defaults.AdjustLimit
 
 	if msg.Params != nil {
 		if params, ok := msg.Params.(map[string]interface{}); ok {
@@ -176,30 +321,136 @@ func (p *StdioProxy) handleToolsList(msg MCPMessage) MCPMessage {
 			}
 			if s, ok := params["simplified"].(bool); ok {
 				simplified = s
+				legacyFlags = true
 			}
 			if u, ok := params["ultra_minimal"].(bool); ok {
 				ultraMinimal = u
+				legacyFlags = true
+			}
+			if sl, ok := params["schema_level"].(string); ok {
+				schemaLevelName = sl
+			}
+			if e, ok := params["if_none_match"].(string); ok {
+				ifNoneMatch = e
+			}
+			if a, ok := params["adjust_limit"].(bool); ok {
+				adjustLimit = a
 			}
 		}
 	}
 
-	// Get tools from running servers using enhanced discovery
-	allTools, diagnostics := p.enhancedDiscovery.DiscoverToolsWithDiagnostics()
+	// The legacy booleans map onto levels when no level is named
+	schemaLevel := defaults.SchemaLevel
+	if legacyFlags {
+		schemaLevel = schemaLevelFromFlags(simplified, ultraMinimal)
+	}
+	if schemaLevelName != "" {
+		level, err := parseSchemaLevel(schemaLevelName)
+		if err != nil {
+			return p.sendErrorResponse(msg.ID, errCodeInvalidParams, err.Error(),
+				map[string]interface{}{"param": "schema_level"})
+		}
+		schemaLevel = level
+	}
+
+	// Get the tools the active profile exposes from running servers
+	profileTools, diagnostics, selection := p.exposedTools()
 
 	// Apply filtering
-	filteredTools := p.filterTools(allTools, category, namePattern)
+	filteredTools := p.filterTools(profileTools, category, namePattern)
+
+	// Last-resort safety net above the profile limits: a discovery gone wrong
+	// mustn't hand clients tens of thousands of tools
+	matchedCount := len(filteredTools)
+	toolsCapped := false
+	if maxTools := p.config.MaxTools; maxTools > 0 && matchedCount > maxTools {
+		// The proxy's log is discarded, so the warning goes to the client with
+		// the discovery diagnostics; the pass's own slice is shared, so copy it
+		diagnostics = append(diagnostics[:len(diagnostics):len(diagnostics)], DiagnosticIssue{
+			ServerID:    "proxy",
+			Type:        "tool_cap_reached",
+			Description: fmt.Sprintf("tools/list matched %d tools, over the cap of %d; listing only the first %d", matchedCount, maxTools, maxTools),
+			Timestamp:   time.Now(),
+			Severity:    "warning",
+			Resolution:  "Check discovery for a server reporting far more tools than expected, or raise MCP_MAX_TOOLS",
+		})
+		filteredTools = filteredTools[:maxTools]
+		toolsCapped = true
+	}
+
+	if limit <= 0 {
+		limit = defaults.Limit
+	}
+	if offset < 0 {
+		offset = 0
+	}
 
-	// Intelligent context-aware limit adjustment
-	adjustedLimit := p.adjustLimitForContext(limit, len(filteredTools))
+	// Intelligent context-aware limit adjustment, unless the client opted out
+	adjustedLimit := limit
+	if adjustLimit {
+		adjustedLimit = p.adjustLimitForContext(limit, len(filteredTools))
+	}
 
 	// Apply pagination
 	paginatedTools := p.paginateTools(filteredTools, adjustedLimit, offset)
 
-	// Apply schema simplification based on mode
-	if ultraMinimal {
-		paginatedTools = p.ultraMinimalToolSchemas(paginatedTools)
-	} else if simplified {
-		paginatedTools = p.simplifyToolSchemas(paginatedTools)
+	// Page from what was actually returned so clients can always reach the end
+	nextOffset := offset + len(paginatedTools)
+	hasMore := nextOffset < len(filteredTools)
+
+	// Flag tools whose server lacks credentials, except in minimal listings
+	if schemaLevel != SchemaLevelMinimal {
+		paginatedTools = p.annotateReadiness(paginatedTools)
+	}
+
+	// Apply schema simplification based on level
+	paginatedTools = applySchemaLevel(paginatedTools, schemaLevel)
+
+	// Skip the tool payload when the client already has this exact page
+	etag := toolSetHash(paginatedTools, len(filteredTools))
+	if ifNoneMatch != "" && ifNoneMatch == etag {
+		return MCPMessage{
+			ID:      msg.ID,
+			JSONRPC: "2.0",
+			Result: map[string]interface{}{
+				"_meta": map[string]interface{}{
+					"etag":         etag,
+					"not_modified": true,
+				},
+			},
+		}
+	}
+
+	meta := map[string]interface{}{
+		"etag":              etag,
+		"not_modified":      false,
+		"total_count":       len(filteredTools),
+		"returned_count":    len(paginatedTools),
+		"requested_limit":   limit,
+		"adjusted_limit":    adjustedLimit,
+		"offset":            offset,
+		"schema_level":      schemaLevel,
+		"simplified":        schemaLevel != SchemaLevelFull,
+		"ultra_minimal":     schemaLevel == SchemaLevelMinimal,
+		"has_more":          hasMore,
+		"next_offset":       nextOffset,
+		"context_optimized": adjustedLimit != limit,
+		"quarantined":       p.quarantinedServerIDs(),
+		"capped_servers":    p.enhancedDiscovery.CappedServers(),
+		"tool_cap_reached":  toolsCapped,
+		"profile": map[string]interface{}{
+			"id":               p.config.Profile.ID,
+			"discovered_tools": selection.TotalTools,
+			"selected_tools":   selection.SelectedTools,
+			"excluded_servers": selection.ExcludedServers,
+			"limits_hit":       selection.LimitsHit,
+		},
+	}
+	if toolsCapped {
+		meta["tool_cap"] = map[string]interface{}{
+			"max_tools":     p.config.MaxTools,
+			"matched_count": matchedCount,
+		}
 	}
 
 	// Return response with metadata and diagnostics
@@ -209,30 +460,183 @@ func (p *StdioProxy) handleToolsList(msg MCPMessage) MCPMessage {
 		Result: map[string]interface{}{
 			"tools":       paginatedTools,
 			"diagnostics": diagnostics,
-			"_meta": map[string]interface{}{
-				"total_count":       len(filteredTools),
-				"returned_count":    len(paginatedTools),
-				"requested_limit":   limit,
-				"adjusted_limit":    adjustedLimit,
-				"offset":            offset,
-				"simplified":        simplified,
-				"ultra_minimal":     ultraMinimal,
-				"has_more":          offset+adjustedLimit < len(filteredTools),
-				"context_optimized": adjustedLimit != limit,
+			"_meta":       meta,
+		},
+	}
+}
+
+// handleToolGet handles the tools/get request, returning one tool with its full,
+// unsimplified input schema and any outputSchema it declares so clients can
+// list minimal schemas and fetch details on demand
+func (p *StdioProxy) handleToolGet(msg MCPMessage) MCPMessage {
+	var name, serverID string
+	if params, ok := msg.Params.(map[string]interface{}); ok {
+		name, _ = params["name"].(string)
+		serverID, _ = params["server_id"].(string)
+	}
+	if name == "" {
+		return p.sendErrorResponse(msg.ID, errCodeInvalidParams, "Missing required parameter: name",
+			map[string]interface{}{"param": "name"})
+	}
+
+	if err := p.checkOrchestrator(); err != nil {
+		return p.orchestratorUnavailable(msg.ID, err)
+	}
+
+	// Served from the discovery cache when it is warm
+	allTools, _, _ := p.exposedTools()
+	for _, toolData := range allTools {
+		tool, ok := toolData.(map[string]interface{})
+		if !ok || tool["name"] != name {
+			continue
+		}
+		if serverID != "" && tool["_server_id"] != serverID {
+			continue
+		}
+
+		return MCPMessage{
+			ID:      msg.ID,
+			JSONRPC: "2.0",
+			Result: map[string]interface{}{
+				"tool": tool,
 			},
+		}
+	}
+
+	return p.sendErrorResponse(msg.ID, errCodeToolNotFound, fmt.Sprintf("Tool not found: %s", name),
+		map[string]interface{}{"tool": name, "server_id": serverID})
+}
+
+// toolSetHash returns a stable hash of a tools/list page. Discovery timestamps
+// are excluded so re-discovering unchanged tools doesn't change the hash.
+func toolSetHash(tools []interface{}, totalCount int) string {
+	stable := make([]interface{}, 0, len(tools))
+	for _, toolData := range tools {
+		tool, ok := toolData.(map[string]interface{})
+		if !ok {
+			stable = append(stable, toolData)
+			continue
+		}
+
+		copied := make(map[string]interface{}, len(tool))
+		for key, value := range tool {
+			if key != "_discovered_at" {
+				copied[key] = value
+			}
+		}
+		stable = append(stable, copied)
+	}
+
+	// encoding/json sorts map keys, so equal tool sets always marshal identically
+	data, err := json.Marshal(map[string]interface{}{
+		"tools":       stable,
+		"total_count": totalCount,
+	})
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// handleQuarantineStatus handles the servers/quarantine request
+func (p *StdioProxy) handleQuarantineStatus(msg MCPMessage) MCPMessage {
+	return MCPMessage{
+		ID:      msg.ID,
+		JSONRPC: "2.0",
+		Result: map[string]interface{}{
+			"servers": p.quarantine.GetStatus(),
+			"config":  p.quarantine.GetConfig(),
+		},
+	}
+}
+
+// handleBudgetStatus handles the tools/budgets request
+func (p *StdioProxy) handleBudgetStatus(msg MCPMessage) MCPMessage {
+	return MCPMessage{
+		ID:      msg.ID,
+		JSONRPC: "2.0",
+		Result: map[string]interface{}{
+			"budgets": p.budgets.GetStatus(),
+		},
+	}
+}
+
+// handleServerStats handles the servers/stats request, reporting each
+// server's in-flight, queued and rejected tool calls
+func (p *StdioProxy) handleServerStats(msg MCPMessage) MCPMessage {
+	return MCPMessage{
+		ID:      msg.ID,
+		JSONRPC: "2.0",
+		Result: map[string]interface{}{
+			"calls":                      p.calls.GetStatus(),
+			"max_concurrent_calls":       p.config.MaxConcurrentCalls,
+			"call_queue_timeout_seconds": p.config.CallQueueTimeout.Seconds(),
 		},
 	}
 }
 
-// handleToolCall handles the tools/call request
-func (p *StdioProxy) handleToolCall(msg MCPMessage) MCPMessage {
+// handleDiscoveryStats handles the servers/discovery request, reporting how
+// long each server takes to list its tools so slow servers can be spotted
+func (p *StdioProxy) handleDiscoveryStats(msg MCPMessage) MCPMessage {
+	timings := p.enhancedDiscovery.DiscoveryTimings()
+
+	result := map[string]interface{}{
+		"servers": timings,
+	}
+	if len(timings) > 0 {
+		result["slowest"] = timings[0].ServerID
+	}
+
+	return MCPMessage{
+		ID:      msg.ID,
+		JSONRPC: "2.0",
+		Result:  result,
+	}
+}
+
+// handleDiscoveryCache lists the cached discovery result of each server.
+// With "clear": true it first drops the cache of "server_id", or of every
+// server when none is given.
+func (p *StdioProxy) handleDiscoveryCache(msg MCPMessage) MCPMessage {
+	result := map[string]interface{}{}
+	if params, ok := msg.Params.(map[string]interface{}); ok {
+		if clear, _ := params["clear"].(bool); clear {
+			serverID, _ := params["server_id"].(string)
+			result["cleared"] = p.enhancedDiscovery.ClearCache(serverID)
+		}
+	}
+	result["entries"] = p.enhancedDiscovery.CacheEntries()
+
+	return MCPMessage{
+		ID:      msg.ID,
+		JSONRPC: "2.0",
+		Result:  result,
+	}
+}
+
+// quarantinedServerIDs returns the IDs of servers currently in quarantine
+func (p *StdioProxy) quarantinedServerIDs() []string {
+	ids := []string{}
+	for _, status := range p.quarantine.GetStatus() {
+		if status.Quarantined {
+			ids = append(ids, status.ServerID)
+		}
+	}
+	return ids
+}
+
+// handleToolCall handles the tools/call request. Cancelling ctx aborts the
+// forwarded call.
+func (p *StdioProxy) handleToolCall(ctx context.Context, msg MCPMessage) MCPMessage {
 	// Check if orchestrator is running first
-	if !p.isOrchestratorRunning() {
-		return p.sendErrorResponse(msg.ID, "MCP Orchestrator is not running")
+	if err := p.checkOrchestrator(); err != nil {
+		return p.orchestratorUnavailable(msg.ID, err)
 	}
 
 	// Forward tool calls to GoHighLevel server
-	result := p.forwardToolCall(msg)
+	result := p.forwardToolCall(ctx, msg)
 	if result != nil {
 		// Check if result contains an error
 		if resultMap, ok := result.(map[string]interface{}); ok {
@@ -253,8 +657,13 @@ func (p *StdioProxy) handleToolCall(msg MCPMessage) MCPMessage {
 		}
 	}
 
-	// Fallback error with more context
-	return p.sendErrorResponse(msg.ID, "Failed to execute tool - GoHighLevel server may not be running or tool not found")
+	// The server was found but produced no usable response
+	toolName := ""
+	if params, ok := msg.Params.(map[string]interface{}); ok {
+		toolName, _ = params["name"].(string)
+	}
+	return p.sendErrorResponse(msg.ID, errCodeServerError, "Failed to execute tool - the server returned no response",
+		map[string]interface{}{"tool": toolName})
 }
 
 // handleResourcesList handles the resources/list request
@@ -282,15 +691,16 @@ func (p *StdioProxy) handlePromptsList(msg MCPMessage) MCPMessage {
 // handleToolsCategories handles the tools/categories request
 func (p *StdioProxy) handleToolsCategories(msg MCPMessage) MCPMessage {
 	// Check if orchestrator is running
-	if !p.isOrchestratorRunning() {
-		return p.sendErrorResponse(msg.ID, "MCP Orchestrator is not running")
+	if err := p.checkOrchestrator(); err != nil {
+		return p.orchestratorUnavailable(msg.ID, err)
 	}
 
-	// Get all tools from running servers
-	allTools := p.getToolsFromServers()
+	// Use the same discovery, cache and profile as tools/list so the counts agree
+	allTools, diagnostics, _ := p.exposedTools()
 
-	// Extract unique categories
+	// Count tools and contributing servers per category
 	categories := make(map[string]int)
+	categoryServers := make(map[string]map[string]bool)
 	for _, toolData := range allTools {
 		tool, ok := toolData.(map[string]interface{})
 		if !ok {
@@ -299,18 +709,37 @@ func (p *StdioProxy) handleToolsCategories(msg MCPMessage) MCPMessage {
 
 		category, ok := tool["category"].(string)
 		if !ok || category == "" {
-			category = "uncategorized"
+			category = profiles.Uncategorized
 		}
 
 		categories[category]++
+		if serverID, ok := tool["_server_id"].(string); ok && serverID != "" {
+			if categoryServers[category] == nil {
+				categoryServers[category] = make(map[string]bool)
+			}
+			categoryServers[category][serverID] = true
+		}
+	}
+
+	// Convert to a list sorted by name with counts and servers
+	names := make([]string, 0, len(categories))
+	for category := range categories {
+		names = append(names, category)
 	}
+	profiles.SortCategories(names)
+
+	categoryList := make([]interface{}, 0, len(names))
+	for _, category := range names {
+		servers := make([]string, 0, len(categoryServers[category]))
+		for serverID := range categoryServers[category] {
+			servers = append(servers, serverID)
+		}
+		sort.Strings(servers)
 
-	// Convert to array with counts
-	var categoryList []interface{}
-	for category, count := range categories {
 		categoryList = append(categoryList, map[string]interface{}{
-			"name":  category,
-			"count": count,
+			"name":    category,
+			"count":   categories[category],
+			"servers": servers,
 		})
 	}
 
@@ -320,69 +749,32 @@ func (p *StdioProxy) handleToolsCategories(msg MCPMessage) MCPMessage {
 		Result: map[string]interface{}{
 			"categories":  categoryList,
 			"total_tools": len(allTools),
+			"diagnostics": diagnostics,
+			"_meta": map[string]interface{}{
+				"total_categories": len(categoryList),
+				"quarantined":      p.quarantinedServerIDs(),
+			},
 		},
 	}
 }
 
-// isOrchestratorRunning checks if the orchestrator is running
-func (p *StdioProxy) isOrchestratorRunning() bool {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, "GET", p.orchestratorURL+"/health", nil)
-	if err != nil {
-		return false
-	}
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return false
-	}
-	defer resp.Body.Close()
-
-	return resp.StatusCode == 200
-}
-
-// getToolsFromServers gets real tools from all running MCP servers
+// getToolsFromServers gets real tools from all running MCP servers. A server
+// that fails to list its tools is left out rather than failing the whole
+// list, and the last known server list is used if the orchestrator API fails.
 func (p *StdioProxy) getToolsFromServers() []interface{} {
 	// Check which servers are running
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", p.orchestratorURL+"/api/servers", nil)
-	if err != nil {
-		return []interface{}{}
-	}
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return []interface{}{}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return []interface{}{}
-	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return []interface{}{}
-	}
-
-	servers, ok := result["servers"].([]interface{})
-	if !ok {
+	servers, _ := p.api.KnownServers(ctx)
+	if servers == nil {
 		return []interface{}{}
 	}
 
 	var allTools []interface{}
 
 	// Collect tools from all running servers
-	for _, serverData := range servers {
-		server, ok := serverData.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
+	for _, server := range servers {
 		status, ok := server["status"].(string)
 		if !ok || status != "running" {
 			continue
@@ -393,6 +785,11 @@ func (p *StdioProxy) getToolsFromServers() []interface{} {
 			continue
 		}
 
+		// Skip servers that are quarantined or excluded by the allowlist or denylist
+		if p.quarantine.IsQuarantined(id) || !p.config.ServerAccess.Allows(id) {
+			continue
+		}
+
 		// Get tools based on server type - now dynamic for all servers
 		var serverTools []interface{}
 		switch id {
@@ -430,26 +827,8 @@ func (p *StdioProxy) getToolsFromServers() []interface{} {
 
 			// Set category if not already set
 			if tool["category"] == nil || tool["category"] == "" {
-				switch id {
-				case "gohighlevel":
-					tool["category"] = "gohighlevel"
-				case "meta-ads":
-					tool["category"] = "meta-ads"
-				case "google-ads":
-					tool["category"] = "google-ads"
-				case "github":
-					tool["category"] = "development"
-				case "puppeteer":
-					tool["category"] = "web_browser"
-				case "slack":
-					tool["category"] = "communication"
-				case "gmail":
-					tool["category"] = "email"
-				case "brave-search":
-					tool["category"] = "web_browser"
-				default:
-					tool["category"] = id
-				}
+				category, _ := server["category"].(string)
+				tool["category"] = profiles.ToolCategory(id, category)
 			}
 
 			allTools = append(allTools, tool)
@@ -468,39 +847,14 @@ func (p *StdioProxy) getGoHighLevelTools() []interface{} {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", p.orchestratorURL+"/api/servers", nil)
-	if err != nil {
-		return []interface{}{}
-	}
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return []interface{}{}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return []interface{}{}
-	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return []interface{}{}
-	}
-
-	servers, ok := result["servers"].([]interface{})
-	if !ok {
+	servers, _ := p.api.KnownServers(ctx)
+	if servers == nil {
 		return []interface{}{}
 	}
 
 	// Find the GoHighLevel server and check if it's running
 	ghlFound := false
-	for _, serverData := range servers {
-		server, ok := serverData.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
+	for _, server := range servers {
 		status, ok := server["status"].(string)
 		if !ok {
 			continue
@@ -524,229 +878,352 @@ func (p *StdioProxy) getGoHighLevelTools() []interface{} {
 		return []interface{}{}
 	}
 
-	// Create the MCP messages
-	initMsg := MCPMessage{
-		ID:      1,
-		Method:  "initialize",
-		JSONRPC: "2.0",
-		Params: map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities":    map[string]interface{}{},
-			"clientInfo": map[string]interface{}{
-				"name":    "mcp-orchestrator",
-				"version": "1.0.0",
-			},
-		},
-	}
-
-	notifyMsg := MCPMessage{
-		Method:  "notifications/initialized",
-		JSONRPC: "2.0",
-	}
-
-	toolsMsg := MCPMessage{
-		ID:      2,
-		Method:  "tools/list",
-		JSONRPC: "2.0",
-		Params:  map[string]interface{}{},
+	// Handshake, then list the tools
+	input, err := mcpclient.BuildHandshake(mcpclient.ToolsListRequest())
+	if err != nil {
+		return []interface{}{}
 	}
 
-	// Marshal messages
-	initData, _ := json.Marshal(initMsg)
-	notifyData, _ := json.Marshal(notifyMsg)
-	toolsData, _ := json.Marshal(toolsMsg)
-
-	// Combine into input
-	input := string(initData) + "\n" + string(notifyData) + "\n" + string(toolsData) + "\n"
-
 	// Execute GoHighLevel server
 	ctx2, cancel2 := context.WithTimeout(context.Background(), 45*time.Second)
 	defer cancel2()
 
-	cmd := exec.CommandContext(ctx2, "node", "dist/server.js")
-	cmd.Dir = ghlPath
+	command, args, dir := p.config.ServerOverrides.launch("gohighlevel", ghlPath, "node", []string{"dist/server.js"}, p.config.SharedEnv)
+	cmd := exec.CommandContext(ctx2, command, args...)
+	cmd.Dir = dir
 	cmd.Stdin = strings.NewReader(input)
+	cmd.Env = p.config.serverEnviron()
 
-	output, err := cmd.Output()
+	output, err := runWithOutputCap(cmd, p.config.MaxOutputBytes, false)
 	if err != nil {
 		return []interface{}{}
 	}
 
-	// Convert output to string and find JSON responses
-	outputStr := string(output)
-
-	// Look for JSON objects in the output using a more robust approach
-	// Split by lines but also check for JSON patterns in the entire output
-	lines := strings.Split(outputStr, "\n")
-
-	// Try line-by-line first for smaller responses
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || !strings.HasPrefix(line, "{") {
-			continue
-		}
+	tools, err := mcpclient.ParseToolsResponse(string(output))
+	if err != nil {
+		return []interface{}{}
+	}
+	return tools
+}
 
-		var msg MCPMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+// findTool returns the server, category and details of a discovered tool, or
+// an empty server ID when no discovered server provides it
+func findTool(allTools []interface{}, toolName string) (string, string, map[string]interface{}) {
+	for _, toolData := range allTools {
+		tool, ok := toolData.(map[string]interface{})
+		if !ok || tool["name"] != toolName {
 			continue
 		}
 
-		// Check if this is our tools/list response
-		// Check if ID is 2 (could be float64 or int)
-		idMatches := false
-		if id, ok := msg.ID.(float64); ok && id == 2 {
-			idMatches = true
-		} else if id, ok := msg.ID.(int); ok && id == 2 {
-			idMatches = true
-		}
-		if idMatches && msg.Result != nil {
-			if result, ok := msg.Result.(map[string]interface{}); ok {
-				if tools, ok := result["tools"].([]interface{}); ok {
-					return tools
-				}
-			}
-		}
-	}
-
-	// If line-by-line didn't work, try to find JSON responses in the entire output
-	// Look for patterns like {"result":{"tools":[...],"jsonrpc":"2.0","id":2}
-	toolsPatternStart := `"result":{"tools":[`
-	toolsPatternEnd := `]},"jsonrpc":"2.0","id":2}`
-
-	startIdx := strings.Index(outputStr, toolsPatternStart)
-	if startIdx != -1 {
-		// Find the opening { before "result"
-		jsonStart := strings.LastIndex(outputStr[:startIdx], "{")
-		if jsonStart != -1 {
-			endIdx := strings.Index(outputStr[startIdx:], toolsPatternEnd)
-			if endIdx != -1 {
-				jsonEnd := jsonStart + (startIdx - jsonStart) + endIdx + len(toolsPatternEnd)
-				jsonStr := outputStr[jsonStart:jsonEnd]
-
-				var msg MCPMessage
-				if err := json.Unmarshal([]byte(jsonStr), &msg); err == nil {
-					// Check if ID is 2 (could be float64 or int)
-					idMatches := false
-					if id, ok := msg.ID.(float64); ok && id == 2 {
-						idMatches = true
-					} else if id, ok := msg.ID.(int); ok && id == 2 {
-						idMatches = true
-					}
-					if idMatches && msg.Result != nil {
-						if result, ok := msg.Result.(map[string]interface{}); ok {
-							if tools, ok := result["tools"].([]interface{}); ok {
-								return tools
-							}
-						}
-					}
-				}
-			}
+		if serverID, ok := tool["_server_id"].(string); ok {
+			category, _ := tool["category"].(string)
+			return serverID, category, tool
 		}
 	}
 
-	return []interface{}{}
+	return "", "", nil
 }
 
 // forwardToolCall forwards tool calls to the appropriate MCP server based on tool name
-func (p *StdioProxy) forwardToolCall(msg MCPMessage) interface{} {
+func (p *StdioProxy) forwardToolCall(ctx context.Context, msg MCPMessage) interface{} {
 	// Get the tool name from the message
 	params, ok := msg.Params.(map[string]interface{})
 	if !ok {
-		return nil
+		return map[string]interface{}{
+			"error": rpcError(errCodeInvalidParams, "Missing tool call params", nil),
+		}
 	}
 
 	toolName, ok := params["name"].(string)
-	if !ok {
-		return nil
+	if !ok || toolName == "" {
+		return map[string]interface{}{
+			"error": rpcError(errCodeInvalidParams, "Missing required parameter: name",
+				map[string]interface{}{"param": "name"}),
+		}
 	}
 
-	// Find which server this tool belongs to using enhanced discovery (same as tool listing)
-	allTools, _ := p.enhancedDiscovery.DiscoverToolsWithDiagnostics()
-	var targetServerID string
-
-	for _, toolData := range allTools {
-		tool, ok := toolData.(map[string]interface{})
-		if !ok {
-			continue
-		}
+	// Find which server this tool belongs to among the tools tools/list exposes
+	allTools, _, _ := p.exposedTools()
+	targetServerID, toolCategory, targetTool := findTool(allTools, toolName)
 
-		if tool["name"] == toolName {
-			if serverID, ok := tool["_server_id"].(string); ok {
-				targetServerID = serverID
-				break
-			}
+	// With lazy start on, a stopped server is started for the call
+	if targetServerID == "" && p.config.LazyStart {
+		if stoppedServerID := p.enhancedDiscovery.StoppedServerForTool(toolName); stoppedServerID != "" {
+			if err := p.startServerForCall(ctx, stoppedServerID); err != nil {
+				data := map[string]interface{}{
+					"tool":      toolName,
+					"server_id": stoppedServerID,
+					"reason":    err.Error(),
+				}
+				var startErr *lazyStartError
+				if errors.As(err, &startErr) {
+					data["reason"] = startErr.Reason
+					if startErr.RetryAfter > 0 {
+						data["retry_after_seconds"] = int(startErr.RetryAfter.Seconds()) + 1
+					}
+				}
+				return map[string]interface{}{
+					"error": rpcError(errCodeServerStartFailed, err.Error(), data),
+				}
+			}
+
+			p.enhancedDiscovery.Invalidate()
+			allTools, _, _ = p.exposedTools()
+			targetServerID, toolCategory, targetTool = findTool(allTools, toolName)
 		}
 	}
 
 	if targetServerID == "" {
-		return nil
+		// Tools of disabled servers are rejected with a clear reason
+		disabledServerID, disabled := p.enhancedDiscovery.DisabledServerForTool(toolName)
+		if disabledServerID != "" {
+			return map[string]interface{}{
+				"error": rpcError(errCodeServerDisabled,
+					fmt.Sprintf("Server %s is disabled; enable it to use %s", disabledServerID, toolName),
+					map[string]interface{}{"tool": toolName, "server_id": disabledServerID}),
+			}
+		}
+
+		return map[string]interface{}{
+			"error": rpcError(errCodeToolNotFound, fmt.Sprintf("Tool not found: %s", toolName),
+				map[string]interface{}{"tool": toolName, "disabled_servers": disabled}),
+		}
+	}
+
+	// Discovery already hides excluded servers; this guards every other route to them
+	if !p.config.ServerAccess.Allows(targetServerID) {
+		data := p.config.ServerAccess.Summary()
+		data["tool"] = toolName
+		data["server_id"] = targetServerID
+		return map[string]interface{}{
+			"error": rpcError(errCodeServerNotAllowed,
+				fmt.Sprintf("Server %s is not allowed by this proxy's configuration", targetServerID), data),
+		}
+	}
+
+	// A dry run is forwarded to tools that accept a dry_run argument; for any
+	// other tool only a preview of the resolved request is returned
+	dryRun, _ := params["dry_run"].(bool)
+	delete(params, "dry_run")
+	if dryRun {
+		if !supportsDryRun(targetTool) {
+			return p.previewToolCall(targetServerID, toolName, params["arguments"])
+		}
+
+		arguments, _ := params["arguments"].(map[string]interface{})
+		if arguments == nil {
+			arguments = map[string]interface{}{}
+		}
+		arguments["dry_run"] = true
+		params["arguments"] = arguments
+	}
+
+	// Large array-shaped results can be paged with _page and _page_size; later
+	// pages are served from the full result kept when an earlier one was fetched
+	page, paging, err := takeResultPage(params)
+	if err != nil {
+		return map[string]interface{}{
+			"error": rpcError(errCodeInvalidParams, err.Error(),
+				map[string]interface{}{"tool": toolName}),
+		}
+	}
+	release, rejection := p.admitToolCall(ctx, targetServerID, toolName, toolCategory)
+	if rejection != nil {
+		return rejection
+	}
+	defer release()
+
+	// Cached pages are served only once the call is admitted, so quarantine
+	// and budgets apply to every page alike
+	pageKey := ""
+	if paging {
+		pageKey = resultPageKey(targetServerID, toolName, params["arguments"])
+		if full, ok := p.resultPages.Get(pageKey); ok && page.Page > 1 {
+			return truncateToolResult(pageToolResult(full, page), p.config.MaxResultBytes)
+		}
 	}
 
 	// Route to the appropriate server
+	var result interface{}
 	switch targetServerID {
 	case "gohighlevel":
-		return p.forwardToGoHighLevel(msg)
+		result = p.forwardToGoHighLevel(ctx, msg)
 	case "meta-ads":
-		return p.forwardToMetaAds(msg)
+		result = p.forwardToMetaAds(ctx, msg)
 	case "google-ads":
-		return p.forwardToGoogleAds(msg)
+		result = p.forwardToGoogleAds(ctx, msg)
 	case "github":
-		return p.forwardToGenericServer(msg, targetServerID, "npx", []string{"-y", "@modelcontextprotocol/server-github"})
+		result = p.forwardToGenericServer(ctx, msg, targetServerID, "npx", []string{"-y", "@modelcontextprotocol/server-github"})
 	case "puppeteer":
-		return p.forwardToGenericServer(msg, targetServerID, "npx", []string{"-y", "@modelcontextprotocol/server-puppeteer"})
+		result = p.forwardToGenericServer(ctx, msg, targetServerID, "npx", []string{"-y", "@modelcontextprotocol/server-puppeteer"})
 	case "slack":
-		return p.forwardToGenericServer(msg, targetServerID, "npx", []string{"-y", "@modelcontextprotocol/server-slack"})
+		result = p.forwardToGenericServer(ctx, msg, targetServerID, "npx", []string{"-y", "@modelcontextprotocol/server-slack"})
 	case "gmail":
-		return p.forwardToGenericServer(msg, targetServerID, "npx", []string{"-y", "@modelcontextprotocol/server-gmail"})
+		result = p.forwardToGenericServer(ctx, msg, targetServerID, "npx", []string{"-y", "@modelcontextprotocol/server-gmail"})
 	case "brave-search":
-		return p.forwardToGenericServer(msg, targetServerID, "npx", []string{"-y", "@modelcontextprotocol/server-brave-search"})
+		result = p.forwardToGenericServer(ctx, msg, targetServerID, "npx", []string{"-y", "@modelcontextprotocol/server-brave-search"})
 	default:
 		// Try generic forwarding for any unknown server
-		return p.forwardToGenericServer(msg, targetServerID, "npx", []string{"-y", "@modelcontextprotocol/server-" + targetServerID})
+		result = p.forwardToGenericServer(ctx, msg, targetServerID, "npx", []string{"-y", "@modelcontextprotocol/server-" + targetServerID})
 	}
-}
 
-// forwardToGoHighLevel forwards tool calls to GoHighLevel server
-func (p *StdioProxy) forwardToGoHighLevel(msg MCPMessage) interface{} {
-	ghlPath := "/Users/user/.mcp_orchestrator/gohighlevel"
+	// Feed the outcome into the server's quarantine state, letting the
+	// orchestrator know right away when the server enters or leaves quarantine
+	wasQuarantined := p.quarantine.IsQuarantined(targetServerID)
+	p.quarantine.RecordResult(targetServerID, isSuccessfulResult(result))
+	if p.quarantine.IsQuarantined(targetServerID) != wasQuarantined {
+		go p.reportState()
+	}
+	p.enhancedDiscovery.RecordToolCall(targetServerID, toolName)
+	go p.reportActivity(targetServerID)
 
-	// First, check if the GoHighLevel server is actually running
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
+	if resultMap, ok := result.(map[string]interface{}); ok && dryRun {
+		if _, hasError := resultMap["error"]; !hasError {
+			resultMap["_meta"] = map[string]interface{}{"dry_run": "forwarded"}
+		}
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", p.orchestratorURL+"/api/servers", nil)
-	if err != nil {
-		return nil
+	if paging && isSuccessfulResult(result) {
+		p.resultPages.Set(pageKey, result, 0)
+		result = pageToolResult(result, page)
 	}
 
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return nil
+	// Keep oversized results from overwhelming the client's context
+	return truncateToolResult(result, p.config.MaxResultBytes)
+}
+
+// admitToolCall runs the checks a call must pass before it is forwarded:
+// quarantine, a free call slot, then the call budgets. Budgets are checked
+// last, so only calls that reach the server count against them. It returns
+// the call slot to release, or the error result of a rejected call.
+func (p *StdioProxy) admitToolCall(ctx context.Context, targetServerID, toolName, toolCategory string) (func(), map[string]interface{}) {
+	// Short-circuit servers that are quarantined after repeated failures
+	if !p.quarantine.Allow(targetServerID) {
+		return nil, map[string]interface{}{
+			"error": rpcError(errCodeServerQuarantined,
+				fmt.Sprintf("Server %s is quarantined after repeated failures; it will be retried after %v",
+					targetServerID, p.config.Quarantine.ProbeInterval),
+				map[string]interface{}{
+					"server_id":           targetServerID,
+					"retry_after_seconds": int(p.config.Quarantine.ProbeInterval.Seconds()),
+				}),
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil
+	// Wait for a call slot so parallel calls don't overwhelm the server
+	release, err := p.calls.Acquire(ctx, targetServerID)
+	if err != nil {
+		data := map[string]interface{}{
+			"tool":                toolName,
+			"server_id":           targetServerID,
+			"retry_after_seconds": 1,
+		}
+		var limitErr *performance.CallLimitError
+		if errors.As(err, &limitErr) {
+			data["max_concurrent"] = limitErr.Limit
+		}
+		return nil, map[string]interface{}{
+			"error": rpcError(errCodeServerBusy, err.Error(), data),
+		}
 	}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil
+	// Enforce the active profile's per-tool and per-category call budgets
+	if err := p.budgets.Allow(toolName, toolCategory); err != nil {
+		release()
+		go p.reportState()
+		data := map[string]interface{}{"tool": toolName}
+		var budgetErr *performance.BudgetExceededError
+		if errors.As(err, &budgetErr) {
+			data["budget"] = budgetErr.Status
+			data["retry_after_seconds"] = int(budgetErr.RetryAfter.Seconds()) + 1
+		}
+		return nil, map[string]interface{}{
+			"error": rpcError(errCodeBudgetExceeded, err.Error(), data),
+		}
 	}
 
-	servers, ok := result["servers"].([]interface{})
+	return release, nil
+}
+
+// supportsDryRun reports whether a tool's input schema declares a dry_run argument
+func supportsDryRun(tool map[string]interface{}) bool {
+	inputSchema, ok := tool["inputSchema"].(map[string]interface{})
 	if !ok {
+		return false
+	}
+	properties, ok := inputSchema["properties"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = properties["dry_run"]
+	return ok
+}
+
+// previewToolCall describes how a tool call would be routed without invoking it
+func (p *StdioProxy) previewToolCall(serverID, toolName string, arguments interface{}) map[string]interface{} {
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+
+	endpoint := map[string]interface{}{"transport": "stdio"}
+	serverPath := "/Users/user/.mcp_orchestrator/" + serverID
+	if cmd, err := p.enhancedDiscovery.createServerCommand(serverID, serverPath); err == nil {
+		endpoint["command"] = cmd.Args
+		endpoint["dir"] = cmd.Dir
+	}
+
+	preview := map[string]interface{}{
+		"server_id":   serverID,
+		"tool":        toolName,
+		"arguments":   arguments,
+		"endpoint":    endpoint,
+		"quarantined": p.quarantine.IsQuarantined(serverID),
+	}
+	previewJSON, _ := json.MarshalIndent(preview, "", "  ")
+
+	return map[string]interface{}{
+		"content": []interface{}{
+			map[string]interface{}{
+				"type": "text",
+				"text": "Dry run preview (the tool was not invoked):\n" + string(previewJSON),
+			},
+		},
+		"_meta": map[string]interface{}{
+			"dry_run": "preview",
+			"preview": preview,
+		},
+	}
+}
+
+// isSuccessfulResult reports whether a forwarded call produced a non-error result
+func isSuccessfulResult(result interface{}) bool {
+	if result == nil {
+		return false
+	}
+	if resultMap, ok := result.(map[string]interface{}); ok {
+		if _, hasError := resultMap["error"]; hasError {
+			return false
+		}
+	}
+	return true
+}
+
+// forwardToGoHighLevel forwards tool calls to GoHighLevel server
+func (p *StdioProxy) forwardToGoHighLevel(ctx context.Context, msg MCPMessage) interface{} {
+	ghlPath := "/Users/user/.mcp_orchestrator/gohighlevel"
+
+	// First, check if the GoHighLevel server is actually running
+	statusCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	servers, _ := p.api.KnownServers(statusCtx)
+	if servers == nil {
 		return nil
 	}
 
 	// Check if GoHighLevel server is running
 	ghlRunning := false
-	for _, serverData := range servers {
-		server, ok := serverData.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
+	for _, server := range servers {
 		id, ok := server["id"].(string)
 		if !ok || id != "gohighlevel" {
 			continue
@@ -763,57 +1240,31 @@ func (p *StdioProxy) forwardToGoHighLevel(msg MCPMessage) interface{} {
 		return nil
 	}
 
-	// Create the MCP messages for the tool call
-	initMsg := MCPMessage{
-		ID:      1,
-		Method:  "initialize",
-		JSONRPC: "2.0",
-		Params: map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities":    map[string]interface{}{},
-			"clientInfo": map[string]interface{}{
-				"name":    "mcp-orchestrator",
-				"version": "1.0.0",
-			},
-		},
-	}
-
-	notifyMsg := MCPMessage{
-		Method:  "notifications/initialized",
-		JSONRPC: "2.0",
-	}
-
-	// Use the original message for the tool call
+	// Handshake, then send the original message as the tool call
 	toolCallMsg := msg
-	toolCallMsg.ID = 2
+	toolCallMsg.ID = mcpclient.RequestID
 
-	// Marshal messages
-	initData, _ := json.Marshal(initMsg)
-	notifyData, _ := json.Marshal(notifyMsg)
-	toolCallData, _ := json.Marshal(toolCallMsg)
-
-	// Combine into input
-	input := string(initData) + "\n" + string(notifyData) + "\n" + string(toolCallData) + "\n"
+	input, err := mcpclient.BuildHandshake(toolCallMsg)
+	if err != nil {
+		return nil
+	}
 
 	// Execute GoHighLevel server
-	ctx2, cancel2 := context.WithTimeout(context.Background(), 50*time.Second)
+	ctx2, cancel2 := context.WithTimeout(ctx, 50*time.Second)
 	defer cancel2()
 
-	cmd := exec.CommandContext(ctx2, "node", "dist/server.js")
-	cmd.Dir = ghlPath
+	command, args, dir := p.config.ServerOverrides.launch("gohighlevel", ghlPath, "node", []string{"dist/server.js"}, p.config.SharedEnv)
+	cmd := exec.CommandContext(ctx2, command, args...)
+	cmd.Dir = dir
 	cmd.Stdin = strings.NewReader(input)
+	cmd.Env = p.config.serverEnviron()
 
-	output, err := cmd.Output()
-	if err != nil {
-		return nil
-	}
-
-	// Parse the response with better error handling
-	return p.parseToolCallResponse(string(output))
+	// Stream the output so large or chatty results aren't buffered whole
+	return p.streamToolCallResponse(cmd)
 }
 
 // forwardToMetaAds forwards tool calls to Meta Ads server
-func (p *StdioProxy) forwardToMetaAds(msg MCPMessage) interface{} {
+func (p *StdioProxy) forwardToMetaAds(ctx context.Context, msg MCPMessage) interface{} {
 	metaAdsPath := "/Users/user/.mcp_orchestrator/meta-ads"
 
 	// Check if the Meta Ads server directory exists
@@ -821,40 +1272,17 @@ func (p *StdioProxy) forwardToMetaAds(msg MCPMessage) interface{} {
 		return nil
 	}
 
-	// Create the MCP messages for the tool call
-	initMsg := MCPMessage{
-		ID:      1,
-		Method:  "initialize",
-		JSONRPC: "2.0",
-		Params: map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities":    map[string]interface{}{},
-			"clientInfo": map[string]interface{}{
-				"name":    "mcp-orchestrator",
-				"version": "1.0.0",
-			},
-		},
-	}
-
-	notifyMsg := MCPMessage{
-		Method:  "notifications/initialized",
-		JSONRPC: "2.0",
-	}
-
-	// Use the original message for the tool call
+	// Handshake, then send the original message as the tool call
 	toolCallMsg := msg
-	toolCallMsg.ID = 2
+	toolCallMsg.ID = mcpclient.RequestID
 
-	// Marshal messages
-	initData, _ := json.Marshal(initMsg)
-	notifyData, _ := json.Marshal(notifyMsg)
-	toolCallData, _ := json.Marshal(toolCallMsg)
-
-	// Combine into input
-	input := string(initData) + "\n" + string(notifyData) + "\n" + string(toolCallData) + "\n"
+	input, err := mcpclient.BuildHandshake(toolCallMsg)
+	if err != nil {
+		return nil
+	}
 
 	// Execute Meta Ads server with virtual environment Python
-	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Second)
 	defer cancel()
 
 	pythonPath := metaAdsPath + "/venv/bin/python"
@@ -863,21 +1291,18 @@ func (p *StdioProxy) forwardToMetaAds(msg MCPMessage) interface{} {
 		pythonPath = metaAdsPath + "/venv/Scripts/python.exe"
 	}
 
-	cmd := exec.CommandContext(ctx, pythonPath, "-m", "meta_ads_mcp")
-	cmd.Dir = metaAdsPath
+	command, args, dir := p.config.ServerOverrides.launch("meta-ads", metaAdsPath, pythonPath, []string{"-m", "meta_ads_mcp"}, p.config.SharedEnv)
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = dir
 	cmd.Stdin = strings.NewReader(input)
+	cmd.Env = p.config.serverEnviron()
 
-	output, err := cmd.Output()
-	if err != nil {
-		return nil
-	}
-
-	// Parse the response
-	return p.parseToolCallResponse(string(output))
+	// Stream the output so large or chatty results aren't buffered whole
+	return p.streamToolCallResponse(cmd)
 }
 
 // forwardToGoogleAds forwards tool calls to Google Ads server
-func (p *StdioProxy) forwardToGoogleAds(msg MCPMessage) interface{} {
+func (p *StdioProxy) forwardToGoogleAds(ctx context.Context, msg MCPMessage) interface{} {
 	googleAdsPath := "/Users/user/.mcp_orchestrator/google-ads"
 
 	// Check if the Google Ads server directory exists
@@ -885,40 +1310,17 @@ func (p *StdioProxy) forwardToGoogleAds(msg MCPMessage) interface{} {
 		return nil
 	}
 
-	// Create the MCP messages for the tool call
-	initMsg := MCPMessage{
-		ID:      1,
-		Method:  "initialize",
-		JSONRPC: "2.0",
-		Params: map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities":    map[string]interface{}{},
-			"clientInfo": map[string]interface{}{
-				"name":    "mcp-orchestrator",
-				"version": "1.0.0",
-			},
-		},
-	}
-
-	notifyMsg := MCPMessage{
-		Method:  "notifications/initialized",
-		JSONRPC: "2.0",
-	}
-
-	// Use the original message for the tool call
+	// Handshake, then send the original message as the tool call
 	toolCallMsg := msg
-	toolCallMsg.ID = 2
-
-	// Marshal messages
-	initData, _ := json.Marshal(initMsg)
-	notifyData, _ := json.Marshal(notifyMsg)
-	toolCallData, _ := json.Marshal(toolCallMsg)
+	toolCallMsg.ID = mcpclient.RequestID
 
-	// Combine into input
-	input := string(initData) + "\n" + string(notifyData) + "\n" + string(toolCallData) + "\n"
+	input, err := mcpclient.BuildHandshake(toolCallMsg)
+	if err != nil {
+		return nil
+	}
 
 	// Execute Google Ads server with virtual environment Python
-	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Second)
 	defer cancel()
 
 	pythonPath := googleAdsPath + "/venv/bin/python"
@@ -927,21 +1329,18 @@ func (p *StdioProxy) forwardToGoogleAds(msg MCPMessage) interface{} {
 		pythonPath = googleAdsPath + "/venv/Scripts/python.exe"
 	}
 
-	cmd := exec.CommandContext(ctx, pythonPath, "-m", "mcp_google_ads")
-	cmd.Dir = googleAdsPath
+	command, args, dir := p.config.ServerOverrides.launch("google-ads", googleAdsPath, pythonPath, []string{"-m", "mcp_google_ads"}, p.config.SharedEnv)
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = dir
 	cmd.Stdin = strings.NewReader(input)
+	cmd.Env = p.config.serverEnviron()
 
-	output, err := cmd.Output()
-	if err != nil {
-		return nil
-	}
-
-	// Parse the response with better error handling
-	return p.parseToolCallResponse(string(output))
+	// Stream the output so large or chatty results aren't buffered whole
+	return p.streamToolCallResponse(cmd)
 }
 
 // forwardToGenericServer forwards tool calls to generic MCP servers
-func (p *StdioProxy) forwardToGenericServer(msg MCPMessage, serverID, command string, args []string) interface{} {
+func (p *StdioProxy) forwardToGenericServer(ctx context.Context, msg MCPMessage, serverID, command string, args []string) interface{} {
 	serverPath := "/Users/user/.mcp_orchestrator/" + serverID
 
 	// Check if the server directory exists
@@ -949,44 +1348,21 @@ func (p *StdioProxy) forwardToGenericServer(msg MCPMessage, serverID, command st
 		return nil
 	}
 
-	// Create the MCP messages for the tool call
-	initMsg := MCPMessage{
-		ID:      1,
-		Method:  "initialize",
-		JSONRPC: "2.0",
-		Params: map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities":    map[string]interface{}{},
-			"clientInfo": map[string]interface{}{
-				"name":    "mcp-orchestrator",
-				"version": "1.0.0",
-			},
-		},
-	}
-
-	notifyMsg := MCPMessage{
-		Method:  "notifications/initialized",
-		JSONRPC: "2.0",
-	}
-
-	// Use the original message for the tool call
+	// Handshake, then send the original message as the tool call
 	toolCallMsg := msg
-	toolCallMsg.ID = 2
-
-	// Marshal messages
-	initData, _ := json.Marshal(initMsg)
-	notifyData, _ := json.Marshal(notifyMsg)
-	toolCallData, _ := json.Marshal(toolCallMsg)
+	toolCallMsg.ID = mcpclient.RequestID
 
-	// Combine into input
-	input := string(initData) + "\n" + string(notifyData) + "\n" + string(toolCallData) + "\n"
+	input, err := mcpclient.BuildHandshake(toolCallMsg)
+	if err != nil {
+		return nil
+	}
 
 	// Execute server
-	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Second)
 	defer cancel()
 
-	// Set up environment variables based on server
-	env := os.Environ()
+	// Set up environment variables based on server, over the shared ones
+	env := p.config.serverEnviron()
 	switch serverID {
 	case "github":
 		env = append(env, "GITHUB_PERSONAL_ACCESS_TOKEN="+os.Getenv("GITHUB_PERSONAL_ACCESS_TOKEN"))
@@ -999,62 +1375,21 @@ func (p *StdioProxy) forwardToGenericServer(msg MCPMessage, serverID, command st
 		env = append(env, "BRAVE_SEARCH_API_KEY="+os.Getenv("BRAVE_SEARCH_API_KEY"))
 	}
 
+	command, args, dir := p.config.ServerOverrides.launch(serverID, serverPath, command, args, p.config.SharedEnv)
 	cmd := exec.CommandContext(ctx, command, args...)
-	cmd.Dir = serverPath
+	cmd.Dir = dir
 	cmd.Stdin = strings.NewReader(input)
 	cmd.Env = env
 
-	output, err := cmd.Output()
-	if err != nil {
-		return nil
-	}
-
-	// Parse the response with better error handling
-	return p.parseToolCallResponse(string(output))
-}
-
-// parseToolCallResponse parses the response from a tool call
-func (p *StdioProxy) parseToolCallResponse(outputStr string) interface{} {
-	// Look for JSON objects in the output
-	lines := strings.Split(outputStr, "\n")
-
-	// Try line-by-line first
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || !strings.HasPrefix(line, "{") {
-			continue
-		}
-
-		var msg MCPMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			continue
-		}
-
-		// Check if this is our tool call response (ID 2)
-		idMatches := false
-		if id, ok := msg.ID.(float64); ok && id == 2 {
-			idMatches = true
-		} else if id, ok := msg.ID.(int); ok && id == 2 {
-			idMatches = true
-		}
-
-		if idMatches {
-			if msg.Result != nil {
-				return msg.Result
-			}
-			if msg.Error != nil {
-				return map[string]interface{}{
-					"error": msg.Error,
-				}
-			}
-		}
-	}
-
-	return nil
+	// Stream the output so large or chatty results aren't buffered whole
+	return p.streamToolCallResponse(cmd)
 }
 
 // sendResponse sends a response message to stdout
 func (p *StdioProxy) sendResponse(msg MCPMessage) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
 	// Ensure JSONRPC version is set
 	if msg.JSONRPC == "" {
 		msg.JSONRPC = "2.0"
@@ -1076,18 +1411,57 @@ func (p *StdioProxy) sendResponse(msg MCPMessage) error {
 	return p.writer.Flush()
 }
 
-// sendErrorResponse sends an error response
-func (p *StdioProxy) sendErrorResponse(id interface{}, message string) MCPMessage {
+// JSON-RPC error codes returned by the proxy. Codes from -32000 down are
+// server-defined and describe failures behind the proxy.
+const (
+	errCodeParse                   = -32700
+	errCodeMethodNotFound          = -32601
+	errCodeInvalidParams           = -32602
+	errCodeInternal                = -32603
+	errCodeServerError             = -32000 // A backend MCP server failed to handle the request
+	errCodeOrchestratorUnavailable = -32001 // The orchestrator is not running or not ready
+	errCodeToolNotFound            = -32002 // No running server provides the requested tool
+	errCodeServerQuarantined       = -32003 // The tool's server is quarantined after repeated failures
+	errCodeBudgetExceeded          = -32004 // A per-tool or per-category call budget is used up
+	errCodeServerDisabled          = -32005 // The tool's server is disabled
+	errCodeServerBusy              = -32006 // The tool's server has too many calls in flight
+	errCodeServerNotAllowed        = -32007 // The tool's server is excluded by the allowlist or denylist
+	errCodeServerStartFailed       = -32008 // The tool's server is stopped and lazy start couldn't start it
+	errCodeOutputTooLarge          = -32009 // The tool's server wrote more output than MCP_MAX_OUTPUT_BYTES allows
+)
+
+// sendErrorResponse builds a JSON-RPC error response; data is omitted when nil
+func (p *StdioProxy) sendErrorResponse(id interface{}, code int, message string, data interface{}) MCPMessage {
 	return MCPMessage{
 		ID:      id,
 		JSONRPC: "2.0",
-		Error: map[string]interface{}{
-			"code":    -32700,
-			"message": message,
-		},
+		Error:   rpcError(code, message, data),
 	}
 }
 
+// rpcError builds a JSON-RPC error object
+func rpcError(code int, message string, data interface{}) map[string]interface{} {
+	rpcErr := map[string]interface{}{
+		"code":    code,
+		"message": message,
+	}
+	if data != nil {
+		rpcErr["data"] = data
+	}
+	return rpcErr
+}
+
+// orchestratorUnavailable is the error returned while the orchestrator is down
+func (p *StdioProxy) orchestratorUnavailable(id interface{}, err error) MCPMessage {
+	data := map[string]interface{}{"orchestrator_url": p.orchestratorURL}
+	if checkErr, ok := err.(*orchestratorCheckError); ok {
+		data["reason"] = checkErr.Reason
+		data["attempts"] = checkErr.Attempts
+	}
+
+	return p.sendErrorResponse(id, errCodeOrchestratorUnavailable, err.Error(), data)
+}
+
 // filterTools filters tools based on category and name pattern
 func (p *StdioProxy) filterTools(tools []interface{}, category, namePattern string) []interface{} {
 	if category == "" && namePattern == "" {
@@ -1137,108 +1511,28 @@ func (p *StdioProxy) paginateTools(tools []interface{}, limit, offset int) []int
 	return tools[offset:end]
 }
 
-// simplifyToolSchemas returns simplified versions of tool schemas to reduce context size
-func (p *StdioProxy) simplifyToolSchemas(tools []interface{}) []interface{} {
-	var simplified []interface{}
-
-	for _, toolData := range tools {
-		tool, ok := toolData.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		// Create simplified version with just essential fields
-		simplifiedTool := map[string]interface{}{
-			"name":        tool["name"],
-			"description": tool["description"],
-			"category":    tool["category"],
-		}
+// adjustLimitForContext caps the page size by how many tools match, using
+// the configured caps so large tool sets don't overflow the client's context
+func (p *StdioProxy) adjustLimitForContext(requestedLimit, totalTools int) int {
+	defaults := p.config.ToolList
 
-		// Add simplified input schema
-		if inputSchema, ok := tool["inputSchema"].(map[string]interface{}); ok {
-			simplifiedSchema := map[string]interface{}{
-				"type":       "object",
-				"properties": map[string]interface{}{},
+	// Caps are ordered from the largest tool count down
+	for _, contextCap := range defaults.ContextCaps {
+		if totalTools > contextCap.AboveTools {
+			if requestedLimit > contextCap.MaxLimit {
+				return contextCap.MaxLimit
 			}
-
-			if props, ok := inputSchema["properties"].(map[string]interface{}); ok {
-				simplifiedProps := make(map[string]interface{})
-				for propName, propData := range props {
-					if prop, ok := propData.(map[string]interface{}); ok {
-						// Only include type and description for simplified schema
-						simplifiedProps[propName] = map[string]interface{}{
-							"type":        prop["type"],
-							"description": prop["description"],
-						}
-					}
-				}
-				simplifiedSchema["properties"] = simplifiedProps
-			}
-
-			simplifiedTool["inputSchema"] = simplifiedSchema
-		}
-
-		simplified = append(simplified, simplifiedTool)
-	}
-
-	return simplified
-}
-
-// adjustLimitForContext intelligently adjusts the limit based on total tools and context constraints
-func (p *StdioProxy) adjustLimitForContext(requestedLimit, totalTools int) int {
-	// If we have a massive number of tools (like GoHighLevel's 253), be more conservative
-	if totalTools > 200 {
-		// For very large tool sets, cap at 20 to prevent context overflow
-		if requestedLimit > 20 {
-			return 20
-		}
-	} else if totalTools > 100 {
-		// For moderately large tool sets, cap at 30
-		if requestedLimit > 30 {
-			return 30
-		}
-	} else if totalTools > 50 {
-		// For medium tool sets, cap at 40
-		if requestedLimit > 40 {
-			return 40
+			break
 		}
 	}
 
-	// For smaller tool sets, allow the requested limit up to 50
-	if requestedLimit > 50 {
-		return 50
+	if defaults.MaxLimit > 0 && requestedLimit > defaults.MaxLimit {
+		return defaults.MaxLimit
 	}
 
 	return requestedLimit
 }
 
-// ultraMinimalToolSchemas returns ultra-minimal tool schemas with only name and description
-func (p *StdioProxy) ultraMinimalToolSchemas(tools []interface{}) []interface{} {
-	var ultraMinimal []interface{}
-
-	for _, toolData := range tools {
-		tool, ok := toolData.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		// Ultra-minimal version with only essential fields
-		minimalTool := map[string]interface{}{
-			"name":        tool["name"],
-			"description": tool["description"],
-		}
-
-		// Add category if available for grouping
-		if category, ok := tool["category"]; ok && category != nil {
-			minimalTool["category"] = category
-		}
-
-		ultraMinimal = append(ultraMinimal, minimalTool)
-	}
-
-	return ultraMinimal
-}
-
 // getMetaAdsTools connects to Meta Ads server and gets real tools
 func (p *StdioProxy) getMetaAdsTools() []interface{} {
 	// Execute the Meta Ads server and get tools
@@ -1249,41 +1543,12 @@ func (p *StdioProxy) getMetaAdsTools() []interface{} {
 		return []interface{}{}
 	}
 
-	// Create the MCP messages
-	initMsg := MCPMessage{
-		ID:      1,
-		Method:  "initialize",
-		JSONRPC: "2.0",
-		Params: map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities":    map[string]interface{}{},
-			"clientInfo": map[string]interface{}{
-				"name":    "mcp-orchestrator",
-				"version": "1.0.0",
-			},
-		},
-	}
-
-	notifyMsg := MCPMessage{
-		Method:  "notifications/initialized",
-		JSONRPC: "2.0",
-	}
-
-	toolsMsg := MCPMessage{
-		ID:      2,
-		Method:  "tools/list",
-		JSONRPC: "2.0",
-		Params:  map[string]interface{}{},
+	// Handshake, then list the tools
+	input, err := mcpclient.BuildHandshake(mcpclient.ToolsListRequest())
+	if err != nil {
+		return []interface{}{}
 	}
 
-	// Marshal messages
-	initData, _ := json.Marshal(initMsg)
-	notifyData, _ := json.Marshal(notifyMsg)
-	toolsData, _ := json.Marshal(toolsMsg)
-
-	// Combine into input
-	input := string(initData) + "\n" + string(notifyData) + "\n" + string(toolsData) + "\n"
-
 	// Execute Meta Ads server with virtual environment Python
 	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
 	defer cancel()
@@ -1294,11 +1559,13 @@ func (p *StdioProxy) getMetaAdsTools() []interface{} {
 		pythonPath = metaAdsPath + "/venv/Scripts/python.exe"
 	}
 
-	cmd := exec.CommandContext(ctx, pythonPath, "-m", "meta_ads_mcp")
-	cmd.Dir = metaAdsPath
+	command, args, dir := p.config.ServerOverrides.launch("meta-ads", metaAdsPath, pythonPath, []string{"-m", "meta_ads_mcp"}, p.config.SharedEnv)
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = dir
 	cmd.Stdin = strings.NewReader(input)
+	cmd.Env = p.config.serverEnviron()
 
-	output, err := cmd.Output()
+	output, err := runWithOutputCap(cmd, p.config.MaxOutputBytes, false)
 	if err != nil {
 		return []interface{}{}
 	}
@@ -1317,41 +1584,12 @@ func (p *StdioProxy) getGoogleAdsTools() []interface{} {
 		return []interface{}{}
 	}
 
-	// Create the MCP messages
-	initMsg := MCPMessage{
-		ID:      1,
-		Method:  "initialize",
-		JSONRPC: "2.0",
-		Params: map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities":    map[string]interface{}{},
-			"clientInfo": map[string]interface{}{
-				"name":    "mcp-orchestrator",
-				"version": "1.0.0",
-			},
-		},
-	}
-
-	notifyMsg := MCPMessage{
-		Method:  "notifications/initialized",
-		JSONRPC: "2.0",
-	}
-
-	toolsMsg := MCPMessage{
-		ID:      2,
-		Method:  "tools/list",
-		JSONRPC: "2.0",
-		Params:  map[string]interface{}{},
+	// Handshake, then list the tools
+	input, err := mcpclient.BuildHandshake(mcpclient.ToolsListRequest())
+	if err != nil {
+		return []interface{}{}
 	}
 
-	// Marshal messages
-	initData, _ := json.Marshal(initMsg)
-	notifyData, _ := json.Marshal(notifyMsg)
-	toolsData, _ := json.Marshal(toolsMsg)
-
-	// Combine into input
-	input := string(initData) + "\n" + string(notifyData) + "\n" + string(toolsData) + "\n"
-
 	// Execute Google Ads server with virtual environment Python
 	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
 	defer cancel()
@@ -1362,11 +1600,13 @@ func (p *StdioProxy) getGoogleAdsTools() []interface{} {
 		pythonPath = googleAdsPath + "/venv/Scripts/python.exe"
 	}
 
-	cmd := exec.CommandContext(ctx, pythonPath, "-m", "mcp_google_ads")
-	cmd.Dir = googleAdsPath
+	command, args, dir := p.config.ServerOverrides.launch("google-ads", googleAdsPath, pythonPath, []string{"-m", "mcp_google_ads"}, p.config.SharedEnv)
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = dir
 	cmd.Stdin = strings.NewReader(input)
+	cmd.Env = p.config.serverEnviron()
 
-	output, err := cmd.Output()
+	output, err := runWithOutputCap(cmd, p.config.MaxOutputBytes, false)
 	if err != nil {
 		return []interface{}{}
 	}
@@ -1375,78 +1615,14 @@ func (p *StdioProxy) getGoogleAdsTools() []interface{} {
 	return p.parseToolsFromOutput(string(output))
 }
 
-// parseToolsFromOutput extracts tools from MCP server output
+// parseToolsFromOutput extracts tools from MCP server output, or none when
+// the output has no tools/list response
 func (p *StdioProxy) parseToolsFromOutput(outputStr string) []interface{} {
-	// Look for JSON objects in the output using a more robust approach
-	// Split by lines but also check for JSON patterns in the entire output
-	lines := strings.Split(outputStr, "\n")
-
-	// Try line-by-line first for smaller responses
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || !strings.HasPrefix(line, "{") {
-			continue
-		}
-
-		var msg MCPMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			continue
-		}
-
-		// Check if this is our tools/list response
-		// Check if ID is 2 (could be float64 or int)
-		idMatches := false
-		if id, ok := msg.ID.(float64); ok && id == 2 {
-			idMatches = true
-		} else if id, ok := msg.ID.(int); ok && id == 2 {
-			idMatches = true
-		}
-		if idMatches && msg.Result != nil {
-			if result, ok := msg.Result.(map[string]interface{}); ok {
-				if tools, ok := result["tools"].([]interface{}); ok {
-					return tools
-				}
-			}
-		}
-	}
-
-	// If line-by-line didn't work, try to find JSON responses in the entire output
-	// Look for patterns like {"result":{"tools":[...],"jsonrpc":"2.0","id":2}
-	toolsPatternStart := `"result":{"tools":[`
-	toolsPatternEnd := `]},"jsonrpc":"2.0","id":2}`
-
-	startIdx := strings.Index(outputStr, toolsPatternStart)
-	if startIdx != -1 {
-		// Find the opening { before "result"
-		jsonStart := strings.LastIndex(outputStr[:startIdx], "{")
-		if jsonStart != -1 {
-			endIdx := strings.Index(outputStr[startIdx:], toolsPatternEnd)
-			if endIdx != -1 {
-				jsonEnd := jsonStart + (startIdx - jsonStart) + endIdx + len(toolsPatternEnd)
-				jsonStr := outputStr[jsonStart:jsonEnd]
-
-				var msg MCPMessage
-				if err := json.Unmarshal([]byte(jsonStr), &msg); err == nil {
-					// Check if ID is 2 (could be float64 or int)
-					idMatches := false
-					if id, ok := msg.ID.(float64); ok && id == 2 {
-						idMatches = true
-					} else if id, ok := msg.ID.(int); ok && id == 2 {
-						idMatches = true
-					}
-					if idMatches && msg.Result != nil {
-						if result, ok := msg.Result.(map[string]interface{}); ok {
-							if tools, ok := result["tools"].([]interface{}); ok {
-								return tools
-							}
-						}
-					}
-				}
-			}
-		}
+	tools, err := mcpclient.ParseToolsResponse(outputStr)
+	if err != nil {
+		return []interface{}{}
 	}
-
-	return []interface{}{}
+	return tools
 }
 
 // getGenericServerTools connects to generic MCP servers and gets tools
@@ -1459,47 +1635,18 @@ func (p *StdioProxy) getGenericServerTools(serverID, command string, args []stri
 		return []interface{}{}
 	}
 
-	// Create the MCP messages
-	initMsg := MCPMessage{
-		ID:      1,
-		Method:  "initialize",
-		JSONRPC: "2.0",
-		Params: map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities":    map[string]interface{}{},
-			"clientInfo": map[string]interface{}{
-				"name":    "mcp-orchestrator",
-				"version": "1.0.0",
-			},
-		},
-	}
-
-	notifyMsg := MCPMessage{
-		Method:  "notifications/initialized",
-		JSONRPC: "2.0",
-	}
-
-	toolsMsg := MCPMessage{
-		ID:      2,
-		Method:  "tools/list",
-		JSONRPC: "2.0",
-		Params:  map[string]interface{}{},
+	// Handshake, then list the tools
+	input, err := mcpclient.BuildHandshake(mcpclient.ToolsListRequest())
+	if err != nil {
+		return []interface{}{}
 	}
 
-	// Marshal messages
-	initData, _ := json.Marshal(initMsg)
-	notifyData, _ := json.Marshal(notifyMsg)
-	toolsData, _ := json.Marshal(toolsMsg)
-
-	// Combine into input
-	input := string(initData) + "\n" + string(notifyData) + "\n" + string(toolsData) + "\n"
-
 	// Execute server
 	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
 	defer cancel()
 
-	// Set up environment variables based on server
-	env := os.Environ()
+	// Set up environment variables based on server, over the shared ones
+	env := p.config.serverEnviron()
 	switch serverID {
 	case "github":
 		env = append(env, "GITHUB_PERSONAL_ACCESS_TOKEN="+os.Getenv("GITHUB_PERSONAL_ACCESS_TOKEN"))
@@ -1512,12 +1659,13 @@ func (p *StdioProxy) getGenericServerTools(serverID, command string, args []stri
 		env = append(env, "BRAVE_SEARCH_API_KEY="+os.Getenv("BRAVE_SEARCH_API_KEY"))
 	}
 
+	command, args, dir := p.config.ServerOverrides.launch(serverID, serverPath, command, args, p.config.SharedEnv)
 	cmd := exec.CommandContext(ctx, command, args...)
-	cmd.Dir = serverPath
+	cmd.Dir = dir
 	cmd.Stdin = strings.NewReader(input)
 	cmd.Env = env
 
-	output, err := cmd.Output()
+	output, err := runWithOutputCap(cmd, p.config.MaxOutputBytes, false)
 	if err != nil {
 		return []interface{}{}
 	}
@@ -1527,8 +1675,21 @@ func (p *StdioProxy) getGenericServerTools(serverID, command string, args []stri
 }
 
 func main() {
+	// Without flags the proxy goes straight to serving MCP on stdio
+	options, exitCode, exit := parseCommandLine(os.Args[1:])
+	if exit {
+		os.Exit(exitCode)
+	}
+
+	// Fail fast on a bad URL; stdout is reserved for MCP messages
+	orchestratorURL, err := loadOrchestratorURL(options.orchestratorURL)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	// Create stdio proxy
-	proxy := NewStdioProxy("http://localhost:8080")
+	proxy := NewStdioProxy(orchestratorURL, loadProxyConfig())
 
 	// Start the proxy
 	if err := proxy.Start(); err != nil {
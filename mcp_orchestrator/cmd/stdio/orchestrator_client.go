@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// orchestratorClient makes the proxy's requests to the orchestrator API over
+// one pooled HTTP client, retrying transient failures and briefly caching the
+// server list that every tools/list and tool call looks up
+type orchestratorClient struct {
+	baseURL    string
+	client     *http.Client
+	retry      RetryPolicy   // Retries for GET requests
+	serversTTL time.Duration // How long a fetched server list is reused
+	serversMu  sync.Mutex    // Guards servers, fetchedAt and lastKnown
+	servers    []map[string]interface{}
+	fetchedAt  time.Time
+	lastKnown  []map[string]interface{} // Last list fetched, kept across invalidation
+}
+
+// apiError is a failed orchestrator API request, described the way tool
+// discovery reports it as a diagnostic
+type apiError struct {
+	IssueType  string // Diagnostic type, e.g. api_connection_failed
+	Severity   string
+	Resolution string
+	Err        error
+}
+
+// Error returns the underlying failure
+func (e *apiError) Error() string {
+	return e.Err.Error()
+}
+
+// newOrchestratorClient creates a client for the orchestrator at baseURL
+func newOrchestratorClient(baseURL string, config ProxyConfig) *orchestratorClient {
+	return &orchestratorClient{
+		baseURL: baseURL,
+		client: &http.Client{
+			Timeout:   60 * time.Second, // Lazy starts can take a while to answer
+			Transport: newOrchestratorTransport(),
+		},
+		retry:      config.APIRetry,
+		serversTTL: config.ServersCacheTTL,
+	}
+}
+
+// newOrchestratorTransport keeps enough idle connections for concurrent
+// discovery and calls to reuse them instead of dialing for every request
+func newOrchestratorTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          16,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// newRequest builds a request for an API path such as /api/servers
+func (c *orchestratorClient) newRequest(ctx context.Context, method, path string) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+}
+
+// newJSONRequest builds a request for an API path with body encoded as JSON
+func (c *orchestratorClient) newJSONRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request body: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// Do sends a request. GET requests that fail to connect or get a 502, 503 or
+// 504 back are retried with backoff; other methods are sent once, as they may
+// not be safe to repeat. A successful non-GET request drops the cached server
+// list, since it may have changed a server's status.
+func (c *orchestratorClient) Do(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if req.Method == http.MethodGet && c.retry.MaxAttempts > 1 {
+		attempts = c.retry.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.client.Do(req)
+		if attempt >= attempts || !retryableResponse(resp, err) || req.Context().Err() != nil {
+			if err == nil && req.Method != http.MethodGet {
+				c.InvalidateServers()
+			}
+			return resp, err
+		}
+
+		if resp != nil {
+			// Drain the body so the connection goes back to the pool
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(c.retry.Backoff(attempt)):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// retryableResponse reports whether a request failed in a way a retry may fix
+func retryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Servers returns every server the orchestrator knows about, reusing a list
+// fetched within the cache TTL. Callers must not modify the returned maps.
+func (c *orchestratorClient) Servers(ctx context.Context) ([]map[string]interface{}, error) {
+	c.serversMu.Lock()
+	defer c.serversMu.Unlock()
+
+	if c.servers != nil && time.Since(c.fetchedAt) < c.serversTTL {
+		return c.servers, nil
+	}
+
+	servers, err := c.fetchServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.servers = servers
+	c.fetchedAt = time.Now()
+	c.lastKnown = servers
+	return servers, nil
+}
+
+// KnownServers is Servers, falling back to the last list fetched when the
+// orchestrator can't be reached or answers with an error, so one failed
+// request doesn't make every server disappear. The fetch error is returned
+// with the fallback list; the list is nil only if no fetch has succeeded.
+func (c *orchestratorClient) KnownServers(ctx context.Context) ([]map[string]interface{}, error) {
+	servers, err := c.Servers(ctx)
+	if err == nil {
+		return servers, nil
+	}
+
+	c.serversMu.Lock()
+	defer c.serversMu.Unlock()
+
+	return c.lastKnown, err
+}
+
+// InvalidateServers drops the cached server list so the next lookup fetches it
+func (c *orchestratorClient) InvalidateServers() {
+	c.serversMu.Lock()
+	defer c.serversMu.Unlock()
+
+	c.servers = nil
+}
+
+// fetchServers requests the server list from /api/servers
+func (c *orchestratorClient) fetchServers(ctx context.Context) ([]map[string]interface{}, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/servers")
+	if err != nil {
+		return nil, &apiError{IssueType: "api_request_failed", Severity: "error",
+			Resolution: "Check if orchestrator is running and accessible",
+			Err:        fmt.Errorf("Failed to create API request: %v", err)}
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, &apiError{IssueType: "api_connection_failed", Severity: "error",
+			Resolution: "Start the MCP Orchestrator service",
+			Err:        fmt.Errorf("Failed to connect to orchestrator API: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &apiError{IssueType: "api_error_response", Severity: "error",
+			Resolution: "Check orchestrator logs for errors",
+			Err:        fmt.Errorf("Orchestrator API returned status %d", resp.StatusCode)}
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, &apiError{IssueType: "api_parse_failed", Severity: "error",
+			Resolution: "Check orchestrator API response format",
+			Err:        fmt.Errorf("Failed to parse API response: %v", err)}
+	}
+
+	servers, ok := result["servers"].([]interface{})
+	if !ok {
+		return nil, &apiError{IssueType: "unexpected_response", Severity: "warning",
+			Resolution: "Check orchestrator API implementation",
+			Err:        fmt.Errorf("API response does not contain expected servers array")}
+	}
+
+	serverList := make([]map[string]interface{}, 0, len(servers))
+	for _, serverData := range servers {
+		if server, ok := serverData.(map[string]interface{}); ok {
+			serverList = append(serverList, server)
+		}
+	}
+
+	return serverList, nil
+}
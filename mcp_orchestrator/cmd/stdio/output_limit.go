@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// defaultMaxOutputBytes bounds what is read from one server process
+const defaultMaxOutputBytes = 16 << 20
+
+// outputWaitDelay is how long a killed server's children get to release its
+// output pipes before they are closed
+const outputWaitDelay = 5 * time.Second
+
+// outputTooLargeError reports a server process that wrote more than the
+// output cap allows
+type outputTooLargeError struct {
+	Limit int
+}
+
+// Error describes the exceeded cap
+func (e *outputTooLargeError) Error() string {
+	return fmt.Sprintf("output too large: server wrote more than %d bytes", e.Limit)
+}
+
+// cappedOutput collects process output up to a limit. Once the limit is
+// exceeded the process is killed and further output is discarded.
+type cappedOutput struct {
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	limit    int
+	exceeded bool
+	cmd      *exec.Cmd
+}
+
+// Write keeps data up to the limit, killing the process when it goes over
+func (c *cappedOutput) Write(data []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.exceeded {
+		return len(data), nil
+	}
+	if room := c.limit - c.buf.Len(); len(data) > room {
+		c.buf.Write(data[:room])
+		c.exceeded = true
+		if c.cmd.Process != nil {
+			c.cmd.Process.Kill()
+		}
+		return len(data), nil
+	}
+
+	c.buf.Write(data)
+	return len(data), nil
+}
+
+// runWithOutputCap runs cmd like Output, or like CombinedOutput when
+// combined is set, keeping at most limit bytes. Going over the limit kills
+// the process and returns the kept output with an outputTooLargeError. A
+// limit of 0 or less disables the cap.
+func runWithOutputCap(cmd *exec.Cmd, limit int, combined bool) ([]byte, error) {
+	if limit <= 0 {
+		if combined {
+			return cmd.CombinedOutput()
+		}
+		return cmd.Output()
+	}
+
+	output := &cappedOutput{limit: limit, cmd: cmd}
+	cmd.Stdout = output
+	if combined {
+		cmd.Stderr = output
+	}
+	// Children of a killed launcher (e.g. npx) can hold the pipe open
+	if cmd.WaitDelay == 0 {
+		cmd.WaitDelay = outputWaitDelay
+	}
+
+	err := cmd.Run()
+
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	if output.exceeded {
+		return output.buf.Bytes(), &outputTooLargeError{Limit: limit}
+	}
+	return output.buf.Bytes(), err
+}
+
+// outputTooLargeResult is the error result of a forwarded call whose server
+// wrote more than the output cap
+func outputTooLargeResult(limit int) map[string]interface{} {
+	return map[string]interface{}{
+		"error": rpcError(errCodeOutputTooLarge,
+			fmt.Sprintf("Tool output too large - the server wrote more than %d bytes", limit),
+			map[string]interface{}{
+				"reason":      "output_too_large",
+				"limit_bytes": limit,
+				"resolution":  "Narrow the request, or raise MCP_MAX_OUTPUT_BYTES",
+			}),
+	}
+}
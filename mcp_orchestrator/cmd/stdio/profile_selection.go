@@ -0,0 +1,52 @@
+package main
+
+import "mcp_orchestrator/internal/profiles"
+
+// exposedTools discovers the tools of running servers and applies the active
+// profile's selection to them. Every request that lists, looks up or routes
+// tools goes through it, so a tool the profile hides can't be reached.
+func (p *StdioProxy) exposedTools() ([]interface{}, []DiagnosticIssue, profiles.ToolSelection) {
+	allTools, diagnostics := p.enhancedDiscovery.DiscoverToolsWithDiagnostics()
+	tools, selection := selectProfileTools(&p.config.Profile, allTools)
+	return tools, diagnostics, selection
+}
+
+// selectProfileTools applies the active profile's enabled servers, server and
+// tool filters, and tool limits to discovered tools. It goes through the same
+// profiles.FilterTools as the orchestrator's profile preview and simulation,
+// so those show exactly what tools/list returns.
+func selectProfileTools(profile *profiles.Profile, tools []interface{}) ([]interface{}, profiles.ToolSelection) {
+	candidates := make([]profiles.Tool, 0, len(tools))
+	byKey := make(map[string][]interface{}, len(tools))
+	for _, toolData := range tools {
+		tool, ok := toolData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		candidate := profiles.Tool{}
+		candidate.Name, _ = tool["name"].(string)
+		candidate.Description, _ = tool["description"].(string)
+		candidate.Category, _ = tool["category"].(string)
+		candidate.ServerID, _ = tool["_server_id"].(string)
+		candidates = append(candidates, candidate)
+
+		key := candidate.ServerID + "\x00" + candidate.Name
+		byKey[key] = append(byKey[key], tool)
+	}
+
+	// Discovery has already given every tool its category with ToolCategory,
+	// from the server categories in the orchestrator's server list
+	selection := profile.FilterTools(candidates, nil)
+
+	// Map the selection back to the discovered tools, in the order selected
+	selected := make([]interface{}, 0, len(selection.Tools))
+	for _, tool := range selection.Tools {
+		key := tool.ServerID + "\x00" + tool.Name
+		if matches := byKey[key]; len(matches) > 0 {
+			selected = append(selected, matches[0])
+			byKey[key] = matches[1:]
+		}
+	}
+	return selected, selection
+}
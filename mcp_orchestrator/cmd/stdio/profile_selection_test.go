@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"mcp_orchestrator/internal/profiles"
+)
+
+// discoveredTool builds a tool as discovery returns it
+func discoveredTool(serverID, name, category string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        name,
+		"description": name + " tool",
+		"category":    category,
+		"_server_id":  serverID,
+	}
+}
+
+func TestSelectProfileToolsMatchesFilterTools(t *testing.T) {
+	profile := &profiles.Profile{
+		ID:             "test",
+		EnabledServers: []string{"github", "slack"},
+		ServerConfigs: map[string]profiles.ServerConfig{
+			"github": {Enabled: true, Priority: 2, MaxTools: 3},
+			"slack":  {Enabled: true, Priority: 1},
+		},
+		ToolFilters: profiles.ToolFilters{ExcludeTools: []string{"slack_delete"}},
+		ToolLimits:  profiles.ToolLimits{MaxToolsTotal: 5},
+	}
+
+	var tools []interface{}
+	var candidates []profiles.Tool
+	add := func(serverID, name, category string) {
+		tools = append(tools, discoveredTool(serverID, name, category))
+		candidates = append(candidates, profiles.Tool{Name: name, Description: name + " tool", Category: category, ServerID: serverID})
+	}
+	for i := 0; i < 5; i++ {
+		add("github", fmt.Sprintf("github_%d", i), "development")
+	}
+	add("slack", "slack_post", "communication")
+	add("slack", "slack_delete", "communication")
+	add("brave-search", "search", "web_browser")
+
+	selected, selection := selectProfileTools(profile, tools)
+	want := profile.FilterTools(candidates, nil)
+
+	if len(selected) != want.SelectedTools {
+		t.Fatalf("selected %d tools, FilterTools selects %d", len(selected), want.SelectedTools)
+	}
+	for i, toolData := range selected {
+		tool := toolData.(map[string]interface{})
+		if tool["name"] != want.Tools[i].Name || tool["_server_id"] != want.Tools[i].ServerID {
+			t.Errorf("tool %d is %v/%v, want %s/%s", i, tool["_server_id"], tool["name"], want.Tools[i].ServerID, want.Tools[i].Name)
+		}
+	}
+	if fmt.Sprint(selection.LimitsHit) != fmt.Sprint(want.LimitsHit) {
+		t.Errorf("limits hit %v, want %v", selection.LimitsHit, want.LimitsHit)
+	}
+
+	// slack_post first by priority, then three github tools under max_tools
+	if len(selected) != 4 || selected[0].(map[string]interface{})["name"] != "slack_post" {
+		t.Errorf("unexpected selection %v", selected)
+	}
+	if len(selection.ExcludedServers) != 1 || selection.ExcludedServers[0] != "brave-search" {
+		t.Errorf("excluded servers %v, want [brave-search]", selection.ExcludedServers)
+	}
+}
+
+func TestSelectProfileToolsWithoutProfileKeepsEverything(t *testing.T) {
+	tools := []interface{}{
+		discoveredTool("github", "create_issue", "development"),
+		discoveredTool("slack", "post_message", "communication"),
+	}
+
+	selected, selection := selectProfileTools(&profiles.Profile{}, tools)
+	if len(selected) != len(tools) || selection.SelectedTools != len(tools) {
+		t.Fatalf("an empty profile selected %d of %d tools", len(selected), len(tools))
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+)
+
+// ResultFormat names the shape tool call results are returned in
+type ResultFormat string
+
+// Result formats
+const (
+	ResultFormatNormalized ResultFormat = "normalized" // Always {content: [...], isError: bool}
+	ResultFormatRaw        ResultFormat = "raw"        // Exactly as the server returned it
+)
+
+// loadResultFormat reads MCP_RESULT_FORMAT, defaulting to normalized results
+func loadResultFormat() ResultFormat {
+	name := os.Getenv("MCP_RESULT_FORMAT")
+	switch ResultFormat(name) {
+	case "":
+		return ResultFormatNormalized
+	case ResultFormatNormalized, ResultFormatRaw:
+		return ResultFormat(name)
+	}
+
+	log.Printf("Warning: Ignoring MCP_RESULT_FORMAT %q: must be %s or %s", name, ResultFormatNormalized, ResultFormatRaw)
+	return ResultFormatNormalized
+}
+
+// normalizeToolResult wraps a server's tool result in the MCP result
+// envelope. Results that already have a content array keep it and gain an
+// isError flag; any other result becomes a single text item, with objects
+// also kept as structuredContent. An object whose only sign of failure is an
+// "error" field is marked isError, so it isn't taken for a JSON-RPC error.
+func normalizeToolResult(result interface{}) interface{} {
+	resultMap, isMap := result.(map[string]interface{})
+	if !isMap {
+		return map[string]interface{}{
+			"content": []interface{}{textContent(result)},
+			"isError": false,
+		}
+	}
+
+	switch content := resultMap["content"].(type) {
+	case []interface{}, string:
+		normalized := make(map[string]interface{}, len(resultMap)+1)
+		for key, value := range resultMap {
+			normalized[key] = value
+		}
+		if text, ok := content.(string); ok {
+			normalized["content"] = []interface{}{textContent(text)}
+		}
+		isError, _ := resultMap["isError"].(bool)
+		normalized["isError"] = isError
+		return normalized
+	}
+
+	item := textContent(result)
+	errorValue, hasError := resultMap["error"]
+	if hasError {
+		item = textContent(errorValue)
+	}
+	envelope := map[string]interface{}{
+		"content":           []interface{}{item},
+		"structuredContent": resultMap,
+		"isError":           hasError,
+	}
+	if meta, ok := resultMap["_meta"]; ok {
+		envelope["_meta"] = meta
+	}
+	return envelope
+}
+
+// textContent renders a value as an MCP text content item, strings as they
+// are and anything else as JSON
+func textContent(value interface{}) map[string]interface{} {
+	text, ok := value.(string)
+	if !ok {
+		data, err := json.Marshal(value)
+		if err != nil {
+			text = fmt.Sprint(value)
+		} else {
+			text = string(data)
+		}
+	}
+	return map[string]interface{}{"type": "text", "text": text}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// toolCallResult parses a server's response line to the forwarded tool call
+func toolCallResult(t *testing.T, line string, format ResultFormat) interface{} {
+	t.Helper()
+	result, ok := parseToolCallLine(line, format)
+	if !ok {
+		t.Fatalf("line %s was not taken for the tool call response", line)
+	}
+	return result
+}
+
+func TestNormalizedResultKeepsContentArray(t *testing.T) {
+	result := toolCallResult(t, `{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"hi"}],"_meta":{"page":1}}}`, ResultFormatNormalized)
+
+	want := map[string]interface{}{
+		"content": []interface{}{map[string]interface{}{"type": "text", "text": "hi"}},
+		"_meta":   map[string]interface{}{"page": float64(1)},
+		"isError": false,
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("got %v, want %v", result, want)
+	}
+}
+
+func TestNormalizedResultKeepsToolErrorFlag(t *testing.T) {
+	result := toolCallResult(t, `{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"not found"}],"isError":true}}`, ResultFormatNormalized)
+
+	if result.(map[string]interface{})["isError"] != true {
+		t.Errorf("got %v, want isError true", result)
+	}
+}
+
+func TestNormalizedResultWrapsContentString(t *testing.T) {
+	result := toolCallResult(t, `{"jsonrpc":"2.0","id":2,"result":{"content":"plain text"}}`, ResultFormatNormalized)
+
+	want := map[string]interface{}{
+		"content": []interface{}{map[string]interface{}{"type": "text", "text": "plain text"}},
+		"isError": false,
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("got %v, want %v", result, want)
+	}
+}
+
+func TestNormalizedResultWrapsBareObject(t *testing.T) {
+	result := toolCallResult(t, `{"jsonrpc":"2.0","id":2,"result":{"count":3}}`, ResultFormatNormalized)
+
+	want := map[string]interface{}{
+		"content":           []interface{}{map[string]interface{}{"type": "text", "text": `{"count":3}`}},
+		"structuredContent": map[string]interface{}{"count": float64(3)},
+		"isError":           false,
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("got %v, want %v", result, want)
+	}
+}
+
+func TestNormalizedResultMarksErrorField(t *testing.T) {
+	result := toolCallResult(t, `{"jsonrpc":"2.0","id":2,"result":{"error":"quota exceeded"}}`, ResultFormatNormalized)
+
+	envelope := result.(map[string]interface{})
+	if envelope["isError"] != true {
+		t.Errorf("got isError %v, want true", envelope["isError"])
+	}
+	want := []interface{}{map[string]interface{}{"type": "text", "text": "quota exceeded"}}
+	if !reflect.DeepEqual(envelope["content"], want) {
+		t.Errorf("got content %v, want %v", envelope["content"], want)
+	}
+	if _, isRPCError := envelope["error"]; isRPCError {
+		t.Error("tool error was turned into a JSON-RPC error")
+	}
+}
+
+func TestNormalizedResultWrapsScalarsAndArrays(t *testing.T) {
+	for raw, text := range map[string]string{
+		`"done"`: "done",
+		`42`:     "42",
+		`[1,2]`:  "[1,2]",
+		`true`:   "true",
+		`"a\"b"`: `a"b`,
+		`["x"]`:  `["x"]`,
+	} {
+		result := toolCallResult(t, `{"jsonrpc":"2.0","id":2,"result":`+raw+`}`, ResultFormatNormalized)
+
+		want := map[string]interface{}{
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": text}},
+			"isError": false,
+		}
+		if !reflect.DeepEqual(result, want) {
+			t.Errorf("result %s: got %v, want %v", raw, result, want)
+		}
+	}
+}
+
+func TestRawResultIsUnchanged(t *testing.T) {
+	result := toolCallResult(t, `{"jsonrpc":"2.0","id":2,"result":{"count":3}}`, ResultFormatRaw)
+
+	want := map[string]interface{}{"count": float64(3)}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("got %v, want %v", result, want)
+	}
+}
+
+func TestRPCErrorIsKeptInEitherFormat(t *testing.T) {
+	line := `{"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"Invalid params"}}`
+
+	for _, format := range []ResultFormat{ResultFormatNormalized, ResultFormatRaw} {
+		result := toolCallResult(t, line, format)
+
+		resultMap, ok := result.(map[string]interface{})
+		if !ok || resultMap["error"] == nil {
+			t.Errorf("%s: got %v, want the JSON-RPC error", format, result)
+			continue
+		}
+		if _, wrapped := resultMap["content"]; wrapped {
+			t.Errorf("%s: JSON-RPC error was wrapped in a result envelope: %v", format, result)
+		}
+	}
+}
+
+func TestOtherMessagesAreNotTheToolCallResponse(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"Server listening on stdio",
+		`{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/progress","params":{}}`,
+	} {
+		if result, ok := parseToolCallLine(line, ResultFormatNormalized); ok {
+			t.Errorf("line %q was taken for the response: %v", line, result)
+		}
+	}
+}
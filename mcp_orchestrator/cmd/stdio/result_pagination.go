@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"mcp_orchestrator/internal/performance"
+)
+
+const (
+	defaultResultPageSize = 50              // Items per page when only _page is given
+	defaultResultPageTTL  = 5 * time.Minute // How long a full result is kept for its later pages
+	maxPagedResults       = 20              // Full results kept at once; the least recently used go first
+)
+
+// resultPage is the page of a tool result a client asked for with the _page
+// and _page_size arguments
+type resultPage struct {
+	Page int
+	Size int
+}
+
+// newResultPageCache creates the cache holding full results for later pages
+func newResultPageCache() *performance.Cache {
+	return performance.NewCache(performance.CacheConfig{
+		MaxSize:         maxPagedResults,
+		DefaultTTL:      defaultResultPageTTL,
+		CleanupInterval: time.Minute,
+	})
+}
+
+// takeResultPage removes _page and _page_size from a call's arguments so the
+// server never sees them, and returns the page requested. ok is false when
+// the client didn't ask for a page.
+func takeResultPage(params map[string]interface{}) (page resultPage, ok bool, err error) {
+	arguments, _ := params["arguments"].(map[string]interface{})
+	if arguments == nil {
+		return resultPage{}, false, nil
+	}
+
+	pageValue, hasPage := arguments["_page"]
+	sizeValue, hasSize := arguments["_page_size"]
+	if !hasPage && !hasSize {
+		return resultPage{}, false, nil
+	}
+	delete(arguments, "_page")
+	delete(arguments, "_page_size")
+
+	page = resultPage{Page: 1, Size: defaultResultPageSize}
+	if hasPage {
+		number, isNumber := pageValue.(float64)
+		if !isNumber || number < 1 || number != float64(int(number)) {
+			return resultPage{}, false, fmt.Errorf("_page must be a whole number of at least 1")
+		}
+		page.Page = int(number)
+	}
+	if hasSize {
+		number, isNumber := sizeValue.(float64)
+		if !isNumber || number < 1 || number != float64(int(number)) {
+			return resultPage{}, false, fmt.Errorf("_page_size must be a whole number of at least 1")
+		}
+		page.Size = int(number)
+	}
+
+	return page, true, nil
+}
+
+// resultPageKey identifies a call's full result by server, tool and
+// arguments, with the paging arguments already removed
+func resultPageKey(serverID, toolName string, arguments interface{}) string {
+	argumentsJSON, _ := json.Marshal(arguments)
+	sum := sha256.Sum256([]byte(serverID + "\x00" + toolName + "\x00" + string(argumentsJSON)))
+	return hex.EncodeToString(sum[:])
+}
+
+// pageToolResult returns one page of an array-shaped result: one whose
+// content is a list of several items, or a single text item holding a JSON
+// array. Paging metadata goes in _meta.pagination. Errors and results of any
+// other shape are returned unchanged.
+func pageToolResult(result interface{}, page resultPage) interface{} {
+	resultMap, ok := result.(map[string]interface{})
+	if !ok {
+		return result
+	}
+	if _, hasError := resultMap["error"]; hasError {
+		return result
+	}
+	content, ok := resultMap["content"].([]interface{})
+	if !ok {
+		return result
+	}
+
+	items := content
+	fromText := false
+	if len(content) == 1 {
+		var array []interface{}
+		item, _ := content[0].(map[string]interface{})
+		text, _ := item["text"].(string)
+		if err := json.Unmarshal([]byte(text), &array); err != nil {
+			return result
+		}
+		items = array
+		fromText = true
+	}
+
+	start := (page.Page - 1) * page.Size
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + page.Size
+	if end > len(items) {
+		end = len(items)
+	}
+	pageItems := items[start:end]
+
+	paged := make(map[string]interface{}, len(resultMap)+1)
+	for key, value := range resultMap {
+		paged[key] = value
+	}
+	if fromText {
+		pageJSON, _ := json.Marshal(pageItems)
+		paged["content"] = []interface{}{
+			map[string]interface{}{"type": "text", "text": string(pageJSON)},
+		}
+	} else {
+		paged["content"] = pageItems
+	}
+
+	totalPages := (len(items) + page.Size - 1) / page.Size
+	pagination := map[string]interface{}{
+		"page":        page.Page,
+		"page_size":   page.Size,
+		"total_items": len(items),
+		"total_pages": totalPages,
+		"has_more":    end < len(items),
+	}
+	if end < len(items) {
+		pagination["next_page"] = page.Page + 1
+	}
+
+	meta := make(map[string]interface{})
+	if existing, ok := resultMap["_meta"].(map[string]interface{}); ok {
+		for key, value := range existing {
+			meta[key] = value
+		}
+	}
+	meta["pagination"] = pagination
+	paged["_meta"] = meta
+
+	return paged
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+)
+
+// SchemaLevel names how much of each tool's schema tools/list returns
+type SchemaLevel string
+
+// Schema levels, from most to least detailed
+const (
+	SchemaLevelFull     SchemaLevel = "full"     // Tools exactly as discovered
+	SchemaLevelStandard SchemaLevel = "standard" // Property types, descriptions and constraints, plus any output schema
+	SchemaLevelCompact  SchemaLevel = "compact"  // Property types and constraints, no property descriptions, plus any output schema
+	SchemaLevelMinimal  SchemaLevel = "minimal"  // Name, description and category only
+)
+
+// schemaLevelSpec lists the schema fields a level retains
+type schemaLevelSpec struct {
+	InputSchema          bool // Include a reduced inputSchema
+	PropertyDescriptions bool // Keep each property's description
+	Constraints          bool // Keep required, enum and default
+	OutputSchema         bool // Include the outputSchema a tool declares, unchanged
+}
+
+// schemaLevels defines every level except full, which returns tools unchanged
+var schemaLevels = map[SchemaLevel]schemaLevelSpec{
+	SchemaLevelStandard: {InputSchema: true, PropertyDescriptions: true, Constraints: true, OutputSchema: true},
+	SchemaLevelCompact:  {InputSchema: true, Constraints: true, OutputSchema: true},
+	SchemaLevelMinimal:  {},
+}
+
+// parseSchemaLevel validates a schema_level param
+func parseSchemaLevel(name string) (SchemaLevel, error) {
+	level := SchemaLevel(name)
+	if level == SchemaLevelFull {
+		return level, nil
+	}
+	if _, ok := schemaLevels[level]; ok {
+		return level, nil
+	}
+
+	return "", fmt.Errorf("unknown schema_level %q (expected full, standard, compact or minimal)", name)
+}
+
+// parseConfiguredSchemaLevel validates a configured default level, also
+// accepting the names of the legacy simplified and ultra_minimal flags
+func parseConfiguredSchemaLevel(name string) (SchemaLevel, error) {
+	switch name {
+	case "simplified":
+		return SchemaLevelStandard, nil
+	case "ultra_minimal":
+		return SchemaLevelMinimal, nil
+	}
+	return parseSchemaLevel(name)
+}
+
+// schemaLevelFromFlags maps the legacy simplified/ultra_minimal params to a level
+func schemaLevelFromFlags(simplified, ultraMinimal bool) SchemaLevel {
+	if ultraMinimal {
+		return SchemaLevelMinimal
+	}
+	if simplified {
+		return SchemaLevelStandard
+	}
+	return SchemaLevelFull
+}
+
+// applySchemaLevel reduces tool schemas to the fields retained by a level
+func applySchemaLevel(tools []interface{}, level SchemaLevel) []interface{} {
+	spec, ok := schemaLevels[level]
+	if !ok {
+		return tools
+	}
+
+	var shaped []interface{}
+	for _, toolData := range tools {
+		tool, ok := toolData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		shapedTool := map[string]interface{}{
+			"name":        tool["name"],
+			"description": tool["description"],
+		}
+
+		// Add category if available for grouping
+		if category, ok := tool["category"]; ok && category != nil {
+			shapedTool["category"] = category
+		}
+
+		// Keep the credential readiness added for tools/list
+		if ready, ok := tool["_ready"]; ok {
+			shapedTool["_ready"] = ready
+		}
+		if missing, ok := tool["_missing_credentials"]; ok {
+			shapedTool["_missing_credentials"] = missing
+		}
+
+		if inputSchema, ok := tool["inputSchema"].(map[string]interface{}); ok && spec.InputSchema {
+			shapedTool["inputSchema"] = reduceInputSchema(inputSchema, spec)
+		}
+
+		// Clients validate structured results against the whole output schema,
+		// so it is never reduced
+		if outputSchema, ok := tool["outputSchema"].(map[string]interface{}); ok && spec.OutputSchema {
+			shapedTool["outputSchema"] = outputSchema
+		}
+
+		shaped = append(shaped, shapedTool)
+	}
+
+	return shaped
+}
+
+// reduceInputSchema keeps only the property fields a level retains
+func reduceInputSchema(inputSchema map[string]interface{}, spec schemaLevelSpec) map[string]interface{} {
+	reducedProps := make(map[string]interface{})
+	if props, ok := inputSchema["properties"].(map[string]interface{}); ok {
+		for propName, propData := range props {
+			prop, ok := propData.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			reducedProp := map[string]interface{}{
+				"type": prop["type"],
+			}
+			if spec.PropertyDescriptions {
+				reducedProp["description"] = prop["description"]
+			}
+			if spec.Constraints {
+				if enum, ok := prop["enum"]; ok {
+					reducedProp["enum"] = enum
+				}
+				if def, ok := prop["default"]; ok {
+					reducedProp["default"] = def
+				}
+			}
+			reducedProps[propName] = reducedProp
+		}
+	}
+
+	reduced := map[string]interface{}{
+		"type":       "object",
+		"properties": reducedProps,
+	}
+	if required, ok := inputSchema["required"]; ok && spec.Constraints {
+		reduced["required"] = required
+	}
+
+	return reduced
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// schemaTool builds a tool whose input schema uses required, enum and default
+func schemaTool() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "search_contacts",
+		"description": "Search contacts",
+		"category":    "crm",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Text to search for",
+					"minLength":   1,
+				},
+				"sort": map[string]interface{}{
+					"type":        "string",
+					"description": "Sort order",
+					"enum":        []interface{}{"asc", "desc"},
+					"default":     "asc",
+				},
+			},
+			"required": []interface{}{"query"},
+		},
+	}
+}
+
+func TestStandardSchemaKeepsConstraints(t *testing.T) {
+	shaped := applySchemaLevel([]interface{}{schemaTool()}, SchemaLevelStandard)
+	schema := shaped[0].(map[string]interface{})["inputSchema"].(map[string]interface{})
+
+	if !reflect.DeepEqual(schema["required"], []interface{}{"query"}) {
+		t.Errorf("required = %v, want [query]", schema["required"])
+	}
+
+	props := schema["properties"].(map[string]interface{})
+	sort := props["sort"].(map[string]interface{})
+	if !reflect.DeepEqual(sort["enum"], []interface{}{"asc", "desc"}) || sort["default"] != "asc" {
+		t.Errorf("sort = %v, want its enum and default kept", sort)
+	}
+	if sort["description"] != "Sort order" || sort["type"] != "string" {
+		t.Errorf("sort = %v, want its type and description kept", sort)
+	}
+
+	// Fields outside the level are dropped
+	if _, ok := props["query"].(map[string]interface{})["minLength"]; ok {
+		t.Error("standard schema kept minLength")
+	}
+}
+
+func TestCompactSchemaDropsDescriptionsOnly(t *testing.T) {
+	shaped := applySchemaLevel([]interface{}{schemaTool()}, SchemaLevelCompact)
+	schema := shaped[0].(map[string]interface{})["inputSchema"].(map[string]interface{})
+	sort := schema["properties"].(map[string]interface{})["sort"].(map[string]interface{})
+
+	if _, ok := sort["description"]; ok {
+		t.Error("compact schema kept a property description")
+	}
+	if sort["default"] != "asc" || sort["enum"] == nil || schema["required"] == nil {
+		t.Errorf("compact schema lost constraints: %v", schema)
+	}
+}
+
+func TestMinimalSchemaKeepsIdentity(t *testing.T) {
+	shaped := applySchemaLevel([]interface{}{schemaTool()}, SchemaLevelMinimal)
+	want := map[string]interface{}{
+		"name":        "search_contacts",
+		"description": "Search contacts",
+		"category":    "crm",
+	}
+	if !reflect.DeepEqual(shaped[0], want) {
+		t.Errorf("minimal tool = %v, want %v", shaped[0], want)
+	}
+}
+
+func TestFullSchemaIsUnchanged(t *testing.T) {
+	tools := []interface{}{schemaTool()}
+	if shaped := applySchemaLevel(tools, SchemaLevelFull); !reflect.DeepEqual(shaped, tools) {
+		t.Errorf("full level changed tools: %v", shaped)
+	}
+}
+
+func TestOutputSchemaKeptWhole(t *testing.T) {
+	outputSchema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"contacts": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "object", "description": "A contact"},
+			},
+		},
+		"required": []interface{}{"contacts"},
+	}
+	tool := schemaTool()
+	tool["outputSchema"] = outputSchema
+
+	for _, level := range []SchemaLevel{SchemaLevelFull, SchemaLevelStandard, SchemaLevelCompact} {
+		shaped := applySchemaLevel([]interface{}{tool}, level)[0].(map[string]interface{})
+		if !reflect.DeepEqual(shaped["outputSchema"], outputSchema) {
+			t.Errorf("%s level changed outputSchema to %v", level, shaped["outputSchema"])
+		}
+	}
+
+	minimal := applySchemaLevel([]interface{}{tool}, SchemaLevelMinimal)[0].(map[string]interface{})
+	if _, ok := minimal["outputSchema"]; ok {
+		t.Error("minimal level kept outputSchema")
+	}
+}
+
+func TestToolWithoutOutputSchemaGetsNone(t *testing.T) {
+	shaped := applySchemaLevel([]interface{}{schemaTool()}, SchemaLevelStandard)[0].(map[string]interface{})
+	if _, ok := shaped["outputSchema"]; ok {
+		t.Error("a tool without an outputSchema was given one")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// ServerAccess restricts which servers the proxy lists tools from and routes
+// calls to. An empty allowlist allows every server; the denylist always wins.
+type ServerAccess struct {
+	Allowed map[string]bool
+	Denied  map[string]bool
+}
+
+// loadServerAccess reads the comma-separated MCP_ALLOWED_SERVERS and
+// MCP_DENIED_SERVERS lists of server IDs
+func loadServerAccess() ServerAccess {
+	return ServerAccess{
+		Allowed: envSet("MCP_ALLOWED_SERVERS"),
+		Denied:  envSet("MCP_DENIED_SERVERS"),
+	}
+}
+
+// Allows reports whether the proxy may route to a server
+func (a ServerAccess) Allows(serverID string) bool {
+	if a.Denied[serverID] {
+		return false
+	}
+	return len(a.Allowed) == 0 || a.Allowed[serverID]
+}
+
+// Summary describes the lists for error data
+func (a ServerAccess) Summary() map[string]interface{} {
+	return map[string]interface{}{
+		"allowed_servers": sortedKeys(a.Allowed),
+		"denied_servers":  sortedKeys(a.Denied),
+	}
+}
+
+// envSet reads a comma-separated list from the environment as a set, or nil
+// when the variable is unset or empty
+func envSet(key string) map[string]bool {
+	var set map[string]bool
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if set == nil {
+			set = make(map[string]bool)
+		}
+		set[item] = true
+	}
+	return set
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"path/filepath"
+	"time"
+
+	"mcp_orchestrator/internal/mcpclient"
+	"mcp_orchestrator/internal/profiles"
+	"mcp_orchestrator/internal/servers"
+)
+
+// serverOverrides holds the active profile's per-server launch overrides
+type serverOverrides map[string]profiles.ServerConfig
+
+// launch returns the command, arguments and working directory for a server,
+// applying any override from the active profile to the given defaults the
+// same way the orchestrator does. sharedEnv is the environment shared with
+// every server.
+func (o serverOverrides) launch(serverID, installPath, command string, args []string, sharedEnv map[string]string) (string, []string, string) {
+	override, exists := o[serverID]
+	if !exists || !override.HasLaunchOverride() {
+		return command, args, installPath
+	}
+
+	return servers.ApplyLaunchOverride(override, command, args, servers.Placeholders{
+		InstallPath: installPath,
+		ServerID:    serverID,
+		Env:         launchEnv(installPath, sharedEnv),
+	})
+}
+
+// launchEnv returns the variables ${VAR} placeholders resolve against before
+// the process environment, as the orchestrator resolves them: the server's
+// .env file, which the orchestrator loads as the server's Env, over the
+// shared environment
+func launchEnv(installPath string, sharedEnv map[string]string) map[string]string {
+	env := make(map[string]string, len(sharedEnv))
+	for key, value := range sharedEnv {
+		env[key] = value
+	}
+	if fileEnv, err := mcpclient.LoadEnvFile(filepath.Join(installPath, ".env")); err == nil {
+		for key, value := range fileEnv {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+// retryPolicy returns a server's discovery retry policy, applying any
+// attempt or backoff override from the active profile to the default
+func (o serverOverrides) retryPolicy(serverID string, defaults RetryPolicy) RetryPolicy {
+	policy := defaults
+	if override, exists := o[serverID]; exists {
+		if override.DiscoveryMaxAttempts > 0 {
+			policy.MaxAttempts = override.DiscoveryMaxAttempts
+		}
+		if override.DiscoveryBackoffMs > 0 {
+			policy.BaseBackoff = time.Duration(override.DiscoveryBackoffMs) * time.Millisecond
+		}
+	}
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	return policy
+}
+
+// callLimits returns the servers whose concurrent tool calls the profile limits
+func (o serverOverrides) callLimits() map[string]int {
+	limits := make(map[string]int)
+	for serverID, override := range o {
+		if override.MaxConcurrentCalls > 0 {
+			limits[serverID] = override.MaxConcurrentCalls
+		}
+	}
+	return limits
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"mcp_orchestrator/internal/performance"
+)
+
+// stateReportTimeout bounds the request reporting the proxy's state
+const stateReportTimeout = 5 * time.Second
+
+// stateReport describes the proxy's runtime state for the orchestrator
+func (p *StdioProxy) stateReport() performance.ProxyReport {
+	return performance.ProxyReport{
+		ProxyID:          p.proxyID,
+		ProfileID:        p.config.Profile.ID,
+		Quarantine:       p.quarantine.GetStatus(),
+		Budgets:          p.budgets.GetStatus(),
+		DiscoveryTimings: p.enhancedDiscovery.DiscoveryTimings(),
+	}
+}
+
+// reportState sends the proxy's quarantine state and budget consumption to
+// the orchestrator. They live in this process, so the orchestrator's API
+// can't see them otherwise.
+func (p *StdioProxy) reportState() {
+	ctx, cancel := context.WithTimeout(context.Background(), stateReportTimeout)
+	defer cancel()
+
+	req, err := p.api.newJSONRequest(ctx, http.MethodPost, "/api/proxies/report", p.stateReport())
+	if err != nil {
+		return
+	}
+
+	resp, err := p.api.Do(req)
+	if err != nil {
+		log.Printf("Warning: Failed to report proxy state: %v", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// watchStateReports reports the proxy's state periodically; the orchestrator
+// forgets a proxy that stops reporting
+func (p *StdioProxy) watchStateReports(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	p.reportState()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p.reportState()
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sync"
+
+	"mcp_orchestrator/internal/profiles"
+)
+
+// ToolCap reports a server whose tools were capped by max_exposed_tools
+type ToolCap struct {
+	ServerID string `json:"server_id"`
+	Exposed  int    `json:"exposed"`
+	Total    int    `json:"total"`
+	ExposeBy string `json:"expose_by"`
+}
+
+// toolUsage counts the calls routed to each tool since the proxy started
+type toolUsage struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+// newToolUsage creates an empty usage count
+func newToolUsage() *toolUsage {
+	return &toolUsage{calls: make(map[string]int)}
+}
+
+// record counts a call to a server's tool
+func (u *toolUsage) record(serverID, toolName string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.calls[serverID+"/"+toolName]++
+}
+
+// count returns the calls made to a server's tool
+func (u *toolUsage) count(serverID, toolName string) int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.calls[serverID+"/"+toolName]
+}
+
+// capTools applies a server's max_exposed_tools from the active profile,
+// returning the tools to expose and, when some were dropped, a report of the
+// cap. Servers without a cap keep all their tools.
+func (o serverOverrides) capTools(serverID string, tools []interface{}, usage *toolUsage) ([]interface{}, *ToolCap) {
+	override := o[serverID]
+
+	names := make([]string, len(tools))
+	for i, toolData := range tools {
+		if tool, ok := toolData.(map[string]interface{}); ok {
+			names[i], _ = tool["name"].(string)
+		}
+	}
+
+	positions := override.ExposedTools(names, func(name string) int {
+		return usage.count(serverID, name)
+	})
+	if positions == nil {
+		return tools, nil
+	}
+
+	exposed := make([]interface{}, len(positions))
+	for i, position := range positions {
+		exposed[i] = tools[position]
+	}
+
+	exposeBy := profiles.ExposeByPriority
+	if override.ExposeBy == profiles.ExposeByPopularity {
+		exposeBy = profiles.ExposeByPopularity
+	}
+
+	return exposed, &ToolCap{
+		ServerID: serverID,
+		Exposed:  len(exposed),
+		Total:    len(tools),
+		ExposeBy: exposeBy,
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"strings"
+
+	"mcp_orchestrator/internal/mcpclient"
+)
+
+// streamToolCallResponse runs a server process and reads its stdout as it is
+// written, returning as soon as the response to the forwarded tool call
+// arrives. Interim output is discarded line by line rather than buffered, and
+// the process is killed once the response is in. At most MaxOutputBytes are
+// read in total; a server writing more is killed and the call fails with an
+// output too large error. Returns nil when the process fails or exits
+// without responding.
+func (p *StdioProxy) streamToolCallResponse(cmd *exec.Cmd) interface{} {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil
+	}
+	if err := cmd.Start(); err != nil {
+		return nil
+	}
+
+	var source io.Reader = stdout
+	limit := p.config.MaxOutputBytes
+	var limited *io.LimitedReader
+	if limit > 0 {
+		// One byte over the cap tells a full cap apart from too much output
+		limited = &io.LimitedReader{R: stdout, N: int64(limit) + 1}
+		source = limited
+	}
+
+	reader := bufio.NewReader(source)
+	for {
+		line, readErr := reader.ReadString('\n')
+		if limited != nil && limited.N <= 0 {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return outputTooLargeResult(limit)
+		}
+		if result, ok := parseToolCallLine(line, p.config.ResultFormat); ok {
+			// Nothing after the response is needed
+			cmd.Process.Kill()
+			cmd.Wait()
+			return result
+		}
+
+		if readErr != nil {
+			if readErr != io.EOF {
+				cmd.Process.Kill()
+			}
+			cmd.Wait()
+			return nil
+		}
+	}
+}
+
+// parseToolCallLine parses one line of server output, reporting whether it is
+// the response to the forwarded tool call. Results are shaped by format;
+// JSON-RPC errors are returned as a map with an "error" key in either format.
+func parseToolCallLine(line string, format ResultFormat) (interface{}, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || !strings.HasPrefix(line, "{") {
+		return nil, false
+	}
+
+	var msg MCPMessage
+	if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		return nil, false
+	}
+
+	// Check if this is our tool call response
+	if id, ok := msg.ID.(float64); !ok || id != mcpclient.RequestID {
+		return nil, false
+	}
+
+	if msg.Result != nil {
+		if format == ResultFormatRaw {
+			return msg.Result, true
+		}
+		return normalizeToolResult(msg.Result), true
+	}
+	if msg.Error != nil {
+		return map[string]interface{}{
+			"error": msg.Error,
+		}, true
+	}
+
+	return nil, false
+}
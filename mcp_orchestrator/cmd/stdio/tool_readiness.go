@@ -0,0 +1,76 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"mcp_orchestrator/internal/servers"
+)
+
+// readinessTTL is how long a server's credential check is reused, so listing
+// tools reads each server's .env file at most this often
+const readinessTTL = 30 * time.Second
+
+// credentialReadiness caches which required credentials each server is missing
+type credentialReadiness struct {
+	mu      sync.Mutex
+	checked map[string]readinessCheck
+}
+
+// readinessCheck is the result of checking one server's credentials
+type readinessCheck struct {
+	missing   []string
+	checkedAt time.Time
+}
+
+// newCredentialReadiness creates an empty readiness cache
+func newCredentialReadiness() *credentialReadiness {
+	return &credentialReadiness{checked: make(map[string]readinessCheck)}
+}
+
+// missing returns the credentials a server lacks, using the validator's list
+// of required credentials
+func (r *credentialReadiness) missing(serverID string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if check, ok := r.checked[serverID]; ok && time.Since(check.checkedAt) < readinessTTL {
+		return check.missing
+	}
+
+	missing := servers.MissingCredentials(serverID, "/Users/user/.mcp_orchestrator/"+serverID)
+	r.checked[serverID] = readinessCheck{missing: missing, checkedAt: time.Now()}
+	return missing
+}
+
+// annotateReadiness returns copies of tools with a _ready flag, plus the
+// names of _missing_credentials for tools whose server lacks some. Tools are
+// copied because discovered tools are shared with the cache.
+func (p *StdioProxy) annotateReadiness(tools []interface{}) []interface{} {
+	annotated := make([]interface{}, 0, len(tools))
+	for _, toolData := range tools {
+		tool, ok := toolData.(map[string]interface{})
+		if !ok {
+			annotated = append(annotated, toolData)
+			continue
+		}
+
+		copied := make(map[string]interface{}, len(tool)+2)
+		for key, value := range tool {
+			copied[key] = value
+		}
+
+		var missing []string
+		if serverID, ok := tool["_server_id"].(string); ok {
+			missing = p.readiness.missing(serverID)
+		}
+		copied["_ready"] = len(missing) == 0
+		if len(missing) > 0 {
+			copied["_missing_credentials"] = missing
+		}
+
+		annotated = append(annotated, copied)
+	}
+
+	return annotated
+}
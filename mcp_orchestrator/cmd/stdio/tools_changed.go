@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// discoveryHash returns a hash of a discovery pass's tools that doesn't depend
+// on the order servers finished discovery in
+func discoveryHash(tools []interface{}) string {
+	sorted := make([]interface{}, len(tools))
+	copy(sorted, tools)
+
+	sortKey := func(toolData interface{}) string {
+		tool, ok := toolData.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		return fmt.Sprintf("%v\x00%v", tool["_server_id"], tool["name"])
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sortKey(sorted[i]) < sortKey(sorted[j])
+	})
+
+	return toolSetHash(sorted, len(sorted))
+}
+
+// trackToolSet records the tool set hash of a discovery pass and sends
+// notifications/tools/list_changed when it differs from the one the client
+// was last told about. The first pass only sets the baseline.
+func (p *StdioProxy) trackToolSet(hash string) {
+	if hash == "" {
+		return
+	}
+
+	p.toolsMu.Lock()
+	if p.lastToolsHash == "" || p.lastToolsHash == hash {
+		p.lastToolsHash = hash
+		p.toolsMu.Unlock()
+		return
+	}
+	p.lastToolsHash = hash
+	ready := p.clientReady
+	p.toolsMu.Unlock()
+
+	// Clients that haven't finished initializing will list tools anyway
+	if !ready {
+		return
+	}
+
+	if err := p.sendResponse(MCPMessage{JSONRPC: "2.0", Method: "notifications/tools/list_changed"}); err != nil {
+		log.Printf("Warning: Failed to send tools/list_changed notification: %v", err)
+	}
+}
+
+// markClientReady allows notifications once the client has finished initializing
+func (p *StdioProxy) markClientReady() {
+	p.toolsMu.Lock()
+	defer p.toolsMu.Unlock()
+
+	p.clientReady = true
+}
+
+// watchToolChanges re-runs discovery periodically so servers being started,
+// stopped or updated are noticed even while the client isn't listing tools
+func (p *StdioProxy) watchToolChanges(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		// An unreachable orchestrator would look like every tool disappearing
+		if p.checkOrchestrator() != nil {
+			continue
+		}
+
+		p.enhancedDiscovery.DiscoverToolsWithDiagnostics()
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newListingProxy builds a proxy whose orchestrator reports one running
+// server, with that server's tools already in the discovery cache
+func newListingProxy(t *testing.T, toolCount int) *StdioProxy {
+	orchestrator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health/ready":
+			fmt.Fprint(w, `{"status": "ready"}`)
+		case "/api/servers":
+			fmt.Fprint(w, `{"servers": [{"id": "big", "status": "running", "category": "crm"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(orchestrator.Close)
+
+	p := NewStdioProxy(orchestrator.URL, ProxyConfig{
+		DiscoveryConcurrency: 1,
+		ToolList:             defaultToolList(),
+		HealthCheckTimeout:   time.Second,
+		HealthCheckAttempts:  1,
+	})
+
+	tools := make([]interface{}, toolCount)
+	for i := range tools {
+		tools[i] = map[string]interface{}{
+			"name":        fmt.Sprintf("tool_%03d", i),
+			"description": "Tool under test",
+			"inputSchema": map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+		}
+	}
+	p.enhancedDiscovery.setCachedTools("big", CachedToolData{
+		Tools:     tools,
+		ServerID:  "big",
+		Status:    "success",
+		Timestamp: time.Now(),
+	})
+	return p
+}
+
+func TestToolsListPagesToTheEnd(t *testing.T) {
+	const toolCount = 253
+	p := newListingProxy(t, toolCount)
+
+	seen := make(map[string]bool)
+	offset := 0
+	for page := 0; ; page++ {
+		if page > toolCount {
+			t.Fatal("paging never reached the end")
+		}
+
+		response := p.handleToolsList(MCPMessage{ID: page, JSONRPC: "2.0", Method: "tools/list",
+			Params: map[string]interface{}{"offset": float64(offset), "limit": float64(40)}})
+		if response.Error != nil {
+			t.Fatalf("page at offset %d failed: %v", offset, response.Error)
+		}
+		result := response.Result.(map[string]interface{})
+		meta := result["_meta"].(map[string]interface{})
+		tools := result["tools"].([]interface{})
+
+		if meta["total_count"] != toolCount {
+			t.Fatalf("total_count = %v, want %d", meta["total_count"], toolCount)
+		}
+		// The large tool set must shrink the page below the requested limit,
+		// which is what used to leave the last tools unreachable
+		if page == 0 && meta["context_optimized"] != true {
+			t.Fatalf("limit wasn't adjusted for %d tools: %v", toolCount, meta)
+		}
+		for _, toolData := range tools {
+			name := toolData.(map[string]interface{})["name"].(string)
+			if seen[name] {
+				t.Errorf("%s listed twice", name)
+			}
+			seen[name] = true
+		}
+
+		if meta["has_more"] != true {
+			break
+		}
+		if len(tools) == 0 {
+			t.Fatalf("empty page at offset %d still reports has_more", offset)
+		}
+		offset = meta["next_offset"].(int)
+	}
+
+	if len(seen) != toolCount {
+		t.Errorf("paged through %d tools, want %d", len(seen), toolCount)
+	}
+}
+
+func TestToolsListReportsToolCapInDiagnostics(t *testing.T) {
+	p := newListingProxy(t, 30)
+	p.config.MaxTools = 20
+
+	response := p.handleToolsList(MCPMessage{ID: 1, JSONRPC: "2.0", Method: "tools/list"})
+	result := response.Result.(map[string]interface{})
+
+	if meta := result["_meta"].(map[string]interface{}); meta["total_count"] != 20 || meta["tool_cap_reached"] != true {
+		t.Errorf("got total_count %v, tool_cap_reached %v; want 20, true", meta["total_count"], meta["tool_cap_reached"])
+	}
+	if diagnostics := result["diagnostics"].([]DiagnosticIssue); !hasDiagnostic(diagnostics, "proxy", "tool_cap_reached") {
+		t.Errorf("no tool_cap_reached diagnostic: %v", diagnostics)
+	}
+
+	// The warning belongs to this response, not to the shared discovery pass
+	if _, diagnostics := p.enhancedDiscovery.DiscoverToolsWithDiagnostics(); hasDiagnostic(diagnostics, "proxy", "tool_cap_reached") {
+		t.Error("tool_cap_reached leaked into the discovery pass's diagnostics")
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	minTruncatedString  = 256 // Strings shorter than this are never cut
+	maxTruncationPasses = 6   // Passes before the result is returned as small as it got
+)
+
+// truncationStats records what was cut from a tool result
+type truncationStats struct {
+	ArraysTruncated  int `json:"arrays_truncated"`
+	ItemsOmitted     int `json:"items_omitted"`
+	StringsTruncated int `json:"strings_truncated"`
+}
+
+// truncateToolResult shrinks a tool result that serializes to more than
+// maxBytes. Every array and string is cut in proportion to the overshoot, so
+// large values give up the most, and a note is appended explaining how to
+// fetch the rest. A maxBytes of 0 disables truncation.
+func truncateToolResult(result interface{}, maxBytes int) interface{} {
+	resultMap, ok := result.(map[string]interface{})
+	if !ok || maxBytes <= 0 {
+		return result
+	}
+	if _, hasError := resultMap["error"]; hasError {
+		return result
+	}
+
+	originalBytes := jsonSize(resultMap)
+	if originalBytes <= maxBytes {
+		return result
+	}
+
+	content, ok := resultMap["content"].([]interface{})
+	if !ok {
+		return result
+	}
+
+	stats := &truncationStats{}
+	ratio := float64(maxBytes) / float64(originalBytes)
+	truncated := content
+	for pass := 0; pass < maxTruncationPasses; pass++ {
+		truncated = truncateValue(content, ratio, stats).([]interface{})
+		if jsonSize(truncated) <= maxBytes {
+			break
+		}
+		// Undershoot on the next pass; stats only describe the final one
+		ratio *= 0.7
+		*stats = truncationStats{}
+	}
+
+	returnedBytes := jsonSize(truncated)
+	hint := "The result was truncated to fit the context budget. Narrow the request " +
+		"(filters, limit/offset or page parameters) to fetch the omitted data."
+	truncated = append(truncated, map[string]interface{}{
+		"type": "text",
+		"text": fmt.Sprintf("[Truncated: %d of %d bytes returned, %d items omitted. %s]",
+			returnedBytes, originalBytes, stats.ItemsOmitted, hint),
+	})
+
+	shaped := make(map[string]interface{}, len(resultMap)+1)
+	for key, value := range resultMap {
+		shaped[key] = value
+	}
+	shaped["content"] = truncated
+
+	meta, _ := shaped["_meta"].(map[string]interface{})
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	meta["truncated"] = true
+	meta["truncation"] = map[string]interface{}{
+		"original_bytes":    originalBytes,
+		"returned_bytes":    returnedBytes,
+		"max_result_bytes":  maxBytes,
+		"arrays_truncated":  stats.ArraysTruncated,
+		"items_omitted":     stats.ItemsOmitted,
+		"strings_truncated": stats.StringsTruncated,
+		"hint":              hint,
+	}
+	shaped["_meta"] = meta
+
+	return shaped
+}
+
+// truncateValue cuts arrays and strings within a value to ratio of their size
+func truncateValue(value interface{}, ratio float64, stats *truncationStats) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		shaped := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			shaped[key] = truncateValue(item, ratio, stats)
+		}
+		return shaped
+	case []interface{}:
+		keep := int(float64(len(v)) * ratio)
+		if keep < 1 {
+			keep = 1
+		}
+		if keep < len(v) {
+			stats.ArraysTruncated++
+			stats.ItemsOmitted += len(v) - keep
+		} else {
+			keep = len(v)
+		}
+		shaped := make([]interface{}, 0, keep)
+		for _, item := range v[:keep] {
+			shaped = append(shaped, truncateValue(item, ratio, stats))
+		}
+		return shaped
+	case string:
+		return truncateString(v, ratio, stats)
+	default:
+		return value
+	}
+}
+
+// truncateString cuts a string to ratio of its length. Strings holding JSON,
+// which is how most servers return text content, are truncated structurally
+// so the client still receives valid JSON.
+func truncateString(s string, ratio float64, stats *truncationStats) string {
+	if len(s) < minTruncatedString {
+		return s
+	}
+
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(trimmed), &parsed); err == nil {
+			if data, err := json.Marshal(truncateValue(parsed, ratio, stats)); err == nil {
+				return string(data)
+			}
+		}
+	}
+
+	keep := int(float64(len(s)) * ratio)
+	if keep < minTruncatedString {
+		keep = minTruncatedString
+	}
+	if keep >= len(s) {
+		return s
+	}
+
+	// Don't split a multi-byte character
+	for keep > 0 && !utf8.RuneStart(s[keep]) {
+		keep--
+	}
+
+	stats.StringsTruncated++
+	return s[:keep] + fmt.Sprintf("...[%d bytes truncated]", len(s)-keep)
+}
+
+// jsonSize returns the serialized size of a value in bytes
+func jsonSize(value interface{}) int {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"mcp_orchestrator/internal/servers"
+)
+
+// runConfigure adds or updates the orchestrator's entry in the Claude Desktop
+// config, pointing it at the stdio proxy next to this binary, and prints the
+// entry written. Returns the process exit code.
+func runConfigure(args []string) int {
+	flags := flag.NewFlagSet("configure", flag.ContinueOnError)
+	stdioPath := flags.String("stdio-path", "", "path to mcp-orchestrator-stdio (default: next to this binary)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *stdioPath == "" {
+		detected, err := servers.StdioBinaryPath()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		*stdioPath = detected
+	}
+
+	// Claude Desktop doesn't start in our working directory
+	absolute, err := filepath.Abs(*stdioPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	*stdioPath = absolute
+
+	if _, err := os.Stat(*stdioPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: stdio proxy not found at %s\n", *stdioPath)
+		fmt.Fprintln(os.Stderr, "Build it with ./build.sh or pass its location with --stdio-path")
+		return 1
+	}
+
+	configFile, entry, err := servers.ConfigureClaudeDesktop(*stdioPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	snippet, _ := json.MarshalIndent(map[string]interface{}{
+		"mcpServers": map[string]interface{}{"mcp-orchestrator": entry},
+	}, "", "  ")
+
+	fmt.Printf("Updated %s with:\n%s\n", configFile, snippet)
+	fmt.Println("Restart Claude Desktop to apply the new configuration.")
+	return 0
+}
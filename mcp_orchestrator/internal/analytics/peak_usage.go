@@ -0,0 +1,130 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// minCallsForPeakUsage is how many calls a period needs before its hourly
+// distribution says anything about peaks
+const minCallsForPeakUsage = 50
+
+// minDaysForPeakWeekday is how many distinct days are needed to compare weekdays
+const minDaysForPeakWeekday = 7
+
+// peakFactor is how far above the average an hour or weekday must be to count as a peak
+const peakFactor = 1.5
+
+// maxPeakHours limits the peak and quiet hours named in the recommendation
+const maxPeakHours = 3
+
+// hourCount is the number of calls started in one hour of the day
+type hourCount struct {
+	hour  int
+	calls int
+}
+
+// generatePeakUsage recommends scheduling maintenance outside the busiest
+// hours and weekdays, and scaling for them. Nothing is recommended while the
+// data is too sparse to show a peak.
+func (t *Tracker) generatePeakUsage(analytics *Analytics, insights *Insights) {
+	if analytics.TotalToolCalls < minCallsForPeakUsage {
+		return
+	}
+
+	hours := make([]hourCount, 0, 24)
+	for hour := 0; hour < 24; hour++ {
+		hours = append(hours, hourCount{hour: hour, calls: analytics.HourlyDistribution[hour]})
+	}
+	sort.SliceStable(hours, func(i, j int) bool {
+		return hours[i].calls > hours[j].calls
+	})
+
+	average := float64(analytics.TotalToolCalls) / 24
+	var peaks []hourCount
+	peakCalls := 0
+	for _, count := range hours {
+		if len(peaks) == maxPeakHours || float64(count.calls) < average*peakFactor {
+			break
+		}
+		peaks = append(peaks, count)
+		peakCalls += count.calls
+	}
+	if len(peaks) == 0 {
+		// Calls are spread evenly over the day
+		return
+	}
+
+	quiet := make([]hourCount, 0, maxPeakHours)
+	for i := len(hours) - 1; i >= 0 && len(quiet) < maxPeakHours; i-- {
+		quiet = append(quiet, hours[i])
+	}
+
+	description := fmt.Sprintf("%.0f%% of calls arrive at %s",
+		float64(peakCalls)/float64(analytics.TotalToolCalls)*100, formatHours(peaks))
+	if weekday, share, ok := peakWeekday(analytics.DailyDistribution); ok {
+		description += fmt.Sprintf(", and %ss carry %.0f%% of the week's calls", weekday, share*100)
+	}
+
+	insights.Recommendations = append(insights.Recommendations, Recommendation{
+		Type:        "usage",
+		Priority:    "low",
+		Title:       "Peak Usage Hours",
+		Description: description,
+		Action: fmt.Sprintf("Schedule restarts and maintenance at %s, and start servers or raise pool sizes ahead of %s",
+			formatHours(quiet), formatHours(peaks)),
+		Impact:    "Fewer calls hit restarting servers, and peaks are served without queuing",
+		CreatedAt: time.Now(),
+	})
+}
+
+// peakWeekday returns the weekday with clearly more calls than the others
+// and its share of all calls. Dates that don't parse are ignored.
+func peakWeekday(daily map[string]int) (time.Weekday, float64, bool) {
+	if len(daily) < minDaysForPeakWeekday {
+		return 0, 0, false
+	}
+
+	var byWeekday [7]int
+	total := 0
+	for day, calls := range daily {
+		date, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			continue
+		}
+		byWeekday[date.Weekday()] += calls
+		total += calls
+	}
+	if total == 0 {
+		return 0, 0, false
+	}
+
+	peak := time.Sunday
+	for weekday := time.Monday; weekday <= time.Saturday; weekday++ {
+		if byWeekday[weekday] > byWeekday[peak] {
+			peak = weekday
+		}
+	}
+	if float64(byWeekday[peak]) < float64(total)/7*peakFactor {
+		return 0, 0, false
+	}
+
+	return peak, float64(byWeekday[peak]) / float64(total), true
+}
+
+// formatHours lists hours as 24-hour clock times in order, e.g. "09:00, 14:00"
+func formatHours(counts []hourCount) string {
+	hours := make([]int, 0, len(counts))
+	for _, count := range counts {
+		hours = append(hours, count.hour)
+	}
+	sort.Ints(hours)
+
+	names := make([]string, 0, len(hours))
+	for _, hour := range hours {
+		names = append(names, fmt.Sprintf("%02d:00", hour))
+	}
+	return strings.Join(names, ", ")
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -15,6 +16,7 @@ type ToolCall struct {
 	ID           string                 `json:"id"`
 	ToolName     string                 `json:"tool_name"`
 	ServerID     string                 `json:"server_id"`
+	Category     string                 `json:"category,omitempty"`
 	ProfileID    string                 `json:"profile_id"`
 	Arguments    map[string]interface{} `json:"arguments"`
 	StartTime    time.Time              `json:"start_time"`
@@ -56,25 +58,40 @@ type ToolMetrics struct {
 	PopularityRank  int           `json:"popularity_rank"`
 }
 
+// CategoryMetrics represents usage metrics rolled up by tool category
+type CategoryMetrics struct {
+	Category        string        `json:"category"`
+	TotalCalls      int           `json:"total_calls"`
+	SuccessfulCalls int           `json:"successful_calls"`
+	FailedCalls     int           `json:"failed_calls"`
+	SuccessRate     float64       `json:"success_rate"`
+	AvgResponseTime time.Duration `json:"avg_response_time"`
+	ToolCount       int           `json:"tool_count"`
+	LastUsed        time.Time     `json:"last_used"`
+}
+
 // Analytics represents overall analytics data
 type Analytics struct {
-	GeneratedAt        time.Time       `json:"generated_at"`
-	Period             string          `json:"period"` // "hourly", "daily", "weekly", "monthly"
-	TotalToolCalls     int             `json:"total_tool_calls"`
-	TotalServers       int             `json:"total_servers"`
-	ActiveServers      int             `json:"active_servers"`
-	AvgResponseTime    time.Duration   `json:"avg_response_time"`
-	SuccessRate        float64         `json:"success_rate"`
-	TopTools           []ToolMetrics   `json:"top_tools"`
-	ServerMetrics      []ServerMetrics `json:"server_metrics"`
-	ProfileUsage       map[string]int  `json:"profile_usage"`
-	HourlyDistribution map[int]int     `json:"hourly_distribution"`
-	DailyDistribution  map[string]int  `json:"daily_distribution"`
+	GeneratedAt        time.Time         `json:"generated_at"`
+	Period             string            `json:"period"`               // "hourly", "daily", "weekly", "monthly"
+	ProfileID          string            `json:"profile_id,omitempty"` // Set when only one profile's calls are counted
+	TotalToolCalls     int               `json:"total_tool_calls"`
+	TotalServers       int               `json:"total_servers"`
+	ActiveServers      int               `json:"active_servers"`
+	AvgResponseTime    time.Duration     `json:"avg_response_time"`
+	SuccessRate        float64           `json:"success_rate"`
+	TopTools           []ToolMetrics     `json:"top_tools"`
+	ServerMetrics      []ServerMetrics   `json:"server_metrics"`
+	CategoryMetrics    []CategoryMetrics `json:"category_metrics"`
+	ProfileUsage       map[string]int    `json:"profile_usage"`
+	HourlyDistribution map[int]int       `json:"hourly_distribution"`
+	DailyDistribution  map[string]int    `json:"daily_distribution"`
 }
 
 // Insights represents actionable insights from analytics
 type Insights struct {
 	GeneratedAt     time.Time              `json:"generated_at"`
+	ProfileID       string                 `json:"profile_id,omitempty"` // Set when only one profile's calls are counted
 	Recommendations []Recommendation       `json:"recommendations"`
 	Alerts          []Alert                `json:"alerts"`
 	TrendAnalysis   TrendAnalysis          `json:"trend_analysis"`
@@ -124,12 +141,31 @@ type HealthScore struct {
 
 // Tracker manages analytics tracking
 type Tracker struct {
-	dataDir string
-	calls   []ToolCall
-	mu      sync.RWMutex
-	config  TrackerConfig
+	dataDir          string
+	calls            []ToolCall
+	mu               sync.RWMutex
+	config           TrackerConfig
+	categoryResolver CategoryResolver
+	configChanged    chan struct{} // Wakes the flush worker to pick up a new interval
+	stopWorkers      chan struct{} // Closed to stop the flush and cleanup workers; nil while stopped
 }
 
+// CategoryResolver returns the category of a tool, or "" when it is unknown
+type CategoryResolver func(serverID, toolName string) string
+
+// uncategorized groups calls whose tool category can't be determined
+const uncategorized = "uncategorized"
+
+// Bounds accepted by UpdateConfig
+const (
+	minFlushInterval  = 10 * time.Second
+	maxFlushInterval  = 24 * time.Hour
+	minRetentionDays  = 1
+	maxRetentionDays  = 365
+	minMaxMemoryCalls = 10
+	maxMaxMemoryCalls = 100000
+)
+
 // TrackerConfig defines analytics configuration
 type TrackerConfig struct {
 	Enabled           bool          `json:"enabled"`
@@ -139,12 +175,23 @@ type TrackerConfig struct {
 	EnableDetailedLog bool          `json:"enable_detailed_log"`
 }
 
+// DefaultTrackerConfig returns analytics settings suitable for a local orchestrator
+func DefaultTrackerConfig() TrackerConfig {
+	return TrackerConfig{
+		Enabled:        true,
+		RetentionDays:  30,
+		FlushInterval:  5 * time.Minute,
+		MaxMemoryCalls: 1000,
+	}
+}
+
 // NewTracker creates a new analytics tracker
 func NewTracker(dataDir string, config TrackerConfig) *Tracker {
 	tracker := &Tracker{
-		dataDir: dataDir,
-		calls:   make([]ToolCall, 0),
-		config:  config,
+		dataDir:       dataDir,
+		calls:         make([]ToolCall, 0),
+		config:        config,
+		configChanged: make(chan struct{}, 1),
 	}
 
 	// Create analytics directory
@@ -152,22 +199,106 @@ func NewTracker(dataDir string, config TrackerConfig) *Tracker {
 
 	// Start background tasks
 	if config.Enabled {
-		go tracker.flushWorker()
-		go tracker.cleanupWorker()
+		tracker.startWorkers()
 	}
 
 	return tracker
 }
 
-// TrackToolCall tracks a tool call execution
-func (t *Tracker) TrackToolCall(call ToolCall) {
-	if !t.config.Enabled {
+// SetEnabled turns tracking on or off at runtime, starting or stopping the
+// background workers. Pending calls are flushed to disk when disabling.
+func (t *Tracker) SetEnabled(enabled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.config.Enabled == enabled {
+		return
+	}
+	t.config.Enabled = enabled
+
+	if enabled {
+		t.startWorkers()
 		return
 	}
 
+	t.flushToDisk()
+	if t.stopWorkers != nil {
+		close(t.stopWorkers)
+		t.stopWorkers = nil
+	}
+}
+
+// startWorkers launches the flush and cleanup workers
+func (t *Tracker) startWorkers() {
+	t.stopWorkers = make(chan struct{})
+	go t.flushWorker(t.stopWorkers)
+	go t.cleanupWorker(t.stopWorkers)
+}
+
+// SetCategoryResolver sets how calls recorded without a category are mapped
+// to one when analytics are generated
+func (t *Tracker) SetCategoryResolver(resolver CategoryResolver) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	t.categoryResolver = resolver
+}
+
+// GetConfig returns the tracker's current settings
+func (t *Tracker) GetConfig() TrackerConfig {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.config
+}
+
+// UpdateConfig applies new flush, memory and retention settings to the
+// running tracker. Enabled is left unchanged; use SetEnabled to change it.
+func (t *Tracker) UpdateConfig(config TrackerConfig) error {
+	if config.FlushInterval < minFlushInterval || config.FlushInterval > maxFlushInterval {
+		return fmt.Errorf("flush interval must be between %v and %v", minFlushInterval, maxFlushInterval)
+	}
+	if config.RetentionDays < minRetentionDays || config.RetentionDays > maxRetentionDays {
+		return fmt.Errorf("retention days must be between %d and %d", minRetentionDays, maxRetentionDays)
+	}
+	if config.MaxMemoryCalls < minMaxMemoryCalls || config.MaxMemoryCalls > maxMaxMemoryCalls {
+		return fmt.Errorf("max memory calls must be between %d and %d", minMaxMemoryCalls, maxMaxMemoryCalls)
+	}
+
+	t.mu.Lock()
+	config.Enabled = t.config.Enabled
+	retentionShortened := config.RetentionDays < t.config.RetentionDays
+	t.config = config
+
+	// A lower memory limit may already be exceeded
+	if len(t.calls) >= t.config.MaxMemoryCalls {
+		t.flushToDisk()
+	}
+	t.mu.Unlock()
+
+	// Wake the flush worker so it restarts its ticker with the new interval
+	select {
+	case t.configChanged <- struct{}{}:
+	default:
+	}
+
+	// Drop data outside a shorter retention period now rather than at the next daily cleanup
+	if retentionShortened {
+		go t.cleanupOldData()
+	}
+
+	return nil
+}
+
+// TrackToolCall tracks a tool call execution
+func (t *Tracker) TrackToolCall(call ToolCall) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.config.Enabled {
+		return
+	}
+
 	call.Duration = call.EndTime.Sub(call.StartTime)
 	t.calls = append(t.calls, call)
 
@@ -199,8 +330,34 @@ func (t *Tracker) CompleteToolCall(call *ToolCall, success bool, errorMsg string
 	t.TrackToolCall(*call)
 }
 
-// GetAnalytics generates analytics for a given period
-func (t *Tracker) GetAnalytics(period string, days int) (*Analytics, error) {
+// GetToolCall returns the recorded call with the given ID, looking through
+// calls not yet flushed and then those on disk within the retention period
+func (t *Tracker) GetToolCall(id string) (*ToolCall, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for i := range t.calls {
+		if t.calls[i].ID == id {
+			call := t.calls[i]
+			return &call, true
+		}
+	}
+
+	calls, err := t.loadCalls(t.config.RetentionDays)
+	if err != nil {
+		return nil, false
+	}
+	for i := range calls {
+		if calls[i].ID == id {
+			return &calls[i], true
+		}
+	}
+	return nil, false
+}
+
+// GetAnalytics generates analytics for a given period. A non-empty
+// profileID limits the analytics to calls made under that profile.
+func (t *Tracker) GetAnalytics(period string, days int, profileID string) (*Analytics, error) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
@@ -213,7 +370,30 @@ func (t *Tracker) GetAnalytics(period string, days int) (*Analytics, error) {
 	// Combine with in-memory calls
 	allCalls := append(calls, t.calls...)
 
-	return t.generateAnalytics(allCalls, period), nil
+	if profileID == "" {
+		return t.generateAnalytics(allCalls, period), nil
+	}
+
+	profileCalls := make([]ToolCall, 0, len(allCalls))
+	for _, call := range allCalls {
+		if callUnderProfile(call, profileID) {
+			profileCalls = append(profileCalls, call)
+		}
+	}
+	analytics := t.generateAnalytics(profileCalls, period)
+	analytics.ProfileID = profileID
+	return analytics, nil
+}
+
+// callUnderProfile reports whether a call was made with profileID active.
+// Calls made while several profiles were active record their IDs comma-separated.
+func callUnderProfile(call ToolCall, profileID string) bool {
+	for _, id := range strings.Split(call.ProfileID, ",") {
+		if id == profileID {
+			return true
+		}
+	}
+	return false
 }
 
 // generateAnalytics creates analytics from tool calls
@@ -222,6 +402,9 @@ func (t *Tracker) generateAnalytics(calls []ToolCall, period string) *Analytics
 		GeneratedAt:        time.Now(),
 		Period:             period,
 		TotalToolCalls:     len(calls),
+		TopTools:           []ToolMetrics{},
+		ServerMetrics:      []ServerMetrics{},
+		CategoryMetrics:    []CategoryMetrics{},
 		ProfileUsage:       make(map[string]int),
 		HourlyDistribution: make(map[int]int),
 		DailyDistribution:  make(map[string]int),
@@ -235,6 +418,9 @@ func (t *Tracker) generateAnalytics(calls []ToolCall, period string) *Analytics
 	serverMap := make(map[string]bool)
 	toolMap := make(map[string]*ToolMetrics)
 	serverMetricsMap := make(map[string]*ServerMetrics)
+	categoryMap := make(map[string]*CategoryMetrics)
+	categoryDurations := make(map[string]time.Duration)
+	categoryTools := make(map[string]map[string]bool)
 
 	successCount := 0
 	totalDuration := time.Duration(0)
@@ -260,11 +446,13 @@ func (t *Tracker) generateAnalytics(calls []ToolCall, period string) *Analytics
 		analytics.DailyDistribution[day]++
 
 		// Track tool metrics
+		category := t.callCategory(call)
 		toolKey := fmt.Sprintf("%s:%s", call.ServerID, call.ToolName)
 		if _, exists := toolMap[toolKey]; !exists {
 			toolMap[toolKey] = &ToolMetrics{
 				ToolName: call.ToolName,
 				ServerID: call.ServerID,
+				Category: category,
 			}
 		}
 
@@ -277,6 +465,25 @@ func (t *Tracker) generateAnalytics(calls []ToolCall, period string) *Analytics
 		}
 		toolMetric.LastUsed = call.StartTime
 
+		// Track category metrics
+		if _, exists := categoryMap[category]; !exists {
+			categoryMap[category] = &CategoryMetrics{Category: category}
+			categoryTools[category] = make(map[string]bool)
+		}
+
+		categoryMetric := categoryMap[category]
+		categoryMetric.TotalCalls++
+		if call.Success {
+			categoryMetric.SuccessfulCalls++
+		} else {
+			categoryMetric.FailedCalls++
+		}
+		if call.StartTime.After(categoryMetric.LastUsed) {
+			categoryMetric.LastUsed = call.StartTime
+		}
+		categoryDurations[category] += call.Duration
+		categoryTools[category][toolKey] = true
+
 		// Track server metrics
 		if _, exists := serverMetricsMap[call.ServerID]; !exists {
 			serverMetricsMap[call.ServerID] = &ServerMetrics{
@@ -361,18 +568,48 @@ func (t *Tracker) generateAnalytics(calls []ToolCall, period string) *Analytics
 		analytics.ServerMetrics = append(analytics.ServerMetrics, *serverMetric)
 	}
 
+	// Convert category metrics to a slice, most used first
+	for category, categoryMetric := range categoryMap {
+		categoryMetric.SuccessRate = float64(categoryMetric.SuccessfulCalls) / float64(categoryMetric.TotalCalls) * 100
+		categoryMetric.AvgResponseTime = categoryDurations[category] / time.Duration(categoryMetric.TotalCalls)
+		categoryMetric.ToolCount = len(categoryTools[category])
+		analytics.CategoryMetrics = append(analytics.CategoryMetrics, *categoryMetric)
+	}
+	sort.Slice(analytics.CategoryMetrics, func(i, j int) bool {
+		if analytics.CategoryMetrics[i].TotalCalls != analytics.CategoryMetrics[j].TotalCalls {
+			return analytics.CategoryMetrics[i].TotalCalls > analytics.CategoryMetrics[j].TotalCalls
+		}
+		return analytics.CategoryMetrics[i].Category < analytics.CategoryMetrics[j].Category
+	})
+
 	return analytics
 }
 
-// GetInsights generates actionable insights from analytics
-func (t *Tracker) GetInsights(days int) (*Insights, error) {
-	analytics, err := t.GetAnalytics("daily", days)
+// callCategory returns the category of a call's tool, resolving calls that
+// were recorded without one
+func (t *Tracker) callCategory(call ToolCall) string {
+	if call.Category != "" {
+		return call.Category
+	}
+	if t.categoryResolver != nil {
+		if category := t.categoryResolver(call.ServerID, call.ToolName); category != "" {
+			return category
+		}
+	}
+	return uncategorized
+}
+
+// GetInsights generates actionable insights from analytics. A non-empty
+// profileID limits the insights to calls made under that profile.
+func (t *Tracker) GetInsights(days int, profileID string) (*Insights, error) {
+	analytics, err := t.GetAnalytics("daily", days, profileID)
 	if err != nil {
 		return nil, err
 	}
 
 	insights := &Insights{
 		GeneratedAt:     time.Now(),
+		ProfileID:       profileID,
 		Recommendations: make([]Recommendation, 0),
 		Alerts:          make([]Alert, 0),
 		ServerHealth:    make(map[string]HealthScore),
@@ -383,12 +620,18 @@ func (t *Tracker) GetInsights(days int) (*Insights, error) {
 	t.generateAlerts(analytics, insights)
 	t.generateHealthScores(analytics, insights)
 	t.generateTrendAnalysis(analytics, insights)
+	t.generatePeakUsage(analytics, insights)
 
 	return insights, nil
 }
 
 // generateRecommendations creates recommendations based on analytics
 func (t *Tracker) generateRecommendations(analytics *Analytics, insights *Insights) {
+	// Without calls the rates are zero, not low
+	if analytics.TotalToolCalls == 0 {
+		return
+	}
+
 	// Performance recommendations
 	if analytics.AvgResponseTime > 10*time.Second {
 		insights.Recommendations = append(insights.Recommendations, Recommendation{
@@ -540,27 +783,39 @@ func (t *Tracker) generateTrendAnalysis(analytics *Analytics, insights *Insights
 	}
 }
 
-// flushWorker periodically flushes data to disk
-func (t *Tracker) flushWorker() {
-	ticker := time.NewTicker(t.config.FlushInterval)
+// flushWorker periodically flushes data to disk until stop is closed
+func (t *Tracker) flushWorker(stop <-chan struct{}) {
+	ticker := time.NewTicker(t.GetConfig().FlushInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		t.mu.Lock()
-		if len(t.calls) > 0 {
-			t.flushToDisk()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			if len(t.calls) > 0 {
+				t.flushToDisk()
+			}
+			t.mu.Unlock()
+		case <-t.configChanged:
+			ticker.Reset(t.GetConfig().FlushInterval)
 		}
-		t.mu.Unlock()
 	}
 }
 
-// cleanupWorker periodically cleans up old data
-func (t *Tracker) cleanupWorker() {
+// cleanupWorker periodically cleans up old data until stop is closed
+func (t *Tracker) cleanupWorker(stop <-chan struct{}) {
 	ticker := time.NewTicker(24 * time.Hour)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		t.cleanupOldData()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			t.cleanupOldData()
+		}
 	}
 }
 
@@ -613,7 +868,7 @@ func (t *Tracker) loadCalls(days int) ([]ToolCall, error) {
 
 // cleanupOldData removes data older than retention period
 func (t *Tracker) cleanupOldData() {
-	cutoffDate := time.Now().AddDate(0, 0, -t.config.RetentionDays)
+	cutoffDate := time.Now().AddDate(0, 0, -t.GetConfig().RetentionDays)
 
 	analyticsDir := filepath.Join(t.dataDir, "analytics")
 	entries, err := os.ReadDir(analyticsDir)
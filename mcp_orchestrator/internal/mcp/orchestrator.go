@@ -3,8 +3,12 @@ package mcp
 import (
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
+
+	"mcp_orchestrator/internal/version"
 
 	"github.com/gorilla/websocket"
 )
@@ -21,9 +25,10 @@ type MCPMessage struct {
 
 // Orchestrator manages multiple MCP servers and acts as a proxy
 type Orchestrator struct {
-	servers  map[string]*MCPServer
-	mu       sync.RWMutex
-	upgrader websocket.Upgrader
+	servers   map[string]*MCPServer
+	mu        sync.RWMutex
+	upgrader  websocket.Upgrader
+	listening atomic.Bool
 }
 
 // MCPServer represents a managed MCP server
@@ -49,9 +54,27 @@ func NewOrchestrator() *Orchestrator {
 
 // Start starts the MCP orchestrator server
 func (o *Orchestrator) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return o.Serve(listener)
+}
+
+// Serve accepts MCP connections on a listener the caller has already bound
+func (o *Orchestrator) Serve(listener net.Listener) error {
 	http.HandleFunc("/", o.handleWebSocket)
-	log.Printf("MCP orchestrator listening on %s", addr)
-	return http.ListenAndServe(addr, nil)
+
+	o.listening.Store(true)
+	defer o.listening.Store(false)
+
+	log.Printf("MCP orchestrator listening on %s", listener.Addr())
+	return http.Serve(listener, nil)
+}
+
+// IsListening reports whether the orchestrator is accepting connections
+func (o *Orchestrator) IsListening() bool {
+	return o.listening.Load()
 }
 
 // Stop stops the orchestrator
@@ -122,7 +145,7 @@ func (o *Orchestrator) handleInitialize(msg MCPMessage) MCPMessage {
 			},
 			"serverInfo": map[string]interface{}{
 				"name":    "MCP Orchestrator",
-				"version": "1.0.0",
+				"version": version.Version,
 			},
 		},
 	}
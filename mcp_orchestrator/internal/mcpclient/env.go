@@ -0,0 +1,62 @@
+package mcpclient
+
+import (
+	"os"
+	"strings"
+)
+
+// LoadEnvFile reads the KEY=VALUE pairs of a server's .env file
+func LoadEnvFile(filename string) (map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseEnv(string(data)), nil
+}
+
+// ParseEnv parses .env content. Blank lines and # comments are skipped, a
+// leading "export " is ignored, everything after the first "=" is the value,
+// and matching single or double quotes around a value are removed.
+func ParseEnv(content string) map[string]string {
+	envVars := make(map[string]string)
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Shell-style files export each variable
+		if rest := strings.TrimPrefix(line, "export"); rest != line && (strings.HasPrefix(rest, " ") || strings.HasPrefix(rest, "\t")) {
+			line = strings.TrimSpace(rest)
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(parts[0])
+		if key == "" {
+			continue
+		}
+		envVars[key] = unquote(strings.TrimSpace(parts[1]))
+	}
+
+	return envVars
+}
+
+// unquote removes matching single or double quotes around a value
+func unquote(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+
+	first, last := value[0], value[len(value)-1]
+	if first == last && (first == '"' || first == '\'') {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
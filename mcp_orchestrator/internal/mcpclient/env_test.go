@@ -0,0 +1,77 @@
+package mcpclient
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseEnvStripsMatchingQuotes(t *testing.T) {
+	env := ParseEnv("DOUBLE=\"secret value\"\nSINGLE='it''s'\nMIXED=\"half'\nEMPTY=\"\"\nLONE=\"\n")
+
+	want := map[string]string{
+		"DOUBLE": "secret value",
+		"SINGLE": "it''s",
+		"MIXED":  "\"half'",
+		"EMPTY":  "",
+		"LONE":   "\"",
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("got %v, want %v", env, want)
+	}
+}
+
+func TestParseEnvIgnoresExportPrefix(t *testing.T) {
+	env := ParseEnv("export API_KEY=abc\nexport\tTABBED='x'\nEXPORTER=kept\n")
+
+	want := map[string]string{
+		"API_KEY":  "abc",
+		"TABBED":   "x",
+		"EXPORTER": "kept",
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("got %v, want %v", env, want)
+	}
+}
+
+func TestParseEnvKeepsEqualsInValues(t *testing.T) {
+	env := ParseEnv("TOKEN=abc==\nURL=\"postgres://u:p@host/db?sslmode=require\"\n")
+
+	if env["TOKEN"] != "abc==" {
+		t.Errorf("got TOKEN %q, want %q", env["TOKEN"], "abc==")
+	}
+	if want := "postgres://u:p@host/db?sslmode=require"; env["URL"] != want {
+		t.Errorf("got URL %q, want %q", env["URL"], want)
+	}
+}
+
+func TestParseEnvSkipsCommentsAndMalformedLines(t *testing.T) {
+	env := ParseEnv("# comment\n\n  KEY = value  \nno_equals\n=missing_key\r\n")
+
+	want := map[string]string{"KEY": "value"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("got %v, want %v", env, want)
+	}
+}
+
+func TestLoadEnvFileReadsServerEnv(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(filename, []byte("export API_KEY=\"abc=\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := LoadEnvFile(filename)
+	if err != nil {
+		t.Fatalf("LoadEnvFile: %v", err)
+	}
+	if env["API_KEY"] != "abc=" {
+		t.Errorf("got API_KEY %q, want %q", env["API_KEY"], "abc=")
+	}
+}
+
+func TestLoadEnvFileMissing(t *testing.T) {
+	if _, err := LoadEnvFile(filepath.Join(t.TempDir(), ".env")); !os.IsNotExist(err) {
+		t.Errorf("got error %v, want a not-exist error", err)
+	}
+}
@@ -0,0 +1,122 @@
+package mcpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"mcp_orchestrator/internal/version"
+)
+
+// ProtocolVersion is the MCP protocol version sent in the initialize request
+const ProtocolVersion = "2024-11-05"
+
+// RequestID is the id of the request sent after the handshake; its response
+// is picked out of the server's output by this id
+const RequestID = 2
+
+// initializeID is the id of the initialize request
+const initializeID = 1
+
+// BuildHandshake returns the stdin input for a one-shot server process: the
+// initialize request, the initialized notification and then request, one
+// JSON message per line. request should carry RequestID as its id.
+func BuildHandshake(request interface{}) (string, error) {
+	initMsg := map[string]interface{}{
+		"id":      initializeID,
+		"method":  "initialize",
+		"jsonrpc": "2.0",
+		"params": map[string]interface{}{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]interface{}{},
+			"clientInfo": map[string]interface{}{
+				"name":    "mcp-orchestrator",
+				"version": version.Version,
+			},
+		},
+	}
+
+	notifyMsg := map[string]interface{}{
+		"method":  "notifications/initialized",
+		"jsonrpc": "2.0",
+	}
+
+	var input strings.Builder
+	for _, msg := range []interface{}{initMsg, notifyMsg, request} {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal MCP message: %v", err)
+		}
+		input.Write(data)
+		input.WriteByte('\n')
+	}
+
+	return input.String(), nil
+}
+
+// ToolsListRequest returns the tools/list request sent after the handshake
+func ToolsListRequest() map[string]interface{} {
+	return map[string]interface{}{
+		"id":      RequestID,
+		"method":  "tools/list",
+		"jsonrpc": "2.0",
+		"params":  map[string]interface{}{},
+	}
+}
+
+// ParseToolsResponse extracts the tools of the tools/list response from a
+// server's output. Responses are normally one per line; when a server splits
+// or prefixes a large response, the output is searched for it instead.
+func ParseToolsResponse(output string) ([]interface{}, error) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		if tools, ok := toolsFromResponse(line); ok {
+			return tools, nil
+		}
+	}
+
+	// Look for patterns like {"result":{"tools":[...]},"jsonrpc":"2.0","id":2}
+	patternStart := `"result":{"tools":[`
+	patternEnd := fmt.Sprintf(`]},"jsonrpc":"2.0","id":%d}`, RequestID)
+
+	startIdx := strings.Index(output, patternStart)
+	if startIdx != -1 {
+		// Find the opening { before "result"
+		jsonStart := strings.LastIndex(output[:startIdx], "{")
+		endIdx := strings.Index(output[startIdx:], patternEnd)
+		if jsonStart != -1 && endIdx != -1 {
+			jsonEnd := startIdx + endIdx + len(patternEnd)
+			if tools, ok := toolsFromResponse(output[jsonStart:jsonEnd]); ok {
+				return tools, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no valid tools response found in output")
+}
+
+// toolsFromResponse returns the tools of a message if it is the tools/list response
+func toolsFromResponse(data string) ([]interface{}, bool) {
+	var msg struct {
+		ID     interface{} `json:"id"`
+		Result interface{} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		return nil, false
+	}
+
+	if id, ok := msg.ID.(float64); !ok || id != RequestID {
+		return nil, false
+	}
+
+	result, ok := msg.Result.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	tools, ok := result["tools"].([]interface{})
+	return tools, ok
+}
@@ -0,0 +1,88 @@
+package mcpclient
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBuildHandshakeSendsOneMessagePerLine(t *testing.T) {
+	input, err := BuildHandshake(ToolsListRequest())
+	if err != nil {
+		t.Fatalf("BuildHandshake: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(input, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %q", len(lines), input)
+	}
+
+	var messages []map[string]interface{}
+	for _, line := range lines {
+		var msg map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if messages[0]["method"] != "initialize" || messages[0]["id"] != float64(initializeID) {
+		t.Errorf("got first message %v, want the initialize request", messages[0])
+	}
+	params := messages[0]["params"].(map[string]interface{})
+	if params["protocolVersion"] != ProtocolVersion {
+		t.Errorf("got protocol version %v, want %s", params["protocolVersion"], ProtocolVersion)
+	}
+	if messages[1]["method"] != "notifications/initialized" {
+		t.Errorf("got second message %v, want the initialized notification", messages[1])
+	}
+	if _, hasID := messages[1]["id"]; hasID {
+		t.Errorf("initialized notification has an id: %v", messages[1])
+	}
+	if messages[2]["method"] != "tools/list" || messages[2]["id"] != float64(RequestID) {
+		t.Errorf("got last message %v, want the tools/list request", messages[2])
+	}
+}
+
+func TestBuildHandshakeRejectsUnmarshalableRequest(t *testing.T) {
+	if _, err := BuildHandshake(map[string]interface{}{"params": func() {}}); err == nil {
+		t.Error("got no error for a request that can't be marshaled")
+	}
+}
+
+func TestParseToolsResponseSkipsOtherMessages(t *testing.T) {
+	output := strings.Join([]string{
+		"server starting...",
+		`{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"wrong"}]}}`,
+		`{"jsonrpc":"2.0","method":"notifications/message","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"result":{"tools":[{"name":"search"},{"name":"fetch"}]}}`,
+	}, "\n")
+
+	tools, err := ParseToolsResponse(output)
+	if err != nil {
+		t.Fatalf("ParseToolsResponse: %v", err)
+	}
+	if len(tools) != 2 || tools[0].(map[string]interface{})["name"] != "search" {
+		t.Errorf("got tools %v, want search and fetch", tools)
+	}
+}
+
+func TestParseToolsResponseFindsPrefixedResponse(t *testing.T) {
+	output := `log: ready {"result":{"tools":[{"name":"search"}]},"jsonrpc":"2.0","id":2} trailing`
+
+	tools, err := ParseToolsResponse(output)
+	if err != nil {
+		t.Fatalf("ParseToolsResponse: %v", err)
+	}
+	if len(tools) != 1 {
+		t.Errorf("got %d tools, want 1", len(tools))
+	}
+}
+
+func TestParseToolsResponseWithoutTools(t *testing.T) {
+	output := `{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"Method not found"}}`
+
+	if _, err := ParseToolsResponse(output); err == nil {
+		t.Error("got no error for output without a tools response")
+	}
+}
@@ -0,0 +1,179 @@
+package performance
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"mcp_orchestrator/internal/profiles"
+)
+
+// Budget caps how many calls may be made within a rolling window
+type Budget struct {
+	MaxCalls int           `json:"max_calls"`
+	Window   time.Duration `json:"window"`
+}
+
+// ProfileBudgets converts a profile's call budgets into runtime budgets
+func ProfileBudgets(callBudgets map[string]profiles.CallBudget) map[string]Budget {
+	budgets := make(map[string]Budget, len(callBudgets))
+	for name, callBudget := range callBudgets {
+		budgets[name] = Budget{
+			MaxCalls: callBudget.MaxCalls,
+			Window:   time.Duration(callBudget.WindowSeconds) * time.Second,
+		}
+	}
+	return budgets
+}
+
+// BudgetStatus describes the consumption of a single tool or category budget
+type BudgetStatus struct {
+	Scope         string  `json:"scope"` // "tool" or "category"
+	Name          string  `json:"name"`
+	MaxCalls      int     `json:"max_calls"`
+	WindowSeconds float64 `json:"window_seconds"`
+	Used          int     `json:"used"`
+	Remaining     int     `json:"remaining"`
+}
+
+// BudgetExceededError is returned when a call would exceed a budget
+type BudgetExceededError struct {
+	Status     BudgetStatus
+	RetryAfter time.Duration // Until the oldest counted call leaves the window
+}
+
+// Error implements the error interface
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s %s call budget exceeded (%d calls per %v); retry after %v",
+		e.Status.Scope, e.Status.Name, e.Status.MaxCalls,
+		time.Duration(e.Status.WindowSeconds*float64(time.Second)), e.RetryAfter.Round(time.Second))
+}
+
+// BudgetTracker enforces per-tool and per-category call budgets over rolling windows
+type BudgetTracker struct {
+	mu         sync.Mutex
+	tools      map[string]Budget
+	categories map[string]Budget
+	calls      map[string][]time.Time // "scope:name" -> call times within the window
+}
+
+// NewBudgetTracker creates a tracker for the given tool and category budgets
+func NewBudgetTracker(tools, categories map[string]Budget) *BudgetTracker {
+	if tools == nil {
+		tools = make(map[string]Budget)
+	}
+	if categories == nil {
+		categories = make(map[string]Budget)
+	}
+
+	return &BudgetTracker{
+		tools:      tools,
+		categories: categories,
+		calls:      make(map[string][]time.Time),
+	}
+}
+
+// Allow records a call to a tool if neither its tool nor its category budget
+// is exhausted. A rejected call is not counted.
+func (bt *BudgetTracker) Allow(toolName, category string) error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	type budgetCheck struct {
+		scope  string
+		name   string
+		budget Budget
+	}
+
+	var checks []budgetCheck
+	if budget, ok := bt.tools[toolName]; ok {
+		checks = append(checks, budgetCheck{"tool", toolName, budget})
+	}
+	if budget, ok := bt.categories[category]; ok && category != "" {
+		checks = append(checks, budgetCheck{"category", category, budget})
+	}
+
+	now := time.Now()
+	var keys []string
+	for _, check := range checks {
+		if check.budget.MaxCalls <= 0 || check.budget.Window <= 0 {
+			continue
+		}
+
+		key := check.scope + ":" + check.name
+		calls := bt.prune(key, check.budget.Window, now)
+		if len(calls) >= check.budget.MaxCalls {
+			return &BudgetExceededError{
+				Status:     budgetStatus(check.scope, check.name, check.budget, len(calls)),
+				RetryAfter: calls[0].Add(check.budget.Window).Sub(now),
+			}
+		}
+		keys = append(keys, key)
+	}
+
+	for _, key := range keys {
+		bt.calls[key] = append(bt.calls[key], now)
+	}
+
+	return nil
+}
+
+// GetStatus returns the current consumption of every configured budget
+func (bt *BudgetTracker) GetStatus() []BudgetStatus {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]BudgetStatus, 0, len(bt.tools)+len(bt.categories))
+
+	for name, budget := range bt.tools {
+		used := len(bt.prune("tool:"+name, budget.Window, now))
+		statuses = append(statuses, budgetStatus("tool", name, budget, used))
+	}
+	for name, budget := range bt.categories {
+		used := len(bt.prune("category:"+name, budget.Window, now))
+		statuses = append(statuses, budgetStatus("category", name, budget, used))
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Scope != statuses[j].Scope {
+			return statuses[i].Scope > statuses[j].Scope // Tools first
+		}
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses
+}
+
+// prune drops calls that have left the window and returns those remaining (caller holds lock)
+func (bt *BudgetTracker) prune(key string, window time.Duration, now time.Time) []time.Time {
+	calls := bt.calls[key]
+	cutoff := now.Add(-window)
+
+	start := 0
+	for start < len(calls) && !calls[start].After(cutoff) {
+		start++
+	}
+	calls = calls[start:]
+
+	bt.calls[key] = calls
+	return calls
+}
+
+// budgetStatus builds the status of one budget
+func budgetStatus(scope, name string, budget Budget, used int) BudgetStatus {
+	remaining := budget.MaxCalls - used
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return BudgetStatus{
+		Scope:         scope,
+		Name:          name,
+		MaxCalls:      budget.MaxCalls,
+		WindowSeconds: budget.Window.Seconds(),
+		Used:          used,
+		Remaining:     remaining,
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/rand"
+	"strings"
 	"sync"
 	"time"
 )
@@ -60,10 +62,11 @@ func NewCache(config CacheConfig) *Cache {
 	return cache
 }
 
-// Get retrieves an item from the cache
+// Get retrieves an item from the cache. It updates access and hit
+// statistics, so it takes the write lock.
 func (c *Cache) Get(key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	item, exists := c.items[key]
 	if !exists {
@@ -143,6 +146,23 @@ func (c *Cache) GetStats() CacheStats {
 	return c.stats
 }
 
+// GetWithExpiry retrieves an item from the cache along with when it expires
+func (c *Cache) GetWithExpiry(key string) (interface{}, time.Time, bool) {
+	value, exists := c.Get(key)
+	if !exists {
+		return nil, time.Time{}, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, exists := c.items[key]
+	if !exists {
+		return nil, time.Time{}, false
+	}
+	return value, item.ExpiresAt, true
+}
+
 // GetAll returns all cache items (for debugging)
 func (c *Cache) GetAll() map[string]*CacheItem {
 	c.mu.RLock()
@@ -213,6 +233,23 @@ type ToolCache struct {
 	responseCache *Cache
 	serverCache   *Cache
 	profileCache  *Cache
+	toolListTTL   ToolListTTL
+}
+
+// ToolListTTL controls how long cached tool lists are used
+type ToolListTTL struct {
+	TTL    time.Duration // How long a tool list stays fresh
+	Jitter float64       // Fraction each list's TTL is randomly spread by either way, e.g. 0.1 for ±10%
+	Grace  time.Duration // How long after going stale a list may still be served while it is refreshed
+}
+
+// DefaultToolListTTL keeps tool lists fresh for five minutes, give or take
+// 10% so lists cached together don't all expire together
+func DefaultToolListTTL() ToolListTTL {
+	return ToolListTTL{
+		TTL:    5 * time.Minute,
+		Jitter: 0.1,
+	}
 }
 
 // NewToolCache creates a new tool cache
@@ -238,19 +275,77 @@ func NewToolCache() *ToolCache {
 			DefaultTTL:      10 * time.Minute,
 			CleanupInterval: 2 * time.Minute,
 		}),
+		toolListTTL: DefaultToolListTTL(),
+	}
+}
+
+// SetToolListTTL sets how long tool lists cached from now on are used. The
+// jitter is clamped to between 0 and 0.9 so every TTL stays positive.
+func (tc *ToolCache) SetToolListTTL(config ToolListTTL) {
+	if config.TTL <= 0 {
+		config.TTL = DefaultToolListTTL().TTL
+	}
+	if config.Jitter < 0 {
+		config.Jitter = 0
+	}
+	if config.Jitter > 0.9 {
+		config.Jitter = 0.9
+	}
+	if config.Grace < 0 {
+		config.Grace = 0
 	}
+	tc.toolListTTL = config
 }
 
-// CacheToolList caches the tool list for a server
+// freshFor returns how long a newly cached tool list stays fresh: the TTL
+// moved by a random amount within the jitter either way
+func (tc *ToolCache) freshFor() time.Duration {
+	config := tc.toolListTTL
+	if config.Jitter == 0 {
+		return config.TTL
+	}
+	spread := float64(config.TTL) * config.Jitter
+	return config.TTL + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// CacheToolList caches the tool list for a server. It is kept through the
+// grace period after it goes stale, for GetStaleToolList.
 func (tc *ToolCache) CacheToolList(serverID string, tools interface{}) {
 	key := fmt.Sprintf("tools:%s", serverID)
-	tc.toolsCache.Set(key, tools, 5*time.Minute)
+	tc.toolsCache.Set(key, tools, tc.freshFor()+tc.toolListTTL.Grace)
 }
 
-// GetCachedToolList retrieves cached tool list
+// GetCachedToolList retrieves a server's tool list while it is fresh
 func (tc *ToolCache) GetCachedToolList(serverID string) (interface{}, bool) {
 	key := fmt.Sprintf("tools:%s", serverID)
-	return tc.toolsCache.Get(key)
+	value, expiresAt, exists := tc.toolsCache.GetWithExpiry(key)
+	if !exists || !time.Now().Before(expiresAt.Add(-tc.toolListTTL.Grace)) {
+		return nil, false
+	}
+	return value, true
+}
+
+// GetStaleToolList retrieves a server's tool list once it has gone stale but
+// is still within the grace period
+func (tc *ToolCache) GetStaleToolList(serverID string) (interface{}, bool) {
+	key := fmt.Sprintf("tools:%s", serverID)
+	value, expiresAt, exists := tc.toolsCache.GetWithExpiry(key)
+	if !exists || time.Now().Before(expiresAt.Add(-tc.toolListTTL.Grace)) {
+		return nil, false
+	}
+	return value, true
+}
+
+// CachedToolLists returns every unexpired cached tool list by server ID
+func (tc *ToolCache) CachedToolLists() map[string]interface{} {
+	now := time.Now()
+	lists := make(map[string]interface{})
+	for key, item := range tc.toolsCache.GetAll() {
+		if serverID, ok := strings.CutPrefix(key, "tools:"); ok && now.Before(item.ExpiresAt) {
+			lists[serverID] = item.Value
+		}
+	}
+	return lists
 }
 
 // CacheResponse caches a tool response
@@ -340,15 +435,43 @@ func (tc *ToolCache) invalidateResponsesForServer(serverID string) {
 	}
 }
 
-// WarmupCache pre-loads frequently used data
-func (tc *ToolCache) WarmupCache(servers []string) {
-	// This would typically load tool lists, server statuses, etc.
-	// Implementation would depend on your specific use case
+// ToolLoader discovers the current tool list for a server
+type ToolLoader func(serverID string) (interface{}, error)
+
+// WarmupCache discovers tools for each server and caches the results, running
+// at most maxConcurrent discoveries at once. Returns the number of servers warmed.
+func (tc *ToolCache) WarmupCache(servers []string, load ToolLoader, maxConcurrent int) int {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	slots := make(chan struct{}, maxConcurrent)
+	warmed := 0
+
 	for _, serverID := range servers {
-		// Pre-load server status (placeholder)
-		tc.CacheServerStatus(serverID, map[string]interface{}{
-			"warmed_up": true,
-			"timestamp": time.Now(),
-		})
+		wg.Add(1)
+		go func(serverID string) {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			// Failed servers are simply left cold; the next lookup retries them
+			tools, err := load(serverID)
+			if err != nil {
+				return
+			}
+
+			tc.CacheToolList(serverID, tools)
+
+			mu.Lock()
+			warmed++
+			mu.Unlock()
+		}(serverID)
 	}
+
+	wg.Wait()
+	return warmed
 }
@@ -0,0 +1,36 @@
+package performance
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestCacheConcurrentGetCountsEveryLookup runs lookups from many goroutines,
+// as parallel tool discovery does. Run with -race to catch statistics
+// updated without the write lock.
+func TestCacheConcurrentGetCountsEveryLookup(t *testing.T) {
+	cache := NewCache(CacheConfig{MaxSize: 10, DefaultTTL: time.Minute, CleanupInterval: time.Minute})
+	cache.Set("tools:alpha", "alpha tools", 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				cache.Get("tools:alpha")
+				cache.Get("tools:missing")
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := cache.GetStats()
+	if stats.Hits != 400 || stats.Misses != 400 {
+		t.Errorf("got %d hits and %d misses, want 400 of each", stats.Hits, stats.Misses)
+	}
+	if stats.HitRate != 50 {
+		t.Errorf("got hit rate %v, want 50", stats.HitRate)
+	}
+}
@@ -0,0 +1,153 @@
+package performance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CallLimitError is returned when a server is at its concurrent call limit
+// and no slot frees up within the queue timeout
+type CallLimitError struct {
+	ServerID string
+	Limit    int
+	Waited   time.Duration
+}
+
+// Error implements the error interface
+func (e *CallLimitError) Error() string {
+	return fmt.Sprintf("server %s is busy: %d calls already in flight (waited %v)",
+		e.ServerID, e.Limit, e.Waited.Round(time.Millisecond))
+}
+
+// CallLimitStatus reports the concurrent calls of a single server
+type CallLimitStatus struct {
+	ServerID string `json:"server_id"`
+	Limit    int    `json:"limit"`
+	InFlight int    `json:"in_flight"`
+	Queued   int    `json:"queued"`
+	Rejected int64  `json:"rejected"`
+}
+
+// CallLimiter bounds the number of calls in flight to each server. Calls
+// beyond the limit wait up to the queue timeout for a slot.
+type CallLimiter struct {
+	mu           sync.Mutex
+	defaultLimit int
+	limits       map[string]int // Per-server overrides of defaultLimit
+	queueTimeout time.Duration
+	servers      map[string]*serverCalls
+}
+
+// serverCalls tracks one server's slots
+type serverCalls struct {
+	slots    chan struct{}
+	queued   int
+	rejected int64
+}
+
+// NewCallLimiter creates a limiter allowing defaultLimit concurrent calls per
+// server, or the server's entry in limits. A limit of 0 or less means no limit.
+func NewCallLimiter(defaultLimit int, limits map[string]int, queueTimeout time.Duration) *CallLimiter {
+	if limits == nil {
+		limits = make(map[string]int)
+	}
+
+	return &CallLimiter{
+		defaultLimit: defaultLimit,
+		limits:       limits,
+		queueTimeout: queueTimeout,
+		servers:      make(map[string]*serverCalls),
+	}
+}
+
+// Acquire takes a call slot for a server, waiting while the server is at its
+// limit. The returned release func must be called when the call finishes.
+func (cl *CallLimiter) Acquire(ctx context.Context, serverID string) (func(), error) {
+	cl.mu.Lock()
+	calls := cl.serverCallsLocked(serverID)
+	cl.mu.Unlock()
+
+	if calls == nil {
+		return func() {}, nil
+	}
+
+	release := func() { <-calls.slots }
+
+	// Fast path: a slot is free
+	select {
+	case calls.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	cl.mu.Lock()
+	calls.queued++
+	cl.mu.Unlock()
+	defer func() {
+		cl.mu.Lock()
+		calls.queued--
+		cl.mu.Unlock()
+	}()
+
+	start := time.Now()
+	timer := time.NewTimer(cl.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case calls.slots <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	cl.mu.Lock()
+	calls.rejected++
+	cl.mu.Unlock()
+
+	return nil, &CallLimitError{ServerID: serverID, Limit: cap(calls.slots), Waited: time.Since(start)}
+}
+
+// GetStatus returns the concurrent calls of every server called so far
+func (cl *CallLimiter) GetStatus() []CallLimitStatus {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	statuses := make([]CallLimitStatus, 0, len(cl.servers))
+	for serverID, calls := range cl.servers {
+		statuses = append(statuses, CallLimitStatus{
+			ServerID: serverID,
+			Limit:    cap(calls.slots),
+			InFlight: len(calls.slots),
+			Queued:   calls.queued,
+			Rejected: calls.rejected,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ServerID < statuses[j].ServerID
+	})
+	return statuses
+}
+
+// serverCallsLocked returns a server's slots, creating them on first use.
+// Returns nil for servers without a limit. Callers must hold cl.mu.
+func (cl *CallLimiter) serverCallsLocked(serverID string) *serverCalls {
+	if calls, exists := cl.servers[serverID]; exists {
+		return calls
+	}
+
+	limit := cl.defaultLimit
+	if override, exists := cl.limits[serverID]; exists {
+		limit = override
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	calls := &serverCalls{slots: make(chan struct{}, limit)}
+	cl.servers[serverID] = calls
+	return calls
+}
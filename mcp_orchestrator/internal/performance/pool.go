@@ -3,6 +3,7 @@ package performance
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
@@ -19,32 +20,68 @@ type Connection struct {
 	IsHealthy  bool
 	IsBusy     bool
 	mu         sync.RWMutex
+	session    *stdioSession // Set for connections created by StdioConnectionFactory
 }
 
 // ConnectionPool manages a pool of connections to MCP servers
 type ConnectionPool struct {
-	serverID    string
-	connections []*Connection
-	maxSize     int
-	minSize     int
-	mu          sync.RWMutex
-	healthCheck HealthChecker
-	factory     ConnectionFactory
-	stats       PoolStats
+	serverID          string
+	connections       []*Connection
+	maxSize           int
+	minSize           int
+	connectionTimeout time.Duration
+	mu                sync.RWMutex
+	healthCheck       HealthChecker
+	factory           ConnectionFactory
+	stats             PoolStats
+	totalWaitTime     time.Duration // Cumulative time callers spent acquiring connections
+	acquisitions      int64         // Number of successful acquisitions
+	draining          bool          // Set once Drain is called; no new connections are handed out
+	replenishing      bool          // Set while a background replenishment is running
+	creating          int           // Connections being created without p.mu held, counted against the pool size
+	reconnect         reconnector   // Restart state for crashed server processes
+	done              chan struct{} // Closed when the pool is closed to stop background routines
+	closeOnce         sync.Once
+}
+
+// Backoff bounds for recreating connections when the factory keeps failing
+const (
+	replenishBaseBackoff = 1 * time.Second
+	replenishMaxBackoff  = 60 * time.Second
+)
+
+// DefaultDrainTimeout bounds how long a pool being removed waits for busy connections
+const DefaultDrainTimeout = 30 * time.Second
+
+// DefaultConnectionTimeout bounds how long GetConnection waits when neither
+// the pool config nor the caller's context provides a limit
+const DefaultConnectionTimeout = 30 * time.Second
+
+// PoolExhaustedError is returned when no connection becomes available in time
+type PoolExhaustedError struct {
+	ServerID string
+	Waited   time.Duration
+}
+
+// Error implements the error interface
+func (e *PoolExhaustedError) Error() string {
+	return fmt.Sprintf("connection pool for server %s exhausted: no connection available after %v", e.ServerID, e.Waited)
 }
 
 // PoolStats holds connection pool statistics
 type PoolStats struct {
-	TotalConnections     int           `json:"total_connections"`
-	ActiveConnections    int           `json:"active_connections"`
-	IdleConnections      int           `json:"idle_connections"`
-	BusyConnections      int           `json:"busy_connections"`
-	CreatedConnections   int64         `json:"created_connections"`
-	DestroyedConnections int64         `json:"destroyed_connections"`
-	TotalRequests        int64         `json:"total_requests"`
-	FailedRequests       int64         `json:"failed_requests"`
-	AverageWaitTime      time.Duration `json:"average_wait_time"`
-	LastReset            time.Time     `json:"last_reset"`
+	TotalConnections     int             `json:"total_connections"`
+	ActiveConnections    int             `json:"active_connections"`
+	IdleConnections      int             `json:"idle_connections"`
+	BusyConnections      int             `json:"busy_connections"`
+	CreatedConnections   int64           `json:"created_connections"`
+	DestroyedConnections int64           `json:"destroyed_connections"`
+	TotalRequests        int64           `json:"total_requests"`
+	FailedRequests       int64           `json:"failed_requests"`
+	AverageWaitTime      time.Duration   `json:"average_wait_time"`
+	AverageWaitTimeMs    float64         `json:"average_wait_time_ms"`
+	LastReset            time.Time       `json:"last_reset"`
+	Reconnect            ReconnectStatus `json:"reconnect"`
 }
 
 // PoolConfig defines connection pool configuration
@@ -59,6 +96,20 @@ type PoolConfig struct {
 	RetryBackoff        time.Duration `json:"retry_backoff"`
 }
 
+// DefaultPoolConfig returns a lazily filled pool configuration for a server
+func DefaultPoolConfig(serverID string) PoolConfig {
+	return PoolConfig{
+		ServerID:            serverID,
+		MinConnections:      0,
+		MaxConnections:      3,
+		ConnectionTimeout:   DefaultConnectionTimeout,
+		IdleTimeout:         5 * time.Minute,
+		HealthCheckInterval: 30 * time.Second,
+		MaxRetries:          3,
+		RetryBackoff:        time.Second,
+	}
+}
+
 // ConnectionFactory creates new connections
 type ConnectionFactory interface {
 	CreateConnection(serverID string) (*Connection, error)
@@ -75,9 +126,16 @@ type HealthChecker interface {
 // LoadBalancer manages multiple connection pools
 type LoadBalancer struct {
 	pools    map[string]*ConnectionPool
+	circuits map[string]*CircuitBreaker // One breaker per server so a failing backend can't trip the others
 	mu       sync.RWMutex
 	strategy LoadBalancingStrategy
-	circuit  *CircuitBreaker
+}
+
+// CircuitStatus describes the circuit breaker of a single server
+type CircuitStatus struct {
+	ServerID string       `json:"server_id"`
+	State    CircuitState `json:"state"`
+	Failures int          `json:"failures"`
 }
 
 // LoadBalancingStrategy defines load balancing algorithm
@@ -112,18 +170,26 @@ const (
 
 // NewConnectionPool creates a new connection pool
 func NewConnectionPool(config PoolConfig, factory ConnectionFactory, healthChecker HealthChecker) *ConnectionPool {
+	connectionTimeout := config.ConnectionTimeout
+	if connectionTimeout <= 0 {
+		connectionTimeout = DefaultConnectionTimeout
+	}
+
 	pool := &ConnectionPool{
-		serverID:    config.ServerID,
-		connections: make([]*Connection, 0, config.MaxConnections),
-		maxSize:     config.MaxConnections,
-		minSize:     config.MinConnections,
-		healthCheck: healthChecker,
-		factory:     factory,
-		stats:       PoolStats{LastReset: time.Now()},
+		serverID:          config.ServerID,
+		connections:       make([]*Connection, 0, config.MaxConnections),
+		maxSize:           config.MaxConnections,
+		minSize:           config.MinConnections,
+		connectionTimeout: connectionTimeout,
+		healthCheck:       healthChecker,
+		factory:           factory,
+		stats:             PoolStats{LastReset: time.Now()},
+		done:              make(chan struct{}),
 	}
 
-	// Initialize minimum connections
+	// Initialize minimum connections, retrying any that failed in the background
 	pool.initializeConnections()
+	pool.replenish()
 
 	// Start health check routine
 	go pool.healthCheckRoutine(config.HealthCheckInterval)
@@ -134,48 +200,103 @@ func NewConnectionPool(config PoolConfig, factory ConnectionFactory, healthCheck
 	return pool
 }
 
-// GetConnection retrieves a connection from the pool
+// GetConnection retrieves a connection from the pool. If the caller's context
+// has no deadline, the pool's connection timeout bounds the wait. While the
+// server is being restarted after a crash callers wait briefly, and while
+// restarts are backing off they fail fast.
 func (p *ConnectionPool) GetConnection(ctx context.Context) (*Connection, error) {
+	start := time.Now()
 	p.mu.Lock()
-	defer p.mu.Unlock()
 
 	p.stats.TotalRequests++
 
+	// A draining pool no longer hands out connections
+	if p.draining {
+		p.stats.FailedRequests++
+		p.mu.Unlock()
+		return nil, fmt.Errorf("connection pool for server %s is draining", p.serverID)
+	}
+
+	conn, err := p.tryAcquire(start)
+	if conn != nil || err != nil {
+		if err != nil {
+			p.stats.FailedRequests++
+		}
+		p.mu.Unlock()
+		return conn, err
+	}
+	reconnecting := p.reconnect.running
+	p.mu.Unlock()
+
+	// Never wait indefinitely for a saturated pool
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.connectionTimeout)
+		defer cancel()
+	}
+
+	// Only queue briefly behind a restart
+	if reconnecting {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, reconnectQueueTimeout)
+		defer cancel()
+	}
+
+	// Pool is full or restarting, wait for a connection to become available
+	return p.waitForConnection(ctx, start)
+}
+
+// tryAcquire hands out an idle connection or creates one if the pool has room.
+// It returns neither a connection nor an error when the caller should wait
+// (caller holds p.mu).
+func (p *ConnectionPool) tryAcquire(start time.Time) (*Connection, error) {
+	p.reapDeadConnections()
+
 	// Find an available healthy connection
 	for _, conn := range p.connections {
 		if p.isConnectionAvailable(conn) {
-			conn.mu.Lock()
-			conn.IsBusy = true
-			conn.LastUsed = time.Now()
-			conn.UsageCount++
-			conn.mu.Unlock()
-
-			p.updateStats()
+			p.checkout(conn, start)
 			return conn, nil
 		}
 	}
 
+	// Don't hammer a server that keeps failing to start
+	if p.reconnect.inBackoff(time.Now()) {
+		return nil, p.reconnectError()
+	}
+
+	// A running restart will provide the next connection
+	if p.reconnect.running {
+		return nil, nil
+	}
+
 	// No available connection, try to create a new one
-	if len(p.connections) < p.maxSize {
+	if len(p.connections)+p.creating < p.maxSize {
 		conn, err := p.createConnection()
 		if err != nil {
-			p.stats.FailedRequests++
+			p.reconnect.recordFailure(err)
 			return nil, err
 		}
-
-		conn.mu.Lock()
-		conn.IsBusy = true
-		conn.LastUsed = time.Now()
-		conn.UsageCount++
-		conn.mu.Unlock()
+		p.reconnect.recordSuccess()
 
 		p.connections = append(p.connections, conn)
-		p.updateStats()
+		p.checkout(conn, start)
 		return conn, nil
 	}
 
-	// Pool is full, wait for a connection to become available
-	return p.waitForConnection(ctx)
+	return nil, nil
+}
+
+// checkout marks a connection as in use (caller holds p.mu)
+func (p *ConnectionPool) checkout(conn *Connection, start time.Time) {
+	conn.mu.Lock()
+	conn.IsBusy = true
+	conn.LastUsed = time.Now()
+	conn.UsageCount++
+	conn.mu.Unlock()
+
+	p.recordWait(start)
+	p.updateStats()
 }
 
 // ReturnConnection returns a connection to the pool
@@ -197,8 +318,16 @@ func (p *ConnectionPool) ReturnConnection(conn *Connection) {
 // RemoveConnection removes a connection from the pool
 func (p *ConnectionPool) RemoveConnection(conn *Connection) {
 	p.mu.Lock()
-	defer p.mu.Unlock()
+	p.removeConnection(conn)
+	p.updateStats()
+	p.mu.Unlock()
+
+	// Keep the pool warm after removals
+	p.replenish()
+}
 
+// removeConnection removes and destroys a connection (caller holds p.mu)
+func (p *ConnectionPool) removeConnection(conn *Connection) {
 	for i, c := range p.connections {
 		if c.ID == conn.ID {
 			// Remove from slice
@@ -211,8 +340,87 @@ func (p *ConnectionPool) RemoveConnection(conn *Connection) {
 			break
 		}
 	}
+}
 
-	p.updateStats()
+// replenish recreates connections in the background until the pool is back
+// at its minimum size. Creation failures back off exponentially so a broken
+// server doesn't cause a tight retry loop.
+func (p *ConnectionPool) replenish() {
+	p.mu.Lock()
+	if p.replenishing || p.draining || len(p.connections) >= p.minSize {
+		p.mu.Unlock()
+		return
+	}
+	p.replenishing = true
+	p.mu.Unlock()
+
+	go func() {
+		backoff := replenishBaseBackoff
+
+		defer func() {
+			p.mu.Lock()
+			p.replenishing = false
+			p.mu.Unlock()
+		}()
+
+		for {
+			select {
+			case <-p.done:
+				return
+			default:
+			}
+
+			// Reserve a slot, then create the connection without holding p.mu:
+			// starting a server process can take seconds
+			p.mu.Lock()
+			if p.draining || len(p.connections)+p.creating >= p.minSize {
+				p.mu.Unlock()
+				return
+			}
+			p.creating++
+			p.mu.Unlock()
+
+			conn, err := p.factory.CreateConnection(p.serverID)
+
+			p.mu.Lock()
+			p.creating--
+			if err == nil {
+				// The pool may have been closed, drained or refilled meanwhile
+				select {
+				case <-p.done:
+					p.mu.Unlock()
+					p.factory.DestroyConnection(conn)
+					return
+				default:
+				}
+				if p.draining || len(p.connections) >= p.minSize {
+					p.mu.Unlock()
+					p.factory.DestroyConnection(conn)
+					return
+				}
+
+				p.adoptConnection(conn)
+				p.connections = append(p.connections, conn)
+				p.updateStats()
+				p.mu.Unlock()
+				backoff = replenishBaseBackoff
+				continue
+			}
+			p.mu.Unlock()
+
+			// Wait before retrying a failed creation
+			select {
+			case <-p.done:
+				return
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+			if backoff > replenishMaxBackoff {
+				backoff = replenishMaxBackoff
+			}
+		}
+	}()
 }
 
 // GetStats returns pool statistics
@@ -220,16 +428,68 @@ func (p *ConnectionPool) GetStats() PoolStats {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	return p.stats
+	stats := p.stats
+	stats.Reconnect = p.reconnectStatus()
+
+	return stats
+}
+
+// Drain stops handing out new connections, waits for busy connections to be
+// returned and then closes the pool. If ctx expires first, the remaining busy
+// connections are destroyed anyway and an error is returned.
+func (p *ConnectionPool) Drain(ctx context.Context) error {
+	p.mu.Lock()
+	p.draining = true
+	p.mu.Unlock()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		busy := p.busyConnections()
+		if busy == 0 {
+			return p.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			p.Close()
+			return fmt.Errorf("timed out draining pool for server %s with %d busy connections: %v", p.serverID, busy, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// busyConnections returns the number of connections currently checked out
+func (p *ConnectionPool) busyConnections() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	busy := 0
+	for _, conn := range p.connections {
+		conn.mu.RLock()
+		if conn.IsBusy {
+			busy++
+		}
+		conn.mu.RUnlock()
+	}
+
+	return busy
 }
 
 // Close closes all connections in the pool
 func (p *ConnectionPool) Close() error {
+	// Stop the background health check and cleanup routines
+	p.closeOnce.Do(func() {
+		close(p.done)
+	})
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	for _, conn := range p.connections {
 		p.factory.DestroyConnection(conn)
+		p.stats.DestroyedConnections++
 	}
 
 	p.connections = p.connections[:0]
@@ -259,14 +519,19 @@ func (p *ConnectionPool) createConnection() (*Connection, error) {
 		return nil, err
 	}
 
+	p.adoptConnection(conn)
+
+	return conn, nil
+}
+
+// adoptConnection initializes a connection created by the factory (caller holds p.mu)
+func (p *ConnectionPool) adoptConnection(conn *Connection) {
 	conn.ID = fmt.Sprintf("%s-%d", p.serverID, time.Now().UnixNano())
 	conn.CreatedAt = time.Now()
 	conn.LastUsed = time.Now()
 	conn.IsHealthy = true
 
 	p.stats.CreatedConnections++
-
-	return conn, nil
 }
 
 // isConnectionAvailable checks if a connection is available for use
@@ -278,30 +543,46 @@ func (p *ConnectionPool) isConnectionAvailable(conn *Connection) bool {
 }
 
 // waitForConnection waits for a connection to become available
-func (p *ConnectionPool) waitForConnection(ctx context.Context) (*Connection, error) {
+func (p *ConnectionPool) waitForConnection(ctx context.Context, start time.Time) (*Connection, error) {
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
 	for {
 		select {
+		case <-p.done:
+			p.mu.Lock()
+			p.stats.FailedRequests++
+			p.mu.Unlock()
+			return nil, fmt.Errorf("connection pool for server %s is closed", p.serverID)
 		case <-ctx.Done():
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.stats.FailedRequests++
+
+			if ctx.Err() == context.DeadlineExceeded {
+				if p.reconnect.running {
+					return nil, p.reconnectError()
+				}
+				return nil, &PoolExhaustedError{ServerID: p.serverID, Waited: time.Since(start)}
+			}
 			return nil, ctx.Err()
 		case <-ticker.C:
 			p.mu.Lock()
-			for _, conn := range p.connections {
-				if p.isConnectionAvailable(conn) {
-					conn.mu.Lock()
-					conn.IsBusy = true
-					conn.LastUsed = time.Now()
-					conn.UsageCount++
-					conn.mu.Unlock()
-
-					p.updateStats()
-					p.mu.Unlock()
-					return conn, nil
-				}
+			if p.draining {
+				p.stats.FailedRequests++
+				p.mu.Unlock()
+				return nil, fmt.Errorf("connection pool for server %s is draining", p.serverID)
+			}
+
+			conn, err := p.tryAcquire(start)
+			if err != nil {
+				p.stats.FailedRequests++
 			}
 			p.mu.Unlock()
+
+			if conn != nil || err != nil {
+				return conn, err
+			}
 		}
 	}
 }
@@ -311,28 +592,47 @@ func (p *ConnectionPool) healthCheckRoutine(interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		p.mu.Lock()
-		unhealthyConns := make([]*Connection, 0)
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
 
-		for _, conn := range p.connections {
+		// Check a snapshot so slow health checks don't block acquisitions
+		p.mu.RLock()
+		conns := make([]*Connection, len(p.connections))
+		copy(conns, p.connections)
+		p.mu.RUnlock()
+
+		unhealthyConns := make([]*Connection, 0)
+		for _, conn := range conns {
 			if !p.healthCheck.CheckHealth(conn) {
 				conn.mu.Lock()
 				conn.IsHealthy = false
+				busy := conn.IsBusy
 				conn.mu.Unlock()
 
-				if !conn.IsBusy {
+				if !busy {
 					unhealthyConns = append(unhealthyConns, conn)
 				}
 			}
 		}
 
-		// Remove unhealthy connections
-		for _, conn := range unhealthyConns {
-			p.RemoveConnection(conn)
+		if len(unhealthyConns) == 0 {
+			continue
 		}
 
+		// Restart crashed processes, then drop the remaining unhealthy connections
+		p.mu.Lock()
+		p.reapDeadConnections()
+		for _, conn := range unhealthyConns {
+			p.removeConnection(conn)
+		}
+		p.updateStats()
 		p.mu.Unlock()
+
+		p.replenish()
 	}
 }
 
@@ -341,7 +641,13 @@ func (p *ConnectionPool) cleanupIdleConnections(idleTimeout time.Duration) {
 	ticker := time.NewTicker(idleTimeout)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+
 		p.mu.Lock()
 		now := time.Now()
 		idleConns := make([]*Connection, 0)
@@ -357,16 +663,28 @@ func (p *ConnectionPool) cleanupIdleConnections(idleTimeout time.Duration) {
 		// Remove idle connections (keep minimum)
 		for _, conn := range idleConns {
 			if len(p.connections) > p.minSize {
-				p.RemoveConnection(conn)
+				p.removeConnection(conn)
 			}
 		}
+		p.updateStats()
 
 		p.mu.Unlock()
 	}
 }
 
+// recordWait records how long a caller waited for a connection (caller holds p.mu)
+func (p *ConnectionPool) recordWait(start time.Time) {
+	p.totalWaitTime += time.Since(start)
+	p.acquisitions++
+}
+
 // updateStats updates pool statistics
 func (p *ConnectionPool) updateStats() {
+	if p.acquisitions > 0 {
+		p.stats.AverageWaitTime = p.totalWaitTime / time.Duration(p.acquisitions)
+		p.stats.AverageWaitTimeMs = float64(p.stats.AverageWaitTime) / float64(time.Millisecond)
+	}
+
 	p.stats.TotalConnections = len(p.connections)
 	p.stats.ActiveConnections = 0
 	p.stats.IdleConnections = 0
@@ -390,8 +708,8 @@ func (p *ConnectionPool) updateStats() {
 func NewLoadBalancer(strategy LoadBalancingStrategy) *LoadBalancer {
 	return &LoadBalancer{
 		pools:    make(map[string]*ConnectionPool),
+		circuits: make(map[string]*CircuitBreaker),
 		strategy: strategy,
-		circuit:  NewCircuitBreaker(5, 30*time.Second, 60*time.Second),
 	}
 }
 
@@ -401,44 +719,144 @@ func (lb *LoadBalancer) AddPool(serverID string, pool *ConnectionPool) {
 	defer lb.mu.Unlock()
 
 	lb.pools[serverID] = pool
+	if _, exists := lb.circuits[serverID]; !exists {
+		lb.circuits[serverID] = NewCircuitBreaker(5, 30*time.Second, 60*time.Second)
+	}
 }
 
-// RemovePool removes a connection pool from the load balancer
-func (lb *LoadBalancer) RemovePool(serverID string) {
+// RemovePool removes a connection pool from the load balancer, draining it so
+// in-flight requests can finish before their connections are closed. The
+// server's circuit breaker is kept, so restarting a failing server doesn't
+// clear an open circuit; ResetCircuit does.
+func (lb *LoadBalancer) RemovePool(serverID string) error {
 	lb.mu.Lock()
-	defer lb.mu.Unlock()
+	pool, exists := lb.pools[serverID]
+	delete(lb.pools, serverID)
+	lb.mu.Unlock()
+
+	if !exists {
+		return nil
+	}
 
-	if pool, exists := lb.pools[serverID]; exists {
-		pool.Close()
-		delete(lb.pools, serverID)
+	// Drain outside the lock so other pools stay usable meanwhile
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultDrainTimeout)
+	defer cancel()
+
+	return pool.Drain(ctx)
+}
+
+// RemoveAllPools drains and removes every pool concurrently
+func (lb *LoadBalancer) RemoveAllPools() {
+	lb.mu.RLock()
+	serverIDs := make([]string, 0, len(lb.pools))
+	for serverID := range lb.pools {
+		serverIDs = append(serverIDs, serverID)
+	}
+	lb.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, serverID := range serverIDs {
+		wg.Add(1)
+		go func(serverID string) {
+			defer wg.Done()
+			lb.RemovePool(serverID)
+		}(serverID)
 	}
+	wg.Wait()
 }
 
 // GetConnection gets a connection using load balancing
 func (lb *LoadBalancer) GetConnection(ctx context.Context, serverID string) (*Connection, error) {
 	lb.mu.RLock()
-	defer lb.mu.RUnlock()
-
-	// Check circuit breaker
-	if !lb.circuit.Allow() {
-		return nil, fmt.Errorf("circuit breaker is open")
-	}
-
 	pool, exists := lb.pools[serverID]
+	circuit := lb.circuits[serverID]
+	lb.mu.RUnlock()
+
 	if !exists {
 		return nil, fmt.Errorf("pool for server %s not found", serverID)
 	}
 
+	// Check the server's circuit breaker
+	if !circuit.Allow() {
+		return nil, fmt.Errorf("circuit breaker for server %s is open", serverID)
+	}
+
 	conn, err := pool.GetConnection(ctx)
 	if err != nil {
-		lb.circuit.RecordFailure()
+		// The pool already backs off a restarting server on its own
+		if _, reconnecting := err.(*ReconnectingError); !reconnecting {
+			circuit.RecordFailure()
+		}
 		return nil, err
 	}
 
-	lb.circuit.RecordSuccess()
+	circuit.RecordSuccess()
 	return conn, nil
 }
 
+// ReturnConnection returns a connection to its server's pool
+func (lb *LoadBalancer) ReturnConnection(serverID string, conn *Connection) {
+	lb.mu.RLock()
+	pool, exists := lb.pools[serverID]
+	lb.mu.RUnlock()
+
+	if exists {
+		pool.ReturnConnection(conn)
+	}
+}
+
+// GetCircuitStatus returns the circuit breaker state of every server
+func (lb *LoadBalancer) GetCircuitStatus() []CircuitStatus {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+
+	statuses := make([]CircuitStatus, 0, len(lb.circuits))
+	for serverID, circuit := range lb.circuits {
+		statuses = append(statuses, CircuitStatus{
+			ServerID: serverID,
+			State:    circuit.GetState(),
+			Failures: circuit.GetFailures(),
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ServerID < statuses[j].ServerID
+	})
+
+	return statuses
+}
+
+// GetReconnectStatus returns the reconnect state of every server's pool
+func (lb *LoadBalancer) GetReconnectStatus() []ReconnectStatus {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+
+	statuses := make([]ReconnectStatus, 0, len(lb.pools))
+	for _, pool := range lb.pools {
+		statuses = append(statuses, pool.GetReconnectStatus())
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ServerID < statuses[j].ServerID
+	})
+
+	return statuses
+}
+
+// ResetCircuit closes the circuit breaker of a server so requests are retried
+func (lb *LoadBalancer) ResetCircuit(serverID string) error {
+	lb.mu.RLock()
+	circuit, exists := lb.circuits[serverID]
+	lb.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("circuit breaker for server %s not found", serverID)
+	}
+
+	circuit.Reset()
+	return nil
+}
+
 // GetAllPools returns all connection pools
 func (lb *LoadBalancer) GetAllPools() map[string]*ConnectionPool {
 	lb.mu.RLock()
@@ -477,8 +895,8 @@ func NewCircuitBreaker(maxFailures int, timeout time.Duration, resetTimeout time
 
 // Allow checks if requests are allowed through the circuit breaker
 func (cb *CircuitBreaker) Allow() bool {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
 
 	switch cb.state {
 	case CircuitClosed:
@@ -513,11 +931,21 @@ func (cb *CircuitBreaker) RecordFailure() {
 	cb.failures++
 	cb.lastFailure = time.Now()
 
-	if cb.failures >= cb.maxFailures {
+	// A failed probe while half-open re-opens the circuit immediately
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.maxFailures {
 		cb.state = CircuitOpen
 	}
 }
 
+// Trip forces the circuit open regardless of the failure count
+func (cb *CircuitBreaker) Trip() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.lastFailure = time.Now()
+	cb.state = CircuitOpen
+}
+
 // GetState returns the current circuit breaker state
 func (cb *CircuitBreaker) GetState() CircuitState {
 	cb.mu.RLock()
@@ -0,0 +1,342 @@
+package performance
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFactory hands out connections that stay valid until destroyed
+type fakeFactory struct {
+	mu        sync.Mutex
+	created   int
+	destroyed int
+}
+
+func (f *fakeFactory) CreateConnection(serverID string) (*Connection, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.created++
+	return &Connection{ServerID: serverID}, nil
+}
+
+func (f *fakeFactory) DestroyConnection(conn *Connection) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.destroyed++
+	return nil
+}
+
+func (f *fakeFactory) ValidateConnection(conn *Connection) bool {
+	return true
+}
+
+// gatedFactory is a fakeFactory whose CreateConnection waits for gate, once
+// set, to be closed, standing in for a slow server start
+type gatedFactory struct {
+	fakeFactory
+	gate chan struct{}
+}
+
+func (f *gatedFactory) CreateConnection(serverID string) (*Connection, error) {
+	f.mu.Lock()
+	gate := f.gate
+	f.mu.Unlock()
+
+	if gate != nil {
+		<-gate
+	}
+	return f.fakeFactory.CreateConnection(serverID)
+}
+
+// startSlowReplenish removes the only connection of a pool with a minimum of
+// one, leaving its replenishment blocked in the factory. Closing the
+// returned channel lets the replenishment finish.
+func startSlowReplenish(t *testing.T) (*ConnectionPool, *gatedFactory, chan struct{}) {
+	factory := &gatedFactory{}
+	config := PoolConfig{
+		ServerID:            "test",
+		MinConnections:      1,
+		MaxConnections:      1,
+		ConnectionTimeout:   time.Second,
+		IdleTimeout:         5 * time.Minute,
+		HealthCheckInterval: 30 * time.Second,
+	}
+	pool := NewConnectionPool(config, factory, fakeHealthChecker{})
+	t.Cleanup(func() { pool.Close() })
+
+	conn, err := pool.GetConnection(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gate := make(chan struct{})
+	factory.mu.Lock()
+	factory.gate = gate
+	factory.mu.Unlock()
+	pool.RemoveConnection(conn)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		pool.mu.RLock()
+		creating := pool.creating
+		pool.mu.RUnlock()
+		if creating == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			close(gate)
+			t.Fatal("replenishment never started creating a connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return pool, factory, gate
+}
+
+// fakeHealthChecker reports every connection healthy
+type fakeHealthChecker struct{}
+
+func (fakeHealthChecker) CheckHealth(conn *Connection) bool { return true }
+func (fakeHealthChecker) IsHealthy(conn *Connection) bool   { return true }
+
+// newTestPool creates a pool of at most one connection
+func newTestPool(t *testing.T, factory *fakeFactory, connectionTimeout time.Duration) *ConnectionPool {
+	config := PoolConfig{
+		ServerID:            "test",
+		MaxConnections:      1,
+		ConnectionTimeout:   connectionTimeout,
+		IdleTimeout:         5 * time.Minute,
+		HealthCheckInterval: 30 * time.Second,
+	}
+
+	pool := NewConnectionPool(config, factory, fakeHealthChecker{})
+	t.Cleanup(func() { pool.Close() })
+	return pool
+}
+
+func TestGetConnectionTimesOutWhenSaturated(t *testing.T) {
+	pool := newTestPool(t, &fakeFactory{}, 200*time.Millisecond)
+
+	conn, err := pool.GetConnection(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.ReturnConnection(conn)
+
+	start := time.Now()
+	_, err = pool.GetConnection(context.Background())
+	waited := time.Since(start)
+
+	var exhausted *PoolExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("got %v, want a PoolExhaustedError", err)
+	}
+	if waited < 200*time.Millisecond || waited > 2*time.Second {
+		t.Errorf("waited %v for a saturated pool, want about the 200ms connection timeout", waited)
+	}
+	if failed := pool.GetStats().FailedRequests; failed != 1 {
+		t.Errorf("recorded %d failed requests, want 1", failed)
+	}
+}
+
+func TestGetConnectionHonorsCallerDeadline(t *testing.T) {
+	pool := newTestPool(t, &fakeFactory{}, time.Minute)
+
+	conn, err := pool.GetConnection(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.ReturnConnection(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = pool.GetConnection(ctx)
+	var exhausted *PoolExhaustedError
+	if !errors.As(err, &exhausted) {
+		t.Fatalf("got %v, want a PoolExhaustedError", err)
+	}
+	if waited := time.Since(start); waited > 2*time.Second {
+		t.Errorf("waited %v despite a 150ms caller deadline", waited)
+	}
+}
+
+func TestGetConnectionWaitsForReturnedConnection(t *testing.T) {
+	pool := newTestPool(t, &fakeFactory{}, 2*time.Second)
+
+	conn, err := pool.GetConnection(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		pool.ReturnConnection(conn)
+	}()
+
+	next, err := pool.GetConnection(context.Background())
+	if err != nil {
+		t.Fatalf("waiter failed after the connection was returned: %v", err)
+	}
+	if next != conn {
+		t.Error("waiter got a new connection instead of the returned one")
+	}
+	pool.ReturnConnection(next)
+}
+
+func TestDrainWaitsForBusyConnection(t *testing.T) {
+	factory := &fakeFactory{}
+	pool := newTestPool(t, factory, time.Second)
+
+	conn, err := pool.GetConnection(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	drained := make(chan error, 1)
+	go func() { drained <- pool.Drain(ctx) }()
+
+	// Wait for Drain to mark the pool before checking it refuses connections
+	deadline := time.Now().Add(time.Second)
+	for {
+		pool.mu.RLock()
+		draining := pool.draining
+		pool.mu.RUnlock()
+		if draining || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := pool.GetConnection(context.Background()); err == nil {
+		t.Error("a draining pool handed out a connection")
+	}
+
+	select {
+	case err := <-drained:
+		t.Fatalf("Drain returned %v while a connection was busy", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	pool.ReturnConnection(conn)
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Fatalf("Drain failed after the connection was returned: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Drain didn't return after the busy connection was returned")
+	}
+
+	factory.mu.Lock()
+	defer factory.mu.Unlock()
+	if factory.destroyed != 1 {
+		t.Errorf("destroyed %d connections, want 1", factory.destroyed)
+	}
+}
+
+func TestDrainTimesOutWithBusyConnection(t *testing.T) {
+	factory := &fakeFactory{}
+	pool := newTestPool(t, factory, time.Second)
+
+	if _, err := pool.GetConnection(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := pool.Drain(ctx); err == nil {
+		t.Fatal("Drain succeeded while a connection was still busy")
+	}
+
+	// The busy connection is destroyed anyway so the server can be stopped
+	factory.mu.Lock()
+	defer factory.mu.Unlock()
+	if factory.destroyed != 1 {
+		t.Errorf("destroyed %d connections, want 1", factory.destroyed)
+	}
+}
+
+func TestCircuitSurvivesPoolRemoval(t *testing.T) {
+	lb := NewLoadBalancer(RoundRobin)
+	lb.AddPool("flaky", newTestPool(t, &fakeFactory{}, time.Second))
+	lb.circuits["flaky"].Trip()
+
+	if err := lb.RemovePool("flaky"); err != nil {
+		t.Fatalf("RemovePool: %v", err)
+	}
+	statuses := lb.GetCircuitStatus()
+	if len(statuses) != 1 || statuses[0].State != CircuitOpen {
+		t.Fatalf("got circuits %v after removing the pool, want flaky still open", statuses)
+	}
+
+	// Restarting the server adds a new pool behind the same open circuit
+	lb.AddPool("flaky", newTestPool(t, &fakeFactory{}, time.Second))
+	if _, err := lb.GetConnection(context.Background(), "flaky"); err == nil {
+		t.Fatal("got a connection through an open circuit after the pool was re-added")
+	}
+
+	if err := lb.ResetCircuit("flaky"); err != nil {
+		t.Fatalf("ResetCircuit: %v", err)
+	}
+	if _, err := lb.GetConnection(context.Background(), "flaky"); err != nil {
+		t.Fatalf("got %v after resetting the circuit, want a connection", err)
+	}
+}
+
+func TestReplenishDoesNotHoldLockWhileCreating(t *testing.T) {
+	pool, factory, gate := startSlowReplenish(t)
+
+	stats := make(chan PoolStats, 1)
+	go func() { stats <- pool.GetStats() }()
+	select {
+	case <-stats:
+	case <-time.After(time.Second):
+		close(gate)
+		t.Fatal("GetStats blocked while a connection was being created")
+	}
+	close(gate)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for pool.GetStats().TotalConnections != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("the pool wasn't replenished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	factory.mu.Lock()
+	defer factory.mu.Unlock()
+	if factory.created != 2 {
+		t.Errorf("created %d connections, want 2", factory.created)
+	}
+}
+
+func TestReplenishDiscardsConnectionOfClosedPool(t *testing.T) {
+	pool, factory, gate := startSlowReplenish(t)
+
+	pool.Close()
+	close(gate)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		factory.mu.Lock()
+		destroyed := factory.destroyed
+		factory.mu.Unlock()
+		if destroyed == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("destroyed %d connections, want the removed one and the late one", destroyed)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if total := pool.GetStats().TotalConnections; total != 0 {
+		t.Errorf("a closed pool has %d connections", total)
+	}
+}
@@ -0,0 +1,113 @@
+package performance
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultProxyReportTTL is how long a proxy's report is kept without a newer
+// one; proxies report several times within it, so only stopped ones expire
+const DefaultProxyReportTTL = time.Minute
+
+// ProxyReport is the runtime state a stdio proxy reports to the orchestrator.
+// Quarantine, call budgets and discovery timings live in each proxy process,
+// so the orchestrator only sees them through these reports.
+type ProxyReport struct {
+	ProxyID          string             `json:"proxy_id"`
+	ProfileID        string             `json:"profile_id,omitempty"`
+	Quarantine       []QuarantineStatus `json:"quarantine"`
+	Budgets          []BudgetStatus     `json:"budgets"`
+	DiscoveryTimings []DiscoveryTiming  `json:"discovery_timings"`
+	ReportedAt       time.Time          `json:"reported_at"`
+}
+
+// DiscoveryTiming summarizes how long a server takes to list its tools
+type DiscoveryTiming struct {
+	ServerID string    `json:"server_id"`
+	Runs     int       `json:"runs"`
+	Failures int       `json:"failures"`
+	MinMs    float64   `json:"min_ms"`
+	AvgMs    float64   `json:"avg_ms"`
+	MaxMs    float64   `json:"max_ms"`
+	LastMs   float64   `json:"last_ms"`
+	LastRun  time.Time `json:"last_run"`
+}
+
+// ProxyStates keeps the latest report of each proxy, forgetting proxies that
+// stopped reporting
+type ProxyStates struct {
+	mu      sync.Mutex
+	reports map[string]ProxyReport
+	ttl     time.Duration
+}
+
+// NewProxyStates creates a store that forgets a proxy ttl after its last report
+func NewProxyStates(ttl time.Duration) *ProxyStates {
+	return &ProxyStates{
+		reports: make(map[string]ProxyReport),
+		ttl:     ttl,
+	}
+}
+
+// Record replaces a proxy's report, stamping the time it was received
+func (ps *ProxyStates) Record(report ProxyReport) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	report.ReportedAt = time.Now()
+	ps.reports[report.ProxyID] = report
+	ps.pruneLocked(report.ReportedAt)
+}
+
+// Reports returns the reports of proxies that are still reporting, by proxy ID
+func (ps *ProxyStates) Reports() []ProxyReport {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.pruneLocked(time.Now())
+	reports := make([]ProxyReport, 0, len(ps.reports))
+	for _, report := range ps.reports {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].ProxyID < reports[j].ProxyID
+	})
+
+	return reports
+}
+
+// Quarantined returns the servers quarantined by any proxy, by server ID.
+// When several proxies quarantined a server, the earliest quarantine is kept.
+func (ps *ProxyStates) Quarantined() map[string]QuarantineStatus {
+	quarantined := make(map[string]QuarantineStatus)
+	for _, report := range ps.Reports() {
+		for _, status := range report.Quarantine {
+			if !status.Quarantined {
+				continue
+			}
+			if existing, exists := quarantined[status.ServerID]; exists && !quarantinedBefore(status, existing) {
+				continue
+			}
+			quarantined[status.ServerID] = status
+		}
+	}
+	return quarantined
+}
+
+// pruneLocked drops reports older than the TTL; callers must hold mu
+func (ps *ProxyStates) pruneLocked(now time.Time) {
+	for proxyID, report := range ps.reports {
+		if now.Sub(report.ReportedAt) > ps.ttl {
+			delete(ps.reports, proxyID)
+		}
+	}
+}
+
+// quarantinedBefore reports whether a was quarantined before b
+func quarantinedBefore(a, b QuarantineStatus) bool {
+	if a.QuarantinedAt == nil {
+		return false
+	}
+	return b.QuarantinedAt == nil || a.QuarantinedAt.Before(*b.QuarantinedAt)
+}
@@ -0,0 +1,49 @@
+package performance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProxyStatesQuarantinedAcrossProxies(t *testing.T) {
+	states := NewProxyStates(time.Minute)
+	earlier := time.Now().Add(-time.Hour)
+	later := time.Now()
+
+	states.Record(ProxyReport{ProxyID: "stdio-1", Quarantine: []QuarantineStatus{
+		{ServerID: "github", Quarantined: true, QuarantinedAt: &later, Reason: "5 consecutive failures"},
+		{ServerID: "slack", Quarantined: false},
+	}})
+	states.Record(ProxyReport{ProxyID: "stdio-2", Quarantine: []QuarantineStatus{
+		{ServerID: "github", Quarantined: true, QuarantinedAt: &earlier, Reason: "success rate 20.0% below 50.0%"},
+	}})
+
+	quarantined := states.Quarantined()
+	if len(quarantined) != 1 {
+		t.Fatalf("quarantined %v, want only github", quarantined)
+	}
+	if got := quarantined["github"].Reason; got != "success rate 20.0% below 50.0%" {
+		t.Errorf("kept quarantine %q, want the earliest one", got)
+	}
+}
+
+func TestProxyStatesForgetSilentProxies(t *testing.T) {
+	states := NewProxyStates(time.Minute)
+	states.Record(ProxyReport{ProxyID: "stdio-1", Quarantine: []QuarantineStatus{
+		{ServerID: "github", Quarantined: true},
+	}})
+
+	// Age the report past the TTL, as if the proxy had exited
+	states.mu.Lock()
+	report := states.reports["stdio-1"]
+	report.ReportedAt = time.Now().Add(-2 * time.Minute)
+	states.reports["stdio-1"] = report
+	states.mu.Unlock()
+
+	if reports := states.Reports(); len(reports) != 0 {
+		t.Errorf("kept %d expired reports", len(reports))
+	}
+	if quarantined := states.Quarantined(); len(quarantined) != 0 {
+		t.Errorf("expired report still quarantines %v", quarantined)
+	}
+}
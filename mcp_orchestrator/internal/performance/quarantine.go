@@ -0,0 +1,195 @@
+package performance
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// QuarantineConfig defines when a failing server is taken out of rotation
+type QuarantineConfig struct {
+	MaxConsecutiveFailures int           `json:"max_consecutive_failures"`
+	MinSuccessRate         float64       `json:"min_success_rate"` // Percentage (0-100)
+	MinCalls               int           `json:"min_calls"`        // Calls required before the success rate is considered
+	WindowSize             int           `json:"window_size"`      // Number of recent calls used for the success rate
+	ProbeInterval          time.Duration `json:"probe_interval"`   // Time before a half-open probe is allowed
+}
+
+// QuarantineStatus describes the quarantine state of a single server
+type QuarantineStatus struct {
+	ServerID            string       `json:"server_id"`
+	Quarantined         bool         `json:"quarantined"`
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	SuccessRate         float64      `json:"success_rate"`
+	RecentCalls         int          `json:"recent_calls"`
+	QuarantinedAt       *time.Time   `json:"quarantined_at,omitempty"`
+	Reason              string       `json:"reason,omitempty"`
+}
+
+// QuarantineManager temporarily disables servers whose health collapses
+type QuarantineManager struct {
+	mu      sync.Mutex
+	config  QuarantineConfig
+	servers map[string]*serverHealth
+}
+
+// serverHealth tracks the recent outcomes and breaker for one server
+type serverHealth struct {
+	breaker       *CircuitBreaker
+	outcomes      []bool
+	quarantinedAt time.Time
+	reason        string
+}
+
+// DefaultQuarantineConfig returns the default quarantine thresholds
+func DefaultQuarantineConfig() QuarantineConfig {
+	return QuarantineConfig{
+		MaxConsecutiveFailures: 5,
+		MinSuccessRate:         50,
+		MinCalls:               10,
+		WindowSize:             20,
+		ProbeInterval:          60 * time.Second,
+	}
+}
+
+// NewQuarantineManager creates a new quarantine manager
+func NewQuarantineManager(config QuarantineConfig) *QuarantineManager {
+	return &QuarantineManager{
+		config:  config,
+		servers: make(map[string]*serverHealth),
+	}
+}
+
+// Allow reports whether calls may be routed to a server. Once the probe
+// interval has elapsed a quarantined server is allowed a half-open probe.
+func (qm *QuarantineManager) Allow(serverID string) bool {
+	qm.mu.Lock()
+	health := qm.getHealth(serverID)
+	qm.mu.Unlock()
+
+	return health.breaker.Allow()
+}
+
+// IsQuarantined reports whether a server is currently quarantined
+func (qm *QuarantineManager) IsQuarantined(serverID string) bool {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+
+	health, exists := qm.servers[serverID]
+	if !exists {
+		return false
+	}
+
+	return health.breaker.GetState() != CircuitClosed
+}
+
+// RecordResult records the outcome of a call to a server and updates its quarantine state
+func (qm *QuarantineManager) RecordResult(serverID string, success bool) {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+
+	health := qm.getHealth(serverID)
+	wasQuarantined := health.breaker.GetState() != CircuitClosed
+
+	if success {
+		health.breaker.RecordSuccess()
+		if wasQuarantined {
+			// The half-open probe succeeded, start over with a clean window
+			health.outcomes = health.outcomes[:0]
+			health.quarantinedAt = time.Time{}
+			health.reason = ""
+		}
+	} else {
+		health.breaker.RecordFailure()
+	}
+
+	health.outcomes = append(health.outcomes, success)
+	if len(health.outcomes) > qm.config.WindowSize {
+		health.outcomes = health.outcomes[len(health.outcomes)-qm.config.WindowSize:]
+	}
+
+	if health.breaker.GetState() == CircuitClosed && len(health.outcomes) >= qm.config.MinCalls {
+		if rate := successRate(health.outcomes); rate < qm.config.MinSuccessRate {
+			health.breaker.Trip()
+			health.reason = fmt.Sprintf("success rate %.1f%% below %.1f%%", rate, qm.config.MinSuccessRate)
+		}
+	}
+
+	if health.breaker.GetState() == CircuitOpen && !wasQuarantined {
+		health.quarantinedAt = time.Now()
+		if health.reason == "" {
+			health.reason = fmt.Sprintf("%d consecutive failures", health.breaker.GetFailures())
+		}
+	}
+}
+
+// GetStatus returns the quarantine status of every tracked server
+func (qm *QuarantineManager) GetStatus() []QuarantineStatus {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+
+	statuses := make([]QuarantineStatus, 0, len(qm.servers))
+	for serverID, health := range qm.servers {
+		state := health.breaker.GetState()
+		status := QuarantineStatus{
+			ServerID:            serverID,
+			Quarantined:         state != CircuitClosed,
+			State:               state,
+			ConsecutiveFailures: health.breaker.GetFailures(),
+			SuccessRate:         successRate(health.outcomes),
+			RecentCalls:         len(health.outcomes),
+			Reason:              health.reason,
+		}
+		if !health.quarantinedAt.IsZero() {
+			quarantinedAt := health.quarantinedAt
+			status.QuarantinedAt = &quarantinedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ServerID < statuses[j].ServerID
+	})
+
+	return statuses
+}
+
+// GetConfig returns the active quarantine thresholds
+func (qm *QuarantineManager) GetConfig() QuarantineConfig {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+
+	return qm.config
+}
+
+// getHealth returns the health record for a server, creating it if needed
+func (qm *QuarantineManager) getHealth(serverID string) *serverHealth {
+	health, exists := qm.servers[serverID]
+	if !exists {
+		health = &serverHealth{
+			breaker:  NewCircuitBreaker(qm.config.MaxConsecutiveFailures, qm.config.ProbeInterval, qm.config.ProbeInterval),
+			outcomes: make([]bool, 0, qm.config.WindowSize),
+		}
+		qm.servers[serverID] = health
+	}
+
+	return health
+}
+
+// successRate calculates the percentage of successful outcomes
+func successRate(outcomes []bool) float64 {
+	if len(outcomes) == 0 {
+		return 100
+	}
+
+	successes := 0
+	for _, success := range outcomes {
+		if success {
+			successes++
+		}
+	}
+
+	return float64(successes) / float64(len(outcomes)) * 100
+}
@@ -0,0 +1,247 @@
+package performance
+
+import (
+	"fmt"
+	"time"
+)
+
+// reconnectQueueTimeout bounds how long a caller waits for a crashed server
+// to be restarted before giving up
+const reconnectQueueTimeout = 10 * time.Second
+
+// ReconnectState describes whether a pool is restoring dead server processes
+type ReconnectState string
+
+const (
+	ReconnectConnected    ReconnectState = "connected"
+	ReconnectReconnecting ReconnectState = "reconnecting"
+	ReconnectBackoff      ReconnectState = "backoff"
+)
+
+// ReconnectStatus reports the reconnect state of a single server's pool
+type ReconnectStatus struct {
+	ServerID      string         `json:"server_id"`
+	State         ReconnectState `json:"state"`
+	Pending       int            `json:"pending"`
+	Attempts      int            `json:"attempts"`
+	Reconnects    int64          `json:"reconnects"`
+	LastError     string         `json:"last_error,omitempty"`
+	LastFailure   *time.Time     `json:"last_failure,omitempty"`
+	NextAttempt   *time.Time     `json:"next_attempt,omitempty"`
+	LastReconnect *time.Time     `json:"last_reconnect,omitempty"`
+}
+
+// ReconnectingError is returned while a server is backing off after failed
+// restarts, or when a caller gave up waiting for a restart to finish
+type ReconnectingError struct {
+	ServerID   string
+	Attempts   int
+	RetryAfter time.Duration
+	LastError  string
+}
+
+// Error implements the error interface
+func (e *ReconnectingError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("server %s is unavailable after %d failed restart attempts (last error: %s); retrying in %v",
+			e.ServerID, e.Attempts, e.LastError, e.RetryAfter.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("server %s is still restarting", e.ServerID)
+}
+
+// reconnector tracks the restart state of a pool's server processes. All
+// fields are guarded by the pool's mutex.
+type reconnector struct {
+	pending       int // Dead connections waiting to be replaced
+	running       bool
+	attempts      int // Consecutive failed attempts to start the server
+	lastError     string
+	lastFailure   time.Time
+	nextAttempt   time.Time
+	reconnects    int64
+	lastReconnect time.Time
+}
+
+// inBackoff reports whether new attempts to start the server are on hold
+func (r *reconnector) inBackoff(now time.Time) bool {
+	return r.attempts > 0 && now.Before(r.nextAttempt)
+}
+
+// recordFailure backs off exponentially after a failed attempt to start the server
+func (r *reconnector) recordFailure(err error) {
+	r.attempts++
+	r.lastError = err.Error()
+	r.lastFailure = time.Now()
+
+	backoff := replenishBaseBackoff
+	for i := 1; i < r.attempts && backoff < replenishMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > replenishMaxBackoff {
+		backoff = replenishMaxBackoff
+	}
+	r.nextAttempt = r.lastFailure.Add(backoff)
+}
+
+// recordSuccess clears the backoff after the server started
+func (r *reconnector) recordSuccess() {
+	r.attempts = 0
+	r.lastError = ""
+	r.nextAttempt = time.Time{}
+}
+
+// reapDeadConnections removes idle connections whose subprocess has exited
+// and schedules their replacement (caller holds p.mu)
+func (p *ConnectionPool) reapDeadConnections() {
+	dead := make([]*Connection, 0)
+	for _, conn := range p.connections {
+		conn.mu.RLock()
+		busy := conn.IsBusy
+		conn.mu.RUnlock()
+
+		// Busy connections are reaped once the caller returns them
+		if !busy && !p.factory.ValidateConnection(conn) {
+			dead = append(dead, conn)
+		}
+	}
+
+	if len(dead) == 0 {
+		return
+	}
+
+	for _, conn := range dead {
+		p.removeConnection(conn)
+	}
+	p.reconnect.pending += len(dead)
+	if limit := p.maxSize - len(p.connections); p.reconnect.pending > limit {
+		p.reconnect.pending = limit
+	}
+	p.updateStats()
+
+	p.scheduleReconnect()
+}
+
+// scheduleReconnect starts restarting dead connections in the background
+// unless a restart is already running (caller holds p.mu)
+func (p *ConnectionPool) scheduleReconnect() {
+	if p.reconnect.running || p.draining || p.reconnect.pending == 0 {
+		return
+	}
+	p.reconnect.running = true
+
+	go p.reconnectLoop()
+}
+
+// reconnectLoop restarts dead server processes, re-running the MCP handshake
+// for each, and backs off exponentially while the server keeps failing
+func (p *ConnectionPool) reconnectLoop() {
+	defer func() {
+		p.mu.Lock()
+		p.reconnect.running = false
+		p.mu.Unlock()
+	}()
+
+	for {
+		p.mu.Lock()
+		if p.draining || p.reconnect.pending == 0 || len(p.connections)+p.creating >= p.maxSize {
+			p.reconnect.pending = 0
+			p.mu.Unlock()
+			return
+		}
+		wait := time.Until(p.reconnect.nextAttempt)
+		p.mu.Unlock()
+
+		if wait > 0 {
+			select {
+			case <-p.done:
+				return
+			case <-time.After(wait):
+			}
+		}
+
+		// Start the process outside the lock so live connections stay usable
+		conn, err := p.factory.CreateConnection(p.serverID)
+
+		p.mu.Lock()
+		if err != nil {
+			p.reconnect.recordFailure(err)
+			p.mu.Unlock()
+			continue
+		}
+
+		select {
+		case <-p.done:
+			p.mu.Unlock()
+			p.factory.DestroyConnection(conn)
+			return
+		default:
+		}
+		if p.draining {
+			p.mu.Unlock()
+			p.factory.DestroyConnection(conn)
+			return
+		}
+
+		p.adoptConnection(conn)
+		p.connections = append(p.connections, conn)
+		p.reconnect.pending--
+		p.reconnect.recordSuccess()
+		p.reconnect.reconnects++
+		p.reconnect.lastReconnect = time.Now()
+		p.updateStats()
+		p.mu.Unlock()
+	}
+}
+
+// reconnectError describes why a caller can't get a connection right now (caller holds p.mu)
+func (p *ConnectionPool) reconnectError() *ReconnectingError {
+	retryAfter := time.Until(p.reconnect.nextAttempt)
+	if retryAfter < 0 {
+		retryAfter = 0
+	}
+
+	return &ReconnectingError{
+		ServerID:   p.serverID,
+		Attempts:   p.reconnect.attempts,
+		RetryAfter: retryAfter,
+		LastError:  p.reconnect.lastError,
+	}
+}
+
+// GetReconnectStatus returns the reconnect state of the pool
+func (p *ConnectionPool) GetReconnectStatus() ReconnectStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.reconnectStatus()
+}
+
+// reconnectStatus builds the reconnect status (caller holds p.mu)
+func (p *ConnectionPool) reconnectStatus() ReconnectStatus {
+	status := ReconnectStatus{
+		ServerID:   p.serverID,
+		State:      ReconnectConnected,
+		Pending:    p.reconnect.pending,
+		Attempts:   p.reconnect.attempts,
+		Reconnects: p.reconnect.reconnects,
+		LastError:  p.reconnect.lastError,
+	}
+
+	if p.reconnect.inBackoff(time.Now()) {
+		status.State = ReconnectBackoff
+		nextAttempt := p.reconnect.nextAttempt
+		status.NextAttempt = &nextAttempt
+	} else if p.reconnect.running {
+		status.State = ReconnectReconnecting
+	}
+	if !p.reconnect.lastFailure.IsZero() {
+		lastFailure := p.reconnect.lastFailure
+		status.LastFailure = &lastFailure
+	}
+	if !p.reconnect.lastReconnect.IsZero() {
+		lastReconnect := p.reconnect.lastReconnect
+		status.LastReconnect = &lastReconnect
+	}
+
+	return status
+}
@@ -0,0 +1,344 @@
+package performance
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp_orchestrator/internal/version"
+)
+
+// StdioServerSpec describes how to launch an MCP server subprocess
+type StdioServerSpec struct {
+	Command string
+	Args    []string
+	Env     map[string]string // Added on top of the current process environment
+	Dir     string
+}
+
+// StdioSpecResolver returns the launch spec for a server
+type StdioSpecResolver func(serverID string) (StdioServerSpec, error)
+
+// StdioConnectionFactory creates pooled connections backed by MCP subprocesses
+type StdioConnectionFactory struct {
+	resolve          StdioSpecResolver
+	handshakeTimeout time.Duration
+}
+
+// StdioHealthChecker checks pooled subprocess connections with an MCP ping
+type StdioHealthChecker struct {
+	timeout time.Duration
+}
+
+// rpcMessage is a JSON-RPC 2.0 message exchanged with an MCP subprocess
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  interface{}     `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+// stdioSession holds the live pipes of an MCP subprocess
+type stdioSession struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	responses chan rpcMessage
+	exited    chan struct{}
+	mu        sync.Mutex // Serializes requests; MCP servers answer one at a time here
+	nextID    int64
+}
+
+// NewStdioConnectionFactory creates a new subprocess connection factory
+func NewStdioConnectionFactory(resolve StdioSpecResolver, handshakeTimeout time.Duration) *StdioConnectionFactory {
+	if handshakeTimeout <= 0 {
+		handshakeTimeout = 30 * time.Second
+	}
+
+	return &StdioConnectionFactory{
+		resolve:          resolve,
+		handshakeTimeout: handshakeTimeout,
+	}
+}
+
+// CreateConnection launches the server subprocess and performs the MCP handshake
+func (f *StdioConnectionFactory) CreateConnection(serverID string) (*Connection, error) {
+	spec, err := f.resolve(serverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve server %s: %v", serverID, err)
+	}
+
+	session, err := startStdioSession(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start server %s: %v", serverID, err)
+	}
+
+	conn := &Connection{
+		ServerID: serverID,
+		Address:  "stdio",
+		session:  session,
+	}
+
+	// Perform the MCP initialize handshake
+	ctx, cancel := context.WithTimeout(context.Background(), f.handshakeTimeout)
+	defer cancel()
+
+	initParams := map[string]interface{}{
+		"protocolVersion": "2024-11-05",
+		"capabilities":    map[string]interface{}{},
+		"clientInfo": map[string]interface{}{
+			"name":    "mcp-orchestrator",
+			"version": version.Version,
+		},
+	}
+	if _, err := conn.Call(ctx, "initialize", initParams); err != nil {
+		session.terminate()
+		return nil, fmt.Errorf("handshake with server %s failed: %v", serverID, err)
+	}
+
+	if err := session.notify("notifications/initialized"); err != nil {
+		session.terminate()
+		return nil, fmt.Errorf("handshake with server %s failed: %v", serverID, err)
+	}
+
+	return conn, nil
+}
+
+// DestroyConnection terminates the subprocess behind a connection
+func (f *StdioConnectionFactory) DestroyConnection(conn *Connection) error {
+	if conn == nil || conn.session == nil {
+		return nil
+	}
+
+	conn.session.terminate()
+	return nil
+}
+
+// ValidateConnection reports whether the subprocess is still running
+func (f *StdioConnectionFactory) ValidateConnection(conn *Connection) bool {
+	return conn != nil && conn.session != nil && conn.session.alive()
+}
+
+// NewStdioHealthChecker creates a health checker with the given ping timeout
+func NewStdioHealthChecker(timeout time.Duration) *StdioHealthChecker {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &StdioHealthChecker{timeout: timeout}
+}
+
+// CheckHealth pings an idle connection. Busy connections are only checked for
+// a live process so a long-running tool call isn't mistaken for a hang.
+func (h *StdioHealthChecker) CheckHealth(conn *Connection) bool {
+	if conn == nil || conn.session == nil || !conn.session.alive() {
+		return false
+	}
+
+	conn.mu.RLock()
+	busy := conn.IsBusy
+	conn.mu.RUnlock()
+	if busy {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	// Any response, even a JSON-RPC error, proves the server is responsive
+	_, err := conn.Call(ctx, "ping", nil)
+	if err != nil {
+		if _, isRPCError := err.(*RPCError); isRPCError {
+			return true
+		}
+		return false
+	}
+
+	return true
+}
+
+// IsHealthy reports the last known health of a connection
+func (h *StdioHealthChecker) IsHealthy(conn *Connection) bool {
+	if conn == nil || conn.session == nil || !conn.session.alive() {
+		return false
+	}
+
+	conn.mu.RLock()
+	defer conn.mu.RUnlock()
+
+	return conn.IsHealthy
+}
+
+// RPCError is a JSON-RPC error returned by an MCP server
+type RPCError struct {
+	Raw json.RawMessage
+}
+
+// Error implements the error interface
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("server returned error: %s", string(e.Raw))
+}
+
+// Call sends a JSON-RPC request over the connection and waits for its result
+func (c *Connection) Call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	if c.session == nil {
+		return nil, fmt.Errorf("connection %s has no session", c.ID)
+	}
+
+	return c.session.call(ctx, method, params)
+}
+
+// startStdioSession launches the subprocess and starts reading its output
+func startStdioSession(spec StdioServerSpec) (*stdioSession, error) {
+	cmd := exec.Command(spec.Command, spec.Args...)
+	cmd.Dir = spec.Dir
+
+	env := os.Environ()
+	for key, value := range spec.Env {
+		env = append(env, fmt.Sprintf("%s=%s", key, value))
+	}
+	cmd.Env = env
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	session := &stdioSession{
+		cmd:       cmd,
+		stdin:     stdin,
+		responses: make(chan rpcMessage, 16),
+		exited:    make(chan struct{}),
+	}
+
+	go session.readLoop(stdout)
+
+	return session, nil
+}
+
+// readLoop forwards responses from the subprocess until its stdout closes
+func (s *stdioSession) readLoop(stdout io.Reader) {
+	defer close(s.exited)
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var msg rpcMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			continue
+		}
+
+		// Only responses are of interest; server-initiated messages are dropped
+		if msg.ID == nil || msg.Method != "" {
+			continue
+		}
+
+		// Never block the reader; a full buffer only holds stale responses
+		select {
+		case s.responses <- msg:
+		default:
+		}
+	}
+
+	s.cmd.Wait()
+}
+
+// call sends a request and waits for the response with the matching ID
+func (s *stdioSession) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	id := s.nextID
+
+	if err := s.write(rpcMessage{JSONRPC: "2.0", ID: id, Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%s request timed out: %v", method, ctx.Err())
+		case <-s.exited:
+			return nil, fmt.Errorf("server process exited during %s request", method)
+		case msg := <-s.responses:
+			// Skip late responses to earlier requests that timed out
+			if responseID, ok := msg.ID.(float64); !ok || int64(responseID) != id {
+				continue
+			}
+
+			if len(msg.Error) > 0 && string(msg.Error) != "null" {
+				return nil, &RPCError{Raw: msg.Error}
+			}
+
+			return msg.Result, nil
+		}
+	}
+}
+
+// notify sends a JSON-RPC notification
+func (s *stdioSession) notify(method string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.write(rpcMessage{JSONRPC: "2.0", Method: method})
+}
+
+// write sends a single newline-delimited message (caller holds s.mu)
+func (s *stdioSession) write(msg rpcMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s message: %v", msg.Method, err)
+	}
+
+	if _, err := s.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s message: %v", msg.Method, err)
+	}
+
+	return nil
+}
+
+// alive reports whether the subprocess is still running
+func (s *stdioSession) alive() bool {
+	select {
+	case <-s.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// terminate closes stdin and kills the subprocess if it doesn't exit promptly
+func (s *stdioSession) terminate() {
+	s.stdin.Close()
+
+	select {
+	case <-s.exited:
+	case <-time.After(2 * time.Second):
+		if s.cmd.Process != nil {
+			s.cmd.Process.Kill()
+		}
+	}
+}
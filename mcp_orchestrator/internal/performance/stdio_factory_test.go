@@ -0,0 +1,162 @@
+package performance
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary stand in for an MCP server: started with
+// FAKE_MCP_SERVER set, it serves MCP over stdio instead of running tests
+func TestMain(m *testing.M) {
+	if mode := os.Getenv("FAKE_MCP_SERVER"); mode != "" {
+		runFakeMCPServer(mode)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeMCPServer answers initialize, ping and tools/call requests. In
+// "silent" mode it never answers, to exercise handshake timeouts.
+func runFakeMCPServer(mode string) {
+	// Servers often log to stdout; non-JSON lines must be skipped
+	fmt.Println("fake MCP server starting")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	initialized := false
+	for scanner.Scan() {
+		var request struct {
+			ID     interface{}            `json:"id"`
+			Method string                 `json:"method"`
+			Params map[string]interface{} `json:"params"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &request) != nil || mode == "silent" {
+			continue
+		}
+
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID}
+		switch request.Method {
+		case "initialize":
+			response["result"] = map[string]interface{}{
+				"protocolVersion": request.Params["protocolVersion"],
+				"serverInfo":      map[string]interface{}{"name": "fake", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			initialized = true
+			continue
+		case "ping":
+			response["result"] = map[string]interface{}{}
+		case "tools/call":
+			arguments, _ := request.Params["arguments"].(map[string]interface{})
+			switch request.Params["name"] {
+			case "echo":
+				text := fmt.Sprintf("%v initialized=%v env=%s", arguments["text"], initialized, os.Getenv("FAKE_VALUE"))
+				response["result"] = map[string]interface{}{
+					"content": []interface{}{map[string]interface{}{"type": "text", "text": text}},
+				}
+			case "exit":
+				os.Exit(0)
+			default:
+				response["error"] = map[string]interface{}{"code": -32601, "message": "unknown tool"}
+			}
+		default:
+			response["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		}
+
+		data, _ := json.Marshal(response)
+		fmt.Println(string(data))
+	}
+}
+
+// fakeServerFactory launches this test binary as an MCP server
+func fakeServerFactory(t *testing.T, mode string, handshakeTimeout time.Duration) *StdioConnectionFactory {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewStdioConnectionFactory(func(serverID string) (StdioServerSpec, error) {
+		return StdioServerSpec{
+			Command: executable,
+			Args:    []string{"-test.run=^$"},
+			Env:     map[string]string{"FAKE_MCP_SERVER": mode, "FAKE_VALUE": "from-spec"},
+			Dir:     t.TempDir(),
+		}, nil
+	}, handshakeTimeout)
+}
+
+func TestStdioFactoryHandshakeAndCall(t *testing.T) {
+	factory := fakeServerFactory(t, "normal", 5*time.Second)
+	conn, err := factory.CreateConnection("fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer factory.DestroyConnection(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := conn.Call(ctx, "tools/call", map[string]interface{}{
+		"name":      "echo",
+		"arguments": map[string]interface{}{"text": "hello"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "hello initialized=true env=from-spec"; !strings.Contains(string(result), want) {
+		t.Errorf("result %s, want it to contain %q", result, want)
+	}
+
+	_, err = conn.Call(ctx, "tools/call", map[string]interface{}{"name": "missing"})
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || !strings.Contains(string(rpcErr.Raw), "unknown tool") {
+		t.Errorf("got %v, want the server's JSON-RPC error", err)
+	}
+
+	if !factory.ValidateConnection(conn) {
+		t.Error("a running server failed validation")
+	}
+	if !NewStdioHealthChecker(time.Second).CheckHealth(conn) {
+		t.Error("a responsive server failed its health check")
+	}
+}
+
+func TestStdioFactoryDetectsExitedServer(t *testing.T) {
+	factory := fakeServerFactory(t, "normal", 5*time.Second)
+	conn, err := factory.CreateConnection("fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer factory.DestroyConnection(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := conn.Call(ctx, "tools/call", map[string]interface{}{"name": "exit"}); err == nil {
+		t.Fatal("a call that killed the server succeeded")
+	}
+	if factory.ValidateConnection(conn) {
+		t.Error("an exited server passed validation")
+	}
+	if NewStdioHealthChecker(time.Second).CheckHealth(conn) {
+		t.Error("an exited server passed its health check")
+	}
+}
+
+func TestStdioFactoryHandshakeTimeout(t *testing.T) {
+	factory := fakeServerFactory(t, "silent", 300*time.Millisecond)
+
+	start := time.Now()
+	if _, err := factory.CreateConnection("fake"); err == nil || !strings.Contains(err.Error(), "handshake") {
+		t.Fatalf("got %v, want a handshake failure", err)
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("handshake took %v to fail, want about 300ms", waited)
+	}
+}
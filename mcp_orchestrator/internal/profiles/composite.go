@@ -0,0 +1,271 @@
+package profiles
+
+import (
+	"strings"
+	"time"
+)
+
+// CompositeUseCase is the use case of a profile merged from several active profiles
+const CompositeUseCase = "composite"
+
+// MergeProfiles combines several active profiles into one view, so a user can
+// work across workflows at once. Earlier profiles take precedence where values
+// can't be combined. The merge is designed so the composite exposes any tool
+// one of its profiles would expose, while keeping every limit the strictest:
+//
+//   - Servers: enabled_servers is the union; a profile without a list
+//     enables every server, so then the composite has no list either.
+//   - Server configs: a server is enabled if any profile enables it and takes
+//     its best (lowest) priority. Allowed categories are unioned (an empty
+//     list allows all). max_tools, max_concurrent_calls and max_exposed_tools
+//     take the lowest value set. Launch and retry overrides, expose_by, the
+//     idle timeout and env vars come from the first profile that sets them;
+//     tool_priority lists are concatenated.
+//   - Tool filters: include lists and required keywords are unioned (an empty
+//     list means no restriction, so it wins); a tool or category is excluded
+//     only if every profile excludes it.
+//   - Tool limits: each numeric limit takes the lowest value set, and each
+//     tool or category budget the one allowing the fewest calls per second.
+//     tools/list defaults come from the first profile that sets each one,
+//     except max_limit, which takes the lowest.
+//   - Performance and analytics settings come from the first profile.
+func MergeProfiles(profiles []*Profile) *Profile {
+	if len(profiles) == 0 {
+		return nil
+	}
+	if len(profiles) == 1 {
+		return profiles[0]
+	}
+
+	first := profiles[0]
+	ids := make([]string, len(profiles))
+	names := make([]string, len(profiles))
+	for i, profile := range profiles {
+		ids[i] = profile.ID
+		names[i] = profile.Name
+	}
+
+	merged := &Profile{
+		ID:          strings.Join(ids, "+"),
+		Name:        strings.Join(names, " + "),
+		Description: "Combined view of the " + strings.Join(ids, ", ") + " profiles",
+		UseCase:     CompositeUseCase,
+		Active:      true,
+		CreatedAt:   first.CreatedAt,
+		UpdatedAt:   first.UpdatedAt,
+		Performance: first.Performance,
+		Analytics:   first.Analytics,
+	}
+	for _, profile := range profiles {
+		if profile.UpdatedAt.After(merged.UpdatedAt) {
+			merged.UpdatedAt = profile.UpdatedAt
+		}
+	}
+
+	enabledServers := make([][]string, len(profiles))
+	for i, profile := range profiles {
+		enabledServers[i] = profile.EnabledServers
+	}
+	merged.EnabledServers = unionUnlessEmpty(enabledServers)
+
+	merged.ServerConfigs = make(map[string]ServerConfig)
+	for _, profile := range profiles {
+		for serverID, config := range profile.ServerConfigs {
+			if existing, exists := merged.ServerConfigs[serverID]; exists {
+				merged.ServerConfigs[serverID] = mergeServerConfigs(existing, config)
+			} else {
+				merged.ServerConfigs[serverID] = copyServerConfig(config)
+			}
+		}
+	}
+
+	merged.ToolFilters = mergeToolFilters(profiles)
+	merged.ToolLimits = mergeToolLimits(profiles)
+
+	return merged
+}
+
+// copyServerConfig copies a server config so merging never writes to a stored profile
+func copyServerConfig(config ServerConfig) ServerConfig {
+	copied := config
+	copied.Categories = append([]string(nil), config.Categories...)
+	copied.ToolPriority = append([]string(nil), config.ToolPriority...)
+	copied.Args = append([]string(nil), config.Args...)
+	copied.EnvVars = make(map[string]string, len(config.EnvVars))
+	for key, value := range config.EnvVars {
+		copied.EnvVars[key] = value
+	}
+	return copied
+}
+
+// mergeServerConfigs merges a later profile's config for a server into the
+// config merged so far
+func mergeServerConfigs(merged, config ServerConfig) ServerConfig {
+	merged.Enabled = merged.Enabled || config.Enabled
+	merged.Priority = lowestSet(merged.Priority, config.Priority)
+	merged.MaxTools = lowestSet(merged.MaxTools, config.MaxTools)
+	merged.MaxConcurrentCalls = lowestSet(merged.MaxConcurrentCalls, config.MaxConcurrentCalls)
+	merged.MaxExposedTools = lowestSet(merged.MaxExposedTools, config.MaxExposedTools)
+	merged.Categories = unionUnlessEmpty([][]string{merged.Categories, config.Categories})
+	merged.ToolPriority = union([][]string{merged.ToolPriority, config.ToolPriority})
+
+	if !merged.HasLaunchOverride() {
+		merged.Command = config.Command
+		merged.Args = append([]string(nil), config.Args...)
+		merged.WorkingDir = config.WorkingDir
+	}
+	if merged.DiscoveryMaxAttempts == 0 {
+		merged.DiscoveryMaxAttempts = config.DiscoveryMaxAttempts
+	}
+	if merged.DiscoveryBackoffMs == 0 {
+		merged.DiscoveryBackoffMs = config.DiscoveryBackoffMs
+	}
+	if merged.ExposeBy == "" {
+		merged.ExposeBy = config.ExposeBy
+	}
+	if merged.IdleTimeoutSeconds == 0 {
+		merged.IdleTimeoutSeconds = config.IdleTimeoutSeconds
+	}
+	for key, value := range config.EnvVars {
+		if _, exists := merged.EnvVars[key]; !exists {
+			merged.EnvVars[key] = value
+		}
+	}
+
+	return merged
+}
+
+// mergeToolFilters unions include filters and intersects exclude filters
+func mergeToolFilters(profiles []*Profile) ToolFilters {
+	var includeCategories, excludeCategories, includeTools, excludeTools, keywords [][]string
+	for _, profile := range profiles {
+		filters := profile.ToolFilters
+		includeCategories = append(includeCategories, filters.IncludeCategories)
+		excludeCategories = append(excludeCategories, filters.ExcludeCategories)
+		includeTools = append(includeTools, filters.IncludeTools)
+		excludeTools = append(excludeTools, filters.ExcludeTools)
+		keywords = append(keywords, filters.RequiredKeywords)
+	}
+
+	return ToolFilters{
+		IncludeCategories: unionUnlessEmpty(includeCategories),
+		ExcludeCategories: intersection(excludeCategories),
+		IncludeTools:      unionUnlessEmpty(includeTools),
+		ExcludeTools:      intersection(excludeTools),
+		RequiredKeywords:  unionUnlessEmpty(keywords),
+	}
+}
+
+// mergeToolLimits keeps the strictest of each limit
+func mergeToolLimits(profiles []*Profile) ToolLimits {
+	merged := ToolLimits{
+		ToolBudgets:     make(map[string]CallBudget),
+		CategoryBudgets: make(map[string]CallBudget),
+	}
+
+	for _, profile := range profiles {
+		limits := profile.ToolLimits
+		merged.MaxToolsPerServer = lowestSet(merged.MaxToolsPerServer, limits.MaxToolsPerServer)
+		merged.MaxToolsTotal = lowestSet(merged.MaxToolsTotal, limits.MaxToolsTotal)
+		merged.MaxConcurrentCalls = lowestSet(merged.MaxConcurrentCalls, limits.MaxConcurrentCalls)
+		merged.RateLimitPerMinute = lowestSet(merged.RateLimitPerMinute, limits.RateLimitPerMinute)
+		merged.MaxResultBytes = lowestSet(merged.MaxResultBytes, limits.MaxResultBytes)
+		mergeBudgets(merged.ToolBudgets, limits.ToolBudgets)
+		mergeBudgets(merged.CategoryBudgets, limits.CategoryBudgets)
+
+		toolList := limits.ToolList
+		if merged.ToolList.DefaultLimit == 0 {
+			merged.ToolList.DefaultLimit = toolList.DefaultLimit
+		}
+		if merged.ToolList.SchemaLevel == "" {
+			merged.ToolList.SchemaLevel = toolList.SchemaLevel
+		}
+		if merged.ToolList.AdjustLimit == nil {
+			merged.ToolList.AdjustLimit = toolList.AdjustLimit
+		}
+		if len(merged.ToolList.ContextCaps) == 0 {
+			merged.ToolList.ContextCaps = toolList.ContextCaps
+		}
+		merged.ToolList.MaxLimit = lowestSet(merged.ToolList.MaxLimit, toolList.MaxLimit)
+	}
+
+	return merged
+}
+
+// mergeBudgets adds budgets to merged, keeping the stricter budget for a name
+// both set
+func mergeBudgets(merged, budgets map[string]CallBudget) {
+	for name, budget := range budgets {
+		existing, exists := merged[name]
+		if !exists || budgetRate(budget) < budgetRate(existing) {
+			merged[name] = budget
+		}
+	}
+}
+
+// budgetRate returns the calls per second a budget allows
+func budgetRate(budget CallBudget) float64 {
+	window := time.Duration(budget.WindowSeconds) * time.Second
+	if window <= 0 {
+		return float64(budget.MaxCalls)
+	}
+	return float64(budget.MaxCalls) / window.Seconds()
+}
+
+// lowestSet returns the lower of two values, ignoring zero (unset) values
+func lowestSet(a, b int) int {
+	if a <= 0 {
+		return b
+	}
+	if b > 0 && b < a {
+		return b
+	}
+	return a
+}
+
+// union returns the distinct values of every list, in first-seen order
+func union(lists [][]string) []string {
+	seen := make(map[string]bool)
+	values := []string{}
+	for _, list := range lists {
+		for _, value := range list {
+			if !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// unionUnlessEmpty unions lists where an empty list means "no restriction",
+// so any empty list makes the result empty
+func unionUnlessEmpty(lists [][]string) []string {
+	for _, list := range lists {
+		if len(list) == 0 {
+			return []string{}
+		}
+	}
+	return union(lists)
+}
+
+// intersection returns the values present in every list, in first-list order
+func intersection(lists [][]string) []string {
+	values := []string{}
+	if len(lists) == 0 {
+		return values
+	}
+	for _, value := range union(lists[:1]) {
+		inAll := true
+		for _, list := range lists[1:] {
+			if !containsString(list, value) {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,99 @@
+package profiles
+
+import "sort"
+
+// Criteria for choosing which tools a capped server exposes
+const (
+	ExposeByPriority   = "priority"   // Configured tool_priority first, then the server's order
+	ExposeByPopularity = "popularity" // Most-called tools first, then priority order
+)
+
+// ExposedTools applies the config's max_exposed_tools to a server's tool
+// names, given in the server's order. It returns the positions of the tools
+// to expose, best first, or nil when the server isn't capped. calls returns
+// how often a tool was called and is only used to expose by popularity; nil
+// ranks by priority alone.
+func (sc ServerConfig) ExposedTools(names []string, calls func(name string) int) []int {
+	if sc.MaxExposedTools <= 0 || len(names) <= sc.MaxExposedTools {
+		return nil
+	}
+
+	// Listed tools rank in list order ahead of the rest, which keep the server's order
+	listed := make(map[string]int, len(sc.ToolPriority))
+	for i, name := range sc.ToolPriority {
+		if _, seen := listed[name]; !seen {
+			listed[name] = i
+		}
+	}
+
+	type rankedTool struct {
+		position int
+		rank     int
+		calls    int
+	}
+	ranked := make([]rankedTool, len(names))
+	for i, name := range names {
+		rank := len(listed) + i
+		if position, ok := listed[name]; ok {
+			rank = position
+		}
+
+		callCount := 0
+		if sc.ExposeBy == ExposeByPopularity && calls != nil {
+			callCount = calls(name)
+		}
+		ranked[i] = rankedTool{position: i, rank: rank, calls: callCount}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].calls != ranked[j].calls {
+			return ranked[i].calls > ranked[j].calls
+		}
+		return ranked[i].rank < ranked[j].rank
+	})
+
+	exposed := make([]int, sc.MaxExposedTools)
+	for i := range exposed {
+		exposed[i] = ranked[i].position
+	}
+	return exposed
+}
+
+// ExposeTools applies every server's max_exposed_tools to tools, the way the
+// proxy does before filtering: tools stay grouped by server, and a capped
+// server's tools come in exposure order. It also returns the servers it
+// capped, sorted by ID. Popularity can't be known outside the proxy, so
+// servers exposing their most popular tools are ranked by priority here.
+func (p *Profile) ExposeTools(tools []Tool) ([]Tool, []string) {
+	byServer := make(map[string][]Tool)
+	var serverIDs []string
+	for _, tool := range tools {
+		if _, seen := byServer[tool.ServerID]; !seen {
+			serverIDs = append(serverIDs, tool.ServerID)
+		}
+		byServer[tool.ServerID] = append(byServer[tool.ServerID], tool)
+	}
+
+	exposedTools := make([]Tool, 0, len(tools))
+	capped := []string{}
+	for _, serverID := range serverIDs {
+		serverTools := byServer[serverID]
+		names := make([]string, len(serverTools))
+		for i, tool := range serverTools {
+			names[i] = tool.Name
+		}
+
+		exposed := p.ServerConfigs[serverID].ExposedTools(names, nil)
+		if exposed == nil {
+			exposedTools = append(exposedTools, serverTools...)
+			continue
+		}
+		for _, i := range exposed {
+			exposedTools = append(exposedTools, serverTools[i])
+		}
+		capped = append(capped, serverID)
+	}
+	sort.Strings(capped)
+
+	return exposedTools, capped
+}
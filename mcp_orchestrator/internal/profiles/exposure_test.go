@@ -0,0 +1,59 @@
+package profiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExposedToolsByPriority(t *testing.T) {
+	config := ServerConfig{MaxExposedTools: 3, ToolPriority: []string{"d", "b"}}
+
+	got := config.ExposedTools([]string{"a", "b", "c", "d", "e"}, nil)
+	if want := []int{3, 1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("exposed %v, want %v", got, want)
+	}
+}
+
+func TestExposedToolsByPopularity(t *testing.T) {
+	config := ServerConfig{MaxExposedTools: 2, ExposeBy: ExposeByPopularity, ToolPriority: []string{"a"}}
+	calls := map[string]int{"c": 5, "b": 2}
+
+	got := config.ExposedTools([]string{"a", "b", "c"}, func(name string) int { return calls[name] })
+	if want := []int{2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("exposed %v, want %v", got, want)
+	}
+}
+
+func TestExposedToolsUncapped(t *testing.T) {
+	if got := (ServerConfig{MaxExposedTools: 5}).ExposedTools([]string{"a", "b"}, nil); got != nil {
+		t.Errorf("a server under its cap exposed %v, want nil", got)
+	}
+	if got := (ServerConfig{}).ExposedTools([]string{"a", "b"}, nil); got != nil {
+		t.Errorf("a server without a cap exposed %v, want nil", got)
+	}
+}
+
+func TestExposeToolsKeepsServersGrouped(t *testing.T) {
+	profile := &Profile{ServerConfigs: map[string]ServerConfig{
+		"big": {MaxExposedTools: 2, ToolPriority: []string{"big_3"}},
+	}}
+	tools := []Tool{
+		{Name: "big_1", ServerID: "big"},
+		{Name: "small_1", ServerID: "small"},
+		{Name: "big_2", ServerID: "big"},
+		{Name: "big_3", ServerID: "big"},
+	}
+
+	exposed, capped := profile.ExposeTools(tools)
+
+	var names []string
+	for _, tool := range exposed {
+		names = append(names, tool.Name)
+	}
+	if want := []string{"big_3", "big_1", "small_1"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("exposed %v, want %v", names, want)
+	}
+	if want := []string{"big"}; !reflect.DeepEqual(capped, want) {
+		t.Errorf("capped %v, want %v", capped, want)
+	}
+}
@@ -0,0 +1,192 @@
+package profiles
+
+import (
+	"sort"
+	"strings"
+)
+
+// Tool is a discovered tool as seen by profile filtering
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Category    string `json:"category"`
+	ServerID    string `json:"server_id"`
+}
+
+// ToolSelection is the result of applying a profile to a set of tools
+type ToolSelection struct {
+	Tools           []Tool         `json:"tools"`
+	TotalTools      int            `json:"total_tools"`       // Tools before filtering
+	SelectedTools   int            `json:"selected_tools"`    // Tools the profile exposes
+	ServerExcluded  int            `json:"server_excluded"`   // Dropped because their server isn't enabled
+	FilterExcluded  int            `json:"filter_excluded"`   // Dropped by category, name or keyword filters
+	LimitExcluded   int            `json:"limit_excluded"`    // Dropped by per-server or total tool limits
+	ToolsByServer   map[string]int `json:"tools_by_server"`   // Selected tools per server
+	ToolsByCategory map[string]int `json:"tools_by_category"` // Selected tools per category
+	ExcludedServers []string       `json:"excluded_servers"`  // Servers with tools but not enabled by the profile
+	LimitsHit       []string       `json:"limits_hit"`        // Limits that dropped tools, e.g. tool_limits.max_tools_total
+}
+
+// ToolCategory returns the category of a tool that doesn't declare one,
+// which is what category filters and budgets match against: the category
+// configured for its server, or the server ID when the server has none
+func ToolCategory(serverID, serverCategory string) string {
+	if serverCategory != "" {
+		return serverCategory
+	}
+	return serverID
+}
+
+// Uncategorized is the category reported for tools without one
+const Uncategorized = "uncategorized"
+
+// SortCategories orders category names alphabetically, keeping the
+// uncategorized bucket last so lists are stable between calls
+func SortCategories(names []string) {
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == Uncategorized) != (names[j] == Uncategorized) {
+			return names[j] == Uncategorized
+		}
+		return names[i] < names[j]
+	})
+}
+
+// FilterTools applies the profile's enabled servers, server and tool
+// filters, and tool limits to tools. Servers are taken in priority order,
+// so limits drop tools of lower-priority servers first. A tool without a
+// category gets its server's from serverCategories, through ToolCategory.
+func (p *Profile) FilterTools(tools []Tool, serverCategories map[string]string) ToolSelection {
+	selection := ToolSelection{
+		Tools:           []Tool{},
+		TotalTools:      len(tools),
+		ToolsByServer:   make(map[string]int),
+		ToolsByCategory: make(map[string]int),
+		ExcludedServers: []string{},
+		LimitsHit:       []string{},
+	}
+
+	// Stable sort keeps each server's own tool order
+	ordered := make([]Tool, len(tools))
+	copy(ordered, tools)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return p.serverPriority(ordered[i].ServerID) < p.serverPriority(ordered[j].ServerID)
+	})
+
+	excludedServers := make(map[string]bool)
+	for _, tool := range ordered {
+		if tool.Category == "" {
+			tool.Category = ToolCategory(tool.ServerID, serverCategories[tool.ServerID])
+		}
+
+		if !p.serverEnabled(tool.ServerID) {
+			selection.ServerExcluded++
+			if !excludedServers[tool.ServerID] {
+				excludedServers[tool.ServerID] = true
+				selection.ExcludedServers = append(selection.ExcludedServers, tool.ServerID)
+			}
+			continue
+		}
+
+		if !p.toolAllowed(tool) {
+			selection.FilterExcluded++
+			continue
+		}
+
+		if limit := p.limitReached(tool.ServerID, selection); limit != "" {
+			selection.LimitExcluded++
+			if !containsString(selection.LimitsHit, limit) {
+				selection.LimitsHit = append(selection.LimitsHit, limit)
+			}
+			continue
+		}
+
+		selection.Tools = append(selection.Tools, tool)
+		selection.ToolsByServer[tool.ServerID]++
+		selection.ToolsByCategory[tool.Category]++
+	}
+
+	selection.SelectedTools = len(selection.Tools)
+	return selection
+}
+
+// serverEnabled reports whether the profile exposes a server's tools. An
+// enabled_servers list decides on its own; without one, a server config
+// can switch a server off.
+func (p *Profile) serverEnabled(serverID string) bool {
+	if len(p.EnabledServers) > 0 {
+		return containsString(p.EnabledServers, serverID)
+	}
+	if config, exists := p.ServerConfigs[serverID]; exists {
+		return config.Enabled
+	}
+	return true
+}
+
+// serverPriority returns a server's priority; servers without one go last
+func (p *Profile) serverPriority(serverID string) int {
+	if config, exists := p.ServerConfigs[serverID]; exists && config.Priority > 0 {
+		return config.Priority
+	}
+	return int(^uint(0) >> 1)
+}
+
+// toolAllowed applies the server's categories and the profile's tool filters
+func (p *Profile) toolAllowed(tool Tool) bool {
+	if config, exists := p.ServerConfigs[tool.ServerID]; exists && len(config.Categories) > 0 {
+		if !containsString(config.Categories, tool.Category) {
+			return false
+		}
+	}
+
+	filters := p.ToolFilters
+	if len(filters.IncludeTools) > 0 && !containsString(filters.IncludeTools, tool.Name) {
+		return false
+	}
+	if containsString(filters.ExcludeTools, tool.Name) {
+		return false
+	}
+	if len(filters.IncludeCategories) > 0 && !containsString(filters.IncludeCategories, tool.Category) {
+		return false
+	}
+	if containsString(filters.ExcludeCategories, tool.Category) {
+		return false
+	}
+
+	// Any one required keyword in the name or description is enough
+	if len(filters.RequiredKeywords) > 0 {
+		text := strings.ToLower(tool.Name + " " + tool.Description)
+		for _, keyword := range filters.RequiredKeywords {
+			if strings.Contains(text, strings.ToLower(keyword)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// limitReached returns the per-server or total tool limit another tool from
+// a server would exceed, or "" if it fits
+func (p *Profile) limitReached(serverID string, selection ToolSelection) string {
+	if limit := p.ToolLimits.MaxToolsTotal; limit > 0 && len(selection.Tools) >= limit {
+		return "tool_limits.max_tools_total"
+	}
+	if limit := p.ToolLimits.MaxToolsPerServer; limit > 0 && selection.ToolsByServer[serverID] >= limit {
+		return "tool_limits.max_tools_per_server"
+	}
+	if config, exists := p.ServerConfigs[serverID]; exists && config.MaxTools > 0 && selection.ToolsByServer[serverID] >= config.MaxTools {
+		return "server_configs." + serverID + ".max_tools"
+	}
+	return ""
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package profiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortCategoriesKeepsUncategorizedLast(t *testing.T) {
+	names := []string{"web_browser", Uncategorized, "crm", "development", "ads"}
+	SortCategories(names)
+
+	want := []string{"ads", "crm", "development", "web_browser", Uncategorized}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("sorted %v, want %v", names, want)
+	}
+}
+
+func TestSortCategoriesIsStableAcrossInputOrder(t *testing.T) {
+	first := []string{"z", Uncategorized, "a", "m"}
+	second := []string{Uncategorized, "m", "z", "a"}
+	SortCategories(first)
+	SortCategories(second)
+
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("orders differ: %v and %v", first, second)
+	}
+}
+
+func TestToolCategoryPrefersServerCategory(t *testing.T) {
+	if got := ToolCategory("brave-search", "web_browser"); got != "web_browser" {
+		t.Errorf("got %q, want %q", got, "web_browser")
+	}
+	if got := ToolCategory("slack", ""); got != "slack" {
+		t.Errorf("got %q, want the server id %q", got, "slack")
+	}
+}
+
+// One server's tools must land in the same category whichever path assigns
+// it: budgets, analytics and the proxy all use ToolCategory with the
+// server's configured category
+func TestFilterToolsUsesServerCategory(t *testing.T) {
+	serverCategories := map[string]string{"brave-search": "web_browser", "slack": ""}
+	tools := []Tool{
+		{Name: "search", ServerID: "brave-search"},
+		{Name: "post_message", ServerID: "slack"},
+		{Name: "create_issue", ServerID: "github", Category: "development"},
+	}
+	profile := &Profile{ToolFilters: ToolFilters{IncludeCategories: []string{"web_browser", "slack"}}}
+
+	selection := profile.FilterTools(tools, serverCategories)
+
+	for _, tool := range selection.Tools {
+		if want := ToolCategory(tool.ServerID, serverCategories[tool.ServerID]); tool.Category != want {
+			t.Errorf("%s is in category %q, want %q", tool.Name, tool.Category, want)
+		}
+	}
+	if want := map[string]int{"web_browser": 1, "slack": 1}; !reflect.DeepEqual(selection.ToolsByCategory, want) {
+		t.Errorf("tools by category %v, want %v", selection.ToolsByCategory, want)
+	}
+	if selection.FilterExcluded != 1 {
+		t.Errorf("filter excluded %d tools, want 1", selection.FilterExcluded)
+	}
+}
+
+func TestFilterToolsLimitsDropLowPriorityServersFirst(t *testing.T) {
+	profile := &Profile{
+		ServerConfigs: map[string]ServerConfig{
+			"high": {Enabled: true, Priority: 1},
+			"low":  {Enabled: true, Priority: 2},
+		},
+		ToolLimits: ToolLimits{MaxToolsTotal: 2},
+	}
+	tools := []Tool{
+		{Name: "low_1", ServerID: "low"},
+		{Name: "high_1", ServerID: "high"},
+		{Name: "high_2", ServerID: "high"},
+	}
+
+	selection := profile.FilterTools(tools, nil)
+
+	if want := map[string]int{"high": 2}; !reflect.DeepEqual(selection.ToolsByServer, want) {
+		t.Errorf("tools by server %v, want %v", selection.ToolsByServer, want)
+	}
+	if want := []string{"tool_limits.max_tools_total"}; !reflect.DeepEqual(selection.LimitsHit, want) {
+		t.Errorf("limits hit %v, want %v", selection.LimitsHit, want)
+	}
+}
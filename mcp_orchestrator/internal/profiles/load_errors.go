@@ -0,0 +1,71 @@
+package profiles
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// quarantineDir holds profile files that failed to parse, under the profiles directory
+const quarantineDir = "quarantine"
+
+// LoadError records a profile file that couldn't be loaded
+type LoadError struct {
+	File          string    `json:"file"`
+	Error         string    `json:"error"`
+	QuarantinedAs string    `json:"quarantined_as,omitempty"` // Where the corrupt file was moved, if it was
+	DetectedAt    time.Time `json:"detected_at"`
+}
+
+// LoadErrors returns the profile files that failed to load at startup
+func (pm *ProfileManager) LoadErrors() []LoadError {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	return append([]LoadError(nil), pm.loadErrors...)
+}
+
+// recordLoadError logs a profile file that failed to load. A corrupt file is
+// moved into the quarantine directory, so it survives for inspection and a
+// default profile saved under the same name can't overwrite it; a file that
+// couldn't be read is left in place.
+func (pm *ProfileManager) recordLoadError(filename string, err error, corrupt bool) {
+	loadError := LoadError{
+		File:       filename,
+		Error:      err.Error(),
+		DetectedAt: time.Now(),
+	}
+
+	if corrupt {
+		quarantined, quarantineErr := quarantineFile(filename)
+		if quarantineErr != nil {
+			log.Printf("Warning: Failed to quarantine corrupt profile file %s: %v", filename, quarantineErr)
+		} else {
+			loadError.QuarantinedAs = quarantined
+		}
+	}
+
+	if loadError.QuarantinedAs != "" {
+		log.Printf("Warning: Failed to load profile file %s: %v (moved to %s)", filename, err, loadError.QuarantinedAs)
+	} else {
+		log.Printf("Warning: Failed to load profile file %s: %v", filename, err)
+	}
+	pm.loadErrors = append(pm.loadErrors, loadError)
+}
+
+// quarantineFile moves a file into the quarantine directory next to it,
+// adding a timestamp so earlier copies of the same file are kept
+func quarantineFile(filename string) (string, error) {
+	dir := filepath.Join(filepath.Dir(filename), quarantineDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+
+	target := filepath.Join(dir, fmt.Sprintf("%s.%s", filepath.Base(filename), time.Now().Format("20060102-150405")))
+	if err := os.Rename(filename, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
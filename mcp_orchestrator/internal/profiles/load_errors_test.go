@@ -0,0 +1,83 @@
+package profiles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeProfileFile writes a file into the profiles directory of configDir
+func writeProfileFile(t *testing.T, configDir, name, content string) string {
+	t.Helper()
+	dir := filepath.Join(configDir, "profiles")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, name)
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestMalformedProfileIsQuarantined(t *testing.T) {
+	configDir := t.TempDir()
+	writeProfileFile(t, configDir, "research.json", `{"id": "research", "name": "Research"}`)
+	const malformed = `{"id": "broken", "name": "Broken",`
+	broken := writeProfileFile(t, configDir, "broken.json", malformed)
+
+	pm := NewProfileManager(configDir)
+
+	if _, err := pm.GetProfile("research"); err != nil {
+		t.Errorf("valid profile wasn't loaded next to a malformed one: %v", err)
+	}
+
+	loadErrors := pm.LoadErrors()
+	if len(loadErrors) != 1 {
+		t.Fatalf("got %d load errors, want 1: %v", len(loadErrors), loadErrors)
+	}
+	loadError := loadErrors[0]
+	if loadError.File != broken || loadError.Error == "" {
+		t.Errorf("got load error %+v, want one for %s with its reason", loadError, broken)
+	}
+
+	wantDir := filepath.Join(configDir, "profiles", quarantineDir)
+	if filepath.Dir(loadError.QuarantinedAs) != wantDir || !strings.HasPrefix(filepath.Base(loadError.QuarantinedAs), "broken.json.") {
+		t.Errorf("quarantined as %q, want broken.json.<time> in %s", loadError.QuarantinedAs, wantDir)
+	}
+	if _, err := os.Stat(broken); !os.IsNotExist(err) {
+		t.Errorf("malformed file is still in the profiles directory: %v", err)
+	}
+	data, err := os.ReadFile(loadError.QuarantinedAs)
+	if err != nil {
+		t.Fatalf("reading quarantined file: %v", err)
+	}
+	if string(data) != malformed {
+		t.Errorf("quarantined file holds %q, want the original %q", data, malformed)
+	}
+}
+
+func TestProfileWithoutIDIsQuarantined(t *testing.T) {
+	configDir := t.TempDir()
+	writeProfileFile(t, configDir, "research.json", `{"id": "research", "name": "Research"}`)
+	writeProfileFile(t, configDir, "anonymous.json", `{"name": "No ID"}`)
+
+	loadErrors := NewProfileManager(configDir).LoadErrors()
+	if len(loadErrors) != 1 || loadErrors[0].QuarantinedAs == "" {
+		t.Fatalf("got load errors %v, want the file without an id quarantined", loadErrors)
+	}
+}
+
+func TestQuarantinedProfilesAreNotReloaded(t *testing.T) {
+	configDir := t.TempDir()
+	writeProfileFile(t, configDir, "research.json", `{"id": "research", "name": "Research"}`)
+	writeProfileFile(t, configDir, "broken.json", `not json`)
+
+	if loadErrors := NewProfileManager(configDir).LoadErrors(); len(loadErrors) != 1 {
+		t.Fatalf("got %d load errors on first load, want 1", len(loadErrors))
+	}
+	if loadErrors := NewProfileManager(configDir).LoadErrors(); len(loadErrors) != 0 {
+		t.Errorf("got load errors %v after restarting, want none", loadErrors)
+	}
+}
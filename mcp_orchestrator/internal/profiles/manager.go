@@ -41,6 +41,34 @@ type ServerConfig struct {
 	MaxTools   int               `json:"max_tools"`  // Limit tools from this server
 	Categories []string          `json:"categories"` // Allowed categories
 	EnvVars    map[string]string `json:"env_vars"`   // Environment variables
+
+	// Launch overrides, used in place of the server's own settings when set
+	Command    string   `json:"command,omitempty"`     // Replaces the server's command
+	Args       []string `json:"args,omitempty"`        // Replaces the server's arguments
+	WorkingDir string   `json:"working_dir,omitempty"` // Relative paths resolve against the install directory
+
+	// Tool discovery retry overrides for slow-starting or flaky servers
+	DiscoveryMaxAttempts int `json:"discovery_max_attempts,omitempty"` // Attempts in total, including the first
+	DiscoveryBackoffMs   int `json:"discovery_backoff_ms,omitempty"`   // Delay before the first retry
+
+	// Tool calls forwarded to the server at once; further calls wait for a slot
+	MaxConcurrentCalls int `json:"max_concurrent_calls,omitempty"`
+
+	// Caps the tools the stdio proxy exposes from the server. ExposeBy picks
+	// which ones: "priority" (the default) takes ToolPriority's tools in order
+	// and then the server's own order; "popularity" takes the most-called first.
+	MaxExposedTools int      `json:"max_exposed_tools,omitempty"`
+	ToolPriority    []string `json:"tool_priority,omitempty"`
+	ExposeBy        string   `json:"expose_by,omitempty"`
+
+	// Stops the server after this long without tool calls; it starts again on
+	// the next call. 0 keeps it running.
+	IdleTimeoutSeconds int `json:"idle_timeout_seconds,omitempty"`
+}
+
+// HasLaunchOverride reports whether the config overrides how the server is launched
+func (sc ServerConfig) HasLaunchOverride() bool {
+	return sc.Command != "" || len(sc.Args) > 0 || sc.WorkingDir != ""
 }
 
 // ToolFilters defines which tools are included/excluded
@@ -54,10 +82,36 @@ type ToolFilters struct {
 
 // ToolLimits defines limits for tool usage
 type ToolLimits struct {
-	MaxToolsPerServer  int `json:"max_tools_per_server"`
-	MaxToolsTotal      int `json:"max_tools_total"`
-	MaxConcurrentCalls int `json:"max_concurrent_calls"`
-	RateLimitPerMinute int `json:"rate_limit_per_minute"`
+	MaxToolsPerServer  int                   `json:"max_tools_per_server"`
+	MaxToolsTotal      int                   `json:"max_tools_total"`
+	MaxConcurrentCalls int                   `json:"max_concurrent_calls"`
+	RateLimitPerMinute int                   `json:"rate_limit_per_minute"`
+	ToolBudgets        map[string]CallBudget `json:"tool_budgets,omitempty"`     // Tool name -> budget
+	CategoryBudgets    map[string]CallBudget `json:"category_budgets,omitempty"` // Category -> budget
+	MaxResultBytes     int                   `json:"max_result_bytes,omitempty"` // Tool results above this are truncated; 0 disables
+	ToolList           ToolListConfig        `json:"tool_list,omitempty"`        // Defaults for tools/list requests
+}
+
+// ToolListConfig sets the tools/list defaults applied when a client doesn't
+// pass the corresponding params. Zero values keep the built-in defaults.
+type ToolListConfig struct {
+	DefaultLimit int          `json:"default_limit,omitempty"`
+	SchemaLevel  string       `json:"schema_level,omitempty"` // full, standard, compact, minimal, simplified or ultra_minimal
+	AdjustLimit  *bool        `json:"adjust_limit,omitempty"` // Cap the page size for large tool sets
+	MaxLimit     int          `json:"max_limit,omitempty"`    // Page size cap for small tool sets
+	ContextCaps  []ContextCap `json:"context_caps,omitempty"` // Page size caps for large tool sets
+}
+
+// ContextCap caps the tools/list page size once more than AboveTools match
+type ContextCap struct {
+	AboveTools int `json:"above_tools"`
+	MaxLimit   int `json:"max_limit"`
+}
+
+// CallBudget caps the calls to a tool or category within a rolling window
+type CallBudget struct {
+	MaxCalls      int `json:"max_calls"`
+	WindowSeconds int `json:"window_seconds"`
 }
 
 // PerformanceConfig defines performance settings
@@ -79,10 +133,12 @@ type AnalyticsConfig struct {
 
 // ProfileManager manages orchestrator profiles
 type ProfileManager struct {
-	profiles  map[string]*Profile
-	activeID  string
-	configDir string
-	mu        sync.RWMutex
+	profiles    map[string]*Profile
+	activeIDs   []string // Active profiles in precedence order; more than one are merged
+	configDir   string
+	mu          sync.RWMutex
+	loadErrors  []LoadError                // Profile files that failed to load
+	knownServer func(serverID string) bool // Checks server references; nil skips the check
 }
 
 // NewProfileManager creates a new profile manager
@@ -146,6 +202,9 @@ func (pm *ProfileManager) createDefaultProfiles() {
 			MaxToolsTotal:      100,
 			MaxConcurrentCalls: 10,
 			RateLimitPerMinute: 100,
+			CategoryBudgets: map[string]CallBudget{
+				"web_browser": {MaxCalls: 10, WindowSeconds: 60}, // Browser automation is expensive
+			},
 		},
 		Performance: PerformanceConfig{
 			EnableCaching:      true,
@@ -200,6 +259,7 @@ func (pm *ProfileManager) createDefaultProfiles() {
 			MaxToolsTotal:      350,
 			MaxConcurrentCalls: 20,
 			RateLimitPerMinute: 200,
+			MaxResultBytes:     64 * 1024, // GoHighLevel list endpoints return very large payloads
 		},
 		Performance: PerformanceConfig{
 			EnableCaching:      true,
@@ -259,18 +319,26 @@ func (pm *ProfileManager) createDefaultProfiles() {
 	pm.profiles["development"] = devProfile
 	pm.profiles["marketing"] = marketingProfile
 	pm.profiles["all_tools"] = allProfile
-	pm.activeID = "development"
+	pm.activeIDs = []string{"development"}
 
 	pm.saveProfiles()
 }
 
-// GetActiveProfile returns the currently active profile
+// GetActiveProfile returns the currently active profile. When several
+// profiles are active, it returns a new composite profile merged from them
+// by MergeProfiles each time it is called.
 func (pm *ProfileManager) GetActiveProfile() *Profile {
 	pm.mu.RLock()
 	defer pm.mu.RUnlock()
 
-	if profile, exists := pm.profiles[pm.activeID]; exists {
-		return profile
+	active := make([]*Profile, 0, len(pm.activeIDs))
+	for _, id := range pm.activeIDs {
+		if profile, exists := pm.profiles[id]; exists {
+			active = append(active, profile)
+		}
+	}
+	if len(active) > 0 {
+		return MergeProfiles(active)
 	}
 
 	// Return first profile as fallback
@@ -306,21 +374,45 @@ func (pm *ProfileManager) ListProfiles() []*Profile {
 	return profiles
 }
 
+// GetActiveProfileIDs returns the IDs of the active profiles in precedence order
+func (pm *ProfileManager) GetActiveProfileIDs() []string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	return append([]string(nil), pm.activeIDs...)
+}
+
 // SetActiveProfile sets the active profile
 func (pm *ProfileManager) SetActiveProfile(id string) error {
+	return pm.SetActiveProfiles([]string{id})
+}
+
+// SetActiveProfiles activates several profiles at once, merged as described
+// by MergeProfiles. Earlier IDs take precedence where settings can't be merged.
+func (pm *ProfileManager) SetActiveProfiles(ids []string) error {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
-	if _, exists := pm.profiles[id]; !exists {
-		return fmt.Errorf("profile %s not found", id)
+	if len(ids) == 0 {
+		return fmt.Errorf("at least one profile must be active")
+	}
+
+	activeIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, exists := pm.profiles[id]; !exists {
+			return fmt.Errorf("profile %s not found", id)
+		}
+		if !containsString(activeIDs, id) {
+			activeIDs = append(activeIDs, id)
+		}
 	}
 
 	// Update active status
 	for pid, profile := range pm.profiles {
-		profile.Active = (pid == id)
+		profile.Active = containsString(activeIDs, pid)
 	}
 
-	pm.activeID = id
+	pm.activeIDs = activeIDs
 	pm.saveProfiles()
 
 	return nil
@@ -331,6 +423,9 @@ func (pm *ProfileManager) CreateProfile(profile *Profile) error {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
+	if err := pm.validateProfile(profile); err != nil {
+		return err
+	}
 	if _, exists := pm.profiles[profile.ID]; exists {
 		return fmt.Errorf("profile %s already exists", profile.ID)
 	}
@@ -344,15 +439,18 @@ func (pm *ProfileManager) CreateProfile(profile *Profile) error {
 	return nil
 }
 
-// UpdateProfile updates an existing profile
+// UpdateProfile updates an existing profile. The updated profile is
+// validated before it replaces the stored one.
 func (pm *ProfileManager) UpdateProfile(id string, updates *Profile) error {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
-	profile, exists := pm.profiles[id]
+	stored, exists := pm.profiles[id]
 	if !exists {
 		return fmt.Errorf("profile %s not found", id)
 	}
+	updated := *stored
+	profile := &updated
 
 	// Update fields
 	if updates.Name != "" {
@@ -367,8 +465,12 @@ func (pm *ProfileManager) UpdateProfile(id string, updates *Profile) error {
 	if len(updates.ServerConfigs) > 0 {
 		profile.ServerConfigs = updates.ServerConfigs
 	}
+	if err := pm.validateProfile(profile); err != nil {
+		return err
+	}
 
 	profile.UpdatedAt = time.Now()
+	*stored = updated
 
 	pm.saveProfiles()
 	return nil
@@ -384,20 +486,27 @@ func (pm *ProfileManager) DeleteProfile(id string) error {
 	}
 
 	// Don't delete if it's the active profile and only profile
-	if pm.activeID == id && len(pm.profiles) == 1 {
+	if containsString(pm.activeIDs, id) && len(pm.profiles) == 1 {
 		return fmt.Errorf("cannot delete the only profile")
 	}
 
 	delete(pm.profiles, id)
 
-	// Set new active profile if needed
-	if pm.activeID == id {
+	// Drop it from the active profiles, activating another if none are left
+	activeIDs := make([]string, 0, len(pm.activeIDs))
+	for _, activeID := range pm.activeIDs {
+		if activeID != id {
+			activeIDs = append(activeIDs, activeID)
+		}
+	}
+	if len(activeIDs) == 0 {
 		for pid := range pm.profiles {
-			pm.activeID = pid
+			activeIDs = append(activeIDs, pid)
 			pm.profiles[pid].Active = true
 			break
 		}
 	}
+	pm.activeIDs = activeIDs
 
 	pm.saveProfiles()
 	return nil
@@ -415,8 +524,11 @@ func (pm *ProfileManager) saveProfiles() {
 		os.WriteFile(filename, data, 0644)
 	}
 
-	// Save active profile info
-	activeData := map[string]string{"active_profile": pm.activeID}
+	// Save active profile info; active_profile keeps older readers working
+	activeData := map[string]interface{}{"active_profiles": pm.activeIDs}
+	if len(pm.activeIDs) > 0 {
+		activeData["active_profile"] = pm.activeIDs[0]
+	}
 	data, _ := json.MarshalIndent(activeData, "", "  ")
 	activeFile := filepath.Join(pm.configDir, "profiles", "active.json")
 	os.WriteFile(activeFile, data, 0644)
@@ -429,10 +541,20 @@ func (pm *ProfileManager) loadProfiles() {
 	// Load active profile info
 	activeFile := filepath.Join(profilesDir, "active.json")
 	if data, err := os.ReadFile(activeFile); err == nil {
-		var activeData map[string]string
-		if json.Unmarshal(data, &activeData) == nil {
-			pm.activeID = activeData["active_profile"]
+		var activeData struct {
+			ActiveProfile  string   `json:"active_profile"`
+			ActiveProfiles []string `json:"active_profiles"`
 		}
+		if err := json.Unmarshal(data, &activeData); err != nil {
+			pm.recordLoadError(activeFile, err, true)
+		} else {
+			pm.activeIDs = activeData.ActiveProfiles
+			if len(pm.activeIDs) == 0 && activeData.ActiveProfile != "" {
+				pm.activeIDs = []string{activeData.ActiveProfile}
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		pm.recordLoadError(activeFile, err, false)
 	}
 
 	// Load all profile files
@@ -446,11 +568,17 @@ func (pm *ProfileManager) loadProfiles() {
 			filename := filepath.Join(profilesDir, entry.Name())
 			data, err := os.ReadFile(filename)
 			if err != nil {
+				pm.recordLoadError(filename, err, false)
 				continue
 			}
 
 			var profile Profile
 			if err := json.Unmarshal(data, &profile); err != nil {
+				pm.recordLoadError(filename, err, true)
+				continue
+			}
+			if profile.ID == "" {
+				pm.recordLoadError(filename, fmt.Errorf("profile has no id"), true)
 				continue
 			}
 
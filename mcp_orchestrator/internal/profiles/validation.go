@@ -0,0 +1,152 @@
+package profiles
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// schemaLevelNames are the tools/list schema levels a profile may default to
+var schemaLevelNames = []string{"full", "standard", "compact", "minimal", "simplified", "ultra_minimal"}
+
+// ValidationError lists everything wrong with a profile
+type ValidationError struct {
+	Problems []string `json:"problems"`
+}
+
+// Error joins the problems into one message
+func (e *ValidationError) Error() string {
+	return "invalid profile: " + strings.Join(e.Problems, "; ")
+}
+
+// SetServerLookup sets how profiles check that the servers they reference
+// exist. Without one, server references aren't checked.
+func (pm *ProfileManager) SetServerLookup(known func(serverID string) bool) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.knownServer = known
+}
+
+// validateProfile checks a profile's ID, server references, limits and
+// filters, returning a *ValidationError naming every problem found. Callers
+// hold pm.mu.
+func (pm *ProfileManager) validateProfile(profile *Profile) error {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// The ID names the profile's file
+	switch {
+	case profile.ID == "":
+		problem("id is required")
+	case profile.ID == "active" || strings.ContainsAny(profile.ID, `/\`) || strings.HasPrefix(profile.ID, "."):
+		problem("id %q can't be used as a file name", profile.ID)
+	}
+
+	if pm.knownServer != nil {
+		for _, serverID := range profile.EnabledServers {
+			if !pm.knownServer(serverID) {
+				problem("enabled_servers: unknown server %q", serverID)
+			}
+		}
+	}
+
+	serverIDs := make([]string, 0, len(profile.ServerConfigs))
+	for serverID := range profile.ServerConfigs {
+		serverIDs = append(serverIDs, serverID)
+	}
+	sort.Strings(serverIDs)
+	for _, serverID := range serverIDs {
+		if pm.knownServer != nil && !pm.knownServer(serverID) {
+			problem("server_configs: unknown server %q", serverID)
+		}
+
+		config := profile.ServerConfigs[serverID]
+		field := func(name string) string {
+			return fmt.Sprintf("server_configs.%s.%s", serverID, name)
+		}
+		nonNegative(problem, field("max_tools"), config.MaxTools)
+		nonNegative(problem, field("max_concurrent_calls"), config.MaxConcurrentCalls)
+		nonNegative(problem, field("max_exposed_tools"), config.MaxExposedTools)
+		nonNegative(problem, field("discovery_max_attempts"), config.DiscoveryMaxAttempts)
+		nonNegative(problem, field("discovery_backoff_ms"), config.DiscoveryBackoffMs)
+		nonNegative(problem, field("idle_timeout_seconds"), config.IdleTimeoutSeconds)
+		switch config.ExposeBy {
+		case "", "priority", "popularity":
+		default:
+			problem("%s must be priority or popularity, not %q", field("expose_by"), config.ExposeBy)
+		}
+	}
+
+	limits := profile.ToolLimits
+	nonNegative(problem, "tool_limits.max_tools_per_server", limits.MaxToolsPerServer)
+	nonNegative(problem, "tool_limits.max_tools_total", limits.MaxToolsTotal)
+	nonNegative(problem, "tool_limits.max_concurrent_calls", limits.MaxConcurrentCalls)
+	nonNegative(problem, "tool_limits.rate_limit_per_minute", limits.RateLimitPerMinute)
+	nonNegative(problem, "tool_limits.max_result_bytes", limits.MaxResultBytes)
+	validateBudgets(problem, "tool_limits.tool_budgets", limits.ToolBudgets)
+	validateBudgets(problem, "tool_limits.category_budgets", limits.CategoryBudgets)
+
+	toolList := limits.ToolList
+	nonNegative(problem, "tool_limits.tool_list.default_limit", toolList.DefaultLimit)
+	nonNegative(problem, "tool_limits.tool_list.max_limit", toolList.MaxLimit)
+	if toolList.SchemaLevel != "" && !containsString(schemaLevelNames, toolList.SchemaLevel) {
+		problem("tool_limits.tool_list.schema_level must be one of %s, not %q", strings.Join(schemaLevelNames, ", "), toolList.SchemaLevel)
+	}
+	for i, contextCap := range toolList.ContextCaps {
+		if contextCap.AboveTools < 0 || contextCap.MaxLimit <= 0 {
+			problem("tool_limits.tool_list.context_caps[%d] needs above_tools of at least 0 and a positive max_limit", i)
+		}
+	}
+
+	// A name both included and excluded would be excluded, leaving the
+	// include list quietly ineffective
+	filters := profile.ToolFilters
+	for _, category := range overlap(filters.IncludeCategories, filters.ExcludeCategories) {
+		problem("tool_filters: category %q is both included and excluded", category)
+	}
+	for _, tool := range overlap(filters.IncludeTools, filters.ExcludeTools) {
+		problem("tool_filters: tool %q is both included and excluded", tool)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// nonNegative reports a limit set below zero
+func nonNegative(problem func(string, ...interface{}), field string, value int) {
+	if value < 0 {
+		problem("%s must not be negative, got %d", field, value)
+	}
+}
+
+// validateBudgets reports call budgets that could never apply
+func validateBudgets(problem func(string, ...interface{}), field string, budgets map[string]CallBudget) {
+	names := make([]string, 0, len(budgets))
+	for name := range budgets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		budget := budgets[name]
+		if budget.MaxCalls <= 0 || budget.WindowSeconds <= 0 {
+			problem("%s.%s needs a positive max_calls and window_seconds", field, name)
+		}
+	}
+}
+
+// overlap returns the values found in both lists, in the first list's order
+func overlap(first, second []string) []string {
+	var both []string
+	for _, value := range first {
+		if containsString(second, value) && !containsString(both, value) {
+			both = append(both, value)
+		}
+	}
+	return both
+}
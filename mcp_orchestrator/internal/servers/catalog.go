@@ -0,0 +1,204 @@
+package servers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	catalogCacheFile    = "catalog_cache.json"
+	catalogFetchTimeout = 10 * time.Second
+	maxCatalogBytes     = 1 << 20 // Refuse catalogs larger than 1 MB
+)
+
+// catalogIDPattern restricts server IDs to names that are safe as directory names
+var catalogIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ServerCatalog is the document served at the remote catalog URL
+type ServerCatalog struct {
+	Servers []*ServerConfig `json:"servers"`
+}
+
+// catalogServers returns the entries of the remote catalog
+func (m *Manager) catalogServers() []*ServerConfig {
+	m.catalogMu.RLock()
+	defer m.catalogMu.RUnlock()
+
+	return m.catalog
+}
+
+// loadCachedCatalog restores the catalog fetched by a previous run so remote
+// servers are offered even when the catalog URL is unreachable
+func (m *Manager) loadCachedCatalog() {
+	data, err := os.ReadFile(filepath.Join(m.basePath, catalogCacheFile))
+	if err != nil {
+		return
+	}
+
+	servers, err := parseCatalog(data)
+	if err != nil {
+		log.Printf("Warning: Ignoring cached server catalog: %v", err)
+		return
+	}
+
+	m.catalogMu.Lock()
+	m.catalog = servers
+	m.catalogMu.Unlock()
+}
+
+// refreshCatalog fetches the remote catalog and caches it locally. On
+// failure the cached or built-in servers remain available.
+func (m *Manager) refreshCatalog(url string) {
+	data, err := fetchCatalog(url)
+	if err != nil {
+		log.Printf("Warning: Failed to fetch server catalog from %s: %v", url, err)
+		return
+	}
+
+	servers, err := parseCatalog(data)
+	if err != nil {
+		log.Printf("Warning: Invalid server catalog from %s: %v", url, err)
+		return
+	}
+
+	m.catalogMu.Lock()
+	m.catalog = servers
+	m.catalogMu.Unlock()
+
+	if err := os.WriteFile(filepath.Join(m.basePath, catalogCacheFile), data, 0644); err != nil {
+		log.Printf("Warning: Failed to cache server catalog: %v", err)
+	}
+
+	log.Printf("Loaded %d servers from catalog %s", len(servers), url)
+}
+
+// fetchCatalog downloads the raw catalog document
+func fetchCatalog(url string) ([]byte, error) {
+	client := &http.Client{Timeout: catalogFetchTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCatalogBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCatalogBytes {
+		return nil, fmt.Errorf("catalog exceeds %d bytes", maxCatalogBytes)
+	}
+
+	return data, nil
+}
+
+// parseCatalog decodes a catalog document, dropping entries that fail validation
+func parseCatalog(data []byte) ([]*ServerConfig, error) {
+	var catalog ServerCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog: %v", err)
+	}
+
+	var servers []*ServerConfig
+	seen := make(map[string]bool)
+	for i, server := range catalog.Servers {
+		if err := validateCatalogEntry(server); err != nil {
+			log.Printf("Warning: Skipping catalog entry %d: %v", i, err)
+			continue
+		}
+		if seen[server.ID] {
+			log.Printf("Warning: Skipping duplicate catalog entry %s", server.ID)
+			continue
+		}
+		seen[server.ID] = true
+
+		// Runtime state never comes from the catalog
+		server.Status = "not_installed"
+		server.InstallPath = ""
+		server.PID = 0
+		server.Logs = nil
+		server.Disabled = false
+
+		servers = append(servers, server)
+	}
+
+	return servers, nil
+}
+
+// validateCatalogEntry checks that a catalog entry is a usable ServerConfig
+func validateCatalogEntry(server *ServerConfig) error {
+	if server == nil {
+		return fmt.Errorf("entry is empty")
+	}
+	if !catalogIDPattern.MatchString(server.ID) {
+		return fmt.Errorf("invalid id %q", server.ID)
+	}
+	if server.Name == "" {
+		return fmt.Errorf("server %s has no name", server.ID)
+	}
+	if !strings.HasPrefix(server.RepoURL, "https://") && !strings.HasPrefix(server.RepoURL, "git@") {
+		return fmt.Errorf("server %s has an invalid repo_url %q", server.ID, server.RepoURL)
+	}
+	for field, link := range map[string]string{"homepage": server.Homepage, "docs_url": server.DocsURL} {
+		if link != "" && !strings.HasPrefix(link, "https://") {
+			return fmt.Errorf("server %s has an invalid %s %q", server.ID, field, link)
+		}
+	}
+	if server.Command == "" {
+		return fmt.Errorf("server %s has no command", server.ID)
+	}
+	if server.ServerType != "nodejs" && server.ServerType != "python" {
+		return fmt.Errorf("server %s has unsupported server_type %q", server.ID, server.ServerType)
+	}
+	if filepath.IsAbs(server.SubPath) || strings.Contains(server.SubPath, "..") {
+		return fmt.Errorf("server %s has an invalid sub_path %q", server.ID, server.SubPath)
+	}
+	for _, dependency := range server.DependsOn {
+		if !catalogIDPattern.MatchString(dependency) {
+			return fmt.Errorf("server %s has an invalid dependency %q", server.ID, dependency)
+		}
+	}
+
+	return nil
+}
+
+// mergeCatalog combines built-in and catalog servers, keeping the built-in
+// order and letting catalog entries replace built-ins with the same ID
+func mergeCatalog(builtins, catalog []*ServerConfig) []*ServerConfig {
+	// Callers may modify the returned templates, so catalog entries are copied
+	copies := make([]*ServerConfig, 0, len(catalog))
+	overrides := make(map[string]*ServerConfig, len(catalog))
+	for _, server := range catalog {
+		serverCopy := *server
+		copies = append(copies, &serverCopy)
+		overrides[server.ID] = &serverCopy
+	}
+
+	merged := make([]*ServerConfig, 0, len(builtins)+len(catalog))
+	for _, server := range builtins {
+		if override, exists := overrides[server.ID]; exists {
+			server = override
+			delete(overrides, server.ID)
+		}
+		merged = append(merged, server)
+	}
+	for _, server := range copies {
+		if _, remaining := overrides[server.ID]; remaining {
+			merged = append(merged, server)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,315 @@
+package servers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	claudeDesktopServerName = "mcp-orchestrator"       // Key of the orchestrator's entry in mcpServers
+	stdioBinaryName         = "mcp-orchestrator-stdio" // Built next to the orchestrator binary
+)
+
+// ClaudeDesktopConfigPath returns where Claude Desktop reads its config on this platform
+func ClaudeDesktopConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %v", err)
+	}
+
+	var configDir string
+	switch runtime.GOOS {
+	case "darwin":
+		configDir = filepath.Join(homeDir, "Library", "Application Support", "Claude")
+	case "windows":
+		appData := os.Getenv("APPDATA")
+		if appData == "" {
+			appData = filepath.Join(homeDir, "AppData", "Roaming")
+		}
+		configDir = filepath.Join(appData, "Claude")
+	default:
+		configDir, err = os.UserConfigDir()
+		if err != nil {
+			configDir = filepath.Join(homeDir, ".config")
+		}
+		configDir = filepath.Join(configDir, "Claude")
+	}
+
+	return filepath.Join(configDir, "claude_desktop_config.json"), nil
+}
+
+// StdioBinaryPath returns the stdio proxy path next to the running binary,
+// resolving symlinks so a linked install points at the real build
+func StdioBinaryPath() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the running binary: %v", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+
+	name := stdioBinaryName
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+
+	return filepath.Join(filepath.Dir(executable), name), nil
+}
+
+// stdioVersionTimeout bounds how long a stdio proxy binary may take to report its version
+const stdioVersionTimeout = 5 * time.Second
+
+// StdioBinaryVersion runs a stdio proxy binary with --version and returns the
+// version it reports. Proxies built before --version was supported print
+// nothing, which is reported as an error.
+func StdioBinaryVersion(path string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), stdioVersionTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s --version: %v", path, err)
+	}
+
+	// The first line is "mcp-orchestrator-stdio <version>"; build details follow
+	firstLine, _, _ := strings.Cut(string(output), "\n")
+	fields := strings.Fields(firstLine)
+	if len(fields) != 2 || fields[0] != stdioBinaryName {
+		return "", fmt.Errorf("%s does not report a version; it may predate --version support", path)
+	}
+
+	return fields[1], nil
+}
+
+// ConfigureClaudeDesktop points the orchestrator's Claude Desktop entry at
+// stdioPath, returning the config file and the entry. Only the
+// mcp-orchestrator entry is touched: other servers and settings are kept as
+// they are, and the file isn't rewritten when the entry is already current.
+func ConfigureClaudeDesktop(stdioPath string) (string, map[string]interface{}, error) {
+	claudeConfigFile, err := ClaudeDesktopConfigPath()
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Create Claude config directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(claudeConfigFile), 0755); err != nil {
+		return "", nil, fmt.Errorf("failed to create Claude config directory: %v", err)
+	}
+
+	entry, err := updateOrchestratorEntry(claudeConfigFile, useStdioProxy(stdioPath))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return claudeConfigFile, entry, nil
+}
+
+// useStdioProxy returns the entry update ConfigureClaudeDesktop applies
+func useStdioProxy(stdioPath string) func(entry map[string]interface{}) {
+	return func(entry map[string]interface{}) {
+		// Use our custom stdio proxy instead of mcp-remote or a websocket transport
+		entry["command"] = stdioPath
+		delete(entry, "transport")
+	}
+}
+
+// ClaudeConfigChange is one difference between the current and proposed Claude Desktop config
+type ClaudeConfigChange struct {
+	Path   string      `json:"path"` // Dotted path, e.g. mcpServers.mcp-orchestrator.command
+	Op     string      `json:"op"`   // "add", "remove" or "change"
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// ClaudeConfigPreview is what ConfigureClaudeDesktop would write, without writing it
+type ClaudeConfigPreview struct {
+	ConfigFile string                 `json:"config_file"`
+	Exists     bool                   `json:"exists"`  // Whether the config file exists yet
+	Changed    bool                   `json:"changed"` // Whether applying would rewrite the file
+	Current    map[string]interface{} `json:"current"`
+	Proposed   map[string]interface{} `json:"proposed"`
+	Changes    []ClaudeConfigChange   `json:"changes"`
+}
+
+// PreviewClaudeDesktop reports how ConfigureClaudeDesktop would change the
+// Claude Desktop config for stdioPath. Nothing is written.
+func PreviewClaudeDesktop(stdioPath string) (*ClaudeConfigPreview, error) {
+	claudeConfigFile, err := ClaudeDesktopConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := planOrchestratorEntry(claudeConfigFile, useStdioProxy(stdioPath))
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &ClaudeConfigPreview{
+		ConfigFile: claudeConfigFile,
+		Exists:     plan.original != nil,
+		Changed:    plan.data != nil,
+		Current:    make(map[string]interface{}),
+		Changes:    []ClaudeConfigChange{},
+	}
+	if plan.original != nil {
+		if err := json.Unmarshal(plan.original, &preview.Current); err != nil {
+			return nil, fmt.Errorf("failed to parse Claude config %s: %v", claudeConfigFile, err)
+		}
+	}
+
+	preview.Proposed = preview.Current
+	if plan.data != nil {
+		preview.Proposed = make(map[string]interface{})
+		if err := json.Unmarshal(plan.data, &preview.Proposed); err != nil {
+			return nil, fmt.Errorf("failed to parse proposed Claude config: %v", err)
+		}
+		preview.Changes = diffConfig("", preview.Current, preview.Proposed, preview.Changes)
+	}
+
+	return preview, nil
+}
+
+// diffConfig appends the differences between two decoded JSON values to
+// changes. Objects are compared key by key; anything else is compared whole.
+func diffConfig(path string, before, after interface{}, changes []ClaudeConfigChange) []ClaudeConfigChange {
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if !beforeIsMap || !afterIsMap {
+		if !reflect.DeepEqual(before, after) {
+			changes = append(changes, ClaudeConfigChange{Path: path, Op: "change", Before: before, After: after})
+		}
+		return changes
+	}
+
+	keys := make([]string, 0, len(beforeMap)+len(afterMap))
+	for key := range beforeMap {
+		keys = append(keys, key)
+	}
+	for key := range afterMap {
+		if _, exists := beforeMap[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+
+		beforeValue, inBefore := beforeMap[key]
+		afterValue, inAfter := afterMap[key]
+		switch {
+		case !inBefore:
+			changes = append(changes, ClaudeConfigChange{Path: keyPath, Op: "add", After: afterValue})
+		case !inAfter:
+			changes = append(changes, ClaudeConfigChange{Path: keyPath, Op: "remove", Before: beforeValue})
+		default:
+			changes = diffConfig(keyPath, beforeValue, afterValue, changes)
+		}
+	}
+
+	return changes
+}
+
+// orchestratorEntryPlan is an update of the mcp-orchestrator entry worked out
+// but not yet written
+type orchestratorEntryPlan struct {
+	original []byte                 // The config file as read; nil when it doesn't exist
+	data     []byte                 // The config to write; nil when the entry is already current
+	entry    map[string]interface{} // The updated entry
+}
+
+// updateOrchestratorEntry applies update to the mcp-orchestrator entry of a
+// Claude Desktop config file, creating the file or entry when missing
+func updateOrchestratorEntry(claudeConfigFile string, update func(entry map[string]interface{})) (map[string]interface{}, error) {
+	plan, err := planOrchestratorEntry(claudeConfigFile, update)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leave the file alone when the entry is already current
+	if plan.data == nil {
+		return plan.entry, nil
+	}
+
+	if err := os.WriteFile(claudeConfigFile, plan.data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write Claude config file: %v", err)
+	}
+
+	return plan.entry, nil
+}
+
+// planOrchestratorEntry works out the config that applying update to the
+// mcp-orchestrator entry produces. The config is edited as raw JSON so fields
+// this package doesn't model survive.
+func planOrchestratorEntry(claudeConfigFile string, update func(entry map[string]interface{})) (*orchestratorEntryPlan, error) {
+	config := make(map[string]json.RawMessage)
+	original, err := os.ReadFile(claudeConfigFile)
+	if err == nil {
+		// Never replace a config we can't parse; it holds the user's other servers
+		if err := json.Unmarshal(original, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse Claude config %s: %v", claudeConfigFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read Claude config: %v", err)
+	}
+
+	mcpServers := make(map[string]json.RawMessage)
+	if raw, exists := config["mcpServers"]; exists && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &mcpServers); err != nil {
+			return nil, fmt.Errorf("failed to parse mcpServers in Claude config: %v", err)
+		}
+	}
+
+	entry := make(map[string]interface{})
+	if raw, exists := mcpServers[claudeDesktopServerName]; exists {
+		if err := json.Unmarshal(raw, &entry); err != nil || entry == nil {
+			entry = make(map[string]interface{})
+		}
+	}
+	before, _ := json.Marshal(entry)
+	update(entry)
+	after, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Claude config entry: %v", err)
+	}
+
+	plan := &orchestratorEntryPlan{original: original, entry: entry}
+	if original != nil && mcpServers[claudeDesktopServerName] != nil && string(before) == string(after) {
+		return plan, nil
+	}
+
+	for name, raw := range mcpServers {
+		var server MCPServerConfig
+		if err := json.Unmarshal(raw, &server); err == nil && server.Command == "" && server.Transport == nil {
+			log.Printf("Warning: Claude Desktop server %s has neither a command nor a transport", name)
+		}
+	}
+
+	mcpServers[claudeDesktopServerName] = after
+	serversJSON, err := json.Marshal(mcpServers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Claude config: %v", err)
+	}
+	config["mcpServers"] = serversJSON
+
+	plan.data, err = json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Claude config: %v", err)
+	}
+
+	return plan, nil
+}
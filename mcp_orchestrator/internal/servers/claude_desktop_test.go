@@ -0,0 +1,114 @@
+package servers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const existingClaudeConfig = `{
+  "globalShortcut": "Ctrl+Space",
+  "mcpServers": {
+    "remote-ws": {
+      "transport": {"type": "websocket", "url": "ws://localhost:9000/mcp"},
+      "customField": {"kept": true}
+    },
+    "filesystem": {"command": "npx", "args": ["-y", "@modelcontextprotocol/server-filesystem", "/tmp"]},
+    "mcp-orchestrator": {
+      "transport": {"type": "websocket", "url": "ws://localhost:3000"},
+      "env": {"MCP_LAZY_START": "true"}
+    }
+  }
+}`
+
+// readClaudeConfig decodes a Claude Desktop config file
+func readClaudeConfig(t *testing.T, path string) map[string]interface{} {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := make(map[string]interface{})
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestUpdateOrchestratorEntryKeepsOtherServers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claude_desktop_config.json")
+	if err := os.WriteFile(path, []byte(existingClaudeConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var before map[string]interface{}
+	json.Unmarshal([]byte(existingClaudeConfig), &before)
+
+	if _, err := updateOrchestratorEntry(path, useStdioProxy("/opt/mcp/mcp-orchestrator-stdio")); err != nil {
+		t.Fatal(err)
+	}
+
+	after := readClaudeConfig(t, path)
+	beforeServers := before["mcpServers"].(map[string]interface{})
+	afterServers := after["mcpServers"].(map[string]interface{})
+
+	// The websocket server and everything else outside our entry are untouched
+	for _, name := range []string{"remote-ws", "filesystem"} {
+		if !reflect.DeepEqual(afterServers[name], beforeServers[name]) {
+			t.Errorf("%s changed from %v to %v", name, beforeServers[name], afterServers[name])
+		}
+	}
+	if after["globalShortcut"] != "Ctrl+Space" {
+		t.Errorf("globalShortcut = %v, want it kept", after["globalShortcut"])
+	}
+
+	// Our entry switches to the stdio proxy but keeps its own settings
+	entry := afterServers["mcp-orchestrator"].(map[string]interface{})
+	if entry["command"] != "/opt/mcp/mcp-orchestrator-stdio" {
+		t.Errorf("command = %v, want the stdio proxy", entry["command"])
+	}
+	if _, hasTransport := entry["transport"]; hasTransport {
+		t.Error("the orchestrator entry kept its websocket transport")
+	}
+	if env, _ := entry["env"].(map[string]interface{}); env["MCP_LAZY_START"] != "true" {
+		t.Errorf("env = %v, want it kept", entry["env"])
+	}
+}
+
+func TestUpdateOrchestratorEntryLeavesCurrentConfigAlone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claude_desktop_config.json")
+	if err := os.WriteFile(path, []byte(existingClaudeConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	update := useStdioProxy("/opt/mcp/mcp-orchestrator-stdio")
+	if _, err := updateOrchestratorEntry(path, update); err != nil {
+		t.Fatal(err)
+	}
+	written, _ := os.ReadFile(path)
+
+	plan, err := planOrchestratorEntry(path, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.data != nil {
+		t.Error("a current entry would rewrite the config")
+	}
+	if current, _ := os.ReadFile(path); string(current) != string(written) {
+		t.Error("planning changed the config file")
+	}
+}
+
+func TestUpdateOrchestratorEntryRefusesUnparsableConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claude_desktop_config.json")
+	broken := `{"mcpServers": {"remote-ws": `
+	if err := os.WriteFile(path, []byte(broken), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := updateOrchestratorEntry(path, useStdioProxy("/opt/mcp/mcp-orchestrator-stdio")); err == nil {
+		t.Fatal("an unparsable config was replaced")
+	}
+	if current, _ := os.ReadFile(path); string(current) != broken {
+		t.Error("the unparsable config was modified")
+	}
+}
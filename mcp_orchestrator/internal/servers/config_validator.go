@@ -7,6 +7,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"mcp_orchestrator/internal/mcpclient"
+	"mcp_orchestrator/internal/version"
 )
 
 // ConfigValidator validates and fixes MCP server configurations
@@ -77,22 +80,8 @@ func (cv *ConfigValidator) ValidateServer(serverID string, server *ServerConfig)
 		cv.validateGoHighLevelServer(server, &result)
 	case "meta-ads", "google-ads":
 		cv.validatePythonServer(server, &result)
-	case "github":
-		cv.validateNodeJSServerWithCredentials(server, &result, []string{"GITHUB_PERSONAL_ACCESS_TOKEN"})
-	case "slack":
-		cv.validateNodeJSServerWithCredentials(server, &result, []string{"SLACK_BOT_TOKEN"})
-	case "notion":
-		cv.validateNodeJSServerWithCredentials(server, &result, []string{"NOTION_API_KEY"})
-	case "stripe":
-		cv.validateNodeJSServerWithCredentials(server, &result, []string{"STRIPE_SECRET_KEY"})
-	case "google-maps":
-		cv.validateNodeJSServerWithCredentials(server, &result, []string{"GOOGLE_MAPS_API_KEY"})
-	case "gmail":
-		cv.validateNodeJSServerWithCredentials(server, &result, []string{"GMAIL_CREDENTIALS"})
-	case "figma":
-		cv.validateNodeJSServerWithCredentials(server, &result, []string{"FIGMA_ACCESS_TOKEN"})
-	case "brave-search":
-		cv.validateNodeJSServerWithCredentials(server, &result, []string{"BRAVE_SEARCH_API_KEY"})
+	case "github", "slack", "notion", "stripe", "google-maps", "gmail", "figma", "brave-search":
+		cv.validateNodeJSServerWithCredentials(server, &result, RequiredCredentials(serverID))
 	case "puppeteer", "docker":
 		// These servers don't require API keys, just basic Node.js validation
 		cv.validateNodeJSServer(server, &result)
@@ -187,8 +176,7 @@ func (cv *ConfigValidator) validateGoHighLevelServer(server *ServerConfig, resul
 	}
 
 	// Check for required environment variables
-	requiredEnvVars := []string{"GHL_API_KEY", "GHL_LOCATION_ID"}
-	cv.checkRequiredEnvVars(installPath, requiredEnvVars, result)
+	cv.checkRequiredEnvVars(installPath, RequiredCredentials("gohighlevel"), result)
 }
 
 // validatePythonServer validates Python MCP servers
@@ -204,10 +192,16 @@ func (cv *ConfigValidator) validatePythonServer(server *ServerConfig, result *Va
 			Description: "Python virtual environment not found",
 		})
 
+		// Suggest the same interpreter the installer would use
+		interpreter, err := resolvePythonInterpreter(server.Build.PythonInterpreter)
+		if err != nil {
+			interpreter = "python3"
+		}
+
 		result.Suggestions = append(result.Suggestions, ValidationSuggestion{
 			Action:      "create_venv",
 			Description: "Create Python virtual environment",
-			Command:     "cd " + installPath + " && python3 -m venv venv",
+			Command:     "cd " + installPath + " && " + interpreter + " -m venv venv",
 			AutoFix:     true,
 		})
 		result.IsValid = false
@@ -257,36 +251,13 @@ func (cv *ConfigValidator) validatePythonServer(server *ServerConfig, result *Va
 	}
 
 	// Check server-specific environment variables
-	serverID := server.ID
-	var requiredEnvVars []string
-
-	switch serverID {
-	case "meta-ads":
-		requiredEnvVars = []string{"META_ACCESS_TOKEN", "META_APP_ID", "META_APP_SECRET"}
-	case "google-ads":
-		requiredEnvVars = []string{"GOOGLE_ADS_CUSTOMER_ID", "GOOGLE_ADS_DEVELOPER_TOKEN"}
-	}
-
-	cv.checkRequiredEnvVars(installPath, requiredEnvVars, result)
+	cv.checkRequiredEnvVars(installPath, RequiredCredentials(server.ID), result)
 }
 
 // validateNodeJSServer validates generic Node.js servers
 func (cv *ConfigValidator) validateNodeJSServer(server *ServerConfig, result *ValidationResult) {
 	// Check if npm/npx is available globally
-	if _, err := exec.LookPath("npm"); err != nil {
-		result.Issues = append(result.Issues, ValidationIssue{
-			Type:        "missing_npm",
-			Severity:    "error",
-			Description: "npm not found in PATH - Node.js may not be properly installed",
-		})
-
-		result.Suggestions = append(result.Suggestions, ValidationSuggestion{
-			Action:      "install_nodejs",
-			Description: "Install Node.js from https://nodejs.org/",
-			AutoFix:     false,
-		})
-		result.IsValid = false
-	}
+	cv.checkNpm(result)
 
 	if _, err := exec.LookPath("npx"); err != nil {
 		result.Issues = append(result.Issues, ValidationIssue{
@@ -310,6 +281,91 @@ func (cv *ConfigValidator) validateNodeJSServer(server *ServerConfig, result *Va
 	})
 }
 
+// ValidateTooling checks that the commands needed to install a server are on
+// PATH, so an installation can fail early with instructions instead of a
+// cryptic build error
+func (cv *ConfigValidator) ValidateTooling(server *ServerConfig) ValidationResult {
+	result := ValidationResult{
+		ServerID:    server.ID,
+		IsValid:     true,
+		Issues:      []ValidationIssue{},
+		Suggestions: []ValidationSuggestion{},
+	}
+
+	if server.RepoURL != "" {
+		if _, err := exec.LookPath("git"); err != nil {
+			result.Issues = append(result.Issues, ValidationIssue{
+				Type:        "missing_git",
+				Severity:    "error",
+				Description: "git not found in PATH - it is needed to clone the server repository",
+			})
+
+			result.Suggestions = append(result.Suggestions, ValidationSuggestion{
+				Action:      "install_git",
+				Description: "Install git with 'xcode-select --install' on macOS or from https://git-scm.com/downloads",
+				AutoFix:     false,
+			})
+			result.IsValid = false
+		}
+	}
+
+	if server.ServerType == "python" {
+		if _, err := resolvePythonInterpreter(server.Build.PythonInterpreter); err != nil {
+			result.Issues = append(result.Issues, ValidationIssue{
+				Type:        "missing_python",
+				Severity:    "error",
+				Description: fmt.Sprintf("Python not found in PATH - %v", err),
+			})
+
+			result.Suggestions = append(result.Suggestions, ValidationSuggestion{
+				Action:      "install_python",
+				Description: "Install Python 3 with 'brew install python' or from https://www.python.org/downloads/, or set MCP_PYTHON_INTERPRETER",
+				AutoFix:     false,
+			})
+			result.IsValid = false
+		}
+
+		// uv is optional; installs fall back to venv and pip without it
+		if _, err := exec.LookPath("uv"); err != nil {
+			result.Issues = append(result.Issues, ValidationIssue{
+				Type:        "missing_uv",
+				Severity:    "info",
+				Description: "uv not found in PATH - falling back to venv and pip, which is slower",
+			})
+
+			result.Suggestions = append(result.Suggestions, ValidationSuggestion{
+				Action:      "install_uv",
+				Description: "Optionally install uv with 'brew install uv' or from https://docs.astral.sh/uv/",
+				AutoFix:     false,
+			})
+		}
+		return result
+	}
+
+	cv.checkNpm(&result)
+	return result
+}
+
+// checkNpm reports a missing npm with instructions for installing Node.js
+func (cv *ConfigValidator) checkNpm(result *ValidationResult) {
+	if _, err := exec.LookPath("npm"); err == nil {
+		return
+	}
+
+	result.Issues = append(result.Issues, ValidationIssue{
+		Type:        "missing_npm",
+		Severity:    "error",
+		Description: "npm not found in PATH - Node.js may not be properly installed",
+	})
+
+	result.Suggestions = append(result.Suggestions, ValidationSuggestion{
+		Action:      "install_nodejs",
+		Description: "Install Node.js from https://nodejs.org/",
+		AutoFix:     false,
+	})
+	result.IsValid = false
+}
+
 // validateNodeJSServerWithCredentials validates Node.js servers that require specific credentials
 func (cv *ConfigValidator) validateNodeJSServerWithCredentials(server *ServerConfig, result *ValidationResult, requiredEnvVars []string) {
 	// First do basic Node.js validation
@@ -322,23 +378,11 @@ func (cv *ConfigValidator) validateNodeJSServerWithCredentials(server *ServerCon
 // checkRequiredEnvVars validates required environment variables
 func (cv *ConfigValidator) checkRequiredEnvVars(installPath string, requiredVars []string, result *ValidationResult) {
 	envFile := filepath.Join(installPath, ".env")
-	envVars := make(map[string]string)
 
 	// Load .env file if it exists
-	if data, err := os.ReadFile(envFile); err == nil {
-		lines := strings.Split(string(data), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" || strings.HasPrefix(line, "#") {
-				continue
-			}
-
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				envVars[parts[0]] = parts[1]
-			}
-		}
-	} else {
+	envVars, err := mcpclient.LoadEnvFile(envFile)
+	if err != nil {
+		envVars = make(map[string]string)
 		result.Issues = append(result.Issues, ValidationIssue{
 			Type:        "missing_env_file",
 			Severity:    "warning",
@@ -347,28 +391,26 @@ func (cv *ConfigValidator) checkRequiredEnvVars(installPath string, requiredVars
 	}
 
 	// Check required variables
-	for _, varName := range requiredVars {
-		if envVars[varName] == "" && os.Getenv(varName) == "" {
-			result.Issues = append(result.Issues, ValidationIssue{
-				Type:        "missing_env_var",
-				Severity:    "error",
-				Description: fmt.Sprintf("Required environment variable %s is not set", varName),
-				Field:       varName,
-			})
+	for _, varName := range missingVars(envVars, requiredVars) {
+		result.Issues = append(result.Issues, ValidationIssue{
+			Type:        "missing_env_var",
+			Severity:    "error",
+			Description: fmt.Sprintf("Required environment variable %s is not set", varName),
+			Field:       varName,
+		})
 
-			result.Suggestions = append(result.Suggestions, ValidationSuggestion{
-				Action:      "configure_env_var",
-				Description: fmt.Sprintf("Set %s in the server configuration", varName),
-				AutoFix:     false,
-			})
-			result.IsValid = false
-		}
+		result.Suggestions = append(result.Suggestions, ValidationSuggestion{
+			Action:      "configure_env_var",
+			Description: fmt.Sprintf("Set %s in the server configuration", varName),
+			AutoFix:     false,
+		})
+		result.IsValid = false
 	}
 }
 
 // validateClaudeDesktopConfig checks Claude Desktop configuration
 func (cv *ConfigValidator) validateClaudeDesktopConfig(result *ValidationResult) {
-	homeDir, err := os.UserHomeDir()
+	claudeConfigFile, err := ClaudeDesktopConfigPath()
 	if err != nil {
 		result.Issues = append(result.Issues, ValidationIssue{
 			Type:        "env_error",
@@ -378,8 +420,6 @@ func (cv *ConfigValidator) validateClaudeDesktopConfig(result *ValidationResult)
 		return
 	}
 
-	claudeConfigFile := filepath.Join(homeDir, "Library", "Application Support", "Claude", "claude_desktop_config.json")
-
 	if _, err := os.Stat(claudeConfigFile); os.IsNotExist(err) {
 		result.Issues = append(result.Issues, ValidationIssue{
 			Type:        "missing_claude_config",
@@ -465,10 +505,43 @@ func (cv *ConfigValidator) validateClaudeDesktopConfig(result *ValidationResult)
 				AutoFix:     true,
 			})
 			result.IsValid = false
+			return
 		}
+
+		cv.validateOrchestratorVersion(orchestratorConfig.Command, result)
 	}
 }
 
+// validateOrchestratorVersion warns when the stdio proxy Claude Desktop runs
+// is from a different build than this orchestrator, since a stale proxy can
+// misunderstand the orchestrator's API
+func (cv *ConfigValidator) validateOrchestratorVersion(command string, result *ValidationResult) {
+	proxyVersion, err := StdioBinaryVersion(command)
+	if err == nil && proxyVersion == version.Version {
+		return
+	}
+
+	description := fmt.Sprintf("MCP Orchestrator stdio proxy at %s is version %s but the orchestrator is version %s",
+		command, proxyVersion, version.Version)
+	if err != nil {
+		description = fmt.Sprintf("Could not determine the version of the MCP Orchestrator stdio proxy (orchestrator is version %s): %v",
+			version.Version, err)
+	}
+
+	result.Issues = append(result.Issues, ValidationIssue{
+		Type:        "orchestrator_version_mismatch",
+		Severity:    "warning",
+		Description: description,
+	})
+
+	result.Suggestions = append(result.Suggestions, ValidationSuggestion{
+		Action:      "update_orchestrator_binary",
+		Description: "Point Claude Desktop at the stdio proxy built with this orchestrator",
+		Command:     "./build.sh",
+		AutoFix:     true,
+	})
+}
+
 // AutoFixIssues attempts to automatically fix validation issues
 func (cv *ConfigValidator) AutoFixIssues(result ValidationResult) error {
 	for _, suggestion := range result.Suggestions {
@@ -489,6 +562,8 @@ func (cv *ConfigValidator) AutoFixIssues(result ValidationResult) error {
 			return cv.addOrchestratorConfig()
 		case "fix_orchestrator_path":
 			return cv.fixOrchestratorPath()
+		case "update_orchestrator_binary":
+			return cv.updateOrchestratorBinary()
 		}
 	}
 
@@ -529,16 +604,13 @@ func (cv *ConfigValidator) runCommand(command string) error {
 
 // createClaudeConfig creates a basic Claude Desktop configuration
 func (cv *ConfigValidator) createClaudeConfig() error {
-	homeDir, err := os.UserHomeDir()
+	claudeConfigFile, err := ClaudeDesktopConfigPath()
 	if err != nil {
 		return err
 	}
 
-	claudeConfigDir := filepath.Join(homeDir, "Library", "Application Support", "Claude")
-	claudeConfigFile := filepath.Join(claudeConfigDir, "claude_desktop_config.json")
-
 	// Create directory if it doesn't exist
-	if err := os.MkdirAll(claudeConfigDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(claudeConfigFile), 0755); err != nil {
 		return err
 	}
 
@@ -556,52 +628,38 @@ func (cv *ConfigValidator) createClaudeConfig() error {
 
 // addOrchestratorConfig adds MCP Orchestrator to Claude Desktop config
 func (cv *ConfigValidator) addOrchestratorConfig() error {
-	homeDir, err := os.UserHomeDir()
+	claudeConfigFile, err := ClaudeDesktopConfigPath()
 	if err != nil {
 		return err
 	}
 
-	claudeConfigFile := filepath.Join(homeDir, "Library", "Application Support", "Claude", "claude_desktop_config.json")
-
-	// Read existing config
-	data, err := os.ReadFile(claudeConfigFile)
-	if err != nil {
-		return err
-	}
-
-	var config ClaudeDesktopConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return err
-	}
-
-	if config.MCPServers == nil {
-		config.MCPServers = make(map[string]MCPServerConfig)
-	}
-
-	// Add orchestrator configuration
+	// Add orchestrator configuration, preferring the proxy built alongside this binary
 	stdioBinaryPath := "/usr/local/bin/mcp-orchestrator-stdio"
-	config.MCPServers["mcp-orchestrator"] = MCPServerConfig{
-		Command: stdioBinaryPath,
-		Args:    []string{},
-	}
-
-	// Write updated config
-	data, err = json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return err
+	if detected, err := StdioBinaryPath(); err == nil {
+		if _, err := os.Stat(detected); err == nil {
+			stdioBinaryPath = detected
+		}
 	}
 
-	return os.WriteFile(claudeConfigFile, data, 0644)
+	// Other servers in the config are left untouched
+	_, err = updateOrchestratorEntry(claudeConfigFile, func(entry map[string]interface{}) {
+		entry["command"] = stdioBinaryPath
+		delete(entry, "transport")
+	})
+	return err
 }
 
 // fixOrchestratorPath updates the orchestrator binary path
 func (cv *ConfigValidator) fixOrchestratorPath() error {
-	// Try common paths
-	possiblePaths := []string{
+	// Try the proxy built alongside this binary, then common paths
+	var possiblePaths []string
+	if detected, err := StdioBinaryPath(); err == nil {
+		possiblePaths = append(possiblePaths, detected)
+	}
+	possiblePaths = append(possiblePaths,
 		"/usr/local/bin/mcp-orchestrator-stdio",
 		"/opt/homebrew/bin/mcp-orchestrator-stdio",
-		filepath.Join(os.Getenv("HOME"), "Downloads", "n8", "mcp_orchestrator", "bin", "mcp-orchestrator-stdio"),
-	}
+	)
 
 	var validPath string
 	for _, path := range possiblePaths {
@@ -615,38 +673,34 @@ func (cv *ConfigValidator) fixOrchestratorPath() error {
 		return fmt.Errorf("could not find mcp-orchestrator-stdio binary in common locations")
 	}
 
-	homeDir, err := os.UserHomeDir()
+	claudeConfigFile, err := ClaudeDesktopConfigPath()
 	if err != nil {
 		return err
 	}
 
-	claudeConfigFile := filepath.Join(homeDir, "Library", "Application Support", "Claude", "claude_desktop_config.json")
+	// Update orchestrator path; other servers in the config are left untouched
+	_, err = updateOrchestratorEntry(claudeConfigFile, func(entry map[string]interface{}) {
+		entry["command"] = validPath
+	})
+	return err
+}
 
-	// Read and update config
-	data, err := os.ReadFile(claudeConfigFile)
+// updateOrchestratorBinary points Claude Desktop at the stdio proxy next to
+// this binary, provided it is the same version as the orchestrator
+func (cv *ConfigValidator) updateOrchestratorBinary() error {
+	stdioPath, err := StdioBinaryPath()
 	if err != nil {
 		return err
 	}
 
-	var config ClaudeDesktopConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return err
-	}
-
-	if config.MCPServers == nil {
-		config.MCPServers = make(map[string]MCPServerConfig)
-	}
-
-	// Update orchestrator path
-	orchestratorConfig := config.MCPServers["mcp-orchestrator"]
-	orchestratorConfig.Command = validPath
-	config.MCPServers["mcp-orchestrator"] = orchestratorConfig
-
-	// Write updated config
-	data, err = json.MarshalIndent(config, "", "  ")
+	proxyVersion, err := StdioBinaryVersion(stdioPath)
 	if err != nil {
-		return err
+		return fmt.Errorf("no usable stdio proxy next to the orchestrator, rebuild both with ./build.sh: %v", err)
+	}
+	if proxyVersion != version.Version {
+		return fmt.Errorf("stdio proxy at %s is version %s, not %s; rebuild both with ./build.sh", stdioPath, proxyVersion, version.Version)
 	}
 
-	return os.WriteFile(claudeConfigFile, data, 0644)
+	_, _, err = ConfigureClaudeDesktop(stdioPath)
+	return err
 }
@@ -0,0 +1,53 @@
+package servers
+
+import (
+	"os"
+	"path/filepath"
+
+	"mcp_orchestrator/internal/mcpclient"
+)
+
+// requiredCredentials lists the environment variables each server needs
+// before its tools can work. Servers not listed need none.
+var requiredCredentials = map[string][]string{
+	"gohighlevel":  {"GHL_API_KEY", "GHL_LOCATION_ID"},
+	"meta-ads":     {"META_ACCESS_TOKEN", "META_APP_ID", "META_APP_SECRET"},
+	"google-ads":   {"GOOGLE_ADS_CUSTOMER_ID", "GOOGLE_ADS_DEVELOPER_TOKEN"},
+	"github":       {"GITHUB_PERSONAL_ACCESS_TOKEN"},
+	"slack":        {"SLACK_BOT_TOKEN"},
+	"notion":       {"NOTION_API_KEY"},
+	"stripe":       {"STRIPE_SECRET_KEY"},
+	"google-maps":  {"GOOGLE_MAPS_API_KEY"},
+	"gmail":        {"GMAIL_CREDENTIALS"},
+	"figma":        {"FIGMA_ACCESS_TOKEN"},
+	"brave-search": {"BRAVE_SEARCH_API_KEY"},
+}
+
+// RequiredCredentials returns the environment variables a server needs
+func RequiredCredentials(serverID string) []string {
+	return requiredCredentials[serverID]
+}
+
+// MissingCredentials returns the required credentials of a server that are
+// set neither in the .env file of its install directory nor in the
+// environment. Only the .env file is read, so it is cheap enough to call
+// while listing tools.
+func MissingCredentials(serverID, installPath string) []string {
+	envVars, err := mcpclient.LoadEnvFile(filepath.Join(installPath, ".env"))
+	if err != nil {
+		envVars = make(map[string]string)
+	}
+	return missingVars(envVars, RequiredCredentials(serverID))
+}
+
+// missingVars returns the required variables that are empty in envVars and
+// in the environment
+func missingVars(envVars map[string]string, required []string) []string {
+	missing := []string{}
+	for _, varName := range required {
+		if envVars[varName] == "" && os.Getenv(varName) == "" {
+			missing = append(missing, varName)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,86 @@
+package servers
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// DiscoveryCacheEntry describes the tools last discovered for a server
+type DiscoveryCacheEntry struct {
+	ServerID     string    `json:"server_id"`
+	Status       string    `json:"status"` // The server's status, e.g. "running"
+	ToolsCount   int       `json:"tools_count"`
+	DiscoveredAt time.Time `json:"discovered_at"`
+	Stale        bool      `json:"stale"` // Rediscovered on the next lookup if the server is running
+}
+
+// DiscoveryCache returns the cached tool listing of every server, sorted by server ID
+func (m *Manager) DiscoveryCache() []DiscoveryCacheEntry {
+	m.toolIndexMu.Lock()
+	listings := make(map[string]toolListing, len(m.toolIndex))
+	for serverID, listing := range m.toolIndex {
+		listings[serverID] = listing
+	}
+	m.toolIndexMu.Unlock()
+
+	m.mu.RLock()
+	entries := make([]DiscoveryCacheEntry, 0, len(listings))
+	for serverID, listing := range listings {
+		entry := DiscoveryCacheEntry{
+			ServerID:     serverID,
+			Status:       "not_installed",
+			ToolsCount:   len(listing.tools),
+			DiscoveredAt: listing.discoveredAt,
+			Stale:        time.Since(listing.discoveredAt) >= toolIndexTTL,
+		}
+		if server, exists := m.servers[serverID]; exists {
+			entry.Status = server.Status
+			entry.Stale = entry.Stale || listing.discoveredAt.Before(server.ToolsRefreshedAt)
+		}
+		entries = append(entries, entry)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ServerID < entries[j].ServerID
+	})
+	return entries
+}
+
+// ClearDiscoveryCache drops the cached tools of one server, or of every
+// server when serverID is empty, and returns the servers cleared. The
+// servers' tools_refreshed_at is moved forward too, so stdio proxies drop
+// their cached tools for them on the next lookup.
+func (m *Manager) ClearDiscoveryCache(serverID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cleared := []string{}
+	if serverID != "" {
+		if _, exists := m.servers[serverID]; !exists {
+			return nil, fmt.Errorf("server %s not found", serverID)
+		}
+		cleared = []string{serverID}
+	} else {
+		for id := range m.servers {
+			cleared = append(cleared, id)
+		}
+		sort.Strings(cleared)
+	}
+
+	now := time.Now()
+	m.toolIndexMu.Lock()
+	for _, id := range cleared {
+		delete(m.toolIndex, id)
+		m.servers[id].ToolsRefreshedAt = now
+	}
+	m.toolIndexMu.Unlock()
+
+	if err := m.saveServerState(); err != nil {
+		log.Printf("Warning: Failed to save server state after clearing the discovery cache: %v", err)
+	}
+
+	return cleared, nil
+}
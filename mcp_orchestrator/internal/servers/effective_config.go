@@ -0,0 +1,112 @@
+package servers
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// EffectiveConfig is the launch configuration StartServer would use for a server
+type EffectiveConfig struct {
+	ServerID            string            `json:"server_id"`
+	ServerType          string            `json:"server_type"`
+	Status              string            `json:"status"`
+	Command             string            `json:"command"`
+	ResolvedCommand     string            `json:"resolved_command,omitempty"` // Absolute path found on PATH
+	CommandError        string            `json:"command_error,omitempty"`
+	Args                []string          `json:"args"` // Secrets expanded from placeholders masked
+	WorkingDir          string            `json:"working_dir"`
+	SubPath             string            `json:"sub_path,omitempty"`
+	Env                 map[string]string `json:"env"`                  // Server environment, secrets masked
+	InheritsEnvironment bool              `json:"inherits_environment"` // The orchestrator's environment is passed through too
+	DependsOn           []string          `json:"depends_on,omitempty"`
+}
+
+// secretKeyMarkers identify environment variables whose values are masked
+var secretKeyMarkers = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "CREDENTIAL", "AUTH"}
+
+// GetEffectiveConfig resolves a server's command, arguments, working directory
+// and environment exactly as StartServer would, without starting it. Secret
+// values are masked, including any expanded into the command or arguments.
+func (m *Manager) GetEffectiveConfig(serverID string) (*EffectiveConfig, error) {
+	spec, err := m.resolveServerSpec(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	server := m.servers[serverID]
+	expand := m.placeholders(server)
+	expand.Masked = true
+	command, args, _ := m.launchSpec(server, expand)
+	config := &EffectiveConfig{
+		ServerID:            serverID,
+		ServerType:          server.ServerType,
+		Status:              server.Status,
+		Command:             command,
+		Args:                args,
+		WorkingDir:          spec.Dir,
+		SubPath:             server.SubPath,
+		Env:                 make(map[string]string, len(spec.Env)),
+		InheritsEnvironment: true,
+		DependsOn:           server.DependsOn,
+	}
+	for key, value := range spec.Env {
+		config.Env[key] = maskSecret(key, value)
+	}
+	m.mu.RUnlock()
+
+	if resolved, err := exec.LookPath(spec.Command); err != nil {
+		config.CommandError = err.Error()
+	} else {
+		config.ResolvedCommand = resolved
+	}
+
+	return config, nil
+}
+
+// MaskArguments returns a copy of tool call arguments with the values of
+// secret-looking keys masked, at any depth, for display
+func MaskArguments(arguments map[string]interface{}) map[string]interface{} {
+	masked := make(map[string]interface{}, len(arguments))
+	for key, value := range arguments {
+		masked[key] = maskArgument(key, value)
+	}
+	return masked
+}
+
+// maskArgument masks one argument value under key. Secrets that aren't
+// strings are masked whole.
+func maskArgument(key string, value interface{}) interface{} {
+	switch typed := value.(type) {
+	case string:
+		return maskSecret(key, typed)
+	case map[string]interface{}:
+		return MaskArguments(typed)
+	case []interface{}:
+		items := make([]interface{}, len(typed))
+		for i, item := range typed {
+			items[i] = maskArgument(key, item)
+		}
+		return items
+	}
+
+	if maskSecret(key, "") != "" {
+		return "****"
+	}
+	return value
+}
+
+// maskSecret hides the value of secret-looking variables, keeping the last
+// few characters of long values so the right credential can be recognized
+func maskSecret(key, value string) string {
+	upperKey := strings.ToUpper(key)
+	for _, marker := range secretKeyMarkers {
+		if strings.Contains(upperKey, marker) {
+			if len(value) > 12 {
+				return "****" + value[len(value)-4:]
+			}
+			return "****"
+		}
+	}
+	return value
+}
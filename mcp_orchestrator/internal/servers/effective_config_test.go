@@ -0,0 +1,38 @@
+package servers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEffectiveConfigMasksExpandedSecrets(t *testing.T) {
+	m := &Manager{
+		servers: map[string]*ServerConfig{
+			"search": {
+				ID:          "search",
+				ServerType:  "nodejs",
+				Command:     "node",
+				Args:        []string{"dist/index.js", "--api-key=${SEARCH_API_KEY}", "--region=${REGION}"},
+				InstallPath: t.TempDir(),
+				Env:         map[string]string{"SEARCH_API_KEY": "sk-0123456789abcdef"},
+			},
+		},
+		sharedEnv: map[string]string{"REGION": "eu-west-1"},
+	}
+
+	config, err := m.GetEffectiveConfig("search")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joined := strings.Join(config.Args, " ")
+	if strings.Contains(joined, "sk-0123456789abcdef") {
+		t.Errorf("args leak the API key: %q", config.Args)
+	}
+	if !strings.Contains(joined, "--api-key=****cdef") || !strings.Contains(joined, "--region=eu-west-1") {
+		t.Errorf("unexpected args %q", config.Args)
+	}
+	if config.Env["SEARCH_API_KEY"] != "****cdef" {
+		t.Errorf("env shows %q for the API key", config.Env["SEARCH_API_KEY"])
+	}
+}
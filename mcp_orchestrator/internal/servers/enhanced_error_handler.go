@@ -60,6 +60,10 @@ func (eh *ErrorHandler) HandleInstallationError(err error, stage string) *Enhanc
 		enhancedErr.Suggestions = eh.getEnvFileSuggestions(errorMsg)
 	case "validation":
 		enhancedErr.Suggestions = eh.getValidationSuggestions(errorMsg)
+	case "integrity":
+		enhancedErr.Suggestions = eh.getIntegritySuggestions(errorMsg)
+	case "pre_install", "post_install":
+		enhancedErr.Suggestions = eh.getInstallHookSuggestions(errorMsg)
 	default:
 		enhancedErr.Suggestions = eh.getGenericSuggestions(errorMsg)
 	}
@@ -67,6 +71,33 @@ func (eh *ErrorHandler) HandleInstallationError(err error, stage string) *Enhanc
 	return enhancedErr
 }
 
+// HandleToolingError creates detailed error information when commands needed
+// for installation are missing
+func (eh *ErrorHandler) HandleToolingError(result ValidationResult) *EnhancedError {
+	var missing []string
+	for _, issue := range result.Issues {
+		if issue.Severity == "error" {
+			missing = append(missing, issue.Description)
+		}
+	}
+
+	var suggestions []string
+	for _, suggestion := range result.Suggestions {
+		suggestions = append(suggestions, suggestion.Description)
+	}
+	suggestions = append(suggestions, "Restart the orchestrator after installing so it picks up the updated PATH")
+
+	return &EnhancedError{
+		Type:        "installation_error",
+		Message:     fmt.Sprintf("Required tooling is missing for server %s", eh.serverID),
+		Details:     strings.Join(missing, "; "),
+		Context:     eh.context,
+		Timestamp:   time.Now(),
+		Severity:    "error",
+		Suggestions: suggestions,
+	}
+}
+
 // HandleStartupError creates detailed error information for startup failures
 func (eh *ErrorHandler) HandleStartupError(err error) *EnhancedError {
 	errorMsg := err.Error()
@@ -273,6 +304,41 @@ func (eh *ErrorHandler) getValidationSuggestions(errorMsg string) []string {
 	return suggestions
 }
 
+// Integrity error suggestions
+func (eh *ErrorHandler) getIntegritySuggestions(errorMsg string) []string {
+	suggestions := []string{}
+
+	if strings.Contains(errorMsg, "does not match") {
+		suggestions = append(suggestions, "The repository did not deliver the pinned commit; the checkout was removed without running it")
+		suggestions = append(suggestions, "Confirm the pinned commit with the server's maintainers before retrying")
+	}
+
+	suggestions = append(suggestions, "Check that the pinned commit exists in the repository")
+	suggestions = append(suggestions, "Install without a pinned commit only if you trust the repository's current state")
+
+	return suggestions
+}
+
+// Install hook error suggestions
+func (eh *ErrorHandler) getInstallHookSuggestions(errorMsg string) []string {
+	suggestions := []string{}
+
+	if strings.Contains(errorMsg, "rejected") {
+		suggestions = append(suggestions, "Use a command on PATH or a relative path inside the install directory")
+	}
+	if strings.Contains(errorMsg, "timed out") {
+		suggestions = append(suggestions, "Raise the hook's timeout_seconds if the setup step is slow")
+	}
+	if strings.Contains(errorMsg, "executable file not found") {
+		suggestions = append(suggestions, "Install the command the hook runs, or check its spelling")
+	}
+
+	suggestions = append(suggestions, "Check the server logs for the hook's output")
+	suggestions = append(suggestions, "Run the hook command by hand in the install directory to reproduce the failure")
+
+	return suggestions
+}
+
 // Startup error suggestions
 func (eh *ErrorHandler) getStartupSuggestions(errorMsg string) []string {
 	suggestions := []string{}
@@ -345,3 +411,35 @@ func (eh *ErrorHandler) getGenericSuggestions(errorMsg string) []string {
 
 	return suggestions
 }
+
+// ErrorFilter selects a subset of retained errors. Empty fields match everything.
+type ErrorFilter struct {
+	Severity string
+	Type     string
+	Since    time.Time
+}
+
+// Matches reports whether an error passes the filter
+func (f ErrorFilter) Matches(err *EnhancedError) bool {
+	if f.Severity != "" && !strings.EqualFold(err.Severity, f.Severity) {
+		return false
+	}
+	if f.Type != "" && !strings.EqualFold(err.Type, f.Type) {
+		return false
+	}
+	if !f.Since.IsZero() && err.Timestamp.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// FilterErrors returns the errors that pass the filter, preserving order
+func FilterErrors(errs []*EnhancedError, filter ErrorFilter) []*EnhancedError {
+	result := make([]*EnhancedError, 0, len(errs))
+	for _, err := range errs {
+		if filter.Matches(err) {
+			result = append(result, err)
+		}
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package servers
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// GitAuth holds optional credentials for cloning private server repositories
+type GitAuth struct {
+	Token      string // HTTPS access token (e.g. a GitHub personal access token)
+	SSHKeyPath string // Private key used for SSH clone URLs
+}
+
+// IsEmpty reports whether no credentials are set
+func (a GitAuth) IsEmpty() bool {
+	return a.Token == "" && a.SSHKeyPath == ""
+}
+
+// gitAuthFromEnv reads default clone credentials from the environment
+func gitAuthFromEnv() GitAuth {
+	return GitAuth{
+		Token:      os.Getenv("MCP_GIT_TOKEN"),
+		SSHKeyPath: os.Getenv("MCP_GIT_SSH_KEY"),
+	}
+}
+
+// tokenUsername is the user name sent with an access token; hosts ignore it
+const tokenUsername = "x-access-token"
+
+// cloneURL returns the URL to clone with, embedding the token for HTTPS remotes
+func (a GitAuth) cloneURL(repoURL string) (string, error) {
+	if a.Token == "" || !strings.HasPrefix(repoURL, "https://") {
+		return repoURL, nil
+	}
+
+	parsed, err := url.Parse(repoURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid repository URL: %v", err)
+	}
+	parsed.User = a.userinfo()
+
+	return parsed.String(), nil
+}
+
+// userinfo returns the credentials embedded in HTTPS clone URLs
+func (a GitAuth) userinfo() *url.Userinfo {
+	return url.UserPassword(tokenUsername, a.Token)
+}
+
+// env returns the environment for git commands using these credentials
+func (a GitAuth) env() []string {
+	// Fail instead of hanging on an interactive credential prompt
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if a.SSHKeyPath != "" {
+		env = append(env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %q -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new", a.SSHKeyPath))
+	}
+
+	return env
+}
+
+// redact removes the token from text destined for logs or error messages,
+// both as given and as git prints it inside a clone URL, where it is
+// escaped the way URL userinfo is
+func (a GitAuth) redact(text string) string {
+	if a.Token == "" {
+		return text
+	}
+
+	escaped := strings.TrimPrefix(a.userinfo().String(), tokenUsername+":")
+	text = strings.ReplaceAll(text, escaped, "***")
+	return strings.ReplaceAll(text, a.Token, "***")
+}
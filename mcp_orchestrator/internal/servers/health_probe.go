@@ -0,0 +1,112 @@
+package servers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mcp_orchestrator/internal/performance"
+)
+
+const (
+	healthProbeTimeout = 5 * time.Second  // Bounds a single MCP ping
+	healthProbeTTL     = 10 * time.Second // Probe results are reused for this long
+)
+
+// ServerHealth is the result of probing a server over MCP
+type ServerHealth struct {
+	ServerID  string    `json:"server_id"`
+	Status    string    `json:"status"` // "healthy", "unresponsive" or "not_running"
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached"`
+}
+
+// ProbeServer checks that a server answers an MCP ping, reusing a recent
+// result so frequent health polling doesn't load the server
+func (m *Manager) ProbeServer(serverID string) (ServerHealth, error) {
+	if _, err := m.GetServer(serverID); err != nil {
+		return ServerHealth{}, err
+	}
+
+	m.probesMu.Lock()
+	cached, exists := m.probes[serverID]
+	m.probesMu.Unlock()
+	if exists && time.Since(cached.CheckedAt) < healthProbeTTL {
+		cached.Cached = true
+		return cached, nil
+	}
+
+	health := m.probe(serverID)
+
+	m.probesMu.Lock()
+	m.probes[serverID] = health
+	m.probesMu.Unlock()
+
+	return health, nil
+}
+
+// ProbeRunningServers probes every running server concurrently
+func (m *Manager) ProbeRunningServers() []ServerHealth {
+	var running []string
+	for _, server := range m.ListServers() {
+		if server.Status == "running" {
+			running = append(running, server.ID)
+		}
+	}
+
+	results := make([]ServerHealth, len(running))
+	var wg sync.WaitGroup
+	for i, serverID := range running {
+		wg.Add(1)
+		go func(i int, serverID string) {
+			defer wg.Done()
+			// Servers removed since listing simply report not_running
+			health, err := m.ProbeServer(serverID)
+			if err != nil {
+				health = ServerHealth{ServerID: serverID, Status: "not_running", CheckedAt: time.Now()}
+			}
+			results[i] = health
+		}(i, serverID)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// probe pings a server through its connection pool
+func (m *Manager) probe(serverID string) ServerHealth {
+	health := ServerHealth{ServerID: serverID, CheckedAt: time.Now()}
+
+	if !m.isRunning(serverID) {
+		health.Status = "not_running"
+		return health
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthProbeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := m.loadBalancer.GetConnection(ctx, serverID)
+	if err != nil {
+		health.Status = "unresponsive"
+		health.Error = err.Error()
+		return health
+	}
+	defer m.loadBalancer.ReturnConnection(serverID, conn)
+
+	// Any response, even a JSON-RPC error, proves the server is responsive
+	_, err = conn.Call(ctx, "ping", nil)
+	health.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		if _, isRPCError := err.(*performance.RPCError); !isRPCError {
+			health.Status = "unresponsive"
+			health.Error = err.Error()
+			return health
+		}
+	}
+
+	health.Status = "healthy"
+	return health
+}
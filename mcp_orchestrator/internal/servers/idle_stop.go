@@ -0,0 +1,154 @@
+package servers
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// defaultIdleCheckInterval is how often running servers are checked for idleness
+const defaultIdleCheckInterval = time.Minute
+
+// maxLifecycleEvents bounds the auto-stop and auto-start events kept in memory
+const maxLifecycleEvents = 100
+
+// LifecycleEvent records a server the orchestrator stopped or started on its
+// own, reported alongside other diagnostics
+type LifecycleEvent struct {
+	ServerID    string    `json:"server_id"`
+	Type        string    `json:"type"` // "auto_stop", "auto_start" or "auto_start_failed"
+	Description string    `json:"description"`
+	Timestamp   time.Time `json:"timestamp"`
+	Severity    string    `json:"severity"`
+	Resolution  string    `json:"resolution,omitempty"`
+}
+
+// RecordActivity marks a server as used now, postponing its idle stop. A
+// server the idle supervisor stopped is started again first.
+func (m *Manager) RecordActivity(serverID string) error {
+	server, err := m.GetServer(serverID)
+	if err != nil {
+		return err
+	}
+
+	if server.IdleStopped && !m.isRunning(serverID) {
+		if err := m.StartServer(serverID); err != nil {
+			m.recordLifecycleEvent(serverID, "auto_start_failed",
+				fmt.Sprintf("Failed to start idle server %s for a tool call: %v", serverID, err), "error",
+				"Check the server's errors and start it from the MCP Orchestrator UI")
+			return fmt.Errorf("failed to start idle server %s: %v", serverID, err)
+		}
+		m.recordLifecycleEvent(serverID, "auto_start",
+			fmt.Sprintf("Started idle server %s for a tool call", serverID), "info", "")
+	}
+
+	m.activityMu.Lock()
+	m.lastUsed[serverID] = time.Now()
+	m.activityMu.Unlock()
+
+	return nil
+}
+
+// GetLifecycleEvents returns recent auto-stop and auto-start events, oldest first
+func (m *Manager) GetLifecycleEvents() []LifecycleEvent {
+	m.activityMu.Lock()
+	defer m.activityMu.Unlock()
+
+	events := make([]LifecycleEvent, len(m.lifecycleEvents))
+	copy(events, m.lifecycleEvents)
+	return events
+}
+
+// superviseIdleServers stops running servers that have gone without tool
+// calls for longer than the idle timeout set for them in the active profile.
+// Servers without an idle_timeout_seconds are never stopped.
+func (m *Manager) superviseIdleServers(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for serverID, timeout := range m.idleTimeouts() {
+			if m.idleFor(serverID) < timeout {
+				continue
+			}
+
+			if err := m.StopServer(serverID); err != nil {
+				log.Printf("Warning: Failed to stop idle server %s: %v", serverID, err)
+				continue
+			}
+
+			m.mu.Lock()
+			if server, exists := m.servers[serverID]; exists {
+				server.IdleStopped = true
+			}
+			if err := m.saveServerState(); err != nil {
+				log.Printf("Warning: Failed to save server state: %v", err)
+			}
+			m.mu.Unlock()
+
+			m.recordLifecycleEvent(serverID, "auto_stop",
+				fmt.Sprintf("Stopped server %s after %v without tool calls", serverID, timeout), "info",
+				"The server starts again on its next tool call")
+		}
+	}
+}
+
+// idleTimeouts returns the running servers the active profile sets an idle timeout for
+func (m *Manager) idleTimeouts() map[string]time.Duration {
+	timeouts := make(map[string]time.Duration)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.profiles == nil {
+		return timeouts
+	}
+	profile := m.profiles.GetActiveProfile()
+	if profile == nil {
+		return timeouts
+	}
+
+	for serverID, config := range profile.ServerConfigs {
+		server, exists := m.servers[serverID]
+		if !exists || server.Status != "running" || config.IdleTimeoutSeconds <= 0 {
+			continue
+		}
+		timeouts[serverID] = time.Duration(config.IdleTimeoutSeconds) * time.Second
+	}
+
+	return timeouts
+}
+
+// idleFor returns how long a server has gone without tool calls. A server
+// with no recorded calls counts from when the supervisor first saw it.
+func (m *Manager) idleFor(serverID string) time.Duration {
+	m.activityMu.Lock()
+	defer m.activityMu.Unlock()
+
+	lastUsed, exists := m.lastUsed[serverID]
+	if !exists {
+		m.lastUsed[serverID] = time.Now()
+		return 0
+	}
+	return time.Since(lastUsed)
+}
+
+// recordLifecycleEvent keeps an auto-stop or auto-start event, dropping the oldest beyond the limit
+func (m *Manager) recordLifecycleEvent(serverID, eventType, description, severity, resolution string) {
+	log.Printf("%s", description)
+
+	m.activityMu.Lock()
+	defer m.activityMu.Unlock()
+
+	m.lifecycleEvents = append(m.lifecycleEvents, LifecycleEvent{
+		ServerID:    serverID,
+		Type:        eventType,
+		Description: description,
+		Timestamp:   time.Now(),
+		Severity:    severity,
+		Resolution:  resolution,
+	})
+	if len(m.lifecycleEvents) > maxLifecycleEvents {
+		m.lifecycleEvents = m.lifecycleEvents[len(m.lifecycleEvents)-maxLifecycleEvents:]
+	}
+}
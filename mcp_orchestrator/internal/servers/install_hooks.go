@@ -0,0 +1,105 @@
+package servers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// InstallHook is a setup command run in a server's install directory during
+// installation, e.g. to download a model or perform a one-time auth
+type InstallHook struct {
+	Command        string   `json:"command"`                   // A command on PATH or a path inside the install directory
+	Args           []string `json:"args,omitempty"`            // Arguments; ${INSTALL_PATH}, ${SERVER_ID} and ${VAR} are expanded
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"` // Defaults to 10 minutes
+}
+
+// defaultInstallHookTimeout leaves room for hooks that download large files
+const defaultInstallHookTimeout = 10 * time.Minute
+
+// runInstallHook runs a pre_install or post_install hook in the server's
+// install directory with the server's environment and install config. The
+// hook's output goes to the log and the server's logs; a nonzero exit fails
+// the hook. A nil hook is a no-op.
+func (m *Manager) runInstallHook(server *ServerConfig, stage string, hook *InstallHook, config map[string]string) error {
+	if hook == nil || hook.Command == "" {
+		return nil
+	}
+
+	expand := m.placeholders(server)
+	command, err := resolveHookCommand(server.InstallPath, expand.Expand(hook.Command))
+	if err != nil {
+		return fmt.Errorf("%s hook rejected: %v", stage, err)
+	}
+	args := expand.ExpandAll(hook.Args)
+
+	// Log secrets expanded into the arguments masked
+	expand.Masked = true
+	loggedArgs := expand.ExpandAll(hook.Args)
+
+	timeout := defaultInstallHookTimeout
+	if hook.TimeoutSeconds > 0 {
+		timeout = time.Duration(hook.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = server.InstallPath
+	cmd.Env = hookEnv(server, m.serverEnv(server), config)
+
+	log.Printf("Running %s hook for %s: %s %s", stage, server.Name, command, strings.Join(loggedArgs, " "))
+	output, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line == "" {
+			continue
+		}
+		log.Printf("[%s %s] %s", server.ID, stage, line)
+		server.Logs = append(server.Logs, fmt.Sprintf("[%s] %s", stage, line))
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s hook timed out after %v", stage, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook failed: %v%s", stage, err, lastOutputLine(output))
+	}
+
+	return nil
+}
+
+// resolveHookCommand keeps hook commands inside the install directory: a
+// bare name is looked up on PATH, and a path must be relative and stay
+// within installPath
+func resolveHookCommand(installPath, command string) (string, error) {
+	if !strings.ContainsRune(command, '/') && !strings.ContainsRune(command, filepath.Separator) {
+		return command, nil
+	}
+	if filepath.IsAbs(command) {
+		return "", fmt.Errorf("command %s must be relative to the install directory", command)
+	}
+
+	resolved := filepath.Join(installPath, command)
+	rel, err := filepath.Rel(installPath, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("command %s is outside the install directory", command)
+	}
+
+	return resolved, nil
+}
+
+// hookEnv returns the environment of an install hook: the process
+// environment, then the server's environment (shared variables beneath its
+// Env) and install config, plus INSTALL_PATH and SERVER_ID
+func hookEnv(server *ServerConfig, serverEnv, config map[string]string) []string {
+	env := MergeEnv(os.Environ(), serverEnv)
+	for key, value := range config {
+		env = append(env, fmt.Sprintf("%s=%s", key, value))
+	}
+	return append(env, "INSTALL_PATH="+server.InstallPath, "SERVER_ID="+server.ID)
+}
@@ -0,0 +1,113 @@
+package servers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// instanceLockFile records the PID of the orchestrator using a base path
+const instanceLockFile = "orchestrator.lock"
+
+// InstanceLock is held by the one orchestrator allowed to use a base path, so
+// a second instance can't overwrite its state or fight over its servers
+type InstanceLock struct {
+	path string
+}
+
+// DefaultBasePath returns the directory servers and orchestrator data are stored in
+func DefaultBasePath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".mcp_orchestrator")
+}
+
+// AcquireInstanceLock claims basePath for this process. It fails if another
+// live orchestrator holds the lock; a lock left by one that exited without
+// releasing it is taken over.
+func AcquireInstanceLock(basePath string) (*InstanceLock, error) {
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", basePath, err)
+	}
+	path := filepath.Join(basePath, instanceLockFile)
+
+	// A stale lock is removed once and the claim retried
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, writeErr := file.WriteString(strconv.Itoa(os.Getpid()))
+			file.Close()
+			if writeErr != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("failed to write lock file %s: %v", path, writeErr)
+			}
+			return &InstanceLock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file %s: %v", path, err)
+		}
+
+		pid, alive := lockHolder(path)
+		if alive {
+			return nil, fmt.Errorf("another orchestrator (PID %d) is already using %s; stop it first, or remove %s if it is not an orchestrator", pid, basePath, path)
+		}
+
+		log.Printf("Warning: Removing stale lock file %s left by PID %d", path, pid)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale lock file %s: %v", path, err)
+		}
+	}
+
+	return nil, fmt.Errorf("failed to acquire lock file %s: another orchestrator is starting", path)
+}
+
+// Release removes the lock file if this process still holds it
+func (l *InstanceLock) Release() {
+	if pid, _ := lockHolder(l.path); pid != os.Getpid() {
+		return
+	}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: Failed to remove lock file %s: %v", l.path, err)
+	}
+}
+
+// lockHolder returns the PID recorded in a lock file and whether that process
+// is a live orchestrator. An unreadable lock has no live holder.
+func lockHolder(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	if pid == os.Getpid() {
+		return pid, true
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return pid, false
+	}
+
+	// Signal 0 checks for existence without affecting the process
+	if err := process.Signal(syscall.Signal(0)); err != nil {
+		return pid, false
+	}
+
+	// A recycled PID running something else doesn't hold the lock
+	executable, err := os.Executable()
+	if err != nil {
+		return pid, true
+	}
+	output, err := exec.Command("ps", "-o", "command=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return pid, true
+	}
+	return pid, strings.Contains(string(output), filepath.Base(executable))
+}
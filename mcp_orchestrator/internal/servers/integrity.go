@@ -0,0 +1,72 @@
+package servers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// commitHashPattern matches full SHA-1 or SHA-256 commit hashes. Abbreviated
+// hashes are rejected because a prefix doesn't identify a commit securely.
+var commitHashPattern = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// validatePinnedCommit checks that a pinned commit is a full commit hash
+func validatePinnedCommit(commit string) error {
+	if !commitHashPattern.MatchString(commit) {
+		return fmt.Errorf("pinned commit %q must be a full 40 or 64 character lowercase hex hash", commit)
+	}
+	return nil
+}
+
+// checkoutPinnedCommit fetches a pinned commit, which a shallow clone of the
+// default branch usually lacks, and checks it out
+func checkoutPinnedCommit(auth GitAuth, cloneURL, installPath, commit string, shallow, submodules bool) error {
+	if head, err := headCommit(installPath); err == nil && head == commit {
+		return nil
+	}
+
+	args := []string{"-C", installPath, "fetch"}
+	if shallow {
+		args = append(args, "--depth", "1")
+	}
+	args = append(args, cloneURL, commit)
+	if _, err := runGit(auth, args...); err != nil {
+		return fmt.Errorf("failed to fetch pinned commit %s: %v", commit, err)
+	}
+
+	if _, err := runGit(auth, "-C", installPath, "checkout", "--detach", commit); err != nil {
+		return fmt.Errorf("failed to check out pinned commit %s: %v", commit, err)
+	}
+
+	if submodules {
+		if _, err := runGit(auth, "-C", installPath, "submodule", "update", "--init", "--recursive"); err != nil {
+			return fmt.Errorf("failed to update submodules for pinned commit %s: %v", commit, err)
+		}
+	}
+
+	return nil
+}
+
+// headCommit returns the commit checked out in a repository
+func headCommit(installPath string) (string, error) {
+	output, err := runGit(GitAuth{}, "-C", installPath, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to read HEAD of %s: %v", installPath, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// verifyInstalledCommit returns the commit a server's clone checked out,
+// failing when it doesn't match the server's pinned commit
+func verifyInstalledCommit(server *ServerConfig) (string, error) {
+	head, err := headCommit(server.InstallPath)
+	if err != nil {
+		return "", err
+	}
+
+	if server.PinnedCommit != "" && head != server.PinnedCommit {
+		return head, fmt.Errorf("checked out commit %s does not match pinned commit %s", head, server.PinnedCommit)
+	}
+
+	return head, nil
+}
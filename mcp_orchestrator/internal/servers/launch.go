@@ -0,0 +1,88 @@
+package servers
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"mcp_orchestrator/internal/profiles"
+)
+
+// placeholderPattern matches ${NAME} placeholders in commands and arguments
+var placeholderPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Placeholders expands ${INSTALL_PATH}, ${SERVER_ID} and ${VAR} in a server's
+// command, arguments and working directory. The orchestrator and the stdio
+// proxy both expand launch settings with it, so they start the same command.
+type Placeholders struct {
+	InstallPath string
+	ServerID    string
+	Env         map[string]string // Variables looked up before the process environment
+	Masked      bool              // Expand secret-named variables masked, for logs and display
+}
+
+// Expand resolves the placeholders in value. Unknown placeholders are left
+// as written so a typo shows up in the server's own error output.
+func (p Placeholders) Expand(value string) string {
+	if !strings.Contains(value, "${") {
+		return value
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(value, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+
+		switch name {
+		case "INSTALL_PATH":
+			return p.InstallPath
+		case "SERVER_ID":
+			return p.ServerID
+		}
+
+		envValue, ok := p.Env[name]
+		if !ok {
+			envValue, ok = os.LookupEnv(name)
+		}
+		if !ok {
+			return placeholder
+		}
+		if p.Masked {
+			return maskSecret(name, envValue)
+		}
+		return envValue
+	})
+}
+
+// ApplyLaunchOverride replaces a server's command, arguments and working
+// directory with those a profile's server config overrides, expanding
+// placeholders in them. A field the override leaves unset keeps the base
+// value, so overriding only the command keeps the server's arguments. A
+// relative working directory resolves against the install directory, which
+// is also the default.
+func ApplyLaunchOverride(override profiles.ServerConfig, command string, args []string, expand Placeholders) (string, []string, string) {
+	dir := expand.InstallPath
+
+	if override.Command != "" {
+		command = expand.Expand(override.Command)
+	}
+	if len(override.Args) > 0 {
+		args = expand.ExpandAll(override.Args)
+	}
+	if override.WorkingDir != "" {
+		dir = expand.Expand(override.WorkingDir)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(expand.InstallPath, dir)
+		}
+	}
+
+	return command, args, dir
+}
+
+// ExpandAll expands each of values
+func (p Placeholders) ExpandAll(values []string) []string {
+	expanded := make([]string, len(values))
+	for i, value := range values {
+		expanded[i] = p.Expand(value)
+	}
+	return expanded
+}
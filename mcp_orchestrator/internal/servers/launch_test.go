@@ -0,0 +1,82 @@
+package servers
+
+import (
+	"reflect"
+	"testing"
+
+	"mcp_orchestrator/internal/profiles"
+)
+
+func TestPlaceholdersExpand(t *testing.T) {
+	t.Setenv("LAUNCH_TEST_REGION", "eu-west-1")
+	expand := Placeholders{
+		InstallPath: "/opt/servers/github",
+		ServerID:    "github",
+		Env:         map[string]string{"API_KEY": "sk-0123456789abcdef", "LAUNCH_TEST_REGION": "us-east-1"},
+	}
+
+	got := expand.ExpandAll([]string{
+		"${INSTALL_PATH}/dist/index.js",
+		"--id=${SERVER_ID}",
+		"--api-key=${API_KEY}",
+		"--region=${LAUNCH_TEST_REGION}",
+		"--missing=${LAUNCH_TEST_UNSET}",
+	})
+	want := []string{
+		"/opt/servers/github/dist/index.js",
+		"--id=github",
+		"--api-key=sk-0123456789abcdef",
+		"--region=us-east-1", // The server's variables win over the process environment
+		"--missing=${LAUNCH_TEST_UNSET}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expanded %q, want %q", got, want)
+	}
+}
+
+func TestPlaceholdersExpandMasked(t *testing.T) {
+	expand := Placeholders{
+		Env:    map[string]string{"API_KEY": "sk-0123456789abcdef", "REGION": "us-east-1"},
+		Masked: true,
+	}
+
+	if got := expand.Expand("--api-key=${API_KEY}"); got != "--api-key=****cdef" {
+		t.Errorf("secret expanded to %q, want it masked", got)
+	}
+	if got := expand.Expand("--region=${REGION}"); got != "--region=us-east-1" {
+		t.Errorf("non-secret expanded to %q", got)
+	}
+}
+
+func TestApplyLaunchOverrideKeepsUnsetFields(t *testing.T) {
+	expand := Placeholders{InstallPath: "/opt/servers/github", ServerID: "github"}
+	baseArgs := []string{"dist/index.js", "--stdio"}
+
+	command, args, dir := ApplyLaunchOverride(profiles.ServerConfig{Command: "/usr/local/bin/node"}, "node", baseArgs, expand)
+	if command != "/usr/local/bin/node" || !reflect.DeepEqual(args, baseArgs) || dir != "/opt/servers/github" {
+		t.Errorf("command-only override gave %q %q in %q", command, args, dir)
+	}
+
+	command, args, dir = ApplyLaunchOverride(profiles.ServerConfig{
+		Args:       []string{"${INSTALL_PATH}/build/index.js"},
+		WorkingDir: "build",
+	}, "node", baseArgs, expand)
+	if command != "node" || !reflect.DeepEqual(args, []string{"/opt/servers/github/build/index.js"}) || dir != "/opt/servers/github/build" {
+		t.Errorf("args and working directory override gave %q %q in %q", command, args, dir)
+	}
+
+	command, args, dir = ApplyLaunchOverride(profiles.ServerConfig{}, "node", baseArgs, expand)
+	if command != "node" || !reflect.DeepEqual(args, baseArgs) || dir != "/opt/servers/github" {
+		t.Errorf("no override gave %q %q in %q", command, args, dir)
+	}
+}
+
+func TestPlaceholdersResolveSharedEnv(t *testing.T) {
+	m := &Manager{sharedEnv: map[string]string{"PROXY_URL": "http://proxy:3128", "REGION": "eu-west-1"}}
+	server := &ServerConfig{ID: "github", Env: map[string]string{"REGION": "us-east-1"}}
+
+	got := m.placeholders(server).ExpandAll([]string{"--proxy=${PROXY_URL}", "--region=${REGION}"})
+	if want := []string{"--proxy=http://proxy:3128", "--region=us-east-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expanded %q, want %q", got, want)
+	}
+}
@@ -9,8 +9,12 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"mcp_orchestrator/internal/mcp"
+	"mcp_orchestrator/internal/mcpclient"
+	"mcp_orchestrator/internal/performance"
+	"mcp_orchestrator/internal/profiles"
 )
 
 // ServerConfig represents configuration for an MCP server
@@ -26,11 +30,43 @@ type ServerConfig struct {
 	Port        int               `json:"port"`
 	Status      string            `json:"status"`
 	Process     *os.Process       `json:"-"`
+	PID         int               `json:"pid,omitempty"` // Persisted so orphans can be reconciled after a restart
 	Logs        []string          `json:"logs"`
 	ServerType  string            `json:"server_type"` // "nodejs" or "python"
 	Category    string            `json:"category"`    // Server category for UI organization
 	ToolsCount  int               `json:"tools_count"` // Number of tools provided by the server
 	SubPath     string            `json:"sub_path"`    // Subdirectory within the repository
+	Clone       CloneOptions      `json:"clone_options"`
+	Build       BuildOptions      `json:"build_options"`
+	Homepage    string            `json:"homepage,omitempty"`     // Project page
+	DocsURL     string            `json:"docs_url,omitempty"`     // Setup and usage documentation
+	Author      string            `json:"author,omitempty"`       // Maintainer of the server
+	License     string            `json:"license,omitempty"`      // SPDX license identifier
+	DependsOn   []string          `json:"depends_on,omitempty"`   // Servers that must be running before this one starts
+	Disabled    bool              `json:"disabled,omitempty"`     // Kept installed but hidden from discovery and tool calls
+	IdleStopped bool              `json:"idle_stopped,omitempty"` // Stopped for being idle; starts again on the next tool call
+
+	PinnedCommit    string `json:"pinned_commit,omitempty"`    // Full commit hash the install must check out
+	InstalledCommit string `json:"installed_commit,omitempty"` // Commit checked out by the last install
+
+	PreInstall  *InstallHook `json:"pre_install,omitempty"`  // Run after cloning, before dependencies are installed
+	PostInstall *InstallHook `json:"post_install,omitempty"` // Run after the build and .env file, before validation
+
+	ToolsRefreshedAt time.Time `json:"tools_refreshed_at"` // Cached tool lists older than this are stale
+}
+
+// BuildOptions controls how a server's dependencies are installed
+type BuildOptions struct {
+	NpmInstallMode    string `json:"npm_install_mode,omitempty"`   // "auto" (default), "ci" or "install"
+	PythonInterpreter string `json:"python_interpreter,omitempty"` // e.g. "python3.11", "3.11" or an absolute path
+}
+
+// CloneOptions controls how a server repository is cloned. The zero value
+// performs a shallow clone, sparse-checking out SubPath when one is set.
+type CloneOptions struct {
+	FullClone         bool `json:"full_clone,omitempty"`         // Fetch the whole history instead of --depth 1
+	FullCheckout      bool `json:"full_checkout,omitempty"`      // Check out the whole tree even when SubPath is set
+	RecurseSubmodules bool `json:"recurse_submodules,omitempty"` // Clone submodules as well
 }
 
 // ClaudeDesktopConfig represents the Claude Desktop configuration structure
@@ -54,21 +90,62 @@ type TransportConfig struct {
 	URL  string `json:"url"`
 }
 
+// ManagerConfig holds tunable settings for the server manager
+type ManagerConfig struct {
+	MaxConcurrentInstalls int           // Installs beyond this limit wait in the "queued" status
+	OrphanPolicy          string        // OrphanPolicyKill or OrphanPolicyAdopt
+	MaxErrorsPerServer    int           // Most recent errors retained per server
+	MaxErrorAge           time.Duration // Errors older than this are dropped (0 keeps them)
+	CatalogURL            string        // Remote JSON catalog of additional servers ("" disables it)
+	IdleCheckInterval     time.Duration // How often servers with an idle timeout are checked
+}
+
+// DefaultManagerConfig returns the default manager settings
+func DefaultManagerConfig() ManagerConfig {
+	return ManagerConfig{
+		MaxConcurrentInstalls: 2,
+		OrphanPolicy:          OrphanPolicyKill,
+		MaxErrorsPerServer:    10,
+		IdleCheckInterval:     defaultIdleCheckInterval,
+	}
+}
+
 // Manager handles MCP server lifecycle
 type Manager struct {
-	orchestrator *mcp.Orchestrator
-	servers      map[string]*ServerConfig
-	mu           sync.RWMutex
-	basePath     string
-	validator    *ConfigValidator
-	errors       map[string][]*EnhancedError // serverID -> errors
-	errorsMu     sync.RWMutex
+	orchestrator    *mcp.Orchestrator
+	servers         map[string]*ServerConfig
+	mu              sync.RWMutex
+	basePath        string
+	validator       *ConfigValidator
+	errors          map[string][]*EnhancedError // serverID -> errors
+	errorsMu        sync.RWMutex
+	loadBalancer    *performance.LoadBalancer
+	connFactory     *performance.StdioConnectionFactory
+	healthCheck     *performance.StdioHealthChecker
+	config          ManagerConfig
+	installSlots    chan struct{} // Bounds the number of installs running at once
+	reconciled      []ReconcileResult
+	ready           bool                    // Set once saved state has been loaded
+	probes          map[string]ServerHealth // Recent health probe results by server
+	probesMu        sync.Mutex
+	toolIndex       map[string]toolListing // Tool names last discovered per server
+	toolIndexMu     sync.Mutex
+	catalog         []*ServerConfig // Validated entries from the remote catalog
+	catalogMu       sync.RWMutex
+	profiles        *profiles.ProfileManager // Supplies the active profile's launch overrides and idle timeouts
+	lastUsed        map[string]time.Time     // Last tool call per server, for idle stops
+	lifecycleEvents []LifecycleEvent         // Recent auto-stop and auto-start events
+	activityMu      sync.Mutex
+	stacks          map[string]*Stack    // Stack definitions by name
+	stackRuns       map[string]*StackRun // Last up run of each stack
+	stacksMu        sync.Mutex
+	sharedEnv       map[string]string // Environment given to every server, beneath its own Env
+	sharedEnvMu     sync.RWMutex
 }
 
 // NewManager creates a new server manager
-func NewManager(orchestrator *mcp.Orchestrator) *Manager {
-	homeDir, _ := os.UserHomeDir()
-	basePath := filepath.Join(homeDir, ".mcp_orchestrator")
+func NewManager(orchestrator *mcp.Orchestrator, config ManagerConfig) *Manager {
+	basePath := DefaultBasePath()
 
 	// Create base directory if it doesn't exist
 	os.MkdirAll(basePath, 0755)
@@ -79,18 +156,87 @@ func NewManager(orchestrator *mcp.Orchestrator) *Manager {
 		basePath:     basePath,
 		validator:    NewConfigValidator(basePath),
 		errors:       make(map[string][]*EnhancedError),
+		loadBalancer: performance.NewLoadBalancer(performance.HealthyFirst),
+		healthCheck:  performance.NewStdioHealthChecker(5 * time.Second),
+		probes:       make(map[string]ServerHealth),
+		toolIndex:    make(map[string]toolListing),
+		lastUsed:     make(map[string]time.Time),
+		stacks:       make(map[string]*Stack),
+		stackRuns:    make(map[string]*StackRun),
+		sharedEnv:    make(map[string]string),
+	}
+
+	if config.MaxConcurrentInstalls <= 0 {
+		config.MaxConcurrentInstalls = DefaultManagerConfig().MaxConcurrentInstalls
+	}
+	if config.MaxErrorsPerServer <= 0 {
+		config.MaxErrorsPerServer = DefaultManagerConfig().MaxErrorsPerServer
+	}
+	if config.IdleCheckInterval <= 0 {
+		config.IdleCheckInterval = DefaultManagerConfig().IdleCheckInterval
+	}
+	if config.OrphanPolicy != OrphanPolicyAdopt {
+		config.OrphanPolicy = OrphanPolicyKill
+	}
+	manager.config = config
+	manager.installSlots = make(chan struct{}, config.MaxConcurrentInstalls)
+	manager.connFactory = performance.NewStdioConnectionFactory(manager.resolveServerSpec, 30*time.Second)
+
+	// Offer servers from the remote catalog, starting from the last cached copy
+	if config.CatalogURL != "" {
+		manager.loadCachedCatalog()
+		go manager.refreshCatalog(config.CatalogURL)
 	}
 
+	// Reconciling running servers resolves their commands with the shared environment
+	manager.loadSharedEnv()
+
 	// Load existing server installations on startup
 	if err := manager.loadServerState(); err != nil {
 		log.Printf("Warning: Failed to load server state: %v", err)
 	}
+	manager.loadStacks()
+
+	manager.mu.Lock()
+	manager.ready = true
+	manager.mu.Unlock()
+
+	// Stop servers the active profile gives an idle timeout once they go unused
+	go manager.superviseIdleServers(config.IdleCheckInterval)
 
 	return manager
 }
 
-// GetAvailableServers returns predefined server configurations
+// IsReady reports whether the manager has finished loading its saved state
+func (m *Manager) IsReady() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.ready
+}
+
+// SetProfileManager sets where the active profile's per-server launch
+// overrides are read from when servers start
+func (m *Manager) SetProfileManager(profileManager *profiles.ProfileManager) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.profiles = profileManager
+}
+
+// GetBasePath returns the directory servers and orchestrator data are stored in
+func (m *Manager) GetBasePath() string {
+	return m.basePath
+}
+
+// GetAvailableServers returns the built-in server configurations merged with
+// any entries from the remote catalog, which replace built-ins of the same ID
 func (m *Manager) GetAvailableServers() []*ServerConfig {
+	return mergeCatalog(builtinServers(), m.catalogServers())
+}
+
+// builtinServers returns the server configurations compiled into the orchestrator
+func builtinServers() []*ServerConfig {
 	return []*ServerConfig{
 		// Existing servers
 		{
@@ -98,6 +244,9 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 			Name:        "GoHighLevel MCP",
 			Description: "Customer relationship management and marketing automation platform with 253 tools for lead generation, nurturing, and sales process automation",
 			RepoURL:     "https://github.com/mastanley13/GoHighLevel-MCP.git",
+			Homepage:    "https://github.com/mastanley13/GoHighLevel-MCP",
+			DocsURL:     "https://github.com/mastanley13/GoHighLevel-MCP#readme",
+			Author:      "mastanley13",
 			Command:     "node",
 			Args:        []string{"dist/server.js"},
 			Port:        8000,
@@ -115,6 +264,9 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 			Name:        "Meta Ads MCP",
 			Description: "Facebook and Instagram advertising platform with 22 tools for campaign management, audience targeting, and performance analytics",
 			RepoURL:     "https://github.com/pipeboard-co/meta-ads-mcp.git",
+			Homepage:    "https://github.com/pipeboard-co/meta-ads-mcp",
+			DocsURL:     "https://github.com/pipeboard-co/meta-ads-mcp#readme",
+			Author:      "Pipeboard",
 			Command:     "python",
 			Args:        []string{"-m", "meta_ads_mcp"},
 			Port:        8001,
@@ -131,6 +283,9 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 			Name:        "Google Ads MCP",
 			Description: "Google Ads platform integration with 30+ tools for search advertising, display campaigns, and conversion tracking",
 			RepoURL:     "https://github.com/cohnen/mcp-google-ads.git",
+			Homepage:    "https://github.com/cohnen/mcp-google-ads",
+			DocsURL:     "https://github.com/cohnen/mcp-google-ads#readme",
+			Author:      "cohnen",
 			Command:     "python",
 			Args:        []string{"-m", "mcp_google_ads"},
 			Port:        8002,
@@ -148,6 +303,9 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 			Name:        "Figma MCP",
 			Description: "Design collaboration platform with 5 tools for accessing Figma files, adding comments, and viewing design nodes",
 			RepoURL:     "https://github.com/MatthewDailey/figma-mcp.git",
+			Homepage:    "https://github.com/MatthewDailey/figma-mcp",
+			DocsURL:     "https://github.com/MatthewDailey/figma-mcp#readme",
+			Author:      "Matthew Dailey",
 			Command:     "npx",
 			Args:        []string{"figma-mcp"},
 			Port:        8003,
@@ -164,6 +322,10 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 			Name:        "GitHub MCP",
 			Description: "Version control and development collaboration with 12 tools for repository management, issues, and pull requests",
 			RepoURL:     "https://github.com/modelcontextprotocol/servers.git",
+			Homepage:    "https://github.com/modelcontextprotocol/servers/tree/main/src/github",
+			DocsURL:     "https://github.com/modelcontextprotocol/servers/blob/main/src/github/README.md",
+			Author:      "Model Context Protocol",
+			License:     "MIT",
 			Command:     "npx",
 			Args:        []string{"-y", "@modelcontextprotocol/server-github"},
 			Port:        8004,
@@ -181,6 +343,10 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 			Name:        "Slack MCP",
 			Description: "Team communication and workspace management with 10 tools for messaging, channels, and integrations",
 			RepoURL:     "https://github.com/modelcontextprotocol/servers.git",
+			Homepage:    "https://github.com/modelcontextprotocol/servers/tree/main/src/slack",
+			DocsURL:     "https://github.com/modelcontextprotocol/servers/blob/main/src/slack/README.md",
+			Author:      "Model Context Protocol",
+			License:     "MIT",
 			Command:     "npx",
 			Args:        []string{"-y", "@modelcontextprotocol/server-slack"},
 			Port:        8005,
@@ -198,6 +364,10 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 			Name:        "Notion MCP",
 			Description: "All-in-one workspace with 7 tools for notes, databases, and collaborative documentation",
 			RepoURL:     "https://github.com/modelcontextprotocol/servers.git",
+			Homepage:    "https://github.com/modelcontextprotocol/servers/tree/main/src/notion",
+			DocsURL:     "https://github.com/modelcontextprotocol/servers/blob/main/src/notion/README.md",
+			Author:      "Model Context Protocol",
+			License:     "MIT",
 			Command:     "npx",
 			Args:        []string{"-y", "@modelcontextprotocol/server-notion"},
 			Port:        8006,
@@ -215,6 +385,10 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 			Name:        "Stripe MCP",
 			Description: "Payment processing and billing with 12 tools for transactions, subscriptions, and customer management",
 			RepoURL:     "https://github.com/modelcontextprotocol/servers.git",
+			Homepage:    "https://github.com/modelcontextprotocol/servers/tree/main/src/stripe",
+			DocsURL:     "https://github.com/modelcontextprotocol/servers/blob/main/src/stripe/README.md",
+			Author:      "Model Context Protocol",
+			License:     "MIT",
 			Command:     "npx",
 			Args:        []string{"-y", "@modelcontextprotocol/server-stripe"},
 			Port:        8007,
@@ -233,6 +407,10 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 			Name:        "Google Maps MCP",
 			Description: "Location services and mapping with 6 tools for geocoding, directions, and place searches",
 			RepoURL:     "https://github.com/modelcontextprotocol/servers.git",
+			Homepage:    "https://github.com/modelcontextprotocol/servers/tree/main/src/google-maps",
+			DocsURL:     "https://github.com/modelcontextprotocol/servers/blob/main/src/google-maps/README.md",
+			Author:      "Model Context Protocol",
+			License:     "MIT",
 			Command:     "npx",
 			Args:        []string{"-y", "@modelcontextprotocol/server-google-maps"},
 			Port:        8008,
@@ -250,6 +428,10 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 			Name:        "Brave Search MCP",
 			Description: "Web search and data retrieval with 3 tools for search queries and result processing",
 			RepoURL:     "https://github.com/modelcontextprotocol/servers.git",
+			Homepage:    "https://github.com/modelcontextprotocol/servers/tree/main/src/brave-search",
+			DocsURL:     "https://github.com/modelcontextprotocol/servers/blob/main/src/brave-search/README.md",
+			Author:      "Model Context Protocol",
+			License:     "MIT",
 			Command:     "npx",
 			Args:        []string{"-y", "@modelcontextprotocol/server-brave-search"},
 			Port:        8009,
@@ -267,6 +449,10 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 			Name:        "Gmail MCP",
 			Description: "Gmail integration with 9 tools for email management, sending, and organization",
 			RepoURL:     "https://github.com/modelcontextprotocol/servers.git",
+			Homepage:    "https://github.com/modelcontextprotocol/servers/tree/main/src/gmail",
+			DocsURL:     "https://github.com/modelcontextprotocol/servers/blob/main/src/gmail/README.md",
+			Author:      "Model Context Protocol",
+			License:     "MIT",
 			Command:     "npx",
 			Args:        []string{"-y", "@modelcontextprotocol/server-gmail"},
 			Port:        8010,
@@ -284,6 +470,10 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 			Name:        "Puppeteer MCP",
 			Description: "Web scraping and automation with 5 tools for browser control and page interaction",
 			RepoURL:     "https://github.com/modelcontextprotocol/servers.git",
+			Homepage:    "https://github.com/modelcontextprotocol/servers/tree/main/src/puppeteer",
+			DocsURL:     "https://github.com/modelcontextprotocol/servers/blob/main/src/puppeteer/README.md",
+			Author:      "Model Context Protocol",
+			License:     "MIT",
 			Command:     "npx",
 			Args:        []string{"-y", "@modelcontextprotocol/server-puppeteer"},
 			Port:        8011,
@@ -301,6 +491,10 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 			Name:        "Docker MCP",
 			Description: "Container management with 8 tools for Docker operations, images, and deployments",
 			RepoURL:     "https://github.com/modelcontextprotocol/servers.git",
+			Homepage:    "https://github.com/modelcontextprotocol/servers/tree/main/src/docker",
+			DocsURL:     "https://github.com/modelcontextprotocol/servers/blob/main/src/docker/README.md",
+			Author:      "Model Context Protocol",
+			License:     "MIT",
 			Command:     "npx",
 			Args:        []string{"-y", "@modelcontextprotocol/server-docker"},
 			Port:        8012,
@@ -316,8 +510,9 @@ func (m *Manager) GetAvailableServers() []*ServerConfig {
 	}
 }
 
-// InstallServer installs a new MCP server
-func (m *Manager) InstallServer(serverID string, config map[string]string) error {
+// InstallServer installs a new MCP server. A non-empty commit pins the
+// install to that commit, overriding any pin from the catalog.
+func (m *Manager) InstallServer(serverID string, config map[string]string, auth GitAuth, commit string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -334,22 +529,53 @@ func (m *Manager) InstallServer(serverID string, config map[string]string) error
 		return fmt.Errorf("server %s not found", serverID)
 	}
 
+	// Don't start a second install of a server that is already pending
+	if existing, exists := m.servers[serverID]; exists && (existing.Status == "queued" || existing.Status == "installing") {
+		return fmt.Errorf("installation of server %s is already %s", serverID, existing.Status)
+	}
+
 	// Create a copy of the template
 	server := *serverTemplate
 	server.InstallPath = filepath.Join(m.basePath, serverID)
-	server.Status = "installing"
+	server.Status = "queued"
+	server.InstalledCommit = ""
+	if commit != "" {
+		server.PinnedCommit = commit
+	}
+	if server.PinnedCommit != "" {
+		if err := validatePinnedCommit(server.PinnedCommit); err != nil {
+			return err
+		}
+	}
 
 	// Add to servers map
 	m.servers[serverID] = &server
 
-	// Start installation in a goroutine
-	go m.performInstallation(&server, config)
+	// Fall back to credentials from the environment for private repositories
+	if auth.IsEmpty() {
+		auth = gitAuthFromEnv()
+	}
+
+	// Queue installation in a goroutine
+	go m.queueInstallation(&server, config, auth)
 
 	return nil
 }
 
+// queueInstallation waits for a free install slot and then runs the installation
+func (m *Manager) queueInstallation(server *ServerConfig, config map[string]string, auth GitAuth) {
+	m.installSlots <- struct{}{}
+	defer func() { <-m.installSlots }()
+
+	m.mu.Lock()
+	server.Status = "installing"
+	m.mu.Unlock()
+
+	m.performInstallation(server, config, auth)
+}
+
 // performInstallation handles the actual installation process
-func (m *Manager) performInstallation(server *ServerConfig, config map[string]string) {
+func (m *Manager) performInstallation(server *ServerConfig, config map[string]string, auth GitAuth) {
 	log.Printf("Starting installation of %s", server.Name)
 
 	// Clear previous errors for this server
@@ -358,8 +584,18 @@ func (m *Manager) performInstallation(server *ServerConfig, config map[string]st
 	// Create error handler for this installation
 	errorHandler := NewErrorHandler(server.ID, fmt.Sprintf("Installing %s", server.Name))
 
+	// Fail early with install instructions when required commands are missing
+	if tooling := m.validator.ValidateTooling(server); !tooling.IsValid {
+		enhancedErr := errorHandler.HandleToolingError(tooling)
+		m.AddError(server.ID, enhancedErr)
+		log.Printf("Missing tooling for %s: %s", server.Name, enhancedErr.Details)
+		server.Status = "failed"
+		server.Logs = append(server.Logs, enhancedErr.Message+": "+enhancedErr.Details)
+		return
+	}
+
 	// Clone the repository
-	if err := m.cloneRepo(server.RepoURL, server.InstallPath); err != nil {
+	if err := m.cloneRepo(server, auth); err != nil {
 		enhancedErr := errorHandler.HandleInstallationError(err, "git_clone")
 		m.AddError(server.ID, enhancedErr)
 		log.Printf("Failed to clone repo: %v", err)
@@ -368,6 +604,32 @@ func (m *Manager) performInstallation(server *ServerConfig, config map[string]st
 		return
 	}
 
+	// Verify the checkout before running any of its code
+	installedCommit, err := verifyInstalledCommit(server)
+	if err != nil {
+		enhancedErr := errorHandler.HandleInstallationError(err, "integrity")
+		m.AddError(server.ID, enhancedErr)
+		log.Printf("Integrity check failed for %s: %v", server.Name, err)
+		if removeErr := os.RemoveAll(server.InstallPath); removeErr != nil {
+			log.Printf("Warning: failed to remove unverified checkout %s: %v", server.InstallPath, removeErr)
+		}
+		server.Status = "failed"
+		server.Logs = append(server.Logs, enhancedErr.Message+": "+enhancedErr.Details)
+		return
+	}
+	server.InstalledCommit = installedCommit
+	log.Printf("Installed %s at commit %s", server.Name, installedCommit)
+
+	// Run the server's own setup before its dependencies are installed
+	if err := m.runInstallHook(server, "pre_install", server.PreInstall, config); err != nil {
+		enhancedErr := errorHandler.HandleInstallationError(err, "pre_install")
+		m.AddError(server.ID, enhancedErr)
+		log.Printf("Pre-install hook failed for %s: %v", server.Name, err)
+		server.Status = "failed"
+		server.Logs = append(server.Logs, enhancedErr.Message+": "+enhancedErr.Details)
+		return
+	}
+
 	// Install dependencies and build
 	if err := m.buildServer(server); err != nil {
 		// Determine the stage based on server type
@@ -393,6 +655,16 @@ func (m *Manager) performInstallation(server *ServerConfig, config map[string]st
 		return
 	}
 
+	// Post-install setup sees the built server and its .env file
+	if err := m.runInstallHook(server, "post_install", server.PostInstall, config); err != nil {
+		enhancedErr := errorHandler.HandleInstallationError(err, "post_install")
+		m.AddError(server.ID, enhancedErr)
+		log.Printf("Post-install hook failed for %s: %v", server.Name, err)
+		server.Status = "failed"
+		server.Logs = append(server.Logs, enhancedErr.Message+": "+enhancedErr.Details)
+		return
+	}
+
 	// Validate installation and attempt auto-fix if needed
 	log.Printf("Validating installation of %s", server.Name)
 	validationResult := m.validator.ValidateServer(server.ID, server)
@@ -436,8 +708,56 @@ func (m *Manager) performInstallation(server *ServerConfig, config map[string]st
 	}
 }
 
-// cloneRepo clones a Git repository
-func (m *Manager) cloneRepo(repoURL, installPath string) error {
+// cloneRepo clones a server's Git repository, authenticating with auth when set.
+// Shallow and sparse clones fall back to a full clone if they fail.
+func (m *Manager) cloneRepo(server *ServerConfig, auth GitAuth) error {
+	repoURL, installPath, opts := server.RepoURL, server.InstallPath, server.Clone
+
+	cloneURL, err := auth.cloneURL(repoURL)
+	if err != nil {
+		return err
+	}
+
+	shallow := !opts.FullClone
+	sparse := server.SubPath != "" && !opts.FullCheckout
+
+	err = m.runClone(cloneURL, installPath, auth, shallow, sparse, opts.RecurseSubmodules)
+	if err != nil && (shallow || sparse) {
+		log.Printf("Optimized clone of %s failed, falling back to a full clone: %v", repoURL, err)
+		shallow, sparse = false, false
+		err = m.runClone(cloneURL, installPath, auth, false, false, opts.RecurseSubmodules)
+	}
+	if err != nil {
+		log.Printf("Git clone failed. Command: git clone %s %s", repoURL, installPath)
+		return err
+	}
+
+	// Limit the working tree to the server's subdirectory
+	if sparse {
+		if _, err := runGit(auth, "-C", installPath, "sparse-checkout", "set", server.SubPath); err != nil {
+			log.Printf("Warning: sparse checkout of %s failed, using the full tree: %v", server.SubPath, err)
+			runGit(auth, "-C", installPath, "sparse-checkout", "disable")
+		}
+	}
+
+	// Move to the pinned commit while the authenticated URL is still at hand
+	if server.PinnedCommit != "" {
+		if err := checkoutPinnedCommit(auth, cloneURL, installPath, server.PinnedCommit, shallow, opts.RecurseSubmodules); err != nil {
+			return err
+		}
+	}
+
+	// Don't leave the token stored in the cloned repository's remote
+	if cloneURL != repoURL {
+		if _, err := runGit(auth, "-C", installPath, "remote", "set-url", "origin", repoURL); err != nil {
+			log.Printf("Warning: failed to reset remote URL for %s: %v", installPath, err)
+		}
+	}
+	return nil
+}
+
+// runClone runs a single git clone attempt into a fresh install directory
+func (m *Manager) runClone(cloneURL, installPath string, auth GitAuth, shallow, sparse, submodules bool) error {
 	// Remove existing directory if it exists
 	if _, err := os.Stat(installPath); err == nil {
 		log.Printf("Removing existing directory: %s", installPath)
@@ -446,40 +766,63 @@ func (m *Manager) cloneRepo(repoURL, installPath string) error {
 		}
 	}
 
-	cmd := exec.Command("git", "clone", repoURL, installPath)
+	args := []string{"clone"}
+	if shallow {
+		args = append(args, "--depth", "1")
+	}
+	if sparse {
+		args = append(args, "--filter=blob:none", "--sparse")
+	}
+	if submodules {
+		args = append(args, "--recurse-submodules")
+		if shallow {
+			args = append(args, "--shallow-submodules")
+		}
+	}
+	args = append(args, cloneURL, installPath)
+
+	if _, err := runGit(auth, args...); err != nil {
+		return fmt.Errorf("git clone failed: %v", err)
+	}
+	return nil
+}
+
+// runGit runs a git command with the given credentials. Output included in
+// the returned error is redacted so tokens never reach logs or the UI.
+func runGit(auth GitAuth, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Env = auth.env()
 	output, err := cmd.CombinedOutput()
+	redacted := auth.redact(string(output))
 	if err != nil {
-		log.Printf("Git clone failed. Command: git clone %s %s", repoURL, installPath)
-		log.Printf("Git error output: %s", string(output))
-		return fmt.Errorf("git clone failed: %s", string(output))
+		log.Printf("Git error output: %s", redacted)
+		return redacted, fmt.Errorf("%s", strings.TrimSpace(redacted))
 	}
-	return nil
+	return redacted, nil
 }
 
 // buildServer builds the MCP server based on server type
 func (m *Manager) buildServer(server *ServerConfig) error {
 	switch server.ServerType {
 	case "nodejs":
-		return m.buildNodeJSServer(server.InstallPath)
+		return m.buildNodeJSServer(server.InstallPath, server.Build)
 	case "python":
-		return m.buildPythonServer(server.InstallPath)
+		return m.buildPythonServer(server.InstallPath, server.Build)
 	default:
 		// Default to Node.js for backward compatibility
-		return m.buildNodeJSServer(server.InstallPath)
+		return m.buildNodeJSServer(server.InstallPath, server.Build)
 	}
 }
 
 // buildNodeJSServer builds a Node.js MCP server
-func (m *Manager) buildNodeJSServer(installPath string) error {
+func (m *Manager) buildNodeJSServer(installPath string, opts BuildOptions) error {
 	// Install dependencies
-	cmd := exec.Command("npm", "install")
-	cmd.Dir = installPath
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("npm install failed: %v", err)
+	if err := m.installNodeDependencies(installPath, opts.NpmInstallMode); err != nil {
+		return err
 	}
 
 	// Build the project
-	cmd = exec.Command("npm", "run", "build")
+	cmd := exec.Command("npm", "run", "build")
 	cmd.Dir = installPath
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("npm build failed: %v", err)
@@ -488,79 +831,195 @@ func (m *Manager) buildNodeJSServer(installPath string) error {
 	return nil
 }
 
+// installNodeDependencies installs npm dependencies, preferring the
+// deterministic `npm ci` whenever a lockfile is committed
+func (m *Manager) installNodeDependencies(installPath, mode string) error {
+	_, lockErr := os.Stat(filepath.Join(installPath, "package-lock.json"))
+	hasLockfile := lockErr == nil
+
+	switch mode {
+	case "install":
+		// Explicitly requested, skip ci
+	case "ci":
+		if !hasLockfile {
+			return fmt.Errorf("npm ci requested but no package-lock.json found in %s", installPath)
+		}
+		log.Printf("Installing dependencies in %s with npm ci", installPath)
+		cmd := exec.Command("npm", "ci")
+		cmd.Dir = installPath
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("npm ci failed: %v", err)
+		}
+		return nil
+	default:
+		if hasLockfile {
+			log.Printf("Installing dependencies in %s with npm ci (package-lock.json found)", installPath)
+			cmd := exec.Command("npm", "ci")
+			cmd.Dir = installPath
+			err := cmd.Run()
+			if err == nil {
+				return nil
+			}
+			log.Printf("npm ci failed in %s, falling back to npm install: %v", installPath, err)
+		}
+	}
+
+	log.Printf("Installing dependencies in %s with npm install", installPath)
+	cmd := exec.Command("npm", "install")
+	cmd.Dir = installPath
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("npm install failed: %v", err)
+	}
+
+	return nil
+}
+
 // buildPythonServer builds a Python MCP server
-func (m *Manager) buildPythonServer(installPath string) error {
+func (m *Manager) buildPythonServer(installPath string, opts BuildOptions) error {
+	// Resolve and validate the interpreter before creating the venv
+	interpreter, err := resolvePythonInterpreter(opts.PythonInterpreter)
+	if err != nil {
+		return err
+	}
+	log.Printf("Using Python interpreter %s for %s", interpreter, installPath)
+
 	// Check if uv is available (faster package manager)
-	if _, err := exec.LookPath("uv"); err == nil {
-		return m.buildPythonWithUV(installPath)
+	_, uvErr := exec.LookPath("uv")
+	useUV := uvErr == nil
+
+	if err := m.createPythonVenv(installPath, interpreter, useUV); err != nil {
+		return err
 	}
 
-	// Fall back to pip
-	return m.buildPythonWithPip(installPath)
+	return m.installPythonDependencies(installPath, useUV)
 }
 
-// buildPythonWithUV builds using uv package manager
-func (m *Manager) buildPythonWithUV(installPath string) error {
-	// Create virtual environment with uv
-	cmd := exec.Command("uv", "venv", "venv")
-	cmd.Dir = installPath
-	if err := cmd.Run(); err != nil {
-		log.Printf("Failed to create uv venv, falling back to pip: %v", err)
-		return m.buildPythonWithPip(installPath)
+// resolvePythonInterpreter returns the interpreter used for venv creation.
+// The per-server setting wins over MCP_PYTHON_INTERPRETER, which wins over
+// python3. A bare version such as "3.11" is expanded to "python3.11".
+func resolvePythonInterpreter(configured string) (string, error) {
+	interpreter := configured
+	if interpreter == "" {
+		interpreter = os.Getenv("MCP_PYTHON_INTERPRETER")
+	}
+	if interpreter == "" {
+		interpreter = "python3"
 	}
 
-	// Install dependencies with uv
-	cmd = exec.Command("uv", "pip", "install", "-e", ".")
-	cmd.Dir = installPath
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("uv pip install failed: %v", err)
+	if isPythonVersion(interpreter) {
+		interpreter = "python" + interpreter
 	}
 
-	return nil
+	path, err := exec.LookPath(interpreter)
+	if err != nil {
+		return "", fmt.Errorf("python interpreter %s not found: %v", interpreter, err)
+	}
+
+	return path, nil
+}
+
+// isPythonVersion reports whether s looks like a bare version such as "3.11"
+func isPythonVersion(s string) bool {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != '.' {
+			return false
+		}
+	}
+	return true
 }
 
-// buildPythonWithPip builds using standard pip
-func (m *Manager) buildPythonWithPip(installPath string) error {
-	// Create virtual environment
-	cmd := exec.Command("python3", "-m", "venv", "venv")
+// createPythonVenv creates the server's virtual environment, preferring uv
+func (m *Manager) createPythonVenv(installPath, interpreter string, useUV bool) error {
+	if useUV {
+		cmd := exec.Command("uv", "venv", "--python", interpreter, "venv")
+		cmd.Dir = installPath
+		err := cmd.Run()
+		if err == nil {
+			return nil
+		}
+		log.Printf("Failed to create uv venv, falling back to venv module: %v", err)
+	}
+
+	cmd := exec.Command(interpreter, "-m", "venv", "venv")
 	cmd.Dir = installPath
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("python venv creation failed: %v", err)
 	}
 
-	// Determine pip path based on OS
+	return nil
+}
+
+// pythonInstallStrategy is one way of installing a Python server's dependencies
+type pythonInstallStrategy struct {
+	name    string
+	command string
+	args    []string
+}
+
+// installPythonDependencies tries each install strategy in order until one
+// succeeds. On total failure the error lists every attempted strategy.
+func (m *Manager) installPythonDependencies(installPath string, useUV bool) error {
+	// Determine venv paths based on OS
+	pythonPath := filepath.Join(installPath, "venv", "bin", "python")
 	pipPath := filepath.Join(installPath, "venv", "bin", "pip")
-	if _, err := os.Stat(pipPath); os.IsNotExist(err) {
-		// Windows path
+	if _, err := os.Stat(pythonPath); os.IsNotExist(err) {
+		// Windows paths
+		pythonPath = filepath.Join(installPath, "venv", "Scripts", "python.exe")
 		pipPath = filepath.Join(installPath, "venv", "Scripts", "pip.exe")
 	}
 
-	// Upgrade pip
-	cmd = exec.Command(pipPath, "install", "--upgrade", "pip")
-	cmd.Dir = installPath
-	if err := cmd.Run(); err != nil {
-		log.Printf("Failed to upgrade pip: %v", err)
-		// Continue anyway, not critical
+	strategies := make([]pythonInstallStrategy, 0, 4)
+	if useUV {
+		strategies = append(strategies, pythonInstallStrategy{"uv pip install -e .", "uv", []string{"pip", "install", "--python", pythonPath, "-e", "."}})
+	}
+	strategies = append(strategies,
+		pythonInstallStrategy{"pip install -e .", pipPath, []string{"install", "-e", "."}},
+		pythonInstallStrategy{"pip install .", pipPath, []string{"install", "."}},
+	)
+	if _, err := os.Stat(filepath.Join(installPath, "requirements.txt")); err == nil {
+		strategies = append(strategies, pythonInstallStrategy{"pip install -r requirements.txt", pipPath, []string{"install", "-r", "requirements.txt"}})
 	}
 
-	// Install dependencies
-	// Try installing in editable mode first
-	cmd = exec.Command(pipPath, "install", "-e", ".")
-	cmd.Dir = installPath
-	if err := cmd.Run(); err != nil {
-		// If editable install fails, try installing from requirements.txt
-		if _, statErr := os.Stat(filepath.Join(installPath, "requirements.txt")); statErr == nil {
-			cmd = exec.Command(pipPath, "install", "-r", "requirements.txt")
+	// Upgrade pip before the first pip-based strategy
+	pipUpgraded := false
+
+	failures := make([]string, 0, len(strategies))
+	for _, strategy := range strategies {
+		if strategy.command == pipPath && !pipUpgraded {
+			pipUpgraded = true
+			cmd := exec.Command(pipPath, "install", "--upgrade", "pip")
 			cmd.Dir = installPath
 			if err := cmd.Run(); err != nil {
-				return fmt.Errorf("pip install from requirements.txt failed: %v", err)
+				log.Printf("Failed to upgrade pip: %v", err)
+				// Continue anyway, not critical
 			}
-		} else {
-			return fmt.Errorf("pip install failed and no requirements.txt found: %v", err)
 		}
+
+		cmd := exec.Command(strategy.command, strategy.args...)
+		cmd.Dir = installPath
+		output, err := cmd.CombinedOutput()
+		if err == nil {
+			log.Printf("Installed Python dependencies in %s with %s", installPath, strategy.name)
+			return nil
+		}
+
+		log.Printf("Python install strategy %q failed in %s: %v", strategy.name, installPath, err)
+		failures = append(failures, fmt.Sprintf("%s: %v%s", strategy.name, err, lastOutputLine(output)))
 	}
 
-	return nil
+	return fmt.Errorf("all Python install strategies failed (%s)", strings.Join(failures, "; "))
+}
+
+// lastOutputLine returns the last non-empty line of command output for error context
+func lastOutputLine(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) == 0 || lines[len(lines)-1] == "" {
+		return ""
+	}
+	return " (" + strings.TrimSpace(lines[len(lines)-1]) + ")"
 }
 
 // createEnvFile creates the environment configuration file
@@ -597,6 +1056,10 @@ func (m *Manager) StartServer(serverID string) error {
 		return fmt.Errorf("server %s is already running", serverID)
 	}
 
+	if server.Disabled {
+		return fmt.Errorf("server %s is disabled; enable it before starting", serverID)
+	}
+
 	// Create error handler for startup
 	errorHandler := NewErrorHandler(serverID, fmt.Sprintf("Starting %s", server.Name))
 	log.Printf("DEBUG: Error handler created for %s", serverID) // DEBUG
@@ -629,40 +1092,21 @@ func (m *Manager) StartServer(serverID string) error {
 	}
 
 	// Prepare command based on server type
-	var cmd *exec.Cmd
 	log.Printf("DEBUG: Preparing command for server type: %s", server.ServerType) // DEBUG
+	expand := m.placeholders(server)
+	command, args, dir := m.launchSpec(server, expand)
 
-	if server.ServerType == "python" {
-		// Use virtual environment Python for Python servers
-		pythonPath := filepath.Join(server.InstallPath, "venv", "bin", "python")
-		if _, err := os.Stat(pythonPath); os.IsNotExist(err) {
-			// Windows path
-			pythonPath = filepath.Join(server.InstallPath, "venv", "Scripts", "python.exe")
-		}
+	// Log secrets expanded into the arguments masked
+	expand.Masked = true
+	_, loggedArgs, _ := m.launchSpec(server, expand)
+	log.Printf("DEBUG: Command: %s %v in directory: %s", command, loggedArgs, dir) // DEBUG
+	cmd := exec.Command(command, args...)
 
-		// Create command with virtual environment python
-		args := append([]string{pythonPath}, server.Args...)
-		log.Printf("DEBUG: Python command: %s %v", args[0], args[1:]) // DEBUG
-		cmd = exec.Command(args[0], args[1:]...)
-	} else if server.ServerType == "nodejs" && server.Command == "node" {
-		// For Node.js servers started with 'node', use relative path from working directory
-		log.Printf("DEBUG: Starting Node.js server with command: %s %v in directory: %s", server.Command, server.Args, server.InstallPath)
-		cmd = exec.Command(server.Command, server.Args...)
-	} else {
-		// Node.js (npx) and other servers
-		log.Printf("DEBUG: Generic command: %s %v", server.Command, server.Args) // DEBUG
-		cmd = exec.Command(server.Command, server.Args...)
-	}
-
-	cmd.Dir = server.InstallPath
+	cmd.Dir = dir
 	log.Printf("DEBUG: Command directory set to: %s", cmd.Dir) // DEBUG
 
-	// Set environment variables
-	env := os.Environ()
-	for key, value := range server.Env {
-		env = append(env, fmt.Sprintf("%s=%s", key, value))
-	}
-	cmd.Env = env
+	// Set environment variables: the server's own over the shared ones
+	cmd.Env = MergeEnv(os.Environ(), m.serverEnv(server))
 	log.Printf("DEBUG: Environment variables prepared for command.") // DEBUG
 
 	if err := cmd.Start(); err != nil {
@@ -675,24 +1119,119 @@ func (m *Manager) StartServer(serverID string) error {
 	log.Printf("DEBUG: cmd.Start() successful. PID: %d", cmd.Process.Pid) // DEBUG
 
 	server.Process = cmd.Process
+	server.PID = cmd.Process.Pid
 	server.Status = "running"
+	server.IdleStopped = false
 	log.Printf("DEBUG: Server status set to 'running' for %s", serverID) // DEBUG
 
+	// Health checks shouldn't see the cached result from before the start
+	m.probesMu.Lock()
+	delete(m.probes, serverID)
+	m.probesMu.Unlock()
+
+	m.attachRunningServer(server)
+
+	// Persist the PID so an unclean shutdown can be reconciled on restart
+	if err := m.saveServerState(); err != nil {
+		log.Printf("Warning: Failed to save server state: %v", err)
+	}
+
+	log.Printf("Started server %s (PID: %d)", server.Name, cmd.Process.Pid)
+	return nil
+}
+
+// attachRunningServer registers a running server with the orchestrator and
+// creates its connection pool
+func (m *Manager) attachRunningServer(server *ServerConfig) {
 	// Register with orchestrator
 	mcpServer := &mcp.MCPServer{
-		ID:     serverID,
+		ID:     server.ID,
 		Name:   server.Name,
 		Status: "running",
 		Port:   server.Port,
 	}
 	m.orchestrator.RegisterServer(mcpServer)
 
-	log.Printf("Started server %s (PID: %d)", server.Name, cmd.Process.Pid)
-	return nil
+	// Pooled connections are spawned on demand for tool calls
+	pool := performance.NewConnectionPool(performance.DefaultPoolConfig(server.ID), m.connFactory, m.healthCheck)
+	m.loadBalancer.AddPool(server.ID, pool)
+}
+
+// placeholders returns the expander for a server's launch settings. ${VAR}
+// resolves against the environment the server starts with: its own Env over
+// the shared one, then the process environment.
+func (m *Manager) placeholders(server *ServerConfig) Placeholders {
+	return Placeholders{
+		InstallPath: server.InstallPath,
+		ServerID:    server.ID,
+		Env:         m.serverEnv(server),
+	}
+}
+
+// serverCommand returns the command and arguments used to launch a server
+func serverCommand(server *ServerConfig, expand Placeholders) (string, []string) {
+	args := expand.ExpandAll(server.Args)
+
+	if server.ServerType == "python" {
+		// Use virtual environment Python for Python servers
+		pythonPath := filepath.Join(server.InstallPath, "venv", "bin", "python")
+		if _, err := os.Stat(pythonPath); os.IsNotExist(err) {
+			// Windows path
+			pythonPath = filepath.Join(server.InstallPath, "venv", "Scripts", "python.exe")
+		}
+		return pythonPath, args
+	}
+
+	// Node.js servers run relative to their install directory; npx and others as-is
+	return expand.Expand(server.Command), args
+}
+
+// launchSpec returns the command, arguments and working directory for a
+// server, applying the active profile's overrides for it, with placeholders
+// resolved by expand. Callers hold m.mu.
+func (m *Manager) launchSpec(server *ServerConfig, expand Placeholders) (string, []string, string) {
+	command, args := serverCommand(server, expand)
+
+	var override profiles.ServerConfig
+	if m.profiles != nil {
+		if profile := m.profiles.GetActiveProfile(); profile != nil {
+			override = profile.ServerConfigs[server.ID]
+		}
+	}
+	return ApplyLaunchOverride(override, command, args, expand)
+}
+
+// resolveServerSpec returns the subprocess launch spec for a pooled connection
+func (m *Manager) resolveServerSpec(serverID string) (performance.StdioServerSpec, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	server, exists := m.servers[serverID]
+	if !exists {
+		return performance.StdioServerSpec{}, fmt.Errorf("server %s not found", serverID)
+	}
+
+	command, args, dir := m.launchSpec(server, m.placeholders(server))
+	return performance.StdioServerSpec{
+		Command: command,
+		Args:    args,
+		Env:     m.serverEnv(server),
+		Dir:     dir,
+	}, nil
+}
+
+// GetLoadBalancer returns the load balancer holding the servers' connection pools
+func (m *Manager) GetLoadBalancer() *performance.LoadBalancer {
+	return m.loadBalancer
 }
 
 // StopServer stops an MCP server
 func (m *Manager) StopServer(serverID string) error {
+	// Let in-flight tool calls finish before the process is killed
+	if err := m.loadBalancer.RemovePool(serverID); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	log.Printf("DEBUG: StopServer called for ID: %s", serverID) // DEBUG
@@ -712,14 +1251,72 @@ func (m *Manager) StopServer(serverID string) error {
 		}
 		server.Process = nil
 	}
+	server.PID = 0
 
 	server.Status = "stopped"
+	server.IdleStopped = false
+	if err := m.saveServerState(); err != nil {
+		log.Printf("Warning: Failed to save server state: %v", err)
+	}
+
 	log.Printf("Stopped server %s", server.Name)
 	return nil
 }
 
+// DisableServer stops a server if it is running and keeps it out of discovery
+// and tool calls until it is enabled again. The server stays installed.
+func (m *Manager) DisableServer(serverID string) error {
+	if _, err := m.GetServer(serverID); err != nil {
+		return err
+	}
+
+	if m.isRunning(serverID) {
+		if err := m.StopServer(serverID); err != nil {
+			return fmt.Errorf("failed to stop server %s: %v", serverID, err)
+		}
+	}
+
+	return m.setDisabled(serverID, true)
+}
+
+// EnableServer makes a disabled server available again; it is left stopped
+func (m *Manager) EnableServer(serverID string) error {
+	return m.setDisabled(serverID, false)
+}
+
+// setDisabled updates and persists a server's disabled flag
+func (m *Manager) setDisabled(serverID string, disabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	server, exists := m.servers[serverID]
+	if !exists {
+		return fmt.Errorf("server %s not found", serverID)
+	}
+	if server.Status == "queued" || server.Status == "installing" {
+		return fmt.Errorf("server %s is %s", serverID, server.Status)
+	}
+
+	server.Disabled = disabled
+	if disabled {
+		server.Status = "disabled"
+	} else if server.Status == "disabled" {
+		server.Status = "stopped"
+	}
+
+	if err := m.saveServerState(); err != nil {
+		log.Printf("Warning: Failed to save server state: %v", err)
+	}
+
+	log.Printf("Server %s disabled: %t", server.Name, disabled)
+	return nil
+}
+
 // StopAll stops all running servers
 func (m *Manager) StopAll() {
+	// Drain every connection pool before killing the processes
+	m.loadBalancer.RemoveAllPools()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -728,8 +1325,14 @@ func (m *Manager) StopAll() {
 			server.Process.Kill()
 			server.Process = nil
 		}
+		server.PID = 0
 		server.Status = "stopped"
 	}
+
+	// Clear the recorded PIDs so the next start has nothing to reconcile
+	if err := m.saveServerState(); err != nil {
+		log.Printf("Warning: Failed to save server state: %v", err)
+	}
 }
 
 // GetServer returns a specific server configuration
@@ -772,68 +1375,20 @@ func (m *Manager) ListServers() []*ServerConfig {
 
 // configureClaudeDesktop automatically configures Claude Desktop to connect to the MCP orchestrator
 func (m *Manager) configureClaudeDesktop() error {
-	homeDir, err := os.UserHomeDir()
+	stdioPath, err := StdioBinaryPath()
 	if err != nil {
-		return fmt.Errorf("failed to get user home directory: %v", err)
-	}
-
-	claudeConfigDir := filepath.Join(homeDir, "Library", "Application Support", "Claude")
-	claudeConfigFile := filepath.Join(claudeConfigDir, "claude_desktop_config.json")
-
-	// Create Claude config directory if it doesn't exist
-	if err := os.MkdirAll(claudeConfigDir, 0755); err != nil {
-		return fmt.Errorf("failed to create Claude config directory: %v", err)
-	}
-
-	// Read existing configuration if it exists
-	var config ClaudeDesktopConfig
-	if data, err := os.ReadFile(claudeConfigFile); err == nil {
-		if err := json.Unmarshal(data, &config); err != nil {
-			log.Printf("Failed to parse existing Claude config, creating new: %v", err)
-			config = ClaudeDesktopConfig{MCPServers: make(map[string]MCPServerConfig)}
-		}
-	} else {
-		// File doesn't exist, create new config
-		config = ClaudeDesktopConfig{MCPServers: make(map[string]MCPServerConfig)}
-	}
-
-	// Clean up any invalid entries
-	if config.MCPServers == nil {
-		config.MCPServers = make(map[string]MCPServerConfig)
-	}
-
-	// Remove any invalid entries that might cause validation errors
-	validServers := make(map[string]MCPServerConfig)
-	for name, server := range config.MCPServers {
-		// Only keep servers that have command and args properly configured
-		// Claude Desktop requires command/args format for all MCP servers
-		if server.Command != "" && len(server.Args) > 0 {
-			validServers[name] = server
-		} else {
-			log.Printf("Removing invalid MCP server config: %s (missing command/args)", name)
-		}
+		return err
 	}
-	config.MCPServers = validServers
-
-	// Add or update the MCP orchestrator configuration
-	// Use our custom stdio proxy instead of mcp-remote
-	stdioBinaryPath := filepath.Join(homeDir, "Downloads", "n8", "mcp_orchestrator", "bin", "mcp-orchestrator-stdio")
-	config.MCPServers["mcp-orchestrator"] = MCPServerConfig{
-		Command: stdioBinaryPath,
-		Args:    []string{},
+	if _, err := os.Stat(stdioPath); err != nil {
+		log.Printf("Warning: stdio proxy not found at %s; run `mcp-orchestrator configure --stdio-path <path>` to fix the Claude Desktop entry", stdioPath)
 	}
 
-	// Write the updated configuration
-	data, err := json.MarshalIndent(config, "", "  ")
+	configFile, _, err := ConfigureClaudeDesktop(stdioPath)
 	if err != nil {
-		return fmt.Errorf("failed to marshal Claude config: %v", err)
-	}
-
-	if err := os.WriteFile(claudeConfigFile, data, 0644); err != nil {
-		return fmt.Errorf("failed to write Claude config file: %v", err)
+		return err
 	}
 
-	log.Printf("Successfully configured Claude Desktop at %s", claudeConfigFile)
+	log.Printf("Successfully configured Claude Desktop at %s", configFile)
 	log.Printf("Please restart Claude Desktop to apply the new configuration")
 	return nil
 }
@@ -849,10 +1404,30 @@ func (m *Manager) AddError(serverID string, enhancedError *EnhancedError) {
 
 	m.errors[serverID] = append(m.errors[serverID], enhancedError)
 
-	// Keep only the last 10 errors per server to prevent memory bloat
-	if len(m.errors[serverID]) > 10 {
-		m.errors[serverID] = m.errors[serverID][len(m.errors[serverID])-10:]
+	// Keep only the most recent errors per server to prevent memory bloat
+	limit := m.config.MaxErrorsPerServer
+	if len(m.errors[serverID]) > limit {
+		m.errors[serverID] = m.errors[serverID][len(m.errors[serverID])-limit:]
+	}
+
+	m.errors[serverID] = m.withoutExpiredErrors(m.errors[serverID])
+}
+
+// withoutExpiredErrors drops errors older than MaxErrorAge (caller holds errorsMu)
+func (m *Manager) withoutExpiredErrors(errors []*EnhancedError) []*EnhancedError {
+	if m.config.MaxErrorAge <= 0 {
+		return errors
 	}
+
+	cutoff := time.Now().Add(-m.config.MaxErrorAge)
+	for i, err := range errors {
+		// Errors are appended in order, so everything after the first fresh one is fresh too
+		if !err.Timestamp.Before(cutoff) {
+			return errors[i:]
+		}
+	}
+
+	return errors[:0]
 }
 
 // GetErrors returns all errors for a server
@@ -861,6 +1436,8 @@ func (m *Manager) GetErrors(serverID string) []*EnhancedError {
 	defer m.errorsMu.RUnlock()
 
 	if errors, exists := m.errors[serverID]; exists {
+		errors = m.withoutExpiredErrors(errors)
+
 		// Return a copy to prevent concurrent modification
 		result := make([]*EnhancedError, len(errors))
 		copy(result, errors)
@@ -885,6 +1462,11 @@ func (m *Manager) GetAllErrors() map[string][]*EnhancedError {
 
 	result := make(map[string][]*EnhancedError)
 	for serverID, errors := range m.errors {
+		errors = m.withoutExpiredErrors(errors)
+		if len(errors) == 0 {
+			continue
+		}
+
 		result[serverID] = make([]*EnhancedError, len(errors))
 		copy(result[serverID], errors)
 	}
@@ -905,7 +1487,11 @@ func (m *Manager) saveServerState() error {
 		serializableServers[id] = &serverCopy
 	}
 
-	data, err := json.MarshalIndent(serializableServers, "", "  ")
+	state := serverStateFile{
+		SchemaVersion: serverStateSchemaVersion,
+		Servers:       serializableServers,
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal server state: %v", err)
 	}
@@ -934,18 +1520,27 @@ func (m *Manager) loadServerState() error {
 		return m.detectExistingInstallations()
 	}
 
-	var savedServers map[string]*ServerConfig
-	if err := json.Unmarshal(data, &savedServers); err != nil {
+	savedServers, schemaVersion, err := decodeServerState(data)
+	if err != nil {
 		log.Printf("Failed to parse server state file, falling back to filesystem detection: %v", err)
 		return m.detectExistingInstallations()
 	}
 
+	// Older state files lack fields added since; fill them from the templates
+	migrated := schemaVersion < serverStateSchemaVersion
+	if migrated {
+		m.migrateServerState(stateFile, data, savedServers, schemaVersion)
+	} else if schemaVersion > serverStateSchemaVersion {
+		log.Printf("Warning: Server state schema version %d is newer than supported version %d", schemaVersion, serverStateSchemaVersion)
+	}
+
 	// Validate that saved servers still exist on disk and update their status
 	for id, server := range savedServers {
 		if _, err := os.Stat(server.InstallPath); err == nil {
 			// Server directory exists, mark as installed but not running
 			server.Status = "installed"
 			server.Process = nil // Ensure process is nil after restart
+			server.ID = id
 
 			// Load environment variables from .env file
 			if envVars, err := m.loadEnvFile(server.InstallPath); err == nil {
@@ -955,6 +1550,14 @@ func (m *Manager) loadServerState() error {
 				log.Printf("Warning: Failed to load environment variables for %s: %v", server.Name, err)
 			}
 
+			// Adopt or kill a process left running by a previous orchestrator
+			if result := m.reconcileProcess(server); result != nil {
+				m.reconciled = append(m.reconciled, *result)
+			}
+			if server.Disabled {
+				server.Status = "disabled"
+			}
+
 			m.servers[id] = server
 			log.Printf("Loaded existing installation: %s at %s", server.Name, server.InstallPath)
 		} else {
@@ -962,10 +1565,27 @@ func (m *Manager) loadServerState() error {
 		}
 	}
 
+	logReconcileResults(m.reconciled)
+	if len(m.reconciled) > 0 || migrated {
+		if err := m.saveServerState(); err != nil {
+			log.Printf("Warning: Failed to save reconciled or migrated server state: %v", err)
+		}
+	}
+
 	log.Printf("Successfully loaded %d server installations from state file", len(m.servers))
 	return nil
 }
 
+// GetReconcileResults returns how orphaned processes were handled at startup
+func (m *Manager) GetReconcileResults() []ReconcileResult {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := make([]ReconcileResult, len(m.reconciled))
+	copy(results, m.reconciled)
+	return results
+}
+
 // detectExistingInstallations scans the filesystem for existing server installations
 func (m *Manager) detectExistingInstallations() error {
 	log.Printf("Scanning %s for existing server installations...", m.basePath)
@@ -1058,30 +1678,10 @@ func (m *Manager) loadEnvFile(installPath string) (map[string]string, error) {
 		return make(map[string]string), nil // Return empty map if no .env file
 	}
 
-	data, err := os.ReadFile(envFile)
+	envVars, err := mcpclient.LoadEnvFile(envFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read .env file: %v", err)
 	}
 
-	envVars := make(map[string]string)
-	lines := strings.Split(string(data), "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		// Parse KEY=VALUE format
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			envVars[key] = value
-		}
-	}
-
 	return envVars, nil
 }
@@ -0,0 +1,131 @@
+package servers
+
+import (
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Orphan policies for server processes left running by a previous orchestrator
+const (
+	OrphanPolicyKill  = "kill"  // Terminate the orphan so the server can be restarted cleanly
+	OrphanPolicyAdopt = "adopt" // Keep the orphan running and manage it as if we started it
+)
+
+// ReconcileResult describes what happened to a server's recorded process on startup
+type ReconcileResult struct {
+	ServerID string `json:"server_id"`
+	PID      int    `json:"pid"`
+	Action   string `json:"action"` // "adopted", "killed", "gone", "unverified" or "kill_failed"
+}
+
+// processMatch is what a recorded PID turned out to be on startup
+type processMatch int
+
+const (
+	processGone       processMatch = iota // No longer running
+	processOther                          // Running a different command, so the PID was reused
+	processServer                         // Still running the server's command
+	processUnverified                     // Running, but its command couldn't be checked
+)
+
+// reconcileProcess checks a loaded server's recorded PID and adopts or kills
+// the process if it is still alive. Called while loading state, before the
+// server is visible to other goroutines.
+func (m *Manager) reconcileProcess(server *ServerConfig) *ReconcileResult {
+	if server.PID <= 0 {
+		return nil
+	}
+
+	result := &ReconcileResult{ServerID: server.ID, PID: server.PID}
+	pid := server.PID
+	server.PID = 0
+
+	command, _, _ := m.launchSpec(server, m.placeholders(server))
+	process, match := findServerProcess(command, pid)
+	switch match {
+	case processGone, processOther:
+		result.Action = "gone"
+		return result
+	case processUnverified:
+		// Killing or adopting a process we can't identify could hit an unrelated program
+		log.Printf("Warning: Can't verify that PID %d still runs %s; leaving it alone", pid, server.Name)
+		result.Action = "unverified"
+		return result
+	}
+
+	if m.config.OrphanPolicy == OrphanPolicyAdopt {
+		server.Process = process
+		server.PID = pid
+		server.Status = "running"
+		m.attachRunningServer(server)
+		result.Action = "adopted"
+		return result
+	}
+
+	if err := process.Kill(); err != nil {
+		log.Printf("Failed to kill orphaned process %d for %s: %v", pid, server.Name, err)
+		result.Action = "kill_failed"
+		return result
+	}
+
+	result.Action = "killed"
+	return result
+}
+
+// findServerProcess returns the process for pid and whether it still runs
+// the server's command, so a recycled PID is never mistaken for an orphan.
+// The command is read with ps; where ps can't be run, e.g. on Windows, a
+// live process is reported as unverified rather than as someone else's.
+func findServerProcess(command string, pid int) (*os.Process, processMatch) {
+	// On Windows, FindProcess fails for processes that no longer exist
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return nil, processGone
+	}
+
+	// Signal 0 checks for existence without affecting the process; Windows
+	// doesn't support it
+	if runtime.GOOS != "windows" {
+		if err := process.Signal(syscall.Signal(0)); err != nil {
+			return nil, processGone
+		}
+	}
+
+	output, err := exec.Command("ps", "-o", "command=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		// ps ran and found no such process: it exited after the check above
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(strings.TrimSpace(string(output))) == 0 {
+			return nil, processGone
+		}
+		return process, processUnverified
+	}
+
+	if !strings.Contains(string(output), filepath.Base(command)) {
+		return nil, processOther
+	}
+	return process, processServer
+}
+
+// logReconcileResults reports startup reconciliation of orphaned processes
+func logReconcileResults(results []ReconcileResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Action]++
+		log.Printf("Reconciled server %s (PID %d): %s", result.ServerID, result.PID, result.Action)
+	}
+
+	log.Printf("Process reconciliation complete: %d adopted, %d killed, %d already gone, %d unverified, %d failed",
+		counts["adopted"], counts["killed"], counts["gone"], counts["unverified"], counts["kill_failed"])
+}
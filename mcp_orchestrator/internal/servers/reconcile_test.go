@@ -0,0 +1,81 @@
+package servers
+
+import (
+	"os/exec"
+	"runtime"
+	"testing"
+)
+
+// startSleeper starts a process standing in for an orphaned server
+func startSleeper(t *testing.T) *exec.Cmd {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sleep and ps")
+	}
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("starting sleep: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	return cmd
+}
+
+func TestFindServerProcessMatchesCommand(t *testing.T) {
+	cmd := startSleeper(t)
+
+	process, match := findServerProcess("/bin/sleep", cmd.Process.Pid)
+	if match != processServer || process == nil {
+		t.Errorf("got match %v, want the server's process", match)
+	}
+}
+
+func TestFindServerProcessReusedPID(t *testing.T) {
+	cmd := startSleeper(t)
+
+	if _, match := findServerProcess("node", cmd.Process.Pid); match != processOther {
+		t.Errorf("got match %v, want another program's process", match)
+	}
+}
+
+func TestFindServerProcessGone(t *testing.T) {
+	cmd := startSleeper(t)
+	cmd.Process.Kill()
+	cmd.Wait()
+
+	if _, match := findServerProcess("/bin/sleep", cmd.Process.Pid); match != processGone {
+		t.Errorf("got match %v, want gone", match)
+	}
+}
+
+func TestFindServerProcessWithoutPs(t *testing.T) {
+	cmd := startSleeper(t)
+	t.Setenv("PATH", t.TempDir())
+
+	process, match := findServerProcess("/bin/sleep", cmd.Process.Pid)
+	if match != processUnverified || process == nil {
+		t.Errorf("got match %v, want unverified when ps can't run", match)
+	}
+}
+
+func TestReconcileLeavesUnverifiedProcessRunning(t *testing.T) {
+	cmd := startSleeper(t)
+	t.Setenv("PATH", t.TempDir())
+
+	m := &Manager{config: DefaultManagerConfig()}
+	server := &ServerConfig{ID: "sleeper", Name: "Sleeper", Command: "sleep", PID: cmd.Process.Pid}
+
+	result := m.reconcileProcess(server)
+	if result == nil || result.Action != "unverified" {
+		t.Fatalf("got result %+v, want unverified", result)
+	}
+	if server.PID != 0 || server.Process != nil {
+		t.Errorf("unverified process was adopted: PID %d", server.PID)
+	}
+	if _, match := findServerProcess("sleep", cmd.Process.Pid); match == processGone {
+		t.Error("unverified process was killed")
+	}
+}
@@ -0,0 +1,127 @@
+package servers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// sharedEnvFile holds the environment variables given to every server
+const sharedEnvFile = "shared_env.json"
+
+// envNamePattern matches a valid environment variable name
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// LoadSharedEnv reads the shared environment saved under basePath. A missing
+// file means no shared environment.
+func LoadSharedEnv(basePath string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(basePath, sharedEnvFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read shared env file: %v", err)
+	}
+
+	env := make(map[string]string)
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed to parse shared env file: %v", err)
+	}
+	return env, nil
+}
+
+// MergeEnv returns base followed by vars as KEY=VALUE entries, in key order.
+// Later entries win when a child process is started, so vars take precedence
+// over base.
+func MergeEnv(base []string, vars map[string]string) []string {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := append([]string(nil), base...)
+	for _, key := range keys {
+		env = append(env, fmt.Sprintf("%s=%s", key, vars[key]))
+	}
+	return env
+}
+
+// SharedEnv returns the shared environment with secret values masked
+func (m *Manager) SharedEnv() map[string]string {
+	m.sharedEnvMu.RLock()
+	defer m.sharedEnvMu.RUnlock()
+
+	masked := make(map[string]string, len(m.sharedEnv))
+	for key, value := range m.sharedEnv {
+		masked[key] = maskSecret(key, value)
+	}
+	return masked
+}
+
+// SetSharedEnv replaces the shared environment and persists it. A secret
+// sent back exactly as SharedEnv masked it keeps its stored value. Running
+// servers keep the environment they were started with.
+func (m *Manager) SetSharedEnv(env map[string]string) error {
+	for key := range env {
+		if !envNamePattern.MatchString(key) {
+			return fmt.Errorf("invalid environment variable name %q", key)
+		}
+	}
+
+	m.sharedEnvMu.Lock()
+	defer m.sharedEnvMu.Unlock()
+
+	copied := make(map[string]string, len(env))
+	for key, value := range env {
+		if stored, exists := m.sharedEnv[key]; exists && value != stored && value == maskSecret(key, stored) {
+			value = stored
+		}
+		copied[key] = value
+	}
+
+	data, err := json.MarshalIndent(copied, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal shared env: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(m.basePath, sharedEnvFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write shared env file: %v", err)
+	}
+	m.sharedEnv = copied
+
+	return nil
+}
+
+// serverEnv returns a server's environment on top of the shared one, so the
+// server's own values win. The process environment is not included.
+func (m *Manager) serverEnv(server *ServerConfig) map[string]string {
+	m.sharedEnvMu.RLock()
+	defer m.sharedEnvMu.RUnlock()
+
+	env := make(map[string]string, len(m.sharedEnv)+len(server.Env))
+	for key, value := range m.sharedEnv {
+		env[key] = value
+	}
+	for key, value := range server.Env {
+		env[key] = value
+	}
+	return env
+}
+
+// loadSharedEnv restores the shared environment saved by a previous run
+func (m *Manager) loadSharedEnv() {
+	env, err := LoadSharedEnv(m.basePath)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+
+	m.sharedEnvMu.Lock()
+	defer m.sharedEnvMu.Unlock()
+
+	m.sharedEnv = env
+}
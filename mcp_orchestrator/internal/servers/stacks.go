@@ -0,0 +1,445 @@
+package servers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	stacksFile               = "stacks.json"
+	stackInstallTimeout      = 30 * time.Minute // Longest a stack's up waits for its installs
+	stackInstallPollInterval = time.Second
+)
+
+// Stack is a named set of servers that are installed, started and stopped
+// together, e.g. every server a project needs
+type Stack struct {
+	Name      string                       `json:"name"`
+	Servers   []string                     `json:"servers"`
+	Config    map[string]map[string]string `json:"config,omitempty"` // Install config per member, used when up installs it
+	CreatedAt time.Time                    `json:"created_at"`
+	UpdatedAt time.Time                    `json:"updated_at"`
+}
+
+// StackRun reports the progress of bringing a stack up
+type StackRun struct {
+	Stack      string            `json:"stack"`
+	Status     string            `json:"status"`    // "installing", "starting", "completed" or "failed"
+	Installed  []string          `json:"installed"` // Members installed by this run
+	Start      *StartResult      `json:"start,omitempty"`
+	Errors     map[string]string `json:"errors,omitempty"` // Failures by server, or by stack for a run-wide failure
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
+
+// StopResult reports the outcome of stopping several servers at once
+type StopResult struct {
+	Order   []string          `json:"order"`   // Stop order, dependents first
+	Stopped []string          `json:"stopped"` // Servers stopped by this call
+	Skipped []string          `json:"skipped"` // Servers that were not running
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// ListStacks returns every defined stack, sorted by name, secrets masked
+func (m *Manager) ListStacks() []*Stack {
+	m.stacksMu.Lock()
+	defer m.stacksMu.Unlock()
+
+	stacks := make([]*Stack, 0, len(m.stacks))
+	for _, stack := range m.stacks {
+		stacks = append(stacks, stack.masked())
+	}
+	sort.Slice(stacks, func(i, j int) bool {
+		return stacks[i].Name < stacks[j].Name
+	})
+	return stacks
+}
+
+// GetStack returns a stack by name, secrets masked
+func (m *Manager) GetStack(name string) (*Stack, error) {
+	m.stacksMu.Lock()
+	defer m.stacksMu.Unlock()
+
+	stack, exists := m.stacks[name]
+	if !exists {
+		return nil, fmt.Errorf("stack %s not found", name)
+	}
+	return stack.masked(), nil
+}
+
+// SaveStack creates or replaces a stack definition and persists it. Members
+// must be known servers, and config may only be given for members.
+func (m *Manager) SaveStack(stack *Stack) error {
+	if err := m.validateStack(stack); err != nil {
+		return err
+	}
+
+	m.stacksMu.Lock()
+	defer m.stacksMu.Unlock()
+
+	now := time.Now()
+	stack.CreatedAt = now
+	if existing, exists := m.stacks[stack.Name]; exists {
+		stack.CreatedAt = existing.CreatedAt
+	}
+	stack.UpdatedAt = now
+	m.stacks[stack.Name] = stack
+
+	return m.saveStacks()
+}
+
+// DeleteStack removes a stack definition. Its servers are left as they are.
+func (m *Manager) DeleteStack(name string) error {
+	m.stacksMu.Lock()
+	defer m.stacksMu.Unlock()
+
+	if _, exists := m.stacks[name]; !exists {
+		return fmt.Errorf("stack %s not found", name)
+	}
+	if m.stackBusy(name) {
+		return fmt.Errorf("stack %s is being brought up", name)
+	}
+	delete(m.stacks, name)
+	delete(m.stackRuns, name)
+
+	return m.saveStacks()
+}
+
+// validateStack checks a stack's name, members and config
+func (m *Manager) validateStack(stack *Stack) error {
+	if !catalogIDPattern.MatchString(stack.Name) {
+		return fmt.Errorf("invalid stack name %q: use lowercase letters, digits, '-' and '_'", stack.Name)
+	}
+	if len(stack.Servers) == 0 {
+		return fmt.Errorf("stack %s has no servers", stack.Name)
+	}
+
+	known := make(map[string]bool)
+	for _, server := range m.GetAvailableServers() {
+		known[server.ID] = true
+	}
+	m.mu.RLock()
+	for serverID := range m.servers {
+		known[serverID] = true
+	}
+	m.mu.RUnlock()
+
+	members := make(map[string]bool)
+	for _, serverID := range stack.Servers {
+		if !known[serverID] {
+			return fmt.Errorf("server %s not found", serverID)
+		}
+		if members[serverID] {
+			return fmt.Errorf("server %s is listed more than once", serverID)
+		}
+		members[serverID] = true
+	}
+	for serverID := range stack.Config {
+		if !members[serverID] {
+			return fmt.Errorf("config given for %s, which is not in the stack", serverID)
+		}
+	}
+
+	return nil
+}
+
+// GetStackRun returns a copy of the last up run of a stack, or nil if it
+// hasn't been brought up since the orchestrator started
+func (m *Manager) GetStackRun(name string) *StackRun {
+	m.stacksMu.Lock()
+	defer m.stacksMu.Unlock()
+
+	run, exists := m.stackRuns[name]
+	if !exists {
+		return nil
+	}
+	return run.copy()
+}
+
+// StackUp installs the members of a stack that aren't installed yet and then
+// starts them, dependencies first. Installs can take minutes, so the work
+// runs in the background; its progress is reported by GetStackRun. If any
+// member fails to install, nothing is started.
+func (m *Manager) StackUp(name string) (*StackRun, error) {
+	m.stacksMu.Lock()
+	defer m.stacksMu.Unlock()
+
+	stack, exists := m.stacks[name]
+	if !exists {
+		return nil, fmt.Errorf("stack %s not found", name)
+	}
+	if m.stackBusy(name) {
+		return nil, fmt.Errorf("stack %s is already being brought up", name)
+	}
+
+	run := &StackRun{
+		Stack:     name,
+		Status:    "installing",
+		Installed: []string{},
+		Errors:    make(map[string]string),
+		StartedAt: time.Now(),
+	}
+	m.stackRuns[name] = run
+
+	go m.runStackUp(stack, run)
+
+	return run.copy(), nil
+}
+
+// runStackUp performs the installs and starts of a StackUp
+func (m *Manager) runStackUp(stack *Stack, run *StackRun) {
+	log.Printf("Bringing up stack %s: %v", stack.Name, stack.Servers)
+
+	installed, errs := m.installStackMembers(stack)
+
+	m.stacksMu.Lock()
+	run.Installed = installed
+	for serverID, err := range errs {
+		run.Errors[serverID] = err
+	}
+	if len(errs) > 0 {
+		m.finishStackRun(run, "failed")
+		m.stacksMu.Unlock()
+		log.Printf("Stack %s not started: %d members failed to install", stack.Name, len(errs))
+		return
+	}
+	run.Status = "starting"
+	m.stacksMu.Unlock()
+
+	result, err := m.StartMany(stack.Servers)
+
+	m.stacksMu.Lock()
+	defer m.stacksMu.Unlock()
+
+	if err != nil {
+		run.Errors[stack.Name] = err.Error()
+		m.finishStackRun(run, "failed")
+		return
+	}
+	run.Start = result
+	for serverID, message := range result.Errors {
+		run.Errors[serverID] = message
+	}
+	if len(run.Errors) > 0 {
+		m.finishStackRun(run, "failed")
+		return
+	}
+	m.finishStackRun(run, "completed")
+}
+
+// installStackMembers installs the members that aren't installed with their
+// stack config and waits for every pending install. It returns the members it
+// installed and the failures by server.
+func (m *Manager) installStackMembers(stack *Stack) ([]string, map[string]string) {
+	errs := make(map[string]string)
+
+	var requested, pending []string
+	for _, serverID := range stack.Servers {
+		switch m.serverStatus(serverID) {
+		case "", "not_installed", "failed":
+			if err := m.InstallServer(serverID, stack.Config[serverID], GitAuth{}, ""); err != nil {
+				errs[serverID] = err.Error()
+				continue
+			}
+			requested = append(requested, serverID)
+			pending = append(pending, serverID)
+		case "queued", "installing":
+			// Installed by someone else; wait for it all the same
+			pending = append(pending, serverID)
+		}
+	}
+
+	deadline := time.Now().Add(stackInstallTimeout)
+	for _, serverID := range pending {
+		for {
+			status := m.serverStatus(serverID)
+			if status == "failed" {
+				errs[serverID] = "installation failed"
+				break
+			}
+			if status != "queued" && status != "installing" {
+				break
+			}
+			if time.Now().After(deadline) {
+				errs[serverID] = fmt.Sprintf("installation did not finish within %v", stackInstallTimeout)
+				break
+			}
+			time.Sleep(stackInstallPollInterval)
+		}
+	}
+
+	installed := []string{}
+	for _, serverID := range requested {
+		if _, failed := errs[serverID]; !failed {
+			installed = append(installed, serverID)
+		}
+	}
+	return installed, errs
+}
+
+// StackDown stops the running members of a stack, dependents first. Servers
+// the members depend on are left running, as other servers may use them.
+func (m *Manager) StackDown(name string) (*StopResult, error) {
+	m.stacksMu.Lock()
+	stack, exists := m.stacks[name]
+	busy := m.stackBusy(name)
+	m.stacksMu.Unlock()
+
+	if !exists {
+		return nil, fmt.Errorf("stack %s not found", name)
+	}
+	if busy {
+		return nil, fmt.Errorf("stack %s is being brought up", name)
+	}
+
+	result := &StopResult{
+		Order:   m.stopOrder(stack.Servers),
+		Stopped: []string{},
+		Skipped: []string{},
+		Errors:  make(map[string]string),
+	}
+
+	for _, serverID := range result.Order {
+		if !m.isRunning(serverID) {
+			result.Skipped = append(result.Skipped, serverID)
+			continue
+		}
+		if err := m.StopServer(serverID); err != nil {
+			result.Errors[serverID] = err.Error()
+			continue
+		}
+		result.Stopped = append(result.Stopped, serverID)
+	}
+
+	log.Printf("Stopped stack %s in order %v (%d stopped, %d skipped, %d failed)",
+		name, result.Order, len(result.Stopped), len(result.Skipped), len(result.Errors))
+	return result, nil
+}
+
+// stopOrder returns the installed servers among serverIDs in reverse start
+// order, so dependents stop before their dependencies
+func (m *Manager) stopOrder(serverIDs []string) []string {
+	members := make(map[string]bool)
+	var installed []string
+	for _, serverID := range serverIDs {
+		members[serverID] = true
+		if m.serverStatus(serverID) != "" {
+			installed = append(installed, serverID)
+		}
+	}
+
+	order, err := m.startOrder(installed)
+	if err != nil {
+		// A broken dependency chain can't order anything; stop in reverse listing order
+		log.Printf("Warning: Stopping stack members in listed order: %v", err)
+		order = installed
+	}
+
+	stopOrder := make([]string, 0, len(installed))
+	for i := len(order) - 1; i >= 0; i-- {
+		if members[order[i]] {
+			stopOrder = append(stopOrder, order[i])
+		}
+	}
+	return stopOrder
+}
+
+// serverStatus returns the status of an installed server, or "" if it isn't installed
+func (m *Manager) serverStatus(serverID string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if server, exists := m.servers[serverID]; exists {
+		return server.Status
+	}
+	return ""
+}
+
+// stackBusy reports whether a stack is being brought up. The caller must hold stacksMu.
+func (m *Manager) stackBusy(name string) bool {
+	run, exists := m.stackRuns[name]
+	return exists && run.FinishedAt == nil
+}
+
+// finishStackRun records the end of a run. The caller must hold stacksMu.
+func (m *Manager) finishStackRun(run *StackRun, status string) {
+	finishedAt := time.Now()
+	run.Status = status
+	run.FinishedAt = &finishedAt
+	log.Printf("Stack %s up %s", run.Stack, status)
+}
+
+// masked returns a copy of a stack with secret-looking config values masked
+func (s *Stack) masked() *Stack {
+	copied := *s
+	copied.Config = make(map[string]map[string]string, len(s.Config))
+	for serverID, config := range s.Config {
+		copied.Config[serverID] = make(map[string]string, len(config))
+		for key, value := range config {
+			copied.Config[serverID][key] = maskSecret(key, value)
+		}
+	}
+	return &copied
+}
+
+// copy returns a snapshot of a run that is safe to read while it progresses
+func (r *StackRun) copy() *StackRun {
+	snapshot := *r
+	snapshot.Installed = append([]string{}, r.Installed...)
+	snapshot.Errors = make(map[string]string, len(r.Errors))
+	for key, value := range r.Errors {
+		snapshot.Errors[key] = value
+	}
+	return &snapshot
+}
+
+// saveStacks persists the stack definitions. The caller must hold stacksMu.
+// The file is private to the user, as stack config may contain credentials.
+func (m *Manager) saveStacks() error {
+	stacks := make([]*Stack, 0, len(m.stacks))
+	for _, stack := range m.stacks {
+		stacks = append(stacks, stack)
+	}
+	sort.Slice(stacks, func(i, j int) bool {
+		return stacks[i].Name < stacks[j].Name
+	})
+
+	data, err := json.MarshalIndent(stacks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal stacks: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(m.basePath, stacksFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write stacks file: %v", err)
+	}
+
+	return nil
+}
+
+// loadStacks restores the stack definitions saved by a previous run
+func (m *Manager) loadStacks() {
+	data, err := os.ReadFile(filepath.Join(m.basePath, stacksFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to read stacks file: %v", err)
+		}
+		return
+	}
+
+	var stacks []*Stack
+	if err := json.Unmarshal(data, &stacks); err != nil {
+		log.Printf("Warning: Failed to parse stacks file: %v", err)
+		return
+	}
+
+	m.stacksMu.Lock()
+	defer m.stacksMu.Unlock()
+
+	for _, stack := range stacks {
+		m.stacks[stack.Name] = stack
+	}
+}
@@ -0,0 +1,176 @@
+package servers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// dependencyReadyTimeout bounds how long a dependent waits for its dependencies
+const dependencyReadyTimeout = 30 * time.Second
+
+// StartResult reports the outcome of starting several servers at once
+type StartResult struct {
+	Order   []string          `json:"order"`   // Computed start order, dependencies first
+	Started []string          `json:"started"` // Servers started by this call
+	Skipped []string          `json:"skipped"` // Servers that were already running
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// StartMany starts the given servers and their dependencies, dependencies
+// first. A dependency cycle fails before anything is started, and a server
+// whose dependency fails to start or become ready is not started.
+func (m *Manager) StartMany(serverIDs []string) (*StartResult, error) {
+	order, err := m.startOrder(serverIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StartResult{
+		Order:   order,
+		Started: []string{},
+		Skipped: []string{},
+		Errors:  make(map[string]string),
+	}
+
+	for _, serverID := range order {
+		// Don't start a server whose dependencies didn't come up
+		if failed := m.failedDependency(serverID, result.Errors); failed != "" {
+			result.Errors[serverID] = fmt.Sprintf("dependency %s failed to start", failed)
+			continue
+		}
+
+		// Dependencies must be ready before their dependents start
+		if err := m.waitForDependencies(serverID); err != nil {
+			result.Errors[serverID] = err.Error()
+			continue
+		}
+
+		if m.isRunning(serverID) {
+			result.Skipped = append(result.Skipped, serverID)
+			continue
+		}
+
+		if err := m.StartServer(serverID); err != nil {
+			result.Errors[serverID] = err.Error()
+			continue
+		}
+		result.Started = append(result.Started, serverID)
+	}
+
+	log.Printf("Started servers in order %v (%d started, %d skipped, %d failed)",
+		order, len(result.Started), len(result.Skipped), len(result.Errors))
+	return result, nil
+}
+
+// startOrder topologically sorts the requested servers and their transitive dependencies
+func (m *Manager) startOrder(serverIDs []string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	order := make([]string, 0, len(serverIDs))
+
+	var visit func(serverID string, path []string) error
+	visit = func(serverID string, path []string) error {
+		switch state[serverID] {
+		case visiting:
+			return fmt.Errorf("dependency cycle detected: %s", strings.Join(append(path, serverID), " -> "))
+		case visited:
+			return nil
+		}
+
+		server, exists := m.servers[serverID]
+		if !exists {
+			if len(path) > 0 {
+				return fmt.Errorf("server %s depends on %s, which is not installed", path[len(path)-1], serverID)
+			}
+			return fmt.Errorf("server %s not found", serverID)
+		}
+
+		state[serverID] = visiting
+		for _, dependency := range server.DependsOn {
+			if err := visit(dependency, append(path, serverID)); err != nil {
+				return err
+			}
+		}
+		state[serverID] = visited
+
+		order = append(order, serverID)
+		return nil
+	}
+
+	for _, serverID := range serverIDs {
+		if err := visit(serverID, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// failedDependency returns the first dependency of a server that failed to start
+func (m *Manager) failedDependency(serverID string, failures map[string]string) string {
+	for _, dependency := range m.dependencies(serverID) {
+		if _, failed := failures[dependency]; failed {
+			return dependency
+		}
+	}
+	return ""
+}
+
+// waitForDependencies blocks until every dependency of a server is ready
+func (m *Manager) waitForDependencies(serverID string) error {
+	for _, dependency := range m.dependencies(serverID) {
+		if err := m.WaitForReady(dependency, dependencyReadyTimeout); err != nil {
+			return fmt.Errorf("dependency %s is not ready: %v", dependency, err)
+		}
+	}
+	return nil
+}
+
+// WaitForReady waits until a running server completes an MCP handshake
+// through its connection pool
+func (m *Manager) WaitForReady(serverID string, timeout time.Duration) error {
+	if !m.isRunning(serverID) {
+		return fmt.Errorf("server %s is not running", serverID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := m.loadBalancer.GetConnection(ctx, serverID)
+	if err != nil {
+		return err
+	}
+	m.loadBalancer.ReturnConnection(serverID, conn)
+
+	return nil
+}
+
+// dependencies returns the declared dependencies of a server
+func (m *Manager) dependencies(serverID string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if server, exists := m.servers[serverID]; exists {
+		return server.DependsOn
+	}
+	return nil
+}
+
+// isRunning reports whether a server is currently running
+func (m *Manager) isRunning(serverID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	server, exists := m.servers[serverID]
+	return exists && server.Status == "running"
+}
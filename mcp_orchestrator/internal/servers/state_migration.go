@@ -0,0 +1,138 @@
+package servers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+)
+
+// serverStateSchemaVersion is the current layout of server_state.json. Bump
+// it when ServerConfig gains fields that older state files lack, so loading
+// fills them from the server templates.
+//
+//	1: a bare map of server ID to ServerConfig (no schema_version)
+//	2: the map under "servers", alongside "schema_version"
+const serverStateSchemaVersion = 2
+
+// serverStateFile is the layout of server_state.json
+type serverStateFile struct {
+	SchemaVersion int                      `json:"schema_version"`
+	Servers       map[string]*ServerConfig `json:"servers"`
+}
+
+// decodeServerState reads a state file of any schema version, returning its
+// servers and the version it was written with
+func decodeServerState(data []byte) (map[string]*ServerConfig, int, error) {
+	var probe struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, 0, err
+	}
+
+	// Version 1 files have no schema_version key
+	if probe.SchemaVersion == nil {
+		var servers map[string]*ServerConfig
+		if err := json.Unmarshal(data, &servers); err != nil {
+			return nil, 0, err
+		}
+		return servers, 1, nil
+	}
+
+	var state serverStateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, 0, err
+	}
+	return state.Servers, state.SchemaVersion, nil
+}
+
+// migrateServerState brings servers saved by an older schema version up to
+// date by filling fields they lack from the matching server template. The
+// original file is kept as a backup.
+func (m *Manager) migrateServerState(stateFile string, data []byte, servers map[string]*ServerConfig, fromVersion int) {
+	log.Printf("Migrating server state from schema version %d to %d", fromVersion, serverStateSchemaVersion)
+
+	backupFile := fmt.Sprintf("%s.v%d.bak", stateFile, fromVersion)
+	if err := os.WriteFile(backupFile, data, 0644); err != nil {
+		log.Printf("Warning: Failed to back up server state before migration: %v", err)
+	}
+
+	templates := make(map[string]*ServerConfig)
+	for _, template := range m.GetAvailableServers() {
+		templates[template.ID] = template
+	}
+
+	for id, server := range servers {
+		template, exists := templates[id]
+		if !exists {
+			log.Printf("Warning: No template for server %s; migrated without defaults", id)
+			continue
+		}
+		if filled := fillFromTemplate(server, template); len(filled) > 0 {
+			log.Printf("Migrated server %s: filled %v from its template", id, filled)
+		}
+	}
+}
+
+// fillFromTemplate sets the fields a saved server left empty to the
+// template's values, returning the JSON names of the fields it filled
+func fillFromTemplate(server, template *ServerConfig) []string {
+	var filled []string
+	fillString := func(field *string, value, name string) {
+		if *field == "" && value != "" {
+			*field = value
+			filled = append(filled, name)
+		}
+	}
+
+	fillString(&server.Name, template.Name, "name")
+	fillString(&server.Description, template.Description, "description")
+	fillString(&server.RepoURL, template.RepoURL, "repo_url")
+	fillString(&server.Command, template.Command, "command")
+	fillString(&server.ServerType, template.ServerType, "server_type")
+	fillString(&server.Category, template.Category, "category")
+	fillString(&server.SubPath, template.SubPath, "sub_path")
+	fillString(&server.Homepage, template.Homepage, "homepage")
+	fillString(&server.DocsURL, template.DocsURL, "docs_url")
+	fillString(&server.Author, template.Author, "author")
+	fillString(&server.License, template.License, "license")
+	fillString(&server.PinnedCommit, template.PinnedCommit, "pinned_commit")
+
+	if server.Args == nil && template.Args != nil {
+		server.Args = append([]string(nil), template.Args...)
+		filled = append(filled, "args")
+	}
+	if server.DependsOn == nil && template.DependsOn != nil {
+		server.DependsOn = append([]string(nil), template.DependsOn...)
+		filled = append(filled, "depends_on")
+	}
+	if server.Port == 0 && template.Port != 0 {
+		server.Port = template.Port
+		filled = append(filled, "port")
+	}
+	if server.ToolsCount == 0 && template.ToolsCount != 0 {
+		server.ToolsCount = template.ToolsCount
+		filled = append(filled, "tools_count")
+	}
+	if server.Clone == (CloneOptions{}) && template.Clone != (CloneOptions{}) {
+		server.Clone = template.Clone
+		filled = append(filled, "clone_options")
+	}
+	if server.Build == (BuildOptions{}) && template.Build != (BuildOptions{}) {
+		server.Build = template.Build
+		filled = append(filled, "build_options")
+	}
+	if server.PreInstall == nil && template.PreInstall != nil {
+		hook := *template.PreInstall
+		server.PreInstall = &hook
+		filled = append(filled, "pre_install")
+	}
+	if server.PostInstall == nil && template.PostInstall != nil {
+		hook := *template.PostInstall
+		server.PostInstall = &hook
+		filled = append(filled, "post_install")
+	}
+
+	return filled
+}
@@ -0,0 +1,35 @@
+package servers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// CallTool invokes a tool on a running server through its connection pool
+// and returns the raw MCP result
+func (m *Manager) CallTool(ctx context.Context, serverID, toolName string, arguments map[string]interface{}) (json.RawMessage, error) {
+	// Postpones an idle stop, or starts a server that was stopped for being idle
+	if err := m.RecordActivity(serverID); err != nil {
+		return nil, err
+	}
+
+	if !m.isRunning(serverID) {
+		return nil, fmt.Errorf("server %s is not running", serverID)
+	}
+
+	conn, err := m.loadBalancer.GetConnection(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	defer m.loadBalancer.ReturnConnection(serverID, conn)
+
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+
+	return conn.Call(ctx, "tools/call", map[string]interface{}{
+		"name":      toolName,
+		"arguments": arguments,
+	})
+}
@@ -0,0 +1,119 @@
+package servers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// toolIndexTTL bounds how long discovered tools of a running server are
+// trusted before they are listed again
+const toolIndexTTL = 5 * time.Minute
+
+// DiscoveredTool is a tool a server exposed when last discovered
+type DiscoveredTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Category    string `json:"category,omitempty"` // Only when the server declares one
+	ServerID    string `json:"server_id"`
+}
+
+// toolListing is the set of tools a server exposed when last discovered
+type toolListing struct {
+	tools        []DiscoveredTool
+	names        map[string]bool
+	discoveredAt time.Time
+}
+
+// DiscoveredTools returns the tools of every installed server, along with
+// the running servers whose tools couldn't be listed
+func (m *Manager) DiscoveredTools(ctx context.Context) ([]DiscoveredTool, []string) {
+	candidates, listings, indexed := m.toolListings(ctx)
+
+	var tools []DiscoveredTool
+	var unindexed []string
+	for i, server := range candidates {
+		if !indexed[i] {
+			if server.Status == "running" {
+				unindexed = append(unindexed, server.ID)
+			}
+			continue
+		}
+		tools = append(tools, listings[i].tools...)
+	}
+
+	return tools, unindexed
+}
+
+// toolListings lists the tools of every installed server concurrently,
+// reporting per server whether its tools are known
+func (m *Manager) toolListings(ctx context.Context) ([]*ServerConfig, []toolListing, []bool) {
+	candidates := m.installedServers()
+
+	listings := make([]toolListing, len(candidates))
+	indexed := make([]bool, len(candidates))
+	var wg sync.WaitGroup
+	for i, server := range candidates {
+		wg.Add(1)
+		go func(i int, server *ServerConfig) {
+			defer wg.Done()
+			listings[i], indexed[i], _ = m.toolListing(ctx, server)
+		}(i, server)
+	}
+	wg.Wait()
+
+	return candidates, listings, indexed
+}
+
+// installedServers returns the servers whose tools can be listed
+func (m *Manager) installedServers() []*ServerConfig {
+	var installed []*ServerConfig
+	for _, server := range m.ListServers() {
+		if server.Status != "not_installed" {
+			installed = append(installed, server)
+		}
+	}
+	return installed
+}
+
+// toolListing returns a server's known tools, discovering them when the
+// server is running and its entry is missing or stale. Stopped servers keep
+// the tools they exposed when last running. A failed discovery is returned
+// along with any stale listing.
+func (m *Manager) toolListing(ctx context.Context, server *ServerConfig) (toolListing, bool, error) {
+	m.toolIndexMu.Lock()
+	listing, exists := m.toolIndex[server.ID]
+	m.toolIndexMu.Unlock()
+
+	fresh := exists && time.Since(listing.discoveredAt) < toolIndexTTL &&
+		!listing.discoveredAt.Before(server.ToolsRefreshedAt)
+	if fresh || server.Status != "running" {
+		return listing, exists, nil
+	}
+
+	tools, err := m.discoverTools(ctx, server.ID)
+	if err != nil {
+		// A stale listing is better than none while the server is unresponsive
+		return listing, exists, err
+	}
+
+	return m.indexTools(server.ID, tools), true, nil
+}
+
+// indexTools records the tools a server exposes
+func (m *Manager) indexTools(serverID string, tools []DiscoveredTool) toolListing {
+	listing := toolListing{
+		tools:        tools,
+		names:        make(map[string]bool, len(tools)),
+		discoveredAt: time.Now(),
+	}
+	for _, tool := range tools {
+		listing.names[tool.Name] = true
+	}
+
+	m.toolIndexMu.Lock()
+	m.toolIndex[serverID] = listing
+	m.toolIndexMu.Unlock()
+
+	return listing
+}
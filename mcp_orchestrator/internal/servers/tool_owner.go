@@ -0,0 +1,65 @@
+package servers
+
+import (
+	"context"
+	"time"
+)
+
+// ToolOwner describes a server that exposes a tool
+type ToolOwner struct {
+	ServerID     string    `json:"server_id"`
+	ServerName   string    `json:"server_name"`
+	Category     string    `json:"category"`
+	Status       string    `json:"status"`
+	Disabled     bool      `json:"disabled"`
+	Reachable    bool      `json:"reachable"`
+	Health       string    `json:"health"`
+	DiscoveredAt time.Time `json:"discovered_at"`
+}
+
+// ToolOwnership reports which servers expose a tool
+type ToolOwnership struct {
+	Tool      string      `json:"tool"`
+	Owners    []ToolOwner `json:"owners"`
+	Duplicate bool        `json:"duplicate"` // More than one server exposes the tool
+	// Running servers whose tools couldn't be listed, so they may also own it
+	Unindexed []string `json:"unindexed_servers,omitempty"`
+}
+
+// FindToolOwners reports the servers exposing a tool. Running servers are
+// discovered when their tool names aren't known or are stale; stopped
+// servers are matched against the names they exposed when last running.
+func (m *Manager) FindToolOwners(ctx context.Context, toolName string) ToolOwnership {
+	ownership := ToolOwnership{Tool: toolName, Owners: []ToolOwner{}}
+
+	candidates, listings, indexed := m.toolListings(ctx)
+
+	for i, server := range candidates {
+		if !indexed[i] {
+			if server.Status == "running" {
+				ownership.Unindexed = append(ownership.Unindexed, server.ID)
+			}
+			continue
+		}
+		if !listings[i].names[toolName] {
+			continue
+		}
+
+		owner := ToolOwner{
+			ServerID:     server.ID,
+			ServerName:   server.Name,
+			Category:     server.Category,
+			Status:       server.Status,
+			Disabled:     server.Disabled,
+			DiscoveredAt: listings[i].discoveredAt,
+		}
+		if health, err := m.ProbeServer(server.ID); err == nil {
+			owner.Health = health.Status
+			owner.Reachable = health.Status == "healthy" && !server.Disabled
+		}
+		ownership.Owners = append(ownership.Owners, owner)
+	}
+
+	ownership.Duplicate = len(ownership.Owners) > 1
+	return ownership
+}
@@ -0,0 +1,118 @@
+package servers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// RefreshResult reports the outcome of re-running tool discovery for a server
+type RefreshResult struct {
+	ServerID    string    `json:"server_id"`
+	ToolsCount  int       `json:"tools_count"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// RefreshTools re-runs tool discovery for a running server and records the
+// new tool count. The stdio proxy drops its cached tools for any server
+// whose tools_refreshed_at is newer than its cache entry.
+func (m *Manager) RefreshTools(ctx context.Context, serverID string) (RefreshResult, error) {
+	if _, err := m.GetServer(serverID); err != nil {
+		return RefreshResult{}, err
+	}
+	if !m.isRunning(serverID) {
+		return RefreshResult{}, fmt.Errorf("server %s is not running", serverID)
+	}
+
+	result := RefreshResult{ServerID: serverID, RefreshedAt: time.Now()}
+
+	// Invalidate cached discovery even if the new discovery below fails
+	m.mu.Lock()
+	if server, exists := m.servers[serverID]; exists {
+		server.ToolsRefreshedAt = result.RefreshedAt
+	}
+	m.mu.Unlock()
+
+	tools, err := m.discoverTools(ctx, serverID)
+	count := len(tools)
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.ToolsCount = count
+		m.indexTools(serverID, tools)
+	}
+
+	m.mu.Lock()
+	if server, exists := m.servers[serverID]; exists && err == nil {
+		server.ToolsCount = count
+	}
+	if saveErr := m.saveServerState(); saveErr != nil {
+		log.Printf("Warning: Failed to save server state after tool refresh: %v", saveErr)
+	}
+	m.mu.Unlock()
+
+	if err != nil {
+		return result, fmt.Errorf("tool discovery failed for server %s: %v", serverID, err)
+	}
+
+	log.Printf("Refreshed tools for %s: %d tools", serverID, count)
+	return result, nil
+}
+
+// RefreshAllTools re-runs tool discovery for every running server concurrently
+func (m *Manager) RefreshAllTools(ctx context.Context) []RefreshResult {
+	var running []string
+	for _, server := range m.ListServers() {
+		if server.Status == "running" {
+			running = append(running, server.ID)
+		}
+	}
+
+	results := make([]RefreshResult, len(running))
+	var wg sync.WaitGroup
+	for i, serverID := range running {
+		wg.Add(1)
+		go func(i int, serverID string) {
+			defer wg.Done()
+			result, err := m.RefreshTools(ctx, serverID)
+			if err != nil && result.ServerID == "" {
+				result = RefreshResult{ServerID: serverID, RefreshedAt: time.Now(), Error: err.Error()}
+			}
+			results[i] = result
+		}(i, serverID)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// discoverTools lists a server's tools through its connection pool
+func (m *Manager) discoverTools(ctx context.Context, serverID string) ([]DiscoveredTool, error) {
+	conn, err := m.loadBalancer.GetConnection(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+	defer m.loadBalancer.ReturnConnection(serverID, conn)
+
+	raw, err := conn.Call(ctx, "tools/list", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	var listed struct {
+		Tools []DiscoveredTool `json:"tools"`
+	}
+	if err := json.Unmarshal(raw, &listed); err != nil {
+		return nil, fmt.Errorf("invalid tools/list response: %v", err)
+	}
+
+	for i := range listed.Tools {
+		listed.Tools[i].ServerID = serverID
+	}
+
+	return listed.Tools, nil
+}
@@ -0,0 +1,99 @@
+package servers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ServerTools is one server's tools, reported as soon as listing them finishes
+type ServerTools struct {
+	ServerID    string           `json:"server_id"`
+	ServerName  string           `json:"server_name"`
+	Status      string           `json:"status"`
+	Tools       []DiscoveredTool `json:"tools"`
+	Diagnostics []ToolDiagnostic `json:"diagnostics"`
+	DurationMs  int64            `json:"duration_ms"`
+}
+
+// ToolDiagnostic explains why a server's tools are missing or may be out of date
+type ToolDiagnostic struct {
+	Type        string `json:"type"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// StreamDiscoveredTools lists the tools of every installed server
+// concurrently, as DiscoveredTools does, calling emit with each server's
+// tools as soon as they are known. emit is called from the caller's
+// goroutine, one server at a time; StreamDiscoveredTools returns once every
+// server has been reported.
+func (m *Manager) StreamDiscoveredTools(ctx context.Context, emit func(ServerTools)) {
+	candidates := m.installedServers()
+
+	results := make(chan ServerTools)
+	var wg sync.WaitGroup
+	for _, server := range candidates {
+		wg.Add(1)
+		go func(server *ServerConfig) {
+			defer wg.Done()
+			results <- m.serverTools(ctx, server)
+		}(server)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		emit(result)
+	}
+}
+
+// serverTools lists one server's tools with diagnostics for the stream
+func (m *Manager) serverTools(ctx context.Context, server *ServerConfig) ServerTools {
+	started := time.Now()
+	listing, indexed, err := m.toolListing(ctx, server)
+
+	result := ServerTools{
+		ServerID:    server.ID,
+		ServerName:  server.Name,
+		Status:      server.Status,
+		Tools:       []DiscoveredTool{},
+		Diagnostics: []ToolDiagnostic{},
+	}
+	if indexed {
+		result.Tools = listing.tools
+	}
+
+	switch {
+	case err != nil && indexed:
+		result.Diagnostics = append(result.Diagnostics, ToolDiagnostic{
+			Type:        "stale_tools",
+			Severity:    "warning",
+			Description: fmt.Sprintf("Discovery failed, showing tools from %s: %v", listing.discoveredAt.Format(time.RFC3339), err),
+		})
+	case err != nil:
+		result.Diagnostics = append(result.Diagnostics, ToolDiagnostic{
+			Type:        "discovery_failed",
+			Severity:    "error",
+			Description: fmt.Sprintf("Failed to list tools: %v", err),
+		})
+	case server.Status != "running" && indexed:
+		result.Diagnostics = append(result.Diagnostics, ToolDiagnostic{
+			Type:        "server_not_running",
+			Severity:    "info",
+			Description: "Server is not running; showing the tools it exposed when last running",
+		})
+	case server.Status != "running":
+		result.Diagnostics = append(result.Diagnostics, ToolDiagnostic{
+			Type:        "server_not_running",
+			Severity:    "warning",
+			Description: "Server is not running and its tools have never been listed",
+		})
+	}
+
+	result.DurationMs = time.Since(started).Milliseconds()
+	return result
+}
@@ -1,33 +1,70 @@
 package ui
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"mcp_orchestrator/internal/analytics"
+	"mcp_orchestrator/internal/performance"
+	"mcp_orchestrator/internal/profiles"
 	"mcp_orchestrator/internal/servers"
+	"mcp_orchestrator/internal/version"
 
 	"github.com/gin-gonic/gin"
 )
 
 // API handles HTTP requests for the UI
 type API struct {
-	serverManager *servers.Manager
+	serverManager    *servers.Manager
+	analyticsTracker *analytics.Tracker
+	profileManager   *profiles.ProfileManager
+	proxyStates      *performance.ProxyStates
+	budgets          apiBudgets // Budgets of calls made through the API
 }
 
 // NewAPI creates a new UI API instance
-func NewAPI(serverManager *servers.Manager) *API {
+func NewAPI(serverManager *servers.Manager, analyticsTracker *analytics.Tracker) *API {
 	return &API{
-		serverManager: serverManager,
+		serverManager:    serverManager,
+		analyticsTracker: analyticsTracker,
 	}
 }
 
+// SetProfileManager sets the profiles the profile endpoints serve
+func (a *API) SetProfileManager(profileManager *profiles.ProfileManager) {
+	a.profileManager = profileManager
+}
+
+// SetProxyStates sets the store of runtime state reported by stdio proxies
+func (a *API) SetProxyStates(proxyStates *performance.ProxyStates) {
+	a.proxyStates = proxyStates
+}
+
+// activeProfileID returns the IDs of the active profiles as recorded with
+// tool calls, comma-separated when several are active
+func (a *API) activeProfileID() string {
+	if a.profileManager == nil {
+		return ""
+	}
+	return strings.Join(a.profileManager.GetActiveProfileIDs(), ",")
+}
+
 // InstallRequest represents a server installation request
 type InstallRequest struct {
-	ServerID string            `json:"server_id"`
-	Config   map[string]string `json:"config"`
+	ServerID      string            `json:"server_id"`
+	Config        map[string]string `json:"config"`
+	GitToken      string            `json:"git_token,omitempty"`        // Access token for private HTTPS repositories
+	GitSSHKeyPath string            `json:"git_ssh_key_path,omitempty"` // Private key for SSH repositories
+	Commit        string            `json:"commit,omitempty"`           // Full commit hash to pin the install to
 }
 
 // ListServers returns all available and configured servers
@@ -65,6 +102,13 @@ func (a *API) ListServers(c *gin.Context) {
 func (a *API) InstallServer(c *gin.Context) {
 	var req InstallRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "Request body too large",
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid request format",
 		})
@@ -193,7 +237,8 @@ func (a *API) InstallServer(c *gin.Context) {
 	}
 
 	// Start installation
-	if err := a.serverManager.InstallServer(req.ServerID, req.Config); err != nil {
+	auth := servers.GitAuth{Token: req.GitToken, SSHKeyPath: req.GitSSHKeyPath}
+	if err := a.serverManager.InstallServer(req.ServerID, req.Config, auth, req.Commit); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
@@ -221,6 +266,37 @@ func (a *API) StartServer(c *gin.Context) {
 	})
 }
 
+// StartServersRequest represents a request to start several servers at once
+type StartServersRequest struct {
+	ServerIDs []string `json:"server_ids"`
+}
+
+// StartServers starts several servers, dependencies first
+func (a *API) StartServers(c *gin.Context) {
+	var req StartServersRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.ServerIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: server_ids is required",
+		})
+		return
+	}
+
+	result, err := a.serverManager.StartMany(req.ServerIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if len(result.Errors) > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	c.JSON(status, result)
+}
+
 // StopServer stops a specific server
 func (a *API) StopServer(c *gin.Context) {
 	serverID := c.Param("id")
@@ -237,6 +313,70 @@ func (a *API) StopServer(c *gin.Context) {
 	})
 }
 
+// RecordServerActivity marks a server as used by a tool call routed outside
+// the orchestrator, postponing its idle stop or starting it if it was stopped
+// for being idle
+func (a *API) RecordServerActivity(c *gin.Context) {
+	serverID := c.Param("id")
+
+	if err := a.serverManager.RecordActivity(serverID); err != nil {
+		status := http.StatusInternalServerError
+		if _, getErr := a.serverManager.GetServer(serverID); getErr != nil {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"server_id": serverID,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// GetLifecycleDiagnostics lists servers recently stopped for being idle or
+// started again for a tool call
+func (a *API) GetLifecycleDiagnostics(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"diagnostics": a.serverManager.GetLifecycleEvents(),
+		"timestamp":   time.Now().Unix(),
+	})
+}
+
+// DisableServer stops a server and hides it from discovery without uninstalling it
+func (a *API) DisableServer(c *gin.Context) {
+	serverID := c.Param("id")
+
+	if err := a.serverManager.DisableServer(serverID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Server disabled",
+	})
+}
+
+// EnableServer makes a disabled server available again
+func (a *API) EnableServer(c *gin.Context) {
+	serverID := c.Param("id")
+
+	if err := a.serverManager.EnableServer(serverID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Server enabled",
+	})
+}
+
 // GetServerStatus returns the status of a specific server
 func (a *API) GetServerStatus(c *gin.Context) {
 	serverID := c.Param("id")
@@ -250,8 +390,368 @@ func (a *API) GetServerStatus(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status": server.Status,
-		"port":   server.Port,
+		"status": server.Status,
+		"port":   server.Port,
+	})
+}
+
+// GetServerHealth probes a server over MCP to confirm it is responsive
+func (a *API) GetServerHealth(c *gin.Context) {
+	serverID := c.Param("id")
+
+	health, err := a.serverManager.ProbeServer(serverID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"health":    health,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// RefreshServerTools invalidates cached tool discovery for a server and
+// re-runs discovery, returning the new tool count
+func (a *API) RefreshServerTools(c *gin.Context) {
+	serverID := c.Param("id")
+
+	if _, err := a.serverManager.GetServer(serverID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	result, err := a.serverManager.RefreshTools(c.Request.Context(), serverID)
+	if err != nil {
+		// A failed discovery still invalidated the cache, so report the result
+		if result.ServerID != "" {
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error":     err.Error(),
+				"refresh":   result,
+				"timestamp": time.Now().Unix(),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"refresh":   result,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// RefreshAllServerTools re-runs tool discovery for every running server
+func (a *API) RefreshAllServerTools(c *gin.Context) {
+	results := a.serverManager.RefreshAllTools(c.Request.Context())
+
+	totalTools, failed := 0, 0
+	for _, result := range results {
+		totalTools += result.ToolsCount
+		if result.Error != "" {
+			failed++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"refreshed":   results,
+		"total_tools": totalTools,
+		"failed":      failed,
+		"timestamp":   time.Now().Unix(),
+	})
+}
+
+// CallTool invokes a tool on a server directly so tools can be smoke-tested
+// without an MCP client. The body is the tool's arguments object. The call
+// passes the same checks as one through the stdio proxy (see admitToolCall).
+func (a *API) CallTool(c *gin.Context) {
+	serverID := c.Param("id")
+	toolName := c.Param("tool")
+
+	arguments := map[string]interface{}{}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&arguments); err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+					"error": "Request body too large",
+				})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Arguments must be a JSON object",
+			})
+			return
+		}
+	}
+
+	server, err := a.serverManager.GetServer(serverID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if rejection := a.admitToolCall(c.Request.Context(), server, toolName); rejection != nil {
+		c.JSON(rejection.Status, rejection.Body)
+		return
+	}
+
+	result, call, err := a.trackedToolCall(c, server, toolName, arguments)
+	if err != nil {
+		// JSON-RPC errors from the server are passed through as-is
+		var rpcErr *performance.RPCError
+		if errors.As(err, &rpcErr) {
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error":     json.RawMessage(rpcErr.Raw),
+				"server_id": serverID,
+				"tool":      toolName,
+				"timestamp": time.Now().Unix(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":     err.Error(),
+			"server_id": serverID,
+			"tool":      toolName,
+			"timestamp": time.Now().Unix(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"result":      result,
+		"server_id":   serverID,
+		"tool":        toolName,
+		"duration_ms": time.Since(call.StartTime).Milliseconds(),
+		"timestamp":   time.Now().Unix(),
+	})
+}
+
+// trackedToolCall calls a server's tool and records the call in analytics
+func (a *API) trackedToolCall(c *gin.Context, server *servers.ServerConfig, toolName string, arguments map[string]interface{}) (json.RawMessage, *analytics.ToolCall, error) {
+	call := a.analyticsTracker.StartToolCall(toolName, server.ID, a.activeProfileID(), arguments)
+	call.Category = server.Category
+	call.UserAgent = c.Request.UserAgent()
+	call.ClientIP = c.ClientIP()
+
+	result, err := a.serverManager.CallTool(c.Request.Context(), server.ID, toolName, arguments)
+	if err != nil {
+		a.analyticsTracker.CompleteToolCall(call, false, err.Error(), 0)
+		return nil, call, err
+	}
+
+	// Tools report their own failures with isError in an otherwise successful result
+	var outcome struct {
+		IsError bool `json:"isError"`
+	}
+	json.Unmarshal(result, &outcome)
+	a.analyticsTracker.CompleteToolCall(call, !outcome.IsError, "", len(result))
+
+	return result, call, nil
+}
+
+// GetEffectiveConfig returns the command, arguments, working directory and
+// environment a server would be started with, secrets masked
+func (a *API) GetEffectiveConfig(c *gin.Context) {
+	serverID := c.Param("id")
+
+	config, err := a.serverManager.GetEffectiveConfig(serverID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"config":    config,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// GetSharedEnv returns the environment variables given to every server,
+// secrets masked
+func (a *API) GetSharedEnv(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"env":       a.serverManager.SharedEnv(),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// SetSharedEnv replaces the environment variables given to every server.
+// Servers already running pick the change up when next restarted.
+func (a *API) SetSharedEnv(c *gin.Context) {
+	var req struct {
+		Env map[string]string `json:"env"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	if err := a.serverManager.SetSharedEnv(req.Env); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"env":       a.serverManager.SharedEnv(),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// GetCategoryAnalytics returns tool usage rolled up by tool category
+func (a *API) GetCategoryAnalytics(c *gin.Context) {
+	days := 7
+	if daysStr := c.Query("days"); daysStr != "" {
+		parsedDays, err := strconv.Atoi(daysStr)
+		if err != nil || parsedDays <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid days %q", daysStr),
+			})
+			return
+		}
+		days = parsedDays
+	}
+
+	profileID := c.Query("profile")
+	if profileID != "" {
+		if a.profileManager == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Profiles are not available",
+			})
+			return
+		}
+		if _, err := a.profileManager.GetProfile(profileID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+
+	analytics, err := a.analyticsTracker.GetAnalytics("daily", days, profileID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories":  analytics.CategoryMetrics,
+		"total_calls": analytics.TotalToolCalls,
+		"days":        days,
+		"profile_id":  profileID,
+		"timestamp":   time.Now().Unix(),
+	})
+}
+
+// AnalyticsConfigRequest is a partial update of the analytics tracker settings
+type AnalyticsConfigRequest struct {
+	FlushIntervalSeconds *int  `json:"flush_interval_seconds"`
+	MaxMemoryCalls       *int  `json:"max_memory_calls"`
+	RetentionDays        *int  `json:"retention_days"`
+	EnableDetailedLog    *bool `json:"enable_detailed_log"`
+}
+
+// analyticsConfigResponse renders tracker settings with the interval in seconds
+func analyticsConfigResponse(config analytics.TrackerConfig) gin.H {
+	return gin.H{
+		"enabled":                config.Enabled,
+		"flush_interval_seconds": int(config.FlushInterval.Seconds()),
+		"max_memory_calls":       config.MaxMemoryCalls,
+		"retention_days":         config.RetentionDays,
+		"enable_detailed_log":    config.EnableDetailedLog,
+	}
+}
+
+// GetAnalyticsConfig returns the analytics tracker settings
+func (a *API) GetAnalyticsConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"config":    analyticsConfigResponse(a.analyticsTracker.GetConfig()),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// UpdateAnalyticsConfig adjusts the analytics tracker settings without a restart
+func (a *API) UpdateAnalyticsConfig(c *gin.Context) {
+	var req AnalyticsConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	// Fields left out of the request keep their current values
+	config := a.analyticsTracker.GetConfig()
+	if req.FlushIntervalSeconds != nil {
+		config.FlushInterval = time.Duration(*req.FlushIntervalSeconds) * time.Second
+	}
+	if req.MaxMemoryCalls != nil {
+		config.MaxMemoryCalls = *req.MaxMemoryCalls
+	}
+	if req.RetentionDays != nil {
+		config.RetentionDays = *req.RetentionDays
+	}
+	if req.EnableDetailedLog != nil {
+		config.EnableDetailedLog = *req.EnableDetailedLog
+	}
+
+	if err := a.analyticsTracker.UpdateConfig(config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Analytics configuration updated",
+		"config":    analyticsConfigResponse(a.analyticsTracker.GetConfig()),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// AnalyticsToggleRequest turns analytics tracking on or off
+type AnalyticsToggleRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// ToggleAnalytics enables or disables analytics tracking without a restart
+func (a *API) ToggleAnalytics(c *gin.Context) {
+	var req AnalyticsToggleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Enabled == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Request must include enabled",
+		})
+		return
+	}
+
+	a.analyticsTracker.SetEnabled(*req.Enabled)
+
+	message := "Analytics disabled"
+	if *req.Enabled {
+		message = "Analytics enabled"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":   message,
+		"config":    analyticsConfigResponse(a.analyticsTracker.GetConfig()),
+		"timestamp": time.Now().Unix(),
 	})
 }
 
@@ -323,21 +823,33 @@ func (a *API) GetCategories(c *gin.Context) {
 		categoryMap[cat.ID] = cat
 	}
 
-	// Count servers and tools for each category
+	// Count servers and tools for each category, using the category the proxy
+	// gives their tools. Unknown categories are listed as-is.
 	for _, server := range servers {
-		if cat, exists := categoryMap[server.Category]; exists {
-			cat.ServerCount++
-			cat.ToolsCount += server.ToolsCount
+		categoryID := profiles.ToolCategory(server.ID, server.Category)
+
+		cat, exists := categoryMap[categoryID]
+		if !exists {
+			cat = &CategoryInfo{ID: categoryID, Name: categoryID, Icon: "🔧"}
+			categoryMap[categoryID] = cat
 		}
+		cat.ServerCount++
+		cat.ToolsCount += server.ToolsCount
 	}
 
-	// Filter out categories with no servers
-	result := make([]*CategoryInfo, 0)
-	for _, cat := range categories {
+	// Filter out categories with no servers, ordered the same way as the proxy's tools/categories
+	ids := make([]string, 0, len(categoryMap))
+	for id, cat := range categoryMap {
 		if cat.ServerCount > 0 {
-			result = append(result, cat)
+			ids = append(ids, id)
 		}
 	}
+	profiles.SortCategories(ids)
+
+	result := make([]*CategoryInfo, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, categoryMap[id])
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"categories": result,
@@ -436,6 +948,99 @@ func (a *API) AutoFixServer(c *gin.Context) {
 	})
 }
 
+// ClaudeConfigRequest selects the stdio proxy the Claude Desktop entry points at
+type ClaudeConfigRequest struct {
+	StdioPath string `json:"stdio_path,omitempty"` // Defaults to the proxy next to the orchestrator binary
+}
+
+// claudeStdioPath returns the requested stdio proxy path, or the default one
+func claudeStdioPath(requested string) (string, error) {
+	if requested != "" {
+		return filepath.Abs(requested)
+	}
+	return servers.StdioBinaryPath()
+}
+
+// PreviewClaudeConfig shows how applying the Claude Desktop config would change
+// the file, without writing it. ?stdio_path= previews a different proxy path.
+func (a *API) PreviewClaudeConfig(c *gin.Context) {
+	stdioPath, err := claudeStdioPath(c.Query("stdio_path"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	preview, err := servers.PreviewClaudeDesktop(stdioPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	_, statErr := os.Stat(stdioPath)
+	c.JSON(http.StatusOK, gin.H{
+		"preview":      preview,
+		"stdio_path":   stdioPath,
+		"stdio_exists": statErr == nil,
+		"timestamp":    time.Now().Unix(),
+	})
+}
+
+// ApplyClaudeConfig writes the Claude Desktop config shown by
+// PreviewClaudeConfig, returning the changes that were made
+func (a *API) ApplyClaudeConfig(c *gin.Context) {
+	var req ClaudeConfigRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request format",
+			})
+			return
+		}
+	}
+
+	stdioPath, err := claudeStdioPath(req.StdioPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	preview, err := servers.PreviewClaudeDesktop(stdioPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	configFile, entry, err := servers.ConfigureClaudeDesktop(stdioPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	message := "Claude Desktop config is already up to date"
+	if preview.Changed {
+		message = "Claude Desktop config updated; restart Claude Desktop to apply it"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     message,
+		"config_file": configFile,
+		"entry":       entry,
+		"changed":     preview.Changed,
+		"changes":     preview.Changes,
+		"timestamp":   time.Now().Unix(),
+	})
+}
+
 // GetToolDiagnostics gets tool discovery diagnostics
 func (a *API) GetToolDiagnostics(c *gin.Context) {
 	// This would typically be called by the enhanced discovery system
@@ -447,11 +1052,250 @@ func (a *API) GetToolDiagnostics(c *gin.Context) {
 	})
 }
 
+// GetDiscoveryCache lists the tools cached per server by discovery
+func (a *API) GetDiscoveryCache(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"entries":   a.serverManager.DiscoveryCache(),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// ClearDiscoveryCache drops the cached tools of the server in the path, or
+// of every server, so they are discovered again on the next lookup
+func (a *API) ClearDiscoveryCache(c *gin.Context) {
+	cleared, err := a.serverManager.ClearDiscoveryCache(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cleared":   cleared,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// GetToolOwner reports which servers expose a tool, using the same tool
+// names the proxy routes calls by
+func (a *API) GetToolOwner(c *gin.Context) {
+	toolName := c.Param("name")
+
+	ownership := a.serverManager.FindToolOwners(c.Request.Context(), toolName)
+	if len(ownership.Owners) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":     fmt.Sprintf("no server exposes tool %s", toolName),
+			"ownership": ownership,
+			"timestamp": time.Now().Unix(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ownership": ownership,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// GetProfileTools previews the tools a profile would expose, applying its
+// filters and limits to the currently discovered tools without activating it
+func (a *API) GetProfileTools(c *gin.Context) {
+	if a.profileManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Profiles are not available",
+		})
+		return
+	}
+
+	profile, err := a.profileManager.GetProfile(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tools, unindexed := a.discoveredProfileTools(c.Request.Context())
+	selection, _ := a.selectProfileTools(profile, tools)
+
+	c.JSON(http.StatusOK, gin.H{
+		"profile_id":        profile.ID,
+		"active":            profile.Active,
+		"selection":         selection,
+		"unindexed_servers": unindexed,
+		"timestamp":         time.Now().Unix(),
+	})
+}
+
+// SimulateProfile runs a profile's filters and limits against the tools
+// discovered now, without activating it. It reports the tools that would be
+// exposed, the servers the profile enables that are missing, stopped or
+// couldn't be listed, and the limits that would drop tools.
+func (a *API) SimulateProfile(c *gin.Context) {
+	if a.profileManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Profiles are not available",
+		})
+		return
+	}
+
+	profile, err := a.profileManager.GetProfile(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tools, unindexed := a.discoveredProfileTools(c.Request.Context())
+	selection, capped := a.selectProfileTools(profile, tools)
+
+	discoveredByServer := make(map[string]int)
+	for _, tool := range tools {
+		discoveredByServer[tool.ServerID]++
+	}
+	unindexedServers := make(map[string]bool)
+	for _, serverID := range unindexed {
+		unindexedServers[serverID] = true
+	}
+	if unindexed == nil {
+		unindexed = []string{}
+	}
+	available := make(map[string]bool)
+	for _, server := range a.serverManager.GetAvailableServers() {
+		available[server.ID] = true
+	}
+
+	// Servers the profile enables by name, or through server_configs when it
+	// has no enabled_servers list
+	referenced := append([]string(nil), profile.EnabledServers...)
+	if len(referenced) == 0 {
+		for serverID, config := range profile.ServerConfigs {
+			if config.Enabled {
+				referenced = append(referenced, serverID)
+			}
+		}
+	}
+	sort.Strings(referenced)
+
+	servers := make([]gin.H, 0, len(referenced))
+	missing := []string{}
+	notRunning := []string{}
+	for _, serverID := range referenced {
+		status := "unknown"
+		if server, err := a.serverManager.GetServer(serverID); err == nil {
+			status = server.Status
+		} else if available[serverID] {
+			status = "not_installed"
+		}
+		if unindexedServers[serverID] {
+			status = "unindexed"
+		}
+
+		switch status {
+		case "unknown", "not_installed":
+			missing = append(missing, serverID)
+		case "running", "unindexed":
+		default:
+			notRunning = append(notRunning, serverID)
+		}
+		servers = append(servers, gin.H{
+			"id":               serverID,
+			"status":           status,
+			"discovered_tools": discoveredByServer[serverID],
+			"selected_tools":   selection.ToolsByServer[serverID],
+		})
+	}
+
+	// Caps apply before the profile's limits, as in the proxy. A cap on a
+	// server the profile leaves out drops nothing the profile would expose.
+	excluded := make(map[string]bool, len(selection.ExcludedServers))
+	for _, serverID := range selection.ExcludedServers {
+		excluded[serverID] = true
+	}
+	limitsHit := []string{}
+	cappedServers := []string{}
+	for _, serverID := range capped {
+		if !excluded[serverID] {
+			limitsHit = append(limitsHit, "server_configs."+serverID+".max_exposed_tools")
+			cappedServers = append(cappedServers, serverID)
+		}
+	}
+	limitsHit = append(limitsHit, selection.LimitsHit...)
+
+	c.JSON(http.StatusOK, gin.H{
+		"profile_id":          profile.ID,
+		"active":              profile.Active,
+		"selection":           selection,
+		"servers":             servers,
+		"missing_servers":     missing,
+		"not_running_servers": notRunning,
+		"unindexed_servers":   unindexed,
+		"truncation": gin.H{
+			"truncated":      len(limitsHit) > 0,
+			"limits_hit":     limitsHit,
+			"limit_excluded": selection.LimitExcluded,
+			"capped_servers": cappedServers,
+		},
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// selectProfileTools applies a profile to discovered tools the way the stdio
+// proxy's tools/list does: each server's max_exposed_tools cap first, then
+// the profile's enabled servers, filters and tool limits. It also returns
+// the servers that were capped.
+func (a *API) selectProfileTools(profile *profiles.Profile, tools []profiles.Tool) (profiles.ToolSelection, []string) {
+	serverCategories := make(map[string]string)
+	for _, server := range a.serverManager.ListServers() {
+		serverCategories[server.ID] = server.Category
+	}
+
+	exposed, capped := profile.ExposeTools(tools)
+	return profile.FilterTools(exposed, serverCategories), capped
+}
+
+// discoveredProfileTools returns the tools of every installed server as
+// profile filtering sees them, along with the running servers whose tools
+// couldn't be listed. Tools keep the category they declare;
+// selectProfileTools gives the rest their server's.
+func (a *API) discoveredProfileTools(ctx context.Context) ([]profiles.Tool, []string) {
+	discovered, unindexed := a.serverManager.DiscoveredTools(ctx)
+	tools := make([]profiles.Tool, 0, len(discovered))
+	for _, tool := range discovered {
+		tools = append(tools, profiles.Tool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Category:    tool.Category,
+			ServerID:    tool.ServerID,
+		})
+	}
+	return tools, unindexed
+}
+
+// serverCategory returns the category of a server's tools, as the proxy assigns it
+func (a *API) serverCategory(serverID string) string {
+	if server, err := a.serverManager.GetServer(serverID); err == nil {
+		return profiles.ToolCategory(serverID, server.Category)
+	}
+	return profiles.ToolCategory(serverID, "")
+}
+
 // GetSystemHealth returns overall system health status
 func (a *API) GetSystemHealth(c *gin.Context) {
 	// Get all servers
 	allServers := a.serverManager.ListServers()
 
+	// Probe running servers so hung processes aren't counted as healthy
+	probes := a.serverManager.ProbeRunningServers()
+	unresponsiveServers := 0
+	for _, probe := range probes {
+		if probe.Status == "unresponsive" {
+			unresponsiveServers++
+		}
+	}
+
 	// Count servers by status
 	statusCounts := make(map[string]int)
 	var totalServers, runningServers, errorServers int
@@ -470,58 +1314,221 @@ func (a *API) GetSystemHealth(c *gin.Context) {
 	// Calculate health score (0-100)
 	healthScore := 100
 	if totalServers > 0 {
-		healthScore = (runningServers * 100) / totalServers
+		healthScore = ((runningServers - unresponsiveServers) * 100) / totalServers
 	}
 
 	// Determine overall status
 	overallStatus := "healthy"
-	if errorServers > 0 {
+	if errorServers > 0 || unresponsiveServers > 0 {
 		overallStatus = "degraded"
 	}
-	if runningServers == 0 && totalServers > 0 {
+	if runningServers-unresponsiveServers <= 0 && totalServers > 0 {
 		overallStatus = "unhealthy"
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"health": map[string]interface{}{
-			"status":           overallStatus,
-			"score":            healthScore,
-			"total_servers":    totalServers,
-			"running_servers":  runningServers,
-			"error_servers":    errorServers,
-			"status_breakdown": statusCounts,
-			"timestamp":        time.Now().Unix(),
+			"status":               overallStatus,
+			"score":                healthScore,
+			"total_servers":        totalServers,
+			"running_servers":      runningServers,
+			"error_servers":        errorServers,
+			"unresponsive_servers": unresponsiveServers,
+			"probes":               probes,
+			"status_breakdown":     statusCounts,
+			"timestamp":            time.Now().Unix(),
+			"uptime_seconds":       int64(version.Uptime().Seconds()),
+			"started_at":           version.StartTime.Unix(),
+			"version":              version.Version,
+			"reconciled":           a.serverManager.GetReconcileResults(),
 		},
 	})
 }
 
+// errorQuery holds the filtering and pagination parameters of the errors endpoints
+type errorQuery struct {
+	filter servers.ErrorFilter
+	limit  int // 0 means no limit
+	offset int
+}
+
+// parseErrorQuery parses severity, type, since (RFC3339 or unix seconds), limit and offset
+func parseErrorQuery(c *gin.Context) (errorQuery, error) {
+	query := errorQuery{
+		filter: servers.ErrorFilter{
+			Severity: c.Query("severity"),
+			Type:     c.Query("type"),
+		},
+	}
+
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		if unix, err := strconv.ParseInt(sinceStr, 10, 64); err == nil {
+			query.filter.Since = time.Unix(unix, 0)
+		} else if since, err := time.Parse(time.RFC3339, sinceStr); err == nil {
+			query.filter.Since = since
+		} else {
+			return query, fmt.Errorf("invalid since %q: use RFC3339 or unix seconds", sinceStr)
+		}
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			return query, fmt.Errorf("invalid limit %q", limitStr)
+		}
+		query.limit = limit
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return query, fmt.Errorf("invalid offset %q", offsetStr)
+		}
+		query.offset = offset
+	}
+
+	return query, nil
+}
+
+// apply filters and paginates a list of errors, returning the page and the filtered total
+func (q errorQuery) apply(errs []*servers.EnhancedError) ([]*servers.EnhancedError, int) {
+	filtered := servers.FilterErrors(errs, q.filter)
+	total := len(filtered)
+
+	if q.offset >= total {
+		return []*servers.EnhancedError{}, total
+	}
+	filtered = filtered[q.offset:]
+	if q.limit > 0 && len(filtered) > q.limit {
+		filtered = filtered[:q.limit]
+	}
+
+	return filtered, total
+}
+
 // GetServerErrors returns enhanced error information for a server
 func (a *API) GetServerErrors(c *gin.Context) {
 	serverID := c.Param("id")
 
-	errors := a.serverManager.GetErrors(serverID)
+	query, err := parseErrorQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	errors, total := query.apply(a.serverManager.GetErrors(serverID))
 
 	c.JSON(http.StatusOK, gin.H{
 		"server_id": serverID,
 		"errors":    errors,
 		"count":     len(errors),
 		"timestamp": time.Now().Unix(),
+		"_meta": gin.H{
+			"total":    total,
+			"returned": len(errors),
+			"limit":    query.limit,
+			"offset":   query.offset,
+		},
 	})
 }
 
-// GetAllServerErrors returns enhanced error information for all servers
+// GetAllServerErrors returns enhanced error information for all servers.
+// Filters and pagination apply to each server's list independently.
 func (a *API) GetAllServerErrors(c *gin.Context) {
+	query, err := parseErrorQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	allErrors := a.serverManager.GetAllErrors()
 
-	totalErrors := 0
-	for _, errors := range allErrors {
+	totalErrors, totalMatched, returned := 0, 0, 0
+	for serverID, errors := range allErrors {
 		totalErrors += len(errors)
+
+		page, matched := query.apply(errors)
+		totalMatched += matched
+		returned += len(page)
+
+		if len(page) == 0 {
+			delete(allErrors, serverID)
+			continue
+		}
+		allErrors[serverID] = page
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"errors":      allErrors,
 		"total_count": totalErrors,
 		"timestamp":   time.Now().Unix(),
+		"_meta": gin.H{
+			"total":    totalMatched,
+			"returned": returned,
+			"limit":    query.limit,
+			"offset":   query.offset,
+		},
+	})
+}
+
+// ErrorFeedEntry is an error in the global feed, tagged with its server
+type ErrorFeedEntry struct {
+	ServerID string `json:"server_id"`
+	*servers.EnhancedError
+}
+
+// GetErrorFeed returns recent errors across all servers, newest first, with
+// severity counts over the whole (filtered) feed
+func (a *API) GetErrorFeed(c *gin.Context) {
+	query, err := parseErrorQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if c.Query("limit") == "" {
+		query.limit = 50
+	}
+
+	// Merge every server's errors into one stream
+	feed := make([]ErrorFeedEntry, 0)
+	severityCounts := make(map[string]int)
+	for serverID, errors := range a.serverManager.GetAllErrors() {
+		for _, enhancedErr := range servers.FilterErrors(errors, query.filter) {
+			feed = append(feed, ErrorFeedEntry{ServerID: serverID, EnhancedError: enhancedErr})
+			severityCounts[enhancedErr.Severity]++
+		}
+	}
+
+	sort.SliceStable(feed, func(i, j int) bool {
+		return feed[i].Timestamp.After(feed[j].Timestamp)
+	})
+
+	total := len(feed)
+	if query.offset >= total {
+		feed = feed[:0]
+	} else {
+		feed = feed[query.offset:]
+	}
+	if query.limit > 0 && len(feed) > query.limit {
+		feed = feed[:query.limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"feed":            feed,
+		"severity_counts": severityCounts,
+		"timestamp":       time.Now().Unix(),
+		"_meta": gin.H{
+			"total":    total,
+			"returned": len(feed),
+			"limit":    query.limit,
+			"offset":   query.offset,
+		},
 	})
 }
 
@@ -612,3 +1619,44 @@ func (a *API) GetServerRequiredCredentials(c *gin.Context) {
 		"requires_credentials": len(credentials) > 0,
 	})
 }
+
+// GetCircuitStatus returns the circuit breaker state for each server
+func (a *API) GetCircuitStatus(c *gin.Context) {
+	circuits := a.serverManager.GetLoadBalancer().GetCircuitStatus()
+
+	c.JSON(http.StatusOK, gin.H{
+		"circuits":  circuits,
+		"count":     len(circuits),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// GetReconnectStatus returns the restart state of each server's persistent
+// processes, including servers backing off after repeated crashes
+func (a *API) GetReconnectStatus(c *gin.Context) {
+	statuses := a.serverManager.GetLoadBalancer().GetReconnectStatus()
+
+	c.JSON(http.StatusOK, gin.H{
+		"servers":   statuses,
+		"count":     len(statuses),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// ResetCircuit closes a server's circuit breaker so requests are retried
+func (a *API) ResetCircuit(c *gin.Context) {
+	serverID := c.Param("id")
+
+	if err := a.serverManager.GetLoadBalancer().ResetCircuit(serverID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Circuit breaker reset",
+		"server_id": serverID,
+		"timestamp": time.Now().Unix(),
+	})
+}
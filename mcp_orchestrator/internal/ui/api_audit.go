@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"mcp_orchestrator/internal/analytics"
+	"mcp_orchestrator/internal/performance"
+	"mcp_orchestrator/internal/servers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReplayToolCall re-issues a tool call recorded in analytics with the same
+// arguments, returning the new result next to the original outcome. Replays
+// can have side effects, so the body must be {"confirm": true}. Arguments
+// are shown with secrets masked but replayed as recorded.
+func (a *API) ReplayToolCall(c *gin.Context) {
+	original, found := a.analyticsTracker.GetToolCall(c.Param("id"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Tool call not found in the analytics log",
+		})
+		return
+	}
+
+	var request struct {
+		Confirm bool `json:"confirm"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request body",
+			})
+			return
+		}
+	}
+	if !request.Confirm {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    `Replaying calls the tool again and may have side effects; send {"confirm": true} to replay it`,
+			"original": replayedCall(original),
+		})
+		return
+	}
+
+	server, err := a.serverManager.GetServer(original.ServerID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":    err.Error(),
+			"original": replayedCall(original),
+		})
+		return
+	}
+
+	if rejection := a.admitToolCall(c.Request.Context(), server, original.ToolName); rejection != nil {
+		rejection.Body["original"] = replayedCall(original)
+		c.JSON(rejection.Status, rejection.Body)
+		return
+	}
+
+	result, call, err := a.trackedToolCall(c, server, original.ToolName, original.Arguments)
+	replay := gin.H{
+		"id":            call.ID,
+		"success":       call.Success,
+		"duration_ms":   call.EndTime.Sub(call.StartTime).Milliseconds(),
+		"response_size": call.ResponseSize,
+	}
+	if err != nil {
+		// JSON-RPC errors from the server are passed through as-is
+		var rpcErr *performance.RPCError
+		if errors.As(err, &rpcErr) {
+			replay["error"] = json.RawMessage(rpcErr.Raw)
+		} else {
+			replay["error"] = err.Error()
+		}
+	} else {
+		replay["result"] = result
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"original":     replayedCall(original),
+		"replay":       replay,
+		"same_outcome": call.Success == original.Success,
+		"timestamp":    time.Now().Unix(),
+	})
+}
+
+// replayedCall describes a recorded call for display, secrets masked. Only
+// the outcome of the call is recorded, not its result.
+func replayedCall(call *analytics.ToolCall) gin.H {
+	return gin.H{
+		"id":            call.ID,
+		"server_id":     call.ServerID,
+		"tool":          call.ToolName,
+		"arguments":     servers.MaskArguments(call.Arguments),
+		"called_at":     call.StartTime,
+		"success":       call.Success,
+		"error":         call.ErrorMessage,
+		"duration_ms":   call.Duration.Milliseconds(),
+		"response_size": call.ResponseSize,
+	}
+}
@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"mcp_orchestrator/internal/performance"
+	"mcp_orchestrator/internal/profiles"
+	"mcp_orchestrator/internal/servers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiBudgets counts the API's own tool calls against the active profile's
+// call budgets, starting over when the budgets change
+type apiBudgets struct {
+	mu      sync.Mutex
+	limits  [2]map[string]profiles.CallBudget // Tool and category budgets the tracker enforces
+	tracker *performance.BudgetTracker
+}
+
+// toolCallRejection is why the API refused to forward a tool call
+type toolCallRejection struct {
+	Status int
+	Body   gin.H
+}
+
+// admitToolCall applies the stdio proxy's checks to a call made through the
+// API, in the same order: the server must be enabled, the active profile must
+// expose the tool, no proxy may have the server quarantined, and the call must
+// fit the profile's budgets. Calls still go through the orchestrator's
+// connection pool rather than a proxy, and a proxy's MCP_ALLOWED_SERVERS and
+// MCP_DENIED_SERVERS only apply to that proxy. It returns nil when the call
+// may be forwarded.
+func (a *API) admitToolCall(ctx context.Context, server *servers.ServerConfig, toolName string) *toolCallRejection {
+	reject := func(status int, message string, details gin.H) *toolCallRejection {
+		body := gin.H{
+			"error":     message,
+			"server_id": server.ID,
+			"tool":      toolName,
+			"timestamp": time.Now().Unix(),
+		}
+		for key, value := range details {
+			body[key] = value
+		}
+		return &toolCallRejection{Status: status, Body: body}
+	}
+
+	if server.Disabled {
+		return reject(http.StatusForbidden,
+			fmt.Sprintf("Server %s is disabled; enable it to use %s", server.ID, toolName), nil)
+	}
+
+	category := a.serverCategory(server.ID)
+	var profile *profiles.Profile
+	if a.profileManager != nil {
+		profile = a.profileManager.GetActiveProfile()
+	}
+	if profile != nil {
+		exposed, discovered, toolCategory := a.profileExposesTool(ctx, profile, server.ID, toolName)
+		if discovered && !exposed {
+			return reject(http.StatusForbidden,
+				fmt.Sprintf("Tool %s is not exposed by the active profile", toolName),
+				gin.H{"profile_id": a.activeProfileID()})
+		}
+		if toolCategory != "" {
+			category = toolCategory
+		}
+	}
+
+	if a.proxyStates != nil {
+		if status, quarantined := a.proxyStates.Quarantined()[server.ID]; quarantined {
+			return reject(http.StatusServiceUnavailable,
+				fmt.Sprintf("Server %s is quarantined after repeated failures", server.ID),
+				gin.H{"quarantine": status})
+		}
+	}
+
+	if profile != nil {
+		if err := a.callBudgets(profile).Allow(toolName, category); err != nil {
+			details := gin.H{}
+			var budgetErr *performance.BudgetExceededError
+			if errors.As(err, &budgetErr) {
+				details["budget"] = budgetErr.Status
+				details["retry_after_seconds"] = int(budgetErr.RetryAfter.Seconds()) + 1
+			}
+			return reject(http.StatusTooManyRequests, err.Error(), details)
+		}
+	}
+
+	return nil
+}
+
+// profileExposesTool reports whether a profile exposes a server's tool and
+// whether the tool was discovered at all, with its category. Tools of
+// servers that couldn't be listed are not discovered, so they aren't
+// rejected here; the server reports unknown tools itself.
+func (a *API) profileExposesTool(ctx context.Context, profile *profiles.Profile, serverID, toolName string) (bool, bool, string) {
+	tools, _ := a.discoveredProfileTools(ctx)
+
+	discovered := false
+	for _, tool := range tools {
+		if tool.ServerID == serverID && tool.Name == toolName {
+			discovered = true
+			break
+		}
+	}
+	if !discovered {
+		return false, false, ""
+	}
+
+	selection, _ := a.selectProfileTools(profile, tools)
+	for _, tool := range selection.Tools {
+		if tool.ServerID == serverID && tool.Name == toolName {
+			return true, true, tool.Category
+		}
+	}
+	return false, true, ""
+}
+
+// callBudgets returns the tracker for the API's calls under a profile's
+// budgets, replacing it when the budgets change
+func (a *API) callBudgets(profile *profiles.Profile) *performance.BudgetTracker {
+	a.budgets.mu.Lock()
+	defer a.budgets.mu.Unlock()
+
+	limits := [2]map[string]profiles.CallBudget{profile.ToolLimits.ToolBudgets, profile.ToolLimits.CategoryBudgets}
+	if a.budgets.tracker == nil || !reflect.DeepEqual(a.budgets.limits, limits) {
+		a.budgets.tracker = performance.NewBudgetTracker(
+			performance.ProfileBudgets(limits[0]), performance.ProfileBudgets(limits[1]))
+		a.budgets.limits = limits
+	}
+	return a.budgets.tracker
+}
+
+// budgetStatus returns the API's own budget consumption, or an empty list
+// without an active profile
+func (a *API) budgetStatus() []performance.BudgetStatus {
+	if a.profileManager == nil {
+		return []performance.BudgetStatus{}
+	}
+	profile := a.profileManager.GetActiveProfile()
+	if profile == nil {
+		return []performance.BudgetStatus{}
+	}
+	return a.callBudgets(profile).GetStatus()
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mcp_orchestrator/internal/analytics"
+	"mcp_orchestrator/internal/performance"
+	"mcp_orchestrator/internal/profiles"
+	"mcp_orchestrator/internal/servers"
+)
+
+// newCheckedAPI builds an API whose active profile allows one search call a minute
+func newCheckedAPI(t *testing.T) *API {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	manager := servers.NewManager(nil, servers.DefaultManagerConfig())
+	profileManager := profiles.NewProfileManager(filepath.Join(home, ".mcp_orchestrator"))
+	profile := &profiles.Profile{
+		ID: "budgeted",
+		ToolLimits: profiles.ToolLimits{
+			ToolBudgets: map[string]profiles.CallBudget{"search": {MaxCalls: 1, WindowSeconds: 60}},
+		},
+	}
+	if err := profileManager.CreateProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+	if err := profileManager.SetActiveProfile("budgeted"); err != nil {
+		t.Fatal(err)
+	}
+
+	api := NewAPI(manager, analytics.NewTracker(t.TempDir(), analytics.DefaultTrackerConfig()))
+	api.SetProfileManager(profileManager)
+	api.SetProxyStates(performance.NewProxyStates(time.Minute))
+	return api
+}
+
+func TestAdmitToolCallRejectsDisabledServer(t *testing.T) {
+	api := newCheckedAPI(t)
+
+	rejection := api.admitToolCall(context.Background(), &servers.ServerConfig{ID: "brave", Disabled: true}, "search")
+	if rejection == nil || rejection.Status != http.StatusForbidden {
+		t.Fatalf("disabled server got %+v, want 403", rejection)
+	}
+}
+
+func TestAdmitToolCallQuarantineBeforeBudget(t *testing.T) {
+	api := newCheckedAPI(t)
+	server := &servers.ServerConfig{ID: "brave"}
+	api.proxyStates.Record(performance.ProxyReport{ProxyID: "stdio-1", Quarantine: []performance.QuarantineStatus{
+		{ServerID: "brave", Quarantined: true, Reason: "5 consecutive failures"},
+	}})
+
+	rejection := api.admitToolCall(context.Background(), server, "search")
+	if rejection == nil || rejection.Status != http.StatusServiceUnavailable {
+		t.Fatalf("quarantined server got %+v, want 503", rejection)
+	}
+	if used := api.budgetStatus()[0].Used; used != 0 {
+		t.Errorf("a call to a quarantined server used %d of the budget", used)
+	}
+}
+
+func TestAdmitToolCallEnforcesBudget(t *testing.T) {
+	api := newCheckedAPI(t)
+	server := &servers.ServerConfig{ID: "brave"}
+
+	if rejection := api.admitToolCall(context.Background(), server, "search"); rejection != nil {
+		t.Fatalf("first call rejected: %+v", rejection)
+	}
+	rejection := api.admitToolCall(context.Background(), server, "search")
+	if rejection == nil || rejection.Status != http.StatusTooManyRequests {
+		t.Fatalf("call over budget got %+v, want 429", rejection)
+	}
+	if rejection.Body["retry_after_seconds"] == nil {
+		t.Error("budget rejection has no retry_after_seconds")
+	}
+}
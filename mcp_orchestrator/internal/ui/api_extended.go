@@ -2,6 +2,7 @@ package ui
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,51 +11,65 @@ import (
 	"mcp_orchestrator/internal/analytics"
 	"mcp_orchestrator/internal/performance"
 	"mcp_orchestrator/internal/profiles"
+
+	"github.com/gin-gonic/gin"
 )
 
 // ExtendedAPIServer provides advanced API endpoints
 type ExtendedAPIServer struct {
 	profileManager   *profiles.ProfileManager
 	analyticsTracker *analytics.Tracker
-	toolCache        *performance.ToolCache
 	loadBalancer     *performance.LoadBalancer
+	proxyStates      *performance.ProxyStates
 }
 
-// NewExtendedAPIServer creates a new extended API server
-func NewExtendedAPIServer(profileManager *profiles.ProfileManager, analyticsTracker *analytics.Tracker, toolCache *performance.ToolCache, loadBalancer *performance.LoadBalancer) *ExtendedAPIServer {
+// NewExtendedAPIServer creates a new extended API server. Tool caches live in
+// the stdio proxies, so only the orchestrator's connection pools are reported.
+func NewExtendedAPIServer(profileManager *profiles.ProfileManager, analyticsTracker *analytics.Tracker, loadBalancer *performance.LoadBalancer) *ExtendedAPIServer {
 	return &ExtendedAPIServer{
 		profileManager:   profileManager,
 		analyticsTracker: analyticsTracker,
-		toolCache:        toolCache,
 		loadBalancer:     loadBalancer,
 	}
 }
 
-// RegisterExtendedRoutes registers all extended API routes
-func (s *ExtendedAPIServer) RegisterExtendedRoutes(mux *http.ServeMux) {
+// SetProxyStates sets the store of runtime state reported by stdio proxies
+func (s *ExtendedAPIServer) SetProxyStates(proxyStates *performance.ProxyStates) {
+	s.proxyStates = proxyStates
+}
+
+// RegisterRoutes mounts the extended endpoints on the gin API group, so they
+// share its CORS, timeout and body size middleware. The handlers are plain
+// net/http handlers that check the method themselves; each is registered for
+// the methods it serves.
+func (s *ExtendedAPIServer) RegisterRoutes(api *gin.RouterGroup) {
+	route := func(path string, handler http.HandlerFunc, methods ...string) {
+		api.Match(methods, path, gin.WrapF(handler))
+	}
+
 	// Profile management endpoints
-	mux.HandleFunc("/api/profiles", s.handleProfiles)
-	mux.HandleFunc("/api/profiles/", s.handleProfileByID)
-	mux.HandleFunc("/api/profiles/active", s.handleActiveProfile)
+	route("/profiles", s.handleProfiles, http.MethodGet, http.MethodPost)
+	route("/profiles/active", s.handleActiveProfile, http.MethodGet, http.MethodPost)
+	route("/profiles/errors", s.handleProfileErrors, http.MethodGet)
+	route("/profiles/:id", s.handleProfileByID, http.MethodGet, http.MethodPut, http.MethodDelete)
 
 	// Analytics endpoints
-	mux.HandleFunc("/api/analytics", s.handleAnalytics)
-	mux.HandleFunc("/api/analytics/insights", s.handleInsights)
-	mux.HandleFunc("/api/analytics/tools", s.handleToolAnalytics)
-	mux.HandleFunc("/api/analytics/servers", s.handleServerAnalytics)
+	route("/analytics", s.handleAnalytics, http.MethodGet)
+	route("/analytics/insights", s.handleInsights, http.MethodGet)
+	route("/analytics/tools", s.handleToolAnalytics, http.MethodGet)
+	route("/analytics/servers", s.handleServerAnalytics, http.MethodGet)
 
 	// Performance monitoring endpoints
-	mux.HandleFunc("/api/performance/cache", s.handleCacheStats)
-	mux.HandleFunc("/api/performance/pools", s.handlePoolStats)
-	mux.HandleFunc("/api/performance/health", s.handleHealthCheck)
+	route("/performance/pools", s.handlePoolStats, http.MethodGet)
+	route("/performance/health", s.handleHealthCheck, http.MethodGet)
 
 	// Configuration endpoints
-	mux.HandleFunc("/api/config/profiles", s.handleProfileConfig)
-	mux.HandleFunc("/api/config/performance", s.handlePerformanceConfig)
+	route("/config/profiles", s.handleProfileConfig, http.MethodGet)
+	route("/config/performance", s.handlePerformanceConfig, http.MethodGet)
 
 	// Dashboard endpoints
-	mux.HandleFunc("/api/dashboard/overview", s.handleDashboardOverview)
-	mux.HandleFunc("/api/dashboard/metrics", s.handleDashboardMetrics)
+	route("/dashboard/overview", s.handleDashboardOverview, http.MethodGet)
+	route("/dashboard/metrics", s.handleDashboardMetrics, http.MethodGet)
 }
 
 // Profile Management Endpoints
@@ -72,6 +87,11 @@ func (s *ExtendedAPIServer) handleProfiles(w http.ResponseWriter, r *http.Reques
 		}
 
 		if err := s.profileManager.CreateProfile(&profile); err != nil {
+			var validationErr *profiles.ValidationError
+			if errors.As(err, &validationErr) {
+				s.sendValidationError(w, validationErr)
+				return
+			}
 			s.sendErrorResponse(w, err.Error(), http.StatusConflict)
 			return
 		}
@@ -101,6 +121,11 @@ func (s *ExtendedAPIServer) handleProfileByID(w http.ResponseWriter, r *http.Req
 		}
 
 		if err := s.profileManager.UpdateProfile(profileID, &updates); err != nil {
+			var validationErr *profiles.ValidationError
+			if errors.As(err, &validationErr) {
+				s.sendValidationError(w, validationErr)
+				return
+			}
 			s.sendErrorResponse(w, err.Error(), http.StatusNotFound)
 			return
 		}
@@ -128,25 +153,50 @@ func (s *ExtendedAPIServer) handleActiveProfile(w http.ResponseWriter, r *http.R
 		}
 		s.sendJSONResponse(w, profile)
 	case http.MethodPost:
+		// profile_ids activates several profiles merged into one composite view
 		var request struct {
-			ProfileID string `json:"profile_id"`
+			ProfileID  string   `json:"profile_id"`
+			ProfileIDs []string `json:"profile_ids"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 			s.sendErrorResponse(w, "Invalid request data", http.StatusBadRequest)
 			return
 		}
 
-		if err := s.profileManager.SetActiveProfile(request.ProfileID); err != nil {
+		ids := request.ProfileIDs
+		if len(ids) == 0 {
+			ids = []string{request.ProfileID}
+		}
+		if err := s.profileManager.SetActiveProfiles(ids); err != nil {
 			s.sendErrorResponse(w, err.Error(), http.StatusNotFound)
 			return
 		}
 
-		s.sendJSONResponse(w, map[string]string{"status": "active_profile_set", "id": request.ProfileID})
+		s.sendJSONResponse(w, map[string]interface{}{
+			"status": "active_profile_set",
+			"id":     s.profileManager.GetActiveProfile().ID,
+			"ids":    s.profileManager.GetActiveProfileIDs(),
+		})
 	default:
 		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
+// handleProfileErrors lists the profile files that failed to load, and where
+// corrupt ones were moved
+func (s *ExtendedAPIServer) handleProfileErrors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	loadErrors := s.profileManager.LoadErrors()
+	s.sendJSONResponse(w, map[string]interface{}{
+		"errors": loadErrors,
+		"count":  len(loadErrors),
+	})
+}
+
 // Analytics Endpoints
 
 func (s *ExtendedAPIServer) handleAnalytics(w http.ResponseWriter, r *http.Request) {
@@ -169,7 +219,12 @@ func (s *ExtendedAPIServer) handleAnalytics(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	analytics, err := s.analyticsTracker.GetAnalytics(period, days)
+	profileID, ok := s.analyticsProfile(w, r)
+	if !ok {
+		return
+	}
+
+	analytics, err := s.analyticsTracker.GetAnalytics(period, days, profileID)
 	if err != nil {
 		s.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -192,7 +247,12 @@ func (s *ExtendedAPIServer) handleInsights(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	insights, err := s.analyticsTracker.GetInsights(days)
+	profileID, ok := s.analyticsProfile(w, r)
+	if !ok {
+		return
+	}
+
+	insights, err := s.analyticsTracker.GetInsights(days, profileID)
 	if err != nil {
 		s.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -201,13 +261,27 @@ func (s *ExtendedAPIServer) handleInsights(w http.ResponseWriter, r *http.Reques
 	s.sendJSONResponse(w, insights)
 }
 
+// analyticsProfile reads the optional profile query param, responding with
+// 404 and returning false when the profile doesn't exist
+func (s *ExtendedAPIServer) analyticsProfile(w http.ResponseWriter, r *http.Request) (string, bool) {
+	profileID := r.URL.Query().Get("profile")
+	if profileID == "" {
+		return "", true
+	}
+	if _, err := s.profileManager.GetProfile(profileID); err != nil {
+		s.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		return "", false
+	}
+	return profileID, true
+}
+
 func (s *ExtendedAPIServer) handleToolAnalytics(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	analytics, err := s.analyticsTracker.GetAnalytics("daily", 7)
+	analytics, err := s.analyticsTracker.GetAnalytics("daily", 7, "")
 	if err != nil {
 		s.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -226,7 +300,7 @@ func (s *ExtendedAPIServer) handleServerAnalytics(w http.ResponseWriter, r *http
 		return
 	}
 
-	analytics, err := s.analyticsTracker.GetAnalytics("daily", 7)
+	analytics, err := s.analyticsTracker.GetAnalytics("daily", 7, "")
 	if err != nil {
 		s.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -242,16 +316,6 @@ func (s *ExtendedAPIServer) handleServerAnalytics(w http.ResponseWriter, r *http
 
 // Performance Monitoring Endpoints
 
-func (s *ExtendedAPIServer) handleCacheStats(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	stats := s.toolCache.GetCacheStats()
-	s.sendJSONResponse(w, stats)
-}
-
 func (s *ExtendedAPIServer) handlePoolStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -269,21 +333,19 @@ func (s *ExtendedAPIServer) handleHealthCheck(w http.ResponseWriter, r *http.Req
 	}
 
 	// Get insights for health information
-	insights, err := s.analyticsTracker.GetInsights(1)
+	insights, err := s.analyticsTracker.GetInsights(1, "")
 	if err != nil {
 		s.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Combine with cache and pool stats
-	cacheStats := s.toolCache.GetCacheStats()
+	// Combine with pool stats
 	poolStats := s.loadBalancer.GetPoolStats()
 
 	healthData := map[string]interface{}{
 		"timestamp":     time.Now(),
 		"server_health": insights.ServerHealth,
 		"alerts":        insights.Alerts,
-		"cache_stats":   cacheStats,
 		"pool_stats":    poolStats,
 		"status":        "healthy", // This would be calculated based on various factors
 	}
@@ -324,8 +386,6 @@ func (s *ExtendedAPIServer) handlePerformanceConfig(w http.ResponseWriter, r *ht
 	case http.MethodGet:
 		// Return current performance configuration
 		config := map[string]interface{}{
-			"cache_enabled":      true,
-			"cache_stats":        s.toolCache.GetCacheStats(),
 			"connection_pools":   s.loadBalancer.GetPoolStats(),
 			"optimization_level": "high",
 		}
@@ -344,13 +404,13 @@ func (s *ExtendedAPIServer) handleDashboardOverview(w http.ResponseWriter, r *ht
 	}
 
 	// Get analytics and insights
-	analytics, err := s.analyticsTracker.GetAnalytics("daily", 7)
+	usage, err := s.analyticsTracker.GetAnalytics("daily", 7, "")
 	if err != nil {
 		s.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	insights, err := s.analyticsTracker.GetInsights(7)
+	insights, err := s.analyticsTracker.GetInsights(7, "")
 	if err != nil {
 		s.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -359,23 +419,41 @@ func (s *ExtendedAPIServer) handleDashboardOverview(w http.ResponseWriter, r *ht
 	// Get active profile
 	activeProfile := s.profileManager.GetActiveProfile()
 
-	// Get cache and pool stats
-	cacheStats := s.toolCache.GetCacheStats()
+	// Get pool stats
 	poolStats := s.loadBalancer.GetPoolStats()
 
+	// Lists are empty rather than null before any calls are recorded, e.g. on
+	// a fresh install, so the dashboard can render every section
+	topTools := []analytics.ToolMetrics{}
+	if len(usage.TopTools) > 0 {
+		topTools = usage.TopTools[:dashboardCount(5, len(usage.TopTools))]
+	}
+	recentAlerts := []analytics.Alert{}
+	if len(insights.Alerts) > 0 {
+		recentAlerts = insights.Alerts[:dashboardCount(3, len(insights.Alerts))]
+	}
+	recommendations := []analytics.Recommendation{}
+	if len(insights.Recommendations) > 0 {
+		recommendations = insights.Recommendations[:dashboardCount(3, len(insights.Recommendations))]
+	}
+
 	overview := map[string]interface{}{
 		"timestamp":         time.Now(),
 		"active_profile":    activeProfile,
-		"total_tools":       analytics.TotalToolCalls,
-		"total_servers":     analytics.TotalServers,
-		"active_servers":    analytics.ActiveServers,
-		"success_rate":      analytics.SuccessRate,
-		"avg_response_time": analytics.AvgResponseTime,
-		"top_tools":         analytics.TopTools[:min(5, len(analytics.TopTools))],
-		"recent_alerts":     insights.Alerts[:min(3, len(insights.Alerts))],
-		"cache_hit_rate":    calculateOverallCacheHitRate(cacheStats),
+		"total_tools":       usage.TotalToolCalls,
+		"total_servers":     usage.TotalServers,
+		"active_servers":    usage.ActiveServers,
+		"success_rate":      usage.SuccessRate,
+		"avg_response_time": usage.AvgResponseTime,
+		"has_data":          usage.TotalToolCalls > 0,
+		"top_tools":         topTools,
+		"recent_alerts":     recentAlerts,
 		"pool_efficiency":   calculatePoolEfficiency(poolStats),
-		"recommendations":   insights.Recommendations[:min(3, len(insights.Recommendations))],
+		"recommendations":   recommendations,
+	}
+	if s.proxyStates != nil {
+		overview["quarantined_servers"] = sortedQuarantine(s.proxyStates.Quarantined())
+		overview["budgets"] = proxyBudgets(s.proxyStates.Reports())
 	}
 
 	s.sendJSONResponse(w, overview)
@@ -388,7 +466,7 @@ func (s *ExtendedAPIServer) handleDashboardMetrics(w http.ResponseWriter, r *htt
 	}
 
 	// Get detailed metrics for dashboard charts
-	analytics, err := s.analyticsTracker.GetAnalytics("hourly", 24)
+	analytics, err := s.analyticsTracker.GetAnalytics("hourly", 24, "")
 	if err != nil {
 		s.sendErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -426,20 +504,15 @@ func (s *ExtendedAPIServer) sendErrorResponse(w http.ResponseWriter, message str
 	})
 }
 
-func calculateOverallCacheHitRate(stats map[string]performance.CacheStats) float64 {
-	totalHits := int64(0)
-	totalMisses := int64(0)
-
-	for _, stat := range stats {
-		totalHits += stat.Hits
-		totalMisses += stat.Misses
-	}
-
-	if totalHits+totalMisses == 0 {
-		return 0.0
-	}
-
-	return float64(totalHits) / float64(totalHits+totalMisses) * 100
+// sendValidationError responds 400 with each problem found in a profile
+func (s *ExtendedAPIServer) sendValidationError(w http.ResponseWriter, err *profiles.ValidationError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":     err.Error(),
+		"problems":  err.Problems,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
 }
 
 func calculatePoolEfficiency(stats map[string]performance.PoolStats) float64 {
@@ -459,9 +532,10 @@ func calculatePoolEfficiency(stats map[string]performance.PoolStats) float64 {
 	return totalEfficiency / float64(totalPools)
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
+// dashboardCount returns how many of available items a dashboard list shows
+func dashboardCount(limit, available int) int {
+	if available < limit {
+		return available
 	}
-	return b
+	return limit
 }
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mcp_orchestrator/internal/analytics"
+	"mcp_orchestrator/internal/performance"
+	"mcp_orchestrator/internal/profiles"
+)
+
+// newTestExtendedAPI creates an extended API over a fresh install: no
+// recorded calls, default profiles and no connection pools
+func newTestExtendedAPI(t *testing.T) *ExtendedAPIServer {
+	dataDir := t.TempDir()
+	config := analytics.DefaultTrackerConfig()
+	config.Enabled = false
+
+	return NewExtendedAPIServer(
+		profiles.NewProfileManager(dataDir),
+		analytics.NewTracker(dataDir, config),
+		performance.NewLoadBalancer(performance.RoundRobin),
+	)
+}
+
+func TestDashboardOverviewWithoutAnalyticsData(t *testing.T) {
+	server := newTestExtendedAPI(t)
+
+	recorder := httptest.NewRecorder()
+	server.handleDashboardOverview(recorder, httptest.NewRequest(http.MethodGet, "/api/dashboard/overview", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var overview map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &overview); err != nil {
+		t.Fatalf("decoding overview: %v", err)
+	}
+
+	if overview["has_data"] != false {
+		t.Errorf("got has_data %v, want false", overview["has_data"])
+	}
+	if overview["total_tools"] != float64(0) {
+		t.Errorf("got total_tools %v, want 0", overview["total_tools"])
+	}
+	for _, key := range []string{"top_tools", "recent_alerts", "recommendations"} {
+		list, ok := overview[key].([]interface{})
+		if !ok {
+			t.Errorf("got %s %v, want an empty list", key, overview[key])
+			continue
+		}
+		if len(list) != 0 {
+			t.Errorf("got %d %s, want none", len(list), key)
+		}
+	}
+	if overview["pool_efficiency"] != float64(0) {
+		t.Errorf("got pool_efficiency %v, want 0", overview["pool_efficiency"])
+	}
+	if _, exists := overview["quarantined_servers"]; exists {
+		t.Error("got quarantined_servers without proxy states")
+	}
+}
+
+func TestDashboardOverviewWithoutProxyReports(t *testing.T) {
+	server := newTestExtendedAPI(t)
+	server.SetProxyStates(performance.NewProxyStates(performance.DefaultProxyReportTTL))
+
+	recorder := httptest.NewRecorder()
+	server.handleDashboardOverview(recorder, httptest.NewRequest(http.MethodGet, "/api/dashboard/overview", nil))
+
+	var overview map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &overview); err != nil {
+		t.Fatalf("decoding overview: %v", err)
+	}
+	for _, key := range []string{"quarantined_servers", "budgets"} {
+		if list, ok := overview[key].([]interface{}); !ok || len(list) != 0 {
+			t.Errorf("got %s %v, want an empty list", key, overview[key])
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"mcp_orchestrator/internal/performance"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecordProxyReport stores the runtime state a stdio proxy reports. Each
+// proxy keeps its own quarantine and call budgets, so this is how the
+// orchestrator sees them.
+func (a *API) RecordProxyReport(c *gin.Context) {
+	var report performance.ProxyReport
+	if err := c.ShouldBindJSON(&report); err != nil || report.ProxyID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A report with a proxy_id is required",
+		})
+		return
+	}
+
+	a.proxyStates.Record(report)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "recorded",
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// GetQuarantine returns the servers quarantined by any running stdio proxy,
+// with each proxy's full quarantine state
+func (a *API) GetQuarantine(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"quarantined": sortedQuarantine(a.proxyStates.Quarantined()),
+		"proxies":     a.proxyStates.Reports(),
+		"timestamp":   time.Now().Unix(),
+	})
+}
+
+// GetBudgets returns the call budget consumption of each running stdio proxy
+// and of the calls made through the API. Each enforces the active profile's
+// budgets on its own calls, so consumption is reported separately rather
+// than summed.
+func (a *API) GetBudgets(c *gin.Context) {
+	proxies := []gin.H{}
+	for _, report := range a.proxyStates.Reports() {
+		proxies = append(proxies, gin.H{
+			"proxy_id":    report.ProxyID,
+			"profile_id":  report.ProfileID,
+			"budgets":     report.Budgets,
+			"reported_at": report.ReportedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"proxies":   proxies,
+		"api":       a.budgetStatus(),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// GetDiscoveryTimings returns how long each server takes to list its tools,
+// as timed by each running stdio proxy. Discovery runs in the proxies, so
+// their reports are the only source of these timings.
+func (a *API) GetDiscoveryTimings(c *gin.Context) {
+	proxies := []gin.H{}
+	for _, report := range a.proxyStates.Reports() {
+		timings := report.DiscoveryTimings
+		if timings == nil {
+			timings = []performance.DiscoveryTiming{}
+		}
+		proxy := gin.H{
+			"proxy_id":    report.ProxyID,
+			"profile_id":  report.ProfileID,
+			"servers":     timings,
+			"reported_at": report.ReportedAt,
+		}
+		if len(timings) > 0 {
+			proxy["slowest"] = timings[0].ServerID
+		}
+		proxies = append(proxies, proxy)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"proxies":   proxies,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// proxyBudgets lists every budget in the proxies' reports with the proxy it belongs to
+func proxyBudgets(reports []performance.ProxyReport) []gin.H {
+	budgets := []gin.H{}
+	for _, report := range reports {
+		for _, budget := range report.Budgets {
+			budgets = append(budgets, gin.H{
+				"proxy_id":  report.ProxyID,
+				"scope":     budget.Scope,
+				"name":      budget.Name,
+				"used":      budget.Used,
+				"remaining": budget.Remaining,
+				"max_calls": budget.MaxCalls,
+				"exhausted": budget.MaxCalls > 0 && budget.Remaining == 0,
+			})
+		}
+	}
+	return budgets
+}
+
+// sortedQuarantine lists quarantined servers by server ID
+func sortedQuarantine(quarantined map[string]performance.QuarantineStatus) []performance.QuarantineStatus {
+	statuses := make([]performance.QuarantineStatus, 0, len(quarantined))
+	for _, status := range quarantined {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ServerID < statuses[j].ServerID
+	})
+	return statuses
+}
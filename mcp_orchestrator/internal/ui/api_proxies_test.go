@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp_orchestrator/internal/performance"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProxyReportsExposeQuarantine(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	api := &API{proxyStates: performance.NewProxyStates(time.Minute)}
+	r := gin.New()
+	r.POST("/api/proxies/report", api.RecordProxyReport)
+	r.GET("/api/quarantine", api.GetQuarantine)
+
+	report := `{"proxy_id": "stdio-42", "quarantine": [
+		{"server_id": "github", "quarantined": true, "state": "open", "reason": "5 consecutive failures"},
+		{"server_id": "slack", "quarantined": false, "state": "closed"}
+	]}`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/proxies/report", strings.NewReader(report)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("report returned %d: %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/quarantine", nil))
+	var body struct {
+		Quarantined []performance.QuarantineStatus `json:"quarantined"`
+		Proxies     []performance.ProxyReport      `json:"proxies"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Quarantined) != 1 || body.Quarantined[0].ServerID != "github" {
+		t.Errorf("quarantined %+v, want github", body.Quarantined)
+	}
+	if len(body.Proxies) != 1 || body.Proxies[0].ProxyID != "stdio-42" {
+		t.Errorf("proxies %+v, want stdio-42", body.Proxies)
+	}
+}
+
+func TestProxyReportRequiresProxyID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	api := &API{proxyStates: performance.NewProxyStates(time.Minute)}
+	r := gin.New()
+	r.POST("/api/proxies/report", api.RecordProxyReport)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/proxies/report", strings.NewReader(`{"quarantine": []}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("report without proxy_id returned %d, want 400", w.Code)
+	}
+}
+
+func TestGetBudgetsPerProxy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	api := &API{proxyStates: performance.NewProxyStates(time.Minute)}
+	api.proxyStates.Record(performance.ProxyReport{ProxyID: "stdio-1", Budgets: []performance.BudgetStatus{
+		{Scope: "tool", Name: "search", MaxCalls: 10, WindowSeconds: 60, Used: 10, Remaining: 0},
+	}})
+	r := gin.New()
+	r.GET("/api/budgets", api.GetBudgets)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/budgets", nil))
+	var body struct {
+		Proxies []struct {
+			ProxyID string                     `json:"proxy_id"`
+			Budgets []performance.BudgetStatus `json:"budgets"`
+		} `json:"proxies"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Proxies) != 1 || len(body.Proxies[0].Budgets) != 1 || body.Proxies[0].Budgets[0].Used != 10 {
+		t.Errorf("budgets %+v, want stdio-1 with search at 10 used", body.Proxies)
+	}
+
+	budgets := proxyBudgets(api.proxyStates.Reports())
+	if len(budgets) != 1 || budgets[0]["exhausted"] != true {
+		t.Errorf("dashboard budgets %v, want search exhausted", budgets)
+	}
+}
+
+func TestGetDiscoveryTimingsPerProxy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	api := &API{proxyStates: performance.NewProxyStates(time.Minute)}
+	r := gin.New()
+	r.POST("/api/proxies/report", api.RecordProxyReport)
+	r.GET("/api/discovery/timings", api.GetDiscoveryTimings)
+
+	report := `{"proxy_id": "stdio-1", "discovery_timings": [
+		{"server_id": "github", "runs": 3, "avg_ms": 850},
+		{"server_id": "slack", "runs": 3, "failures": 1, "avg_ms": 120}
+	]}`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/proxies/report", strings.NewReader(report)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("report returned %d: %s", w.Code, w.Body)
+	}
+	api.proxyStates.Record(performance.ProxyReport{ProxyID: "stdio-2"})
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/discovery/timings", nil))
+	var body struct {
+		Proxies []struct {
+			ProxyID string                        `json:"proxy_id"`
+			Servers []performance.DiscoveryTiming `json:"servers"`
+			Slowest string                        `json:"slowest"`
+		} `json:"proxies"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Proxies) != 2 {
+		t.Fatalf("got %d proxies, want 2: %s", len(body.Proxies), w.Body)
+	}
+	if first := body.Proxies[0]; first.ProxyID != "stdio-1" || len(first.Servers) != 2 || first.Slowest != "github" {
+		t.Errorf("got %+v, want stdio-1 with github slowest", first)
+	}
+	if second := body.Proxies[1]; second.Servers == nil || len(second.Servers) != 0 {
+		t.Errorf("got servers %v for a proxy without timings, want an empty list", second.Servers)
+	}
+}
@@ -0,0 +1,155 @@
+package ui
+
+import (
+	"net/http"
+	"time"
+
+	"mcp_orchestrator/internal/servers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StackRequest defines the members of a stack and their install config
+type StackRequest struct {
+	Servers []string                     `json:"servers"`
+	Config  map[string]map[string]string `json:"config,omitempty"` // Install config per server, keyed by server ID
+}
+
+// ListStacks returns every defined stack with the progress of its last up
+func (a *API) ListStacks(c *gin.Context) {
+	stacks := a.serverManager.ListStacks()
+
+	entries := make([]gin.H, 0, len(stacks))
+	for _, stack := range stacks {
+		entries = append(entries, gin.H{
+			"stack":    stack,
+			"last_run": a.serverManager.GetStackRun(stack.Name),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stacks":    entries,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// GetStack returns a stack with the progress of its last up
+func (a *API) GetStack(c *gin.Context) {
+	name := c.Param("name")
+
+	stack, err := a.serverManager.GetStack(name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stack":     stack,
+		"last_run":  a.serverManager.GetStackRun(name),
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// SaveStack creates or replaces the stack named in the path
+func (a *API) SaveStack(c *gin.Context) {
+	var req StackRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Servers) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: servers is required",
+		})
+		return
+	}
+
+	stack := &servers.Stack{
+		Name:    c.Param("name"),
+		Servers: req.Servers,
+		Config:  req.Config,
+	}
+	if err := a.serverManager.SaveStack(stack); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	saved, _ := a.serverManager.GetStack(stack.Name)
+	c.JSON(http.StatusOK, gin.H{
+		"stack":     saved,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// DeleteStack removes a stack definition without touching its servers
+func (a *API) DeleteStack(c *gin.Context) {
+	name := c.Param("name")
+
+	if _, err := a.serverManager.GetStack(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := a.serverManager.DeleteStack(name); err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Stack deleted",
+	})
+}
+
+// StackUp installs and starts a stack's servers in the background. The run's
+// progress is reported by GetStack.
+func (a *API) StackUp(c *gin.Context) {
+	name := c.Param("name")
+
+	if _, err := a.serverManager.GetStack(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	run, err := a.serverManager.StackUp(name)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"run":       run,
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// StackDown stops a stack's running servers, dependents first
+func (a *API) StackDown(c *gin.Context) {
+	name := c.Param("name")
+
+	if _, err := a.serverManager.GetStack(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	result, err := a.serverManager.StackDown(name)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if len(result.Errors) > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	c.JSON(status, result)
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"mcp_orchestrator/internal/servers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// toolStreamLine is one server's line in the tool stream
+type toolStreamLine struct {
+	Type string `json:"type"`
+	servers.ServerTools
+}
+
+// StreamTools streams the tools of every installed server as NDJSON, one
+// line per server as soon as its tools are listed, with that server's
+// diagnostics inline. A final summary line gives the totals.
+func (a *API) StreamTools(c *gin.Context) {
+	started := time.Now()
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	serverCount, toolCount, failed := 0, 0, 0
+	a.serverManager.StreamDiscoveredTools(c.Request.Context(), func(result servers.ServerTools) {
+		// Categorize tools the way the proxy does for servers that don't
+		tools := make([]servers.DiscoveredTool, len(result.Tools))
+		for i, tool := range result.Tools {
+			if tool.Category == "" {
+				tool.Category = a.serverCategory(tool.ServerID)
+			}
+			tools[i] = tool
+		}
+		result.Tools = tools
+
+		serverCount++
+		toolCount += len(tools)
+		for _, diagnostic := range result.Diagnostics {
+			if diagnostic.Severity == "error" {
+				failed++
+				break
+			}
+		}
+
+		encoder.Encode(toolStreamLine{Type: "server", ServerTools: result})
+		c.Writer.Flush()
+	})
+
+	encoder.Encode(gin.H{
+		"type":           "summary",
+		"servers":        serverCount,
+		"tools":          toolCount,
+		"failed_servers": failed,
+		"duration_ms":    time.Since(started).Milliseconds(),
+		"timestamp":      time.Now().Unix(),
+	})
+	c.Writer.Flush()
+}
@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LimitsConfig bounds how long a UI API request may run and how large its body may be
+type LimitsConfig struct {
+	RequestTimeout time.Duration
+	MaxBodyBytes   int64
+	StreamPaths    []string // Paths written straight through instead of buffered, so they can stream
+}
+
+// DefaultLimitsConfig returns limits generous enough for install and config payloads
+func DefaultLimitsConfig() LimitsConfig {
+	return LimitsConfig{
+		RequestTimeout: 60 * time.Second,
+		MaxBodyBytes:   1 << 20, // 1 MiB
+	}
+}
+
+// WithLimits wraps a handler with a request timeout (408) and a body size cap (413)
+func WithLimits(handler http.Handler, config LimitsConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reject declared oversized bodies up front; MaxBytesReader catches the rest
+		if config.MaxBodyBytes > 0 {
+			if r.ContentLength > config.MaxBodyBytes {
+				writeLimitError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, config.MaxBodyBytes)
+		}
+
+		if config.RequestTimeout <= 0 {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), config.RequestTimeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		// Streams end at the deadline through their context instead
+		for _, path := range config.StreamPaths {
+			if r.URL.Path == path {
+				handler.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		// Buffer the response so a handler finishing after the deadline can't
+		// write over the timeout response
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panicChan := make(chan interface{}, 1)
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicChan <- p
+				}
+			}()
+			handler.ServeHTTP(tw, r)
+			close(done)
+		}()
+
+		select {
+		case p := <-panicChan:
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			for key, values := range tw.header {
+				w.Header()[key] = values
+			}
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+			}
+			w.WriteHeader(tw.status)
+			w.Write(tw.body.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			tw.timedOut = true
+			writeLimitError(w, http.StatusRequestTimeout, "Request timed out")
+		}
+	})
+}
+
+// writeLimitError writes a JSON error in the same shape as the gin handlers
+func writeLimitError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// timeoutWriter buffers a response until the handler completes
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+// Header returns the buffered response headers
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write buffers the response body, failing once the request has timed out
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(data)
+}
+
+// WriteHeader records the response status code
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
@@ -0,0 +1,15 @@
+package version
+
+import "time"
+
+// Version is the orchestrator release version, overridable at build time with
+// -ldflags "-X mcp_orchestrator/internal/version.Version=x.y.z"
+var Version = "1.0.0"
+
+// StartTime records when the process started
+var StartTime = time.Now()
+
+// Uptime returns how long the process has been running
+func Uptime() time.Duration {
+	return time.Since(StartTime)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"syscall"
+)
+
+// maxPortAttempts is how many consecutive ports are tried when falling back
+const maxPortAttempts = 10
+
+// listenPort binds a listener for the named server on port. If the port is
+// in use and tryNext is set, the following ports are tried in turn. The port
+// actually bound is returned with the listener.
+func listenPort(name string, port int, tryNext bool) (net.Listener, int, error) {
+	attempts := 1
+	if tryNext {
+		attempts = maxPortAttempts
+	}
+
+	var lastErr error
+	for candidate := port; candidate < port+attempts; candidate++ {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", candidate))
+		if err == nil {
+			if candidate != port {
+				log.Printf("Warning: Port %d is in use; %s is listening on port %d instead", port, name, candidate)
+			}
+			return listener, candidate, nil
+		}
+		lastErr = err
+		if !errors.Is(err, syscall.EADDRINUSE) {
+			break
+		}
+	}
+
+	if errors.Is(lastErr, syscall.EADDRINUSE) {
+		if attempts > 1 {
+			return nil, 0, fmt.Errorf("failed to start %s: ports %d-%d are in use", name, port, port+attempts-1)
+		}
+		return nil, 0, fmt.Errorf("failed to start %s: port %d is in use (set MCP_PORT_FALLBACK=true to try the next free port)", name, port)
+	}
+	return nil, 0, fmt.Errorf("failed to start %s on port %d: %v", name, port, lastErr)
+}
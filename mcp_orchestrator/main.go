@@ -1,12 +1,20 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
+	"mcp_orchestrator/internal/analytics"
 	"mcp_orchestrator/internal/mcp"
+	"mcp_orchestrator/internal/performance"
+	"mcp_orchestrator/internal/profiles"
 	"mcp_orchestrator/internal/servers"
 	"mcp_orchestrator/internal/ui"
 
@@ -14,31 +22,125 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Default ports of the MCP server and the UI API server
+const (
+	defaultMCPPort = 3000
+	defaultAPIPort = 8080
+)
+
 func main() {
+	// Container health checks invoke the binary with --health-check
+	if len(os.Args) > 1 && os.Args[1] == "--health-check" {
+		os.Exit(runHealthCheck())
+	}
+
+	// `mcp-orchestrator configure` writes the Claude Desktop entry and exits
+	if len(os.Args) > 1 && os.Args[1] == "configure" {
+		os.Exit(runConfigure(os.Args[2:]))
+	}
+
+	// Refuse to run alongside another orchestrator using the same state
+	instanceLock, err := servers.AcquireInstanceLock(servers.DefaultBasePath())
+	if err != nil {
+		log.Fatal("Failed to start: ", err)
+	}
+
 	// Initialize the MCP orchestrator
 	orchestrator := mcp.NewOrchestrator()
 
 	// Initialize the server manager
-	serverManager := servers.NewManager(orchestrator)
+	managerConfig := servers.DefaultManagerConfig()
+	managerConfig.MaxConcurrentInstalls = envInt("MCP_MAX_CONCURRENT_INSTALLS", managerConfig.MaxConcurrentInstalls)
+	managerConfig.MaxErrorsPerServer = envInt("MCP_MAX_ERRORS_PER_SERVER", managerConfig.MaxErrorsPerServer)
+	managerConfig.MaxErrorAge = envDuration("MCP_MAX_ERROR_AGE", managerConfig.MaxErrorAge)
+	if policy := os.Getenv("MCP_ORPHAN_POLICY"); policy != "" {
+		managerConfig.OrphanPolicy = policy
+	}
+	managerConfig.CatalogURL = os.Getenv("MCP_CATALOG_URL")
+	serverManager := servers.NewManager(orchestrator, managerConfig)
+
+	// Servers launch with the active profile's command overrides
+	profileManager := profiles.NewProfileManager(serverManager.GetBasePath())
+	serverManager.SetProfileManager(profileManager)
+
+	// Profiles may only reference servers the orchestrator can install or run
+	profileManager.SetServerLookup(func(serverID string) bool {
+		if _, err := serverManager.GetServer(serverID); err == nil {
+			return true
+		}
+		for _, server := range serverManager.GetAvailableServers() {
+			if server.ID == serverID {
+				return true
+			}
+		}
+		return false
+	})
+
+	// Record tool calls made through the API
+	analyticsTracker := analytics.NewTracker(serverManager.GetBasePath(), analytics.DefaultTrackerConfig())
+	analyticsTracker.SetCategoryResolver(func(serverID, toolName string) string {
+		if server, err := serverManager.GetServer(serverID); err == nil {
+			return profiles.ToolCategory(serverID, server.Category)
+		}
+		return ""
+	})
 
 	// Initialize UI API
-	uiAPI := ui.NewAPI(serverManager)
+	uiAPI := ui.NewAPI(serverManager, analyticsTracker)
+	uiAPI.SetProfileManager(profileManager)
+
+	// Stdio proxies report their quarantine, budget and discovery timing state here, as it lives in their processes
+	proxyStates := performance.NewProxyStates(performance.DefaultProxyReportTTL)
+	uiAPI.SetProxyStates(proxyStates)
+
+	// Profile, analytics, performance and dashboard endpoints
+	extendedAPI := ui.NewExtendedAPIServer(profileManager, analyticsTracker, serverManager.GetLoadBalancer())
+	extendedAPI.SetProxyStates(proxyStates)
+
+	// Bind both servers up front. One that can't bind is reported and the
+	// other keeps running; only if neither can is there nothing to serve.
+	tryNextPort := envBool("MCP_PORT_FALLBACK", false)
+	mcpListener, mcpPort, mcpErr := listenPort("MCP server", envInt("MCP_PORT", defaultMCPPort), tryNextPort)
+	if mcpErr != nil {
+		log.Printf("Error: %v", mcpErr)
+	}
+	apiListener, apiPort, apiErr := listenPort("UI API server", envInt("MCP_API_PORT", defaultAPIPort), tryNextPort)
+	if apiErr != nil {
+		log.Printf("Error: %v", apiErr)
+	}
+	if mcpErr != nil && apiErr != nil {
+		instanceLock.Release()
+		log.Fatal("Failed to start: neither the MCP server nor the UI API server could bind a port")
+	}
+	log.Printf("Effective ports: MCP server %s, UI API server %s", portStatus(mcpPort, mcpErr), portStatus(apiPort, apiErr))
+	effectivePorts := gin.H{"mcp": nil, "api": nil}
+	if mcpErr == nil {
+		effectivePorts["mcp"] = mcpPort
+	}
+	if apiErr == nil {
+		effectivePorts["api"] = apiPort
+	}
 
 	// Start the MCP server (for Claude Desktop)
 	go func() {
-		log.Println("Starting MCP server on :3000")
-		if err := orchestrator.Start(":3000"); err != nil {
-			log.Fatal("Failed to start MCP server:", err)
+		if mcpListener == nil {
+			return
+		}
+		if err := orchestrator.Serve(mcpListener); err != nil {
+			log.Printf("Error: MCP server stopped: %v", err)
 		}
 	}()
 
 	// Start the UI API server
 	go func() {
+		if apiListener == nil {
+			return
+		}
 		r := gin.Default()
 
-		// Enable CORS for local development
+		// Enable CORS for the UI; MCP_CORS_ORIGINS lists other allowed origins
 		config := cors.DefaultConfig()
-		config.AllowOrigins = []string{"http://localhost:3001"}
+		config.AllowOrigins = envList("MCP_CORS_ORIGINS", []string{"http://localhost:3001"})
 		config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
 		config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
 		r.Use(cors.New(config))
@@ -49,34 +151,112 @@ func main() {
 			api.GET("/servers", uiAPI.ListServers)
 			api.GET("/categories", uiAPI.GetCategories)
 			api.POST("/servers/install", uiAPI.InstallServer)
+			api.POST("/servers/start", uiAPI.StartServers)
+			api.POST("/servers/refresh", uiAPI.RefreshAllServerTools)
 			api.POST("/servers/:id/start", uiAPI.StartServer)
 			api.POST("/servers/:id/stop", uiAPI.StopServer)
+			api.POST("/servers/:id/activity", uiAPI.RecordServerActivity)
+			api.POST("/servers/:id/disable", uiAPI.DisableServer)
+			api.POST("/servers/:id/enable", uiAPI.EnableServer)
 			api.GET("/servers/:id/status", uiAPI.GetServerStatus)
+			api.GET("/servers/:id/health", uiAPI.GetServerHealth)
+			api.POST("/servers/:id/refresh", uiAPI.RefreshServerTools)
+			api.GET("/servers/:id/effective-config", uiAPI.GetEffectiveConfig)
+			api.GET("/shared-env", uiAPI.GetSharedEnv)
+			api.PUT("/shared-env", uiAPI.SetSharedEnv)
+			api.POST("/servers/:id/tools/:tool/call", uiAPI.CallTool)
 			api.GET("/servers/:id/logs", uiAPI.GetServerLogs)
 			api.GET("/servers/:id/credentials", uiAPI.GetServerRequiredCredentials)
+			api.GET("/stacks", uiAPI.ListStacks)
+			api.GET("/stacks/:name", uiAPI.GetStack)
+			api.PUT("/stacks/:name", uiAPI.SaveStack)
+			api.DELETE("/stacks/:name", uiAPI.DeleteStack)
+			api.POST("/stacks/:name/up", uiAPI.StackUp)
+			api.POST("/stacks/:name/down", uiAPI.StackDown)
 
 			// Validation and diagnostics endpoints
 			api.GET("/validation/servers", uiAPI.ValidateServers)
 			api.GET("/validation/servers/:id", uiAPI.ValidateServer)
 			api.POST("/validation/servers/:id/autofix", uiAPI.AutoFixServer)
 			api.GET("/diagnostics/tools", uiAPI.GetToolDiagnostics)
+			api.GET("/diagnostics/lifecycle", uiAPI.GetLifecycleDiagnostics)
+			api.GET("/tools/:name/owner", uiAPI.GetToolOwner)
+			api.GET("/tools/stream", uiAPI.StreamTools)
+			api.GET("/discovery/cache", uiAPI.GetDiscoveryCache)
+			api.DELETE("/discovery/cache", uiAPI.ClearDiscoveryCache)
+			api.DELETE("/discovery/cache/:id", uiAPI.ClearDiscoveryCache)
+			api.GET("/profiles/:id/tools", uiAPI.GetProfileTools)
+			api.POST("/profiles/:id/simulate", uiAPI.SimulateProfile)
 			api.GET("/system/health", uiAPI.GetSystemHealth)
+			api.GET("/claude/config/preview", uiAPI.PreviewClaudeConfig)
+			api.POST("/claude/config/apply", uiAPI.ApplyClaudeConfig)
+
+			// Analytics endpoints
+			api.GET("/analytics/categories", uiAPI.GetCategoryAnalytics)
+			api.GET("/config/analytics", uiAPI.GetAnalyticsConfig)
+			api.PUT("/config/analytics", uiAPI.UpdateAnalyticsConfig)
+			api.POST("/config/analytics/toggle", uiAPI.ToggleAnalytics)
+			api.POST("/audit/:id/replay", uiAPI.ReplayToolCall)
 
 			// Enhanced error reporting endpoints
+			api.GET("/errors/feed", uiAPI.GetErrorFeed)
 			api.GET("/errors/servers", uiAPI.GetAllServerErrors)
 			api.GET("/errors/servers/:id", uiAPI.GetServerErrors)
 			api.DELETE("/errors/servers/:id", uiAPI.ClearServerErrors)
 			api.GET("/servers/:id/details", uiAPI.GetServerDetails)
+
+			// Circuit breaker endpoints
+			api.GET("/performance/circuit", uiAPI.GetCircuitStatus)
+			api.POST("/performance/circuit/:id/reset", uiAPI.ResetCircuit)
+			api.GET("/performance/reconnect", uiAPI.GetReconnectStatus)
+
+			// State reported by stdio proxies
+			api.POST("/proxies/report", uiAPI.RecordProxyReport)
+			api.GET("/quarantine", uiAPI.GetQuarantine)
+			api.GET("/budgets", uiAPI.GetBudgets)
+			api.GET("/discovery/timings", uiAPI.GetDiscoveryTimings)
+
+			extendedAPI.RegisterRoutes(api)
 		}
 
-		// Health check
-		r.GET("/health", func(c *gin.Context) {
+		// Liveness: the process is up (/health is kept for existing clients)
+		live := func(c *gin.Context) {
 			c.JSON(200, gin.H{"status": "ok"})
+		}
+		r.GET("/health", live)
+		r.GET("/health/live", live)
+
+		// Readiness: state is loaded and the MCP server is accepting connections
+		r.GET("/health/ready", func(c *gin.Context) {
+			checks := gin.H{
+				"manager":      serverManager.IsReady(),
+				"orchestrator": orchestrator.IsListening(),
+			}
+			if !serverManager.IsReady() || !orchestrator.IsListening() {
+				response := gin.H{"status": "not_ready", "checks": checks, "ports": effectivePorts}
+				if mcpErr != nil {
+					response["error"] = mcpErr.Error()
+				}
+				c.JSON(503, response)
+				return
+			}
+			c.JSON(200, gin.H{"status": "ready", "checks": checks, "ports": effectivePorts})
 		})
 
-		log.Println("Starting UI API server on :8080")
-		if err := r.Run(":8080"); err != nil {
-			log.Fatal("Failed to start UI API server:", err)
+		// Bound request duration and body size so misbehaving clients can't tie up handlers
+		limits := ui.DefaultLimitsConfig()
+		limits.RequestTimeout = envDuration("MCP_API_REQUEST_TIMEOUT", limits.RequestTimeout)
+		limits.MaxBodyBytes = int64(envInt("MCP_API_MAX_BODY_BYTES", int(limits.MaxBodyBytes)))
+		limits.StreamPaths = []string{"/api/tools/stream"}
+
+		server := &http.Server{
+			Handler:           ui.WithLimits(r, limits),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		log.Printf("UI API server listening on %s", apiListener.Addr())
+		if err := server.Serve(apiListener); err != nil {
+			log.Printf("Error: UI API server stopped: %v", err)
 		}
 	}()
 
@@ -90,4 +270,69 @@ func main() {
 	// Graceful shutdown
 	serverManager.StopAll()
 	orchestrator.Stop()
+	instanceLock.Release()
+}
+
+// runHealthCheck queries the running orchestrator's readiness endpoint and
+// returns the process exit code
+func runHealthCheck() int {
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://localhost:%d/health/ready", envInt("MCP_API_PORT", defaultAPIPort)))
+	if err != nil {
+		log.Printf("Health check failed: %v", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Health check failed: status %d", resp.StatusCode)
+		return 1
+	}
+	return 0
+}
+
+// portStatus describes a server's port for the startup log
+func portStatus(port int, err error) string {
+	if err != nil {
+		return "not started"
+	}
+	return strconv.Itoa(port)
+}
+
+// envBool reads a boolean (e.g. "true", "1") from the environment
+func envBool(key string, fallback bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// envInt reads a positive integer from the environment
+func envInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// envList reads a comma-separated list from the environment, skipping empty entries
+func envList(key string, fallback []string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
+
+// envDuration reads a positive duration (e.g. "30s") from the environment
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
 }
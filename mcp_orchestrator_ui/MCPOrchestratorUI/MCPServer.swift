@@ -15,6 +15,11 @@ struct MCPServer: Codable, Identifiable {
     let category: String
     let toolsCount: Int?
     let serverType: String?
+    // Optional metadata; custom servers may omit it
+    var homepage: String? = nil
+    var docsURL: String? = nil
+    var author: String? = nil
+    var license: String? = nil
     
     var isRunning: Bool {
         return statusString == "running"
@@ -26,7 +31,9 @@ struct MCPServer: Codable, Identifiable {
             return "healthy"
         case "stopped":
             return "stopped"
-        case "installing":
+        case "disabled":
+            return "disabled"
+        case "installing", "queued":
             return "degraded"
         case "failed":
             return "unhealthy"
@@ -40,12 +47,13 @@ struct MCPServer: Codable, Identifiable {
     }
     
     enum CodingKeys: String, CodingKey {
-        case id, name, description, command, args, env, port, logs, category
+        case id, name, description, command, args, env, port, logs, category, homepage, author, license
         case repoURL = "repo_url"
         case installPath = "install_path"
         case toolsCount = "tools_count"
         case serverType = "server_type"
         case statusString = "status"
+        case docsURL = "docs_url"
     }
     
     var statusColor: String {
@@ -54,7 +62,7 @@ struct MCPServer: Codable, Identifiable {
             return "green"
         case "stopped":
             return "red"
-        case "installing":
+        case "installing", "queued":
             return "orange"
         case "installed":
             return "blue"